@@ -0,0 +1,444 @@
+package certs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+const (
+	// caValidity es la vigencia de la CA local
+	caValidity = 10 * 365 * 24 * time.Hour
+	// leafValidity es la vigencia de los certificados emitidos por la CA local
+	leafValidity = 90 * 24 * time.Hour
+	// RenewBefore es la antelación con la que se rotan los certificados
+	RenewBefore = 30 * 24 * time.Hour
+	// rotationInterval es cada cuánto se revisan los certificados por vencer
+	rotationInterval = 12 * time.Hour
+)
+
+// Config define dónde se guardan las claves y de dónde se obtienen los certificados
+type Config struct {
+	// Dir guarda la CA local y la caché ACME
+	Dir string
+	// ServerNames son los nombres (y IPs) del API/UI de Diplo
+	ServerNames []string
+	// HostPolicy decide si se emite un certificado para un hostname (ej. rutas del proxy)
+	HostPolicy func(host string) bool
+
+	// ACMEDirectoryURL activa ACME contra ese directorio (ej. Pebble en tests)
+	ACMEDirectoryURL string
+	// ACMEEmail es el contacto de la cuenta ACME (opcional)
+	ACMEEmail string
+	// ACMECABundle es un PEM para confiar en el servidor ACME (ej. el minica de Pebble)
+	ACMECABundle string
+}
+
+// CertificateInfo describe un certificado emitido
+type CertificateInfo struct {
+	Host      string    `json:"host"`
+	Issuer    string    `json:"issuer"`
+	NotBefore time.Time `json:"not_before"`
+	NotAfter  time.Time `json:"not_after"`
+}
+
+// Manager emite certificados TLS por hostname con una CA local propia o, si se
+// configura un directorio ACME, mediante ACME. Los certificados de la CA local
+// se rotan automáticamente antes de vencer.
+type Manager struct {
+	config Config
+
+	caCert *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	caPEM  []byte
+
+	mu    sync.Mutex
+	certs map[string]*tls.Certificate
+
+	acme *autocert.Manager
+}
+
+// New carga (o crea) la CA local en config.Dir y prepara ACME si está configurado
+func New(config Config) (*Manager, error) {
+	if config.Dir == "" {
+		config.Dir = "certs"
+	}
+	if err := os.MkdirAll(config.Dir, 0700); err != nil {
+		return nil, fmt.Errorf("error creando directorio de certificados: %v", err)
+	}
+
+	m := &Manager{
+		config: config,
+		certs:  make(map[string]*tls.Certificate),
+	}
+
+	if err := m.loadOrCreateCA(); err != nil {
+		return nil, err
+	}
+
+	if config.ACMEDirectoryURL != "" {
+		client, err := newACMEClient(config.ACMEDirectoryURL, config.ACMECABundle)
+		if err != nil {
+			return nil, err
+		}
+
+		m.acme = &autocert.Manager{
+			Prompt:      autocert.AcceptTOS,
+			Cache:       autocert.DirCache(filepath.Join(config.Dir, "acme")),
+			Client:      client,
+			Email:       config.ACMEEmail,
+			RenewBefore: RenewBefore,
+			HostPolicy: func(_ context.Context, host string) error {
+				if !m.allowed(host) {
+					return fmt.Errorf("host %q no gestionado por Diplo", host)
+				}
+				return nil
+			},
+		}
+		logrus.Infof("🔐 Certificados TLS vía ACME: %s", config.ACMEDirectoryURL)
+	}
+
+	return m, nil
+}
+
+// TLSConfig devuelve la configuración TLS para los listeners HTTPS
+func (m *Manager) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: m.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1", acme.ALPNProto},
+	}
+}
+
+// HTTPHandler atiende los desafíos ACME http-01 y delega el resto en fallback
+func (m *Manager) HTTPHandler(fallback http.Handler) http.Handler {
+	if m.acme == nil {
+		return fallback
+	}
+	return m.acme.HTTPHandler(fallback)
+}
+
+// GetCertificate elige el certificado según el SNI. Sin SNI (acceso por IP) se
+// usa el certificado del servidor emitido por la CA local.
+func (m *Manager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	host := strings.ToLower(strings.TrimSuffix(hello.ServerName, "."))
+	if host == "" || net.ParseIP(host) != nil {
+		return m.serverCertificate()
+	}
+
+	if !m.allowed(host) {
+		return nil, fmt.Errorf("host %q no gestionado por Diplo", host)
+	}
+
+	if m.acme != nil {
+		return m.acme.GetCertificate(hello)
+	}
+
+	if m.isServerName(host) {
+		return m.serverCertificate()
+	}
+	return m.leaf(host, []string{host})
+}
+
+// ACMEDirectoryURL devuelve el directorio ACME configurado ("" con la CA local)
+func (m *Manager) ACMEDirectoryURL() string {
+	return m.config.ACMEDirectoryURL
+}
+
+// RootCertificatePEM devuelve el certificado raíz de la CA local para instalarlo en los clientes
+func (m *Manager) RootCertificatePEM() []byte {
+	return m.caPEM
+}
+
+// Certificates lista los certificados emitidos por la CA local
+func (m *Manager) Certificates() []CertificateInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	infos := make([]CertificateInfo, 0, len(m.certs))
+	for host, cert := range m.certs {
+		infos = append(infos, CertificateInfo{
+			Host:      host,
+			Issuer:    cert.Leaf.Issuer.CommonName,
+			NotBefore: cert.Leaf.NotBefore,
+			NotAfter:  cert.Leaf.NotAfter,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Host < infos[j].Host })
+
+	return infos
+}
+
+// Start revisa periódicamente los certificados y rota los que están por vencer.
+// Los certificados ACME los renueva autocert.
+func (m *Manager) Start(ctx context.Context) {
+	ticker := time.NewTicker(rotationInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Rotate()
+		}
+	}
+}
+
+// Rotate reemite los certificados que vencen dentro de RenewBefore y olvida
+// los de hosts que ya no están permitidos (apps eliminadas)
+func (m *Manager) Rotate() {
+	m.mu.Lock()
+	hosts := make([]string, 0, len(m.certs))
+	for host := range m.certs {
+		hosts = append(hosts, host)
+	}
+	m.mu.Unlock()
+
+	for _, host := range hosts {
+		m.mu.Lock()
+		cert := m.certs[host]
+		m.mu.Unlock()
+
+		if host != serverCertKey && !m.allowed(host) {
+			m.mu.Lock()
+			delete(m.certs, host)
+			m.mu.Unlock()
+			continue
+		}
+
+		if time.Until(cert.Leaf.NotAfter) > RenewBefore {
+			continue
+		}
+
+		names := append([]string{}, cert.Leaf.DNSNames...)
+		for _, ip := range cert.Leaf.IPAddresses {
+			names = append(names, ip.String())
+		}
+		if _, err := m.issue(host, names); err != nil {
+			logrus.Errorf("Error rotando certificado de %s: %v", host, err)
+			continue
+		}
+		logrus.Infof("🔐 Certificado rotado: %s", host)
+	}
+}
+
+// serverCertKey identifica en la caché el certificado del API/UI
+const serverCertKey = "diplo-server"
+
+func (m *Manager) serverCertificate() (*tls.Certificate, error) {
+	return m.leaf(serverCertKey, m.config.ServerNames)
+}
+
+// leaf devuelve el certificado en caché si sigue vigente o emite uno nuevo
+func (m *Manager) leaf(key string, names []string) (*tls.Certificate, error) {
+	m.mu.Lock()
+	cert, ok := m.certs[key]
+	m.mu.Unlock()
+
+	if ok && time.Until(cert.Leaf.NotAfter) > RenewBefore {
+		return cert, nil
+	}
+
+	return m.issue(key, names)
+}
+
+// issue firma con la CA local un certificado para names y lo guarda bajo key
+func (m *Manager) issue(key string, names []string) (*tls.Certificate, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("error generando clave: %v", err)
+	}
+
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	commonName := key
+	if key == serverCertKey && len(names) > 0 {
+		commonName = names[0]
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"Diplo"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(leafValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, name := range names {
+		if ip := net.ParseIP(name); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else if name != "" {
+			template.DNSNames = append(template.DNSNames, name)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, m.caCert, &privateKey.PublicKey, m.caKey)
+	if err != nil {
+		return nil, fmt.Errorf("error firmando certificado para %s: %v", key, err)
+	}
+
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	cert := &tls.Certificate{
+		Certificate: [][]byte{der, m.caCert.Raw},
+		PrivateKey:  privateKey,
+		Leaf:        leaf,
+	}
+
+	m.mu.Lock()
+	m.certs[key] = cert
+	m.mu.Unlock()
+
+	logrus.Debugf("Certificado emitido para %s (vence %s)", key, leaf.NotAfter.Format(time.RFC3339))
+	return cert, nil
+}
+
+func (m *Manager) allowed(host string) bool {
+	if m.isServerName(host) {
+		return true
+	}
+	return m.config.HostPolicy != nil && m.config.HostPolicy(host)
+}
+
+func (m *Manager) isServerName(host string) bool {
+	for _, name := range m.config.ServerNames {
+		if strings.EqualFold(name, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadOrCreateCA lee ca.pem/ca-key.pem de config.Dir o genera una CA nueva
+func (m *Manager) loadOrCreateCA() error {
+	certPath := filepath.Join(m.config.Dir, "ca.pem")
+	keyPath := filepath.Join(m.config.Dir, "ca-key.pem")
+
+	certPEM, certErr := os.ReadFile(certPath)
+	keyPEM, keyErr := os.ReadFile(keyPath)
+	if certErr == nil && keyErr == nil {
+		pair, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return fmt.Errorf("CA local inválida en %s: %v", m.config.Dir, err)
+		}
+		key, ok := pair.PrivateKey.(*ecdsa.PrivateKey)
+		if !ok {
+			return fmt.Errorf("clave de la CA local no soportada")
+		}
+		cert, err := x509.ParseCertificate(pair.Certificate[0])
+		if err != nil {
+			return err
+		}
+		if time.Until(cert.NotAfter) < RenewBefore {
+			logrus.Warnf("⚠️  La CA local vence el %s; elimina %s para regenerarla", cert.NotAfter.Format("2006-01-02"), certPath)
+		}
+
+		m.caCert, m.caKey, m.caPEM = cert, key, certPEM
+		return nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("error generando clave de la CA: %v", err)
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "Diplo Local CA", Organization: []string{"Diplo"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return fmt.Errorf("error creando la CA local: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		return fmt.Errorf("error guardando clave de la CA: %v", err)
+	}
+	if err := os.WriteFile(certPath, certPEM, 0644); err != nil {
+		return fmt.Errorf("error guardando certificado de la CA: %v", err)
+	}
+
+	logrus.Infof("🔐 CA local creada en %s", certPath)
+	m.caCert, m.caKey, m.caPEM = cert, key, certPEM
+	return nil
+}
+
+// newACMEClient crea el cliente ACME; caBundle permite confiar en un servidor
+// con certificado propio (Pebble)
+func newACMEClient(directoryURL, caBundle string) (*acme.Client, error) {
+	client := &acme.Client{DirectoryURL: directoryURL}
+	if caBundle == "" {
+		return client, nil
+	}
+
+	bundle, err := os.ReadFile(caBundle)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo CA del servidor ACME: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(bundle) {
+		return nil, fmt.Errorf("CA del servidor ACME inválida: %s", caBundle)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	client.HTTPClient = &http.Client{Transport: transport}
+
+	return client, nil
+}
+
+func randomSerial() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("error generando número de serie: %v", err)
+	}
+	return serial, nil
+}
@@ -3,16 +3,21 @@ package database
 import (
 	"context"
 	"database/sql"
+	"embed"
 	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
 	"time"
 
-	_ "embed"
-
 	_ "github.com/mattn/go-sqlite3"
 )
 
-//go:embed migrations/apps.sql
-var createAppsTable string
+// migrationsFS contiene las migraciones numeradas (001_*.sql, 002_*.sql, ...)
+// que se aplican en orden y una sola vez.
+//
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
 
 var (
 	StatusIdle        = sql.NullString{String: "idle", Valid: true}
@@ -22,13 +27,148 @@ var (
 	StatusError       = sql.NullString{String: "error", Valid: true}
 )
 
+// Estados de un registro de la tabla deployments
+const (
+	DeploymentRunning   = "running"
+	DeploymentSucceeded = "succeeded"
+	DeploymentFailed    = "failed"
+)
+
+// Estados de un trabajo de la cola de deploys (tabla jobs)
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+func GenerateJobID() string {
+	return fmt.Sprintf("job_%d_%d", time.Now().Unix(), time.Now().UnixNano()%1000000)
+}
+
 func GenerateAppID() string {
 	return fmt.Sprintf("app_%d_%d", time.Now().Unix(), time.Now().UnixNano()%1000000)
 }
 
+// CreateTables aplica las migraciones pendientes registrándolas en schema_migrations
 func (q *Queries) CreateTables(ctx context.Context) error {
-	if _, err := q.db.ExecContext(ctx, createAppsTable); err != nil {
-		return fmt.Errorf("error creando tabla apps: %v", err)
+	if _, err := q.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version TEXT PRIMARY KEY,
+    applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+)`); err != nil {
+		return fmt.Errorf("error creando tabla schema_migrations: %v", err)
+	}
+
+	entries, err := fs.ReadDir(migrationsFS, "migrations")
+	if err != nil {
+		return fmt.Errorf("error leyendo migraciones: %v", err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var applied int
+		if err := q.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM schema_migrations WHERE version = ?", name).Scan(&applied); err != nil {
+			return fmt.Errorf("error verificando migración %s: %v", name, err)
+		}
+		if applied > 0 {
+			continue
+		}
+
+		content, err := migrationsFS.ReadFile("migrations/" + name)
+		if err != nil {
+			return fmt.Errorf("error leyendo migración %s: %v", name, err)
+		}
+
+		if check, ok := migrationChecks[name]; ok {
+			if err := check(ctx, q); err != nil {
+				return fmt.Errorf("no se puede aplicar la migración %s: %w", name, err)
+			}
+		}
+		if err := q.applyMigration(ctx, name, string(content)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrationChecks son comprobaciones de los datos existentes que deben pasar
+// antes de aplicar una migración, para explicar por qué fallaría
+var migrationChecks = map[string]func(ctx context.Context, q *Queries) error{
+	"016_app_path.sql": checkDuplicateAppSources,
+}
+
+// checkDuplicateAppSources detecta apps del mismo repo y ref, que impiden crear
+// el índice único de 016. Antes de la cola de deploys, dos deploys simultáneos
+// del mismo repo podían crear dos apps.
+func checkDuplicateAppSources(ctx context.Context, q *Queries) error {
+	rows, err := q.db.QueryContext(ctx, `SELECT repo_url, COALESCE(ref, ''), GROUP_CONCAT(id, ', ')
+FROM apps GROUP BY repo_url, COALESCE(ref, '') HAVING COUNT(*) > 1`)
+	if err != nil {
+		return fmt.Errorf("error buscando apps duplicadas: %v", err)
+	}
+	defer rows.Close()
+
+	var duplicates []string
+	for rows.Next() {
+		var repoURL, ref, ids string
+		if err := rows.Scan(&repoURL, &ref, &ids); err != nil {
+			return fmt.Errorf("error buscando apps duplicadas: %v", err)
+		}
+		if ref == "" {
+			ref = "HEAD"
+		}
+		duplicates = append(duplicates, fmt.Sprintf("%s@%s (%s)", repoURL, ref, ids))
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error buscando apps duplicadas: %v", err)
+	}
+	if len(duplicates) > 0 {
+		return fmt.Errorf("hay apps duplicadas para el mismo repositorio y ref: %s. Elimina las sobrantes de la tabla apps (y sus contenedores) y reinicia Diplo",
+			strings.Join(duplicates, "; "))
+	}
+	return nil
+}
+
+// applyMigration ejecuta una migración y la registra en una sola transacción,
+// así una migración que falla a la mitad no deja columnas o tablas a medias
+// que impidan reintentarla
+func (q *Queries) applyMigration(ctx context.Context, name, content string) error {
+	db, ok := q.db.(*sql.DB)
+	if !ok {
+		// Ya estamos dentro de una transacción del llamador
+		return q.execMigration(ctx, name, content)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error iniciando migración %s: %v", name, err)
+	}
+	defer tx.Rollback()
+
+	if err := q.WithTx(tx).execMigration(ctx, name, content); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error confirmando migración %s: %v", name, err)
+	}
+	return nil
+}
+
+func (q *Queries) execMigration(ctx context.Context, name, content string) error {
+	if _, err := q.db.ExecContext(ctx, content); err != nil {
+		return fmt.Errorf("error aplicando migración %s: %v", name, err)
+	}
+	if _, err := q.db.ExecContext(ctx, "INSERT INTO schema_migrations (version) VALUES (?)", name); err != nil {
+		return fmt.Errorf("error registrando migración %s: %v", name, err)
 	}
 	return nil
 }
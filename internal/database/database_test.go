@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestQueries(t *testing.T) (*sql.DB, *Queries) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "diplo.db")+"?mode=rwc")
+	if err != nil {
+		t.Fatalf("abriendo base de datos: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	queries := New(db)
+	if err := queries.CreateTables(context.Background()); err != nil {
+		t.Fatalf("CreateTables: %v", err)
+	}
+	return db, queries
+}
+
+func TestCheckDuplicateAppSources(t *testing.T) {
+	ctx := context.Background()
+	db, queries := newTestQueries(t)
+
+	if err := checkDuplicateAppSources(ctx, queries); err != nil {
+		t.Fatalf("sin duplicados: %v", err)
+	}
+
+	// Simula una base anterior a 016, sin el índice único
+	if _, err := db.Exec("DROP INDEX idx_apps_source"); err != nil {
+		t.Fatalf("DROP INDEX: %v", err)
+	}
+	for _, app := range []CreateAppParams{
+		{ID: "app_1", Name: "web", RepoUrl: "https://github.com/org/web", Port: 3000},
+		{ID: "app_2", Name: "web", RepoUrl: "https://github.com/org/web", Port: 3001},
+		{ID: "app_3", Name: "web", RepoUrl: "https://github.com/org/web", Port: 3002, Ref: sql.NullString{String: "staging", Valid: true}},
+	} {
+		if err := queries.CreateApp(ctx, app); err != nil {
+			t.Fatalf("CreateApp: %v", err)
+		}
+	}
+
+	err := checkDuplicateAppSources(ctx, queries)
+	if err == nil {
+		t.Fatal("se esperaba un error por las apps duplicadas")
+	}
+	for _, want := range []string{"https://github.com/org/web@HEAD", "app_1", "app_2"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("el error %q no menciona %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "app_3") {
+		t.Errorf("el error %q menciona una app con otro ref", err)
+	}
+}
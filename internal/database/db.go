@@ -24,47 +24,216 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.acquireAppLockStmt, err = db.PrepareContext(ctx, AcquireAppLock); err != nil {
+		return nil, fmt.Errorf("error preparing query AcquireAppLock: %w", err)
+	}
+	if q.cancelQueuedJobStmt, err = db.PrepareContext(ctx, CancelQueuedJob); err != nil {
+		return nil, fmt.Errorf("error preparing query CancelQueuedJob: %w", err)
+	}
+	if q.claimNextJobStmt, err = db.PrepareContext(ctx, ClaimNextJob); err != nil {
+		return nil, fmt.Errorf("error preparing query ClaimNextJob: %w", err)
+	}
+	if q.countPendingAppJobsStmt, err = db.PrepareContext(ctx, CountPendingAppJobs); err != nil {
+		return nil, fmt.Errorf("error preparing query CountPendingAppJobs: %w", err)
+	}
+	if q.createApiTokenStmt, err = db.PrepareContext(ctx, CreateApiToken); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateApiToken: %w", err)
+	}
 	if q.createAppStmt, err = db.PrepareContext(ctx, CreateApp); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateApp: %w", err)
 	}
 	if q.createAppEnvVarStmt, err = db.PrepareContext(ctx, CreateAppEnvVar); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateAppEnvVar: %w", err)
 	}
+	if q.createDeploymentStmt, err = db.PrepareContext(ctx, CreateDeployment); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateDeployment: %w", err)
+	}
+	if q.createJobStmt, err = db.PrepareContext(ctx, CreateJob); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateJob: %w", err)
+	}
+	if q.createReconcileActionStmt, err = db.PrepareContext(ctx, CreateReconcileAction); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateReconcileAction: %w", err)
+	}
 	if q.deleteAllAppEnvVarsStmt, err = db.PrepareContext(ctx, DeleteAllAppEnvVars); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteAllAppEnvVars: %w", err)
 	}
+	if q.deleteApiTokenStmt, err = db.PrepareContext(ctx, DeleteApiToken); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteApiToken: %w", err)
+	}
 	if q.deleteAppStmt, err = db.PrepareContext(ctx, DeleteApp); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteApp: %w", err)
 	}
 	if q.deleteAppEnvVarStmt, err = db.PrepareContext(ctx, DeleteAppEnvVar); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteAppEnvVar: %w", err)
 	}
+	if q.deleteAppGitPollStmt, err = db.PrepareContext(ctx, DeleteAppGitPoll); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteAppGitPoll: %w", err)
+	}
+	if q.deleteAppWebhookStmt, err = db.PrepareContext(ctx, DeleteAppWebhook); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteAppWebhook: %w", err)
+	}
+	if q.deleteWebhookDeliveryStmt, err = db.PrepareContext(ctx, DeleteWebhookDelivery); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteWebhookDelivery: %w", err)
+	}
+	if q.failRunningDeploymentsStmt, err = db.PrepareContext(ctx, FailRunningDeployments); err != nil {
+		return nil, fmt.Errorf("error preparing query FailRunningDeployments: %w", err)
+	}
+	if q.finishJobStmt, err = db.PrepareContext(ctx, FinishJob); err != nil {
+		return nil, fmt.Errorf("error preparing query FinishJob: %w", err)
+	}
 	if q.getAllAppsStmt, err = db.PrepareContext(ctx, GetAllApps); err != nil {
 		return nil, fmt.Errorf("error preparing query GetAllApps: %w", err)
 	}
+	if q.getApiTokenByHashStmt, err = db.PrepareContext(ctx, GetApiTokenByHash); err != nil {
+		return nil, fmt.Errorf("error preparing query GetApiTokenByHash: %w", err)
+	}
 	if q.getAppStmt, err = db.PrepareContext(ctx, GetApp); err != nil {
 		return nil, fmt.Errorf("error preparing query GetApp: %w", err)
 	}
-	if q.getAppByRepoUrlStmt, err = db.PrepareContext(ctx, GetAppByRepoUrl); err != nil {
-		return nil, fmt.Errorf("error preparing query GetAppByRepoUrl: %w", err)
-	}
 	if q.getAppEnvVarStmt, err = db.PrepareContext(ctx, GetAppEnvVar); err != nil {
 		return nil, fmt.Errorf("error preparing query GetAppEnvVar: %w", err)
 	}
 	if q.getAppEnvVarsStmt, err = db.PrepareContext(ctx, GetAppEnvVars); err != nil {
 		return nil, fmt.Errorf("error preparing query GetAppEnvVars: %w", err)
 	}
+	if q.getAppGitPollStmt, err = db.PrepareContext(ctx, GetAppGitPoll); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAppGitPoll: %w", err)
+	}
+	if q.getAppWebhookStmt, err = db.PrepareContext(ctx, GetAppWebhook); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAppWebhook: %w", err)
+	}
+	if q.getCurrentDeploymentStmt, err = db.PrepareContext(ctx, GetCurrentDeployment); err != nil {
+		return nil, fmt.Errorf("error preparing query GetCurrentDeployment: %w", err)
+	}
+	if q.getDeploymentStmt, err = db.PrepareContext(ctx, GetDeployment); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDeployment: %w", err)
+	}
+	if q.getJobStmt, err = db.PrepareContext(ctx, GetJob); err != nil {
+		return nil, fmt.Errorf("error preparing query GetJob: %w", err)
+	}
+	if q.listApiTokensStmt, err = db.PrepareContext(ctx, ListApiTokens); err != nil {
+		return nil, fmt.Errorf("error preparing query ListApiTokens: %w", err)
+	}
+	if q.listAppDeploymentsStmt, err = db.PrepareContext(ctx, ListAppDeployments); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAppDeployments: %w", err)
+	}
+	if q.listAppGitPollsStmt, err = db.PrepareContext(ctx, ListAppGitPolls); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAppGitPolls: %w", err)
+	}
+	if q.listAppProcessesStmt, err = db.PrepareContext(ctx, ListAppProcesses); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAppProcesses: %w", err)
+	}
+	if q.listAppsByRepoPathStmt, err = db.PrepareContext(ctx, ListAppsByRepoPath); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAppsByRepoPath: %w", err)
+	}
+	if q.listImageAppsStmt, err = db.PrepareContext(ctx, ListImageApps); err != nil {
+		return nil, fmt.Errorf("error preparing query ListImageApps: %w", err)
+	}
+	if q.listJobsStmt, err = db.PrepareContext(ctx, ListJobs); err != nil {
+		return nil, fmt.Errorf("error preparing query ListJobs: %w", err)
+	}
+	if q.listPendingAppJobsStmt, err = db.PrepareContext(ctx, ListPendingAppJobs); err != nil {
+		return nil, fmt.Errorf("error preparing query ListPendingAppJobs: %w", err)
+	}
+	if q.listReconcileActionsStmt, err = db.PrepareContext(ctx, ListReconcileActions); err != nil {
+		return nil, fmt.Errorf("error preparing query ListReconcileActions: %w", err)
+	}
+	if q.pruneWebhookDeliveriesStmt, err = db.PrepareContext(ctx, PruneWebhookDeliveries); err != nil {
+		return nil, fmt.Errorf("error preparing query PruneWebhookDeliveries: %w", err)
+	}
+	if q.recordWebhookDeliveryStmt, err = db.PrepareContext(ctx, RecordWebhookDelivery); err != nil {
+		return nil, fmt.Errorf("error preparing query RecordWebhookDelivery: %w", err)
+	}
+	if q.releaseAppLockStmt, err = db.PrepareContext(ctx, ReleaseAppLock); err != nil {
+		return nil, fmt.Errorf("error preparing query ReleaseAppLock: %w", err)
+	}
+	if q.renewAppLockStmt, err = db.PrepareContext(ctx, RenewAppLock); err != nil {
+		return nil, fmt.Errorf("error preparing query RenewAppLock: %w", err)
+	}
+	if q.requeueRunningJobsStmt, err = db.PrepareContext(ctx, RequeueRunningJobs); err != nil {
+		return nil, fmt.Errorf("error preparing query RequeueRunningJobs: %w", err)
+	}
+	if q.touchApiTokenStmt, err = db.PrepareContext(ctx, TouchApiToken); err != nil {
+		return nil, fmt.Errorf("error preparing query TouchApiToken: %w", err)
+	}
 	if q.updateAppStmt, err = db.PrepareContext(ctx, UpdateApp); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateApp: %w", err)
 	}
+	if q.updateAppDockerfilePathStmt, err = db.PrepareContext(ctx, UpdateAppDockerfilePath); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateAppDockerfilePath: %w", err)
+	}
 	if q.updateAppEnvVarStmt, err = db.PrepareContext(ctx, UpdateAppEnvVar); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateAppEnvVar: %w", err)
 	}
+	if q.updateAppGitPollCheckStmt, err = db.PrepareContext(ctx, UpdateAppGitPollCheck); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateAppGitPollCheck: %w", err)
+	}
+	if q.updateAppGoBuildStmt, err = db.PrepareContext(ctx, UpdateAppGoBuild); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateAppGoBuild: %w", err)
+	}
+	if q.updateAppHealthCheckPathStmt, err = db.PrepareContext(ctx, UpdateAppHealthCheckPath); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateAppHealthCheckPath: %w", err)
+	}
+	if q.updateAppImageStmt, err = db.PrepareContext(ctx, UpdateAppImage); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateAppImage: %w", err)
+	}
+	if q.updateAppLanguageOverrideStmt, err = db.PrepareContext(ctx, UpdateAppLanguageOverride); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateAppLanguageOverride: %w", err)
+	}
+	if q.updateAppRefStmt, err = db.PrepareContext(ctx, UpdateAppRef); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateAppRef: %w", err)
+	}
+	if q.updateAppRuntimeConfigStmt, err = db.PrepareContext(ctx, UpdateAppRuntimeConfig); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateAppRuntimeConfig: %w", err)
+	}
+	if q.updateAppStartCommandStmt, err = db.PrepareContext(ctx, UpdateAppStartCommand); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateAppStartCommand: %w", err)
+	}
+	if q.updateAppUpstreamPortStmt, err = db.PrepareContext(ctx, UpdateAppUpstreamPort); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateAppUpstreamPort: %w", err)
+	}
+	if q.updateDeploymentStmt, err = db.PrepareContext(ctx, UpdateDeployment); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateDeployment: %w", err)
+	}
+	if q.upsertAppGitPollStmt, err = db.PrepareContext(ctx, UpsertAppGitPoll); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertAppGitPoll: %w", err)
+	}
+	if q.upsertAppProcessScaleStmt, err = db.PrepareContext(ctx, UpsertAppProcessScale); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertAppProcessScale: %w", err)
+	}
+	if q.upsertAppWebhookStmt, err = db.PrepareContext(ctx, UpsertAppWebhook); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertAppWebhook: %w", err)
+	}
 	return &q, nil
 }
 
 func (q *Queries) Close() error {
 	var err error
+	if q.acquireAppLockStmt != nil {
+		if cerr := q.acquireAppLockStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing acquireAppLockStmt: %w", cerr)
+		}
+	}
+	if q.cancelQueuedJobStmt != nil {
+		if cerr := q.cancelQueuedJobStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing cancelQueuedJobStmt: %w", cerr)
+		}
+	}
+	if q.claimNextJobStmt != nil {
+		if cerr := q.claimNextJobStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing claimNextJobStmt: %w", cerr)
+		}
+	}
+	if q.countPendingAppJobsStmt != nil {
+		if cerr := q.countPendingAppJobsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countPendingAppJobsStmt: %w", cerr)
+		}
+	}
+	if q.createApiTokenStmt != nil {
+		if cerr := q.createApiTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createApiTokenStmt: %w", cerr)
+		}
+	}
 	if q.createAppStmt != nil {
 		if cerr := q.createAppStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createAppStmt: %w", cerr)
@@ -75,11 +244,31 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing createAppEnvVarStmt: %w", cerr)
 		}
 	}
+	if q.createDeploymentStmt != nil {
+		if cerr := q.createDeploymentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createDeploymentStmt: %w", cerr)
+		}
+	}
+	if q.createJobStmt != nil {
+		if cerr := q.createJobStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createJobStmt: %w", cerr)
+		}
+	}
+	if q.createReconcileActionStmt != nil {
+		if cerr := q.createReconcileActionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createReconcileActionStmt: %w", cerr)
+		}
+	}
 	if q.deleteAllAppEnvVarsStmt != nil {
 		if cerr := q.deleteAllAppEnvVarsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteAllAppEnvVarsStmt: %w", cerr)
 		}
 	}
+	if q.deleteApiTokenStmt != nil {
+		if cerr := q.deleteApiTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteApiTokenStmt: %w", cerr)
+		}
+	}
 	if q.deleteAppStmt != nil {
 		if cerr := q.deleteAppStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteAppStmt: %w", cerr)
@@ -90,21 +279,46 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing deleteAppEnvVarStmt: %w", cerr)
 		}
 	}
+	if q.deleteAppGitPollStmt != nil {
+		if cerr := q.deleteAppGitPollStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteAppGitPollStmt: %w", cerr)
+		}
+	}
+	if q.deleteAppWebhookStmt != nil {
+		if cerr := q.deleteAppWebhookStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteAppWebhookStmt: %w", cerr)
+		}
+	}
+	if q.deleteWebhookDeliveryStmt != nil {
+		if cerr := q.deleteWebhookDeliveryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteWebhookDeliveryStmt: %w", cerr)
+		}
+	}
+	if q.failRunningDeploymentsStmt != nil {
+		if cerr := q.failRunningDeploymentsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing failRunningDeploymentsStmt: %w", cerr)
+		}
+	}
+	if q.finishJobStmt != nil {
+		if cerr := q.finishJobStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing finishJobStmt: %w", cerr)
+		}
+	}
 	if q.getAllAppsStmt != nil {
 		if cerr := q.getAllAppsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getAllAppsStmt: %w", cerr)
 		}
 	}
+	if q.getApiTokenByHashStmt != nil {
+		if cerr := q.getApiTokenByHashStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getApiTokenByHashStmt: %w", cerr)
+		}
+	}
 	if q.getAppStmt != nil {
 		if cerr := q.getAppStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getAppStmt: %w", cerr)
 		}
 	}
-	if q.getAppByRepoUrlStmt != nil {
-		if cerr := q.getAppByRepoUrlStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing getAppByRepoUrlStmt: %w", cerr)
-		}
-	}
 	if q.getAppEnvVarStmt != nil {
 		if cerr := q.getAppEnvVarStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getAppEnvVarStmt: %w", cerr)
@@ -115,16 +329,186 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getAppEnvVarsStmt: %w", cerr)
 		}
 	}
+	if q.getAppGitPollStmt != nil {
+		if cerr := q.getAppGitPollStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAppGitPollStmt: %w", cerr)
+		}
+	}
+	if q.getAppWebhookStmt != nil {
+		if cerr := q.getAppWebhookStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAppWebhookStmt: %w", cerr)
+		}
+	}
+	if q.getCurrentDeploymentStmt != nil {
+		if cerr := q.getCurrentDeploymentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getCurrentDeploymentStmt: %w", cerr)
+		}
+	}
+	if q.getDeploymentStmt != nil {
+		if cerr := q.getDeploymentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDeploymentStmt: %w", cerr)
+		}
+	}
+	if q.getJobStmt != nil {
+		if cerr := q.getJobStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getJobStmt: %w", cerr)
+		}
+	}
+	if q.listApiTokensStmt != nil {
+		if cerr := q.listApiTokensStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listApiTokensStmt: %w", cerr)
+		}
+	}
+	if q.listAppDeploymentsStmt != nil {
+		if cerr := q.listAppDeploymentsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAppDeploymentsStmt: %w", cerr)
+		}
+	}
+	if q.listAppGitPollsStmt != nil {
+		if cerr := q.listAppGitPollsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAppGitPollsStmt: %w", cerr)
+		}
+	}
+	if q.listAppProcessesStmt != nil {
+		if cerr := q.listAppProcessesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAppProcessesStmt: %w", cerr)
+		}
+	}
+	if q.listAppsByRepoPathStmt != nil {
+		if cerr := q.listAppsByRepoPathStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAppsByRepoPathStmt: %w", cerr)
+		}
+	}
+	if q.listImageAppsStmt != nil {
+		if cerr := q.listImageAppsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listImageAppsStmt: %w", cerr)
+		}
+	}
+	if q.listJobsStmt != nil {
+		if cerr := q.listJobsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listJobsStmt: %w", cerr)
+		}
+	}
+	if q.listPendingAppJobsStmt != nil {
+		if cerr := q.listPendingAppJobsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listPendingAppJobsStmt: %w", cerr)
+		}
+	}
+	if q.listReconcileActionsStmt != nil {
+		if cerr := q.listReconcileActionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listReconcileActionsStmt: %w", cerr)
+		}
+	}
+	if q.pruneWebhookDeliveriesStmt != nil {
+		if cerr := q.pruneWebhookDeliveriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing pruneWebhookDeliveriesStmt: %w", cerr)
+		}
+	}
+	if q.recordWebhookDeliveryStmt != nil {
+		if cerr := q.recordWebhookDeliveryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing recordWebhookDeliveryStmt: %w", cerr)
+		}
+	}
+	if q.releaseAppLockStmt != nil {
+		if cerr := q.releaseAppLockStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing releaseAppLockStmt: %w", cerr)
+		}
+	}
+	if q.renewAppLockStmt != nil {
+		if cerr := q.renewAppLockStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing renewAppLockStmt: %w", cerr)
+		}
+	}
+	if q.requeueRunningJobsStmt != nil {
+		if cerr := q.requeueRunningJobsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing requeueRunningJobsStmt: %w", cerr)
+		}
+	}
+	if q.touchApiTokenStmt != nil {
+		if cerr := q.touchApiTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing touchApiTokenStmt: %w", cerr)
+		}
+	}
 	if q.updateAppStmt != nil {
 		if cerr := q.updateAppStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateAppStmt: %w", cerr)
 		}
 	}
+	if q.updateAppDockerfilePathStmt != nil {
+		if cerr := q.updateAppDockerfilePathStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateAppDockerfilePathStmt: %w", cerr)
+		}
+	}
 	if q.updateAppEnvVarStmt != nil {
 		if cerr := q.updateAppEnvVarStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateAppEnvVarStmt: %w", cerr)
 		}
 	}
+	if q.updateAppGitPollCheckStmt != nil {
+		if cerr := q.updateAppGitPollCheckStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateAppGitPollCheckStmt: %w", cerr)
+		}
+	}
+	if q.updateAppGoBuildStmt != nil {
+		if cerr := q.updateAppGoBuildStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateAppGoBuildStmt: %w", cerr)
+		}
+	}
+	if q.updateAppHealthCheckPathStmt != nil {
+		if cerr := q.updateAppHealthCheckPathStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateAppHealthCheckPathStmt: %w", cerr)
+		}
+	}
+	if q.updateAppImageStmt != nil {
+		if cerr := q.updateAppImageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateAppImageStmt: %w", cerr)
+		}
+	}
+	if q.updateAppLanguageOverrideStmt != nil {
+		if cerr := q.updateAppLanguageOverrideStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateAppLanguageOverrideStmt: %w", cerr)
+		}
+	}
+	if q.updateAppRefStmt != nil {
+		if cerr := q.updateAppRefStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateAppRefStmt: %w", cerr)
+		}
+	}
+	if q.updateAppRuntimeConfigStmt != nil {
+		if cerr := q.updateAppRuntimeConfigStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateAppRuntimeConfigStmt: %w", cerr)
+		}
+	}
+	if q.updateAppStartCommandStmt != nil {
+		if cerr := q.updateAppStartCommandStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateAppStartCommandStmt: %w", cerr)
+		}
+	}
+	if q.updateAppUpstreamPortStmt != nil {
+		if cerr := q.updateAppUpstreamPortStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateAppUpstreamPortStmt: %w", cerr)
+		}
+	}
+	if q.updateDeploymentStmt != nil {
+		if cerr := q.updateDeploymentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateDeploymentStmt: %w", cerr)
+		}
+	}
+	if q.upsertAppGitPollStmt != nil {
+		if cerr := q.upsertAppGitPollStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertAppGitPollStmt: %w", cerr)
+		}
+	}
+	if q.upsertAppProcessScaleStmt != nil {
+		if cerr := q.upsertAppProcessScaleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertAppProcessScaleStmt: %w", cerr)
+		}
+	}
+	if q.upsertAppWebhookStmt != nil {
+		if cerr := q.upsertAppWebhookStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertAppWebhookStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -162,37 +546,133 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                      DBTX
-	tx                      *sql.Tx
-	createAppStmt           *sql.Stmt
-	createAppEnvVarStmt     *sql.Stmt
-	deleteAllAppEnvVarsStmt *sql.Stmt
-	deleteAppStmt           *sql.Stmt
-	deleteAppEnvVarStmt     *sql.Stmt
-	getAllAppsStmt          *sql.Stmt
-	getAppStmt              *sql.Stmt
-	getAppByRepoUrlStmt     *sql.Stmt
-	getAppEnvVarStmt        *sql.Stmt
-	getAppEnvVarsStmt       *sql.Stmt
-	updateAppStmt           *sql.Stmt
-	updateAppEnvVarStmt     *sql.Stmt
+	db                            DBTX
+	tx                            *sql.Tx
+	acquireAppLockStmt            *sql.Stmt
+	cancelQueuedJobStmt           *sql.Stmt
+	claimNextJobStmt              *sql.Stmt
+	countPendingAppJobsStmt       *sql.Stmt
+	createApiTokenStmt            *sql.Stmt
+	createAppStmt                 *sql.Stmt
+	createAppEnvVarStmt           *sql.Stmt
+	createDeploymentStmt          *sql.Stmt
+	createJobStmt                 *sql.Stmt
+	createReconcileActionStmt     *sql.Stmt
+	deleteAllAppEnvVarsStmt       *sql.Stmt
+	deleteApiTokenStmt            *sql.Stmt
+	deleteAppStmt                 *sql.Stmt
+	deleteAppEnvVarStmt           *sql.Stmt
+	deleteAppGitPollStmt          *sql.Stmt
+	deleteAppWebhookStmt          *sql.Stmt
+	deleteWebhookDeliveryStmt     *sql.Stmt
+	failRunningDeploymentsStmt    *sql.Stmt
+	finishJobStmt                 *sql.Stmt
+	getAllAppsStmt                *sql.Stmt
+	getApiTokenByHashStmt         *sql.Stmt
+	getAppStmt                    *sql.Stmt
+	getAppEnvVarStmt              *sql.Stmt
+	getAppEnvVarsStmt             *sql.Stmt
+	getAppGitPollStmt             *sql.Stmt
+	getAppWebhookStmt             *sql.Stmt
+	getCurrentDeploymentStmt      *sql.Stmt
+	getDeploymentStmt             *sql.Stmt
+	getJobStmt                    *sql.Stmt
+	listApiTokensStmt             *sql.Stmt
+	listAppDeploymentsStmt        *sql.Stmt
+	listAppGitPollsStmt           *sql.Stmt
+	listAppProcessesStmt          *sql.Stmt
+	listAppsByRepoPathStmt        *sql.Stmt
+	listImageAppsStmt             *sql.Stmt
+	listJobsStmt                  *sql.Stmt
+	listPendingAppJobsStmt        *sql.Stmt
+	listReconcileActionsStmt      *sql.Stmt
+	pruneWebhookDeliveriesStmt    *sql.Stmt
+	recordWebhookDeliveryStmt     *sql.Stmt
+	releaseAppLockStmt            *sql.Stmt
+	renewAppLockStmt              *sql.Stmt
+	requeueRunningJobsStmt        *sql.Stmt
+	touchApiTokenStmt             *sql.Stmt
+	updateAppStmt                 *sql.Stmt
+	updateAppDockerfilePathStmt   *sql.Stmt
+	updateAppEnvVarStmt           *sql.Stmt
+	updateAppGitPollCheckStmt     *sql.Stmt
+	updateAppGoBuildStmt          *sql.Stmt
+	updateAppHealthCheckPathStmt  *sql.Stmt
+	updateAppImageStmt            *sql.Stmt
+	updateAppLanguageOverrideStmt *sql.Stmt
+	updateAppRefStmt              *sql.Stmt
+	updateAppRuntimeConfigStmt    *sql.Stmt
+	updateAppStartCommandStmt     *sql.Stmt
+	updateAppUpstreamPortStmt     *sql.Stmt
+	updateDeploymentStmt          *sql.Stmt
+	upsertAppGitPollStmt          *sql.Stmt
+	upsertAppProcessScaleStmt     *sql.Stmt
+	upsertAppWebhookStmt          *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                      tx,
-		tx:                      tx,
-		createAppStmt:           q.createAppStmt,
-		createAppEnvVarStmt:     q.createAppEnvVarStmt,
-		deleteAllAppEnvVarsStmt: q.deleteAllAppEnvVarsStmt,
-		deleteAppStmt:           q.deleteAppStmt,
-		deleteAppEnvVarStmt:     q.deleteAppEnvVarStmt,
-		getAllAppsStmt:          q.getAllAppsStmt,
-		getAppStmt:              q.getAppStmt,
-		getAppByRepoUrlStmt:     q.getAppByRepoUrlStmt,
-		getAppEnvVarStmt:        q.getAppEnvVarStmt,
-		getAppEnvVarsStmt:       q.getAppEnvVarsStmt,
-		updateAppStmt:           q.updateAppStmt,
-		updateAppEnvVarStmt:     q.updateAppEnvVarStmt,
+		db:                            tx,
+		tx:                            tx,
+		acquireAppLockStmt:            q.acquireAppLockStmt,
+		cancelQueuedJobStmt:           q.cancelQueuedJobStmt,
+		claimNextJobStmt:              q.claimNextJobStmt,
+		countPendingAppJobsStmt:       q.countPendingAppJobsStmt,
+		createApiTokenStmt:            q.createApiTokenStmt,
+		createAppStmt:                 q.createAppStmt,
+		createAppEnvVarStmt:           q.createAppEnvVarStmt,
+		createDeploymentStmt:          q.createDeploymentStmt,
+		createJobStmt:                 q.createJobStmt,
+		createReconcileActionStmt:     q.createReconcileActionStmt,
+		deleteAllAppEnvVarsStmt:       q.deleteAllAppEnvVarsStmt,
+		deleteApiTokenStmt:            q.deleteApiTokenStmt,
+		deleteAppStmt:                 q.deleteAppStmt,
+		deleteAppEnvVarStmt:           q.deleteAppEnvVarStmt,
+		deleteAppGitPollStmt:          q.deleteAppGitPollStmt,
+		deleteAppWebhookStmt:          q.deleteAppWebhookStmt,
+		deleteWebhookDeliveryStmt:     q.deleteWebhookDeliveryStmt,
+		failRunningDeploymentsStmt:    q.failRunningDeploymentsStmt,
+		finishJobStmt:                 q.finishJobStmt,
+		getAllAppsStmt:                q.getAllAppsStmt,
+		getApiTokenByHashStmt:         q.getApiTokenByHashStmt,
+		getAppStmt:                    q.getAppStmt,
+		getAppEnvVarStmt:              q.getAppEnvVarStmt,
+		getAppEnvVarsStmt:             q.getAppEnvVarsStmt,
+		getAppGitPollStmt:             q.getAppGitPollStmt,
+		getAppWebhookStmt:             q.getAppWebhookStmt,
+		getCurrentDeploymentStmt:      q.getCurrentDeploymentStmt,
+		getDeploymentStmt:             q.getDeploymentStmt,
+		getJobStmt:                    q.getJobStmt,
+		listApiTokensStmt:             q.listApiTokensStmt,
+		listAppDeploymentsStmt:        q.listAppDeploymentsStmt,
+		listAppGitPollsStmt:           q.listAppGitPollsStmt,
+		listAppProcessesStmt:          q.listAppProcessesStmt,
+		listAppsByRepoPathStmt:        q.listAppsByRepoPathStmt,
+		listImageAppsStmt:             q.listImageAppsStmt,
+		listJobsStmt:                  q.listJobsStmt,
+		listPendingAppJobsStmt:        q.listPendingAppJobsStmt,
+		listReconcileActionsStmt:      q.listReconcileActionsStmt,
+		pruneWebhookDeliveriesStmt:    q.pruneWebhookDeliveriesStmt,
+		recordWebhookDeliveryStmt:     q.recordWebhookDeliveryStmt,
+		releaseAppLockStmt:            q.releaseAppLockStmt,
+		renewAppLockStmt:              q.renewAppLockStmt,
+		requeueRunningJobsStmt:        q.requeueRunningJobsStmt,
+		touchApiTokenStmt:             q.touchApiTokenStmt,
+		updateAppStmt:                 q.updateAppStmt,
+		updateAppDockerfilePathStmt:   q.updateAppDockerfilePathStmt,
+		updateAppEnvVarStmt:           q.updateAppEnvVarStmt,
+		updateAppGitPollCheckStmt:     q.updateAppGitPollCheckStmt,
+		updateAppGoBuildStmt:          q.updateAppGoBuildStmt,
+		updateAppHealthCheckPathStmt:  q.updateAppHealthCheckPathStmt,
+		updateAppImageStmt:            q.updateAppImageStmt,
+		updateAppLanguageOverrideStmt: q.updateAppLanguageOverrideStmt,
+		updateAppRefStmt:              q.updateAppRefStmt,
+		updateAppRuntimeConfigStmt:    q.updateAppRuntimeConfigStmt,
+		updateAppStartCommandStmt:     q.updateAppStartCommandStmt,
+		updateAppUpstreamPortStmt:     q.updateAppUpstreamPortStmt,
+		updateDeploymentStmt:          q.updateDeploymentStmt,
+		upsertAppGitPollStmt:          q.upsertAppGitPollStmt,
+		upsertAppProcessScaleStmt:     q.upsertAppProcessScaleStmt,
+		upsertAppWebhookStmt:          q.upsertAppWebhookStmt,
 	}
 }
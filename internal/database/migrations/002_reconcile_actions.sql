@@ -0,0 +1,13 @@
+-- Auditoría de acciones del reconciliador (estado deseado vs. estado real)
+CREATE TABLE IF NOT EXISTS reconcile_actions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    app_id TEXT,
+    container_id TEXT,
+    runtime TEXT NOT NULL,
+    action TEXT NOT NULL,
+    result TEXT NOT NULL,
+    detail TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconcile_actions_app_id ON reconcile_actions(app_id);
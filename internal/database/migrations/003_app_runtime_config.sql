@@ -0,0 +1,3 @@
+-- Configuración del runtime con la que se creó el contenedor de la app (JSON),
+-- necesaria para recrearlo durante la recuperación
+ALTER TABLE apps ADD COLUMN runtime_config TEXT;
@@ -0,0 +1,19 @@
+-- Historial de deployments por app: origen, commit, imagen, pasos y log de build
+CREATE TABLE IF NOT EXISTS deployments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    app_id TEXT NOT NULL,
+    triggered_by TEXT NOT NULL,
+    commit_sha TEXT,
+    image_tag TEXT,
+    runtime TEXT NOT NULL,
+    status TEXT NOT NULL,
+    error_msg TEXT,
+    steps TEXT,
+    build_log TEXT,
+    started_at DATETIME NOT NULL,
+    finished_at DATETIME,
+    duration_ms INTEGER,
+    FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_deployments_app_id ON deployments(app_id, id);
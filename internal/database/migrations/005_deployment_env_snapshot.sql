@@ -0,0 +1,2 @@
+-- Variables de entorno (cifradas) con las que corrió cada deployment, para rollbacks
+ALTER TABLE deployments ADD COLUMN env_snapshot TEXT;
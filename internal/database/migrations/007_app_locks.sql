@@ -0,0 +1,8 @@
+-- Lease por app: solo un trabajo de deploy puede tener la app a la vez
+CREATE TABLE IF NOT EXISTS app_locks (
+    app_id TEXT PRIMARY KEY,
+    job_id TEXT NOT NULL,
+    acquired_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE
+);
@@ -0,0 +1,8 @@
+-- Tokens de API para autenticar git push; solo se guarda el hash SHA-256
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at DATETIME NOT NULL,
+    last_used_at DATETIME
+);
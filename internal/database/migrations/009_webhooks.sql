@@ -0,0 +1,19 @@
+-- Webhook de push por app; el secreto se guarda cifrado
+CREATE TABLE IF NOT EXISTS app_webhooks (
+    app_id TEXT PRIMARY KEY,
+    secret TEXT NOT NULL,
+    branch TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE
+);
+
+-- Entregas de webhooks ya procesadas, para ignorar los reintentos del proveedor
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    provider TEXT NOT NULL,
+    delivery_id TEXT NOT NULL,
+    app_id TEXT NOT NULL,
+    received_at DATETIME NOT NULL,
+    PRIMARY KEY (provider, delivery_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_received_at ON webhook_deliveries(received_at);
@@ -0,0 +1,13 @@
+-- Polling de la rama remota por app, para repos que no pueden enviar webhooks;
+-- github_token se guarda cifrado
+CREATE TABLE IF NOT EXISTS app_git_polls (
+    app_id TEXT PRIMARY KEY,
+    branch TEXT NOT NULL,
+    interval_seconds INTEGER NOT NULL,
+    github_token TEXT,
+    last_commit TEXT,
+    last_checked_at DATETIME,
+    last_error TEXT,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+-- Ref de git (rama, tag o commit) que se despliega; NULL sigue el HEAD del repo
+ALTER TABLE apps ADD COLUMN ref TEXT;
@@ -0,0 +1,5 @@
+-- Dockerfile del repo a usar en el build (vacío: "Dockerfile" en la raíz)
+ALTER TABLE apps ADD COLUMN dockerfile_path TEXT;
+
+-- Origen del Dockerfile de cada deployment: el del repo o una plantilla
+ALTER TABLE deployments ADD COLUMN dockerfile TEXT;
@@ -0,0 +1,9 @@
+-- Escala de cada tipo de proceso del Procfile; sin fila se ejecuta una instancia
+CREATE TABLE IF NOT EXISTS app_processes (
+    app_id TEXT NOT NULL,
+    type TEXT NOT NULL,
+    scale INTEGER NOT NULL,
+    updated_at DATETIME NOT NULL,
+    PRIMARY KEY (app_id, type),
+    FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+-- Comando de inicio configurado para la app (vacío: el detectado o el de diplo.yaml)
+ALTER TABLE apps ADD COLUMN start_command TEXT;
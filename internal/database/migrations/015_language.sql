@@ -0,0 +1,5 @@
+-- Lenguaje fijado para la app (vacío: el de diplo.yaml o el detectado)
+ALTER TABLE apps ADD COLUMN language_override TEXT;
+
+-- Detección de lenguaje de cada deployment: el elegido, su origen y los candidatos (JSON)
+ALTER TABLE deployments ADD COLUMN language_detection TEXT;
@@ -0,0 +1,5 @@
+-- Subdirectorio del repo con el código de la app (vacío: la raíz)
+ALTER TABLE apps ADD COLUMN path TEXT;
+
+-- Una app por repo, subdirectorio y ref
+CREATE UNIQUE INDEX IF NOT EXISTS idx_apps_source ON apps(repo_url, COALESCE(path, ''), COALESCE(ref, ''));
@@ -0,0 +1,4 @@
+-- Compilación de apps Go: paquete main, build tags y ldflags (vacíos: los detectados o ninguno)
+ALTER TABLE apps ADD COLUMN go_target TEXT;
+ALTER TABLE apps ADD COLUMN go_tags TEXT;
+ALTER TABLE apps ADD COLUMN go_ldflags TEXT;
@@ -0,0 +1,10 @@
+-- Apps que se despliegan desde una imagen ya construida en vez de un repo:
+-- image es la referencia con tag o digest y repo_url queda vacío
+ALTER TABLE apps ADD COLUMN image TEXT;
+-- Credenciales del registry de la imagen; registry_password se guarda cifrado
+ALTER TABLE apps ADD COLUMN registry_username TEXT;
+ALTER TABLE apps ADD COLUMN registry_password TEXT;
+
+-- Las apps de imagen no tienen repo: la unicidad por repo, path y ref es solo para las de repo
+DROP INDEX IF EXISTS idx_apps_source;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_apps_source ON apps(repo_url, COALESCE(path, ''), COALESCE(ref, '')) WHERE image IS NULL;
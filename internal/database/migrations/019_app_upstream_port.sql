@@ -0,0 +1,5 @@
+-- Puerto del host en el que escucha el contenedor activo. Los redeploys
+-- blue/green arrancan la versión nueva en otro puerto y Diplo sigue publicando
+-- port, el puerto estable de la app, reenviándolo a este.
+-- NULL: el contenedor publica port directamente.
+ALTER TABLE apps ADD COLUMN upstream_port INTEGER;
@@ -0,0 +1,3 @@
+-- Ruta del health check de los redeploys blue/green fijada en la app; gana a
+-- la de diplo.yaml. NULL: la de diplo.yaml o, sin ella, cualquier respuesta HTTP.
+ALTER TABLE apps ADD COLUMN health_check_path TEXT;
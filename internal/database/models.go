@@ -6,20 +6,43 @@ package database
 
 import (
 	"database/sql"
+	"time"
 )
 
+type ApiToken struct {
+	ID         int64        `db:"id" json:"id"`
+	Name       string       `db:"name" json:"name"`
+	TokenHash  string       `db:"token_hash" json:"token_hash"`
+	CreatedAt  time.Time    `db:"created_at" json:"created_at"`
+	LastUsedAt sql.NullTime `db:"last_used_at" json:"last_used_at"`
+}
+
 type App struct {
-	ID          string         `db:"id" json:"id"`
-	Name        string         `db:"name" json:"name"`
-	RepoUrl     string         `db:"repo_url" json:"repo_url"`
-	Language    sql.NullString `db:"language" json:"language"`
-	Port        int64          `db:"port" json:"port"`
-	ContainerID sql.NullString `db:"container_id" json:"container_id"`
-	ImageID     sql.NullString `db:"image_id" json:"image_id"`
-	Status      sql.NullString `db:"status" json:"status"`
-	ErrorMsg    sql.NullString `db:"error_msg" json:"error_msg"`
-	CreatedAt   sql.NullTime   `db:"created_at" json:"created_at"`
-	UpdatedAt   sql.NullTime   `db:"updated_at" json:"updated_at"`
+	ID               string         `db:"id" json:"id"`
+	Name             string         `db:"name" json:"name"`
+	RepoUrl          string         `db:"repo_url" json:"repo_url"`
+	Language         sql.NullString `db:"language" json:"language"`
+	Port             int64          `db:"port" json:"port"`
+	ContainerID      sql.NullString `db:"container_id" json:"container_id"`
+	ImageID          sql.NullString `db:"image_id" json:"image_id"`
+	Status           sql.NullString `db:"status" json:"status"`
+	ErrorMsg         sql.NullString `db:"error_msg" json:"error_msg"`
+	CreatedAt        sql.NullTime   `db:"created_at" json:"created_at"`
+	UpdatedAt        sql.NullTime   `db:"updated_at" json:"updated_at"`
+	RuntimeConfig    sql.NullString `db:"runtime_config" json:"runtime_config"`
+	Ref              sql.NullString `db:"ref" json:"ref"`
+	DockerfilePath   sql.NullString `db:"dockerfile_path" json:"dockerfile_path"`
+	StartCommand     sql.NullString `db:"start_command" json:"start_command"`
+	LanguageOverride sql.NullString `db:"language_override" json:"language_override"`
+	Path             sql.NullString `db:"path" json:"path"`
+	GoTarget         sql.NullString `db:"go_target" json:"go_target"`
+	GoTags           sql.NullString `db:"go_tags" json:"go_tags"`
+	GoLdflags        sql.NullString `db:"go_ldflags" json:"go_ldflags"`
+	Image            sql.NullString `db:"image" json:"image"`
+	RegistryUsername sql.NullString `db:"registry_username" json:"registry_username"`
+	RegistryPassword sql.NullString `db:"registry_password" json:"registry_password"`
+	UpstreamPort     sql.NullInt64  `db:"upstream_port" json:"upstream_port"`
+	HealthCheckPath  sql.NullString `db:"health_check_path" json:"health_check_path"`
 }
 
 type AppEnvVar struct {
@@ -31,3 +54,85 @@ type AppEnvVar struct {
 	CreatedAt sql.NullTime `db:"created_at" json:"created_at"`
 	UpdatedAt sql.NullTime `db:"updated_at" json:"updated_at"`
 }
+
+type AppGitPoll struct {
+	AppID           string         `db:"app_id" json:"app_id"`
+	Branch          string         `db:"branch" json:"branch"`
+	IntervalSeconds int64          `db:"interval_seconds" json:"interval_seconds"`
+	GithubToken     sql.NullString `db:"github_token" json:"github_token"`
+	LastCommit      sql.NullString `db:"last_commit" json:"last_commit"`
+	LastCheckedAt   sql.NullTime   `db:"last_checked_at" json:"last_checked_at"`
+	LastError       sql.NullString `db:"last_error" json:"last_error"`
+	CreatedAt       time.Time      `db:"created_at" json:"created_at"`
+}
+
+type AppLock struct {
+	AppID      string    `db:"app_id" json:"app_id"`
+	JobID      string    `db:"job_id" json:"job_id"`
+	AcquiredAt time.Time `db:"acquired_at" json:"acquired_at"`
+	ExpiresAt  time.Time `db:"expires_at" json:"expires_at"`
+}
+
+type AppProcess struct {
+	AppID     string    `db:"app_id" json:"app_id"`
+	Type      string    `db:"type" json:"type"`
+	Scale     int64     `db:"scale" json:"scale"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type AppWebhook struct {
+	AppID     string    `db:"app_id" json:"app_id"`
+	Secret    string    `db:"secret" json:"secret"`
+	Branch    string    `db:"branch" json:"branch"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type Deployment struct {
+	ID                int64          `db:"id" json:"id"`
+	AppID             string         `db:"app_id" json:"app_id"`
+	TriggeredBy       string         `db:"triggered_by" json:"triggered_by"`
+	CommitSha         sql.NullString `db:"commit_sha" json:"commit_sha"`
+	ImageTag          sql.NullString `db:"image_tag" json:"image_tag"`
+	Runtime           string         `db:"runtime" json:"runtime"`
+	Status            string         `db:"status" json:"status"`
+	ErrorMsg          sql.NullString `db:"error_msg" json:"error_msg"`
+	Steps             sql.NullString `db:"steps" json:"steps"`
+	BuildLog          sql.NullString `db:"build_log" json:"build_log"`
+	StartedAt         time.Time      `db:"started_at" json:"started_at"`
+	FinishedAt        sql.NullTime   `db:"finished_at" json:"finished_at"`
+	DurationMs        sql.NullInt64  `db:"duration_ms" json:"duration_ms"`
+	EnvSnapshot       sql.NullString `db:"env_snapshot" json:"env_snapshot"`
+	Dockerfile        sql.NullString `db:"dockerfile" json:"dockerfile"`
+	LanguageDetection sql.NullString `db:"language_detection" json:"language_detection"`
+}
+
+type Job struct {
+	ID         string         `db:"id" json:"id"`
+	AppID      string         `db:"app_id" json:"app_id"`
+	Kind       string         `db:"kind" json:"kind"`
+	Status     string         `db:"status" json:"status"`
+	Payload    sql.NullString `db:"payload" json:"payload"`
+	ErrorMsg   sql.NullString `db:"error_msg" json:"error_msg"`
+	Attempts   int64          `db:"attempts" json:"attempts"`
+	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+	StartedAt  sql.NullTime   `db:"started_at" json:"started_at"`
+	FinishedAt sql.NullTime   `db:"finished_at" json:"finished_at"`
+}
+
+type ReconcileAction struct {
+	ID          int64          `db:"id" json:"id"`
+	AppID       sql.NullString `db:"app_id" json:"app_id"`
+	ContainerID sql.NullString `db:"container_id" json:"container_id"`
+	Runtime     string         `db:"runtime" json:"runtime"`
+	Action      string         `db:"action" json:"action"`
+	Result      string         `db:"result" json:"result"`
+	Detail      sql.NullString `db:"detail" json:"detail"`
+	CreatedAt   sql.NullTime   `db:"created_at" json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	Provider   string    `db:"provider" json:"provider"`
+	DeliveryID string    `db:"delivery_id" json:"delivery_id"`
+	AppID      string    `db:"app_id" json:"app_id"`
+	ReceivedAt time.Time `db:"received_at" json:"received_at"`
+}
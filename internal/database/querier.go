@@ -6,22 +6,79 @@ package database
 
 import (
 	"context"
+	"time"
 )
 
 type Querier interface {
+	// Per-app deploy lock queries
+	AcquireAppLock(ctx context.Context, arg AcquireAppLockParams) (int64, error)
+	CancelQueuedJob(ctx context.Context, arg CancelQueuedJobParams) (int64, error)
+	ClaimNextJob(ctx context.Context, arg ClaimNextJobParams) (Job, error)
+	CountPendingAppJobs(ctx context.Context, appID string) (int64, error)
+	// API token queries
+	CreateApiToken(ctx context.Context, arg CreateApiTokenParams) (int64, error)
 	CreateApp(ctx context.Context, arg CreateAppParams) error
 	// Environment Variables queries
 	CreateAppEnvVar(ctx context.Context, arg CreateAppEnvVarParams) error
+	// Deployment history queries
+	CreateDeployment(ctx context.Context, arg CreateDeploymentParams) (int64, error)
+	// Deploy job queue queries
+	CreateJob(ctx context.Context, arg CreateJobParams) error
+	// Reconciliation audit queries
+	CreateReconcileAction(ctx context.Context, arg CreateReconcileActionParams) error
 	DeleteAllAppEnvVars(ctx context.Context, appID string) error
+	DeleteApiToken(ctx context.Context, id int64) (int64, error)
 	DeleteApp(ctx context.Context, id string) error
 	DeleteAppEnvVar(ctx context.Context, arg DeleteAppEnvVarParams) error
+	DeleteAppGitPoll(ctx context.Context, appID string) (int64, error)
+	DeleteAppWebhook(ctx context.Context, appID string) (int64, error)
+	DeleteWebhookDelivery(ctx context.Context, arg DeleteWebhookDeliveryParams) error
+	FailRunningDeployments(ctx context.Context, arg FailRunningDeploymentsParams) error
+	FinishJob(ctx context.Context, arg FinishJobParams) error
 	GetAllApps(ctx context.Context) ([]App, error)
+	GetApiTokenByHash(ctx context.Context, tokenHash string) (ApiToken, error)
 	GetApp(ctx context.Context, id string) (App, error)
-	GetAppByRepoUrl(ctx context.Context, repoUrl string) (App, error)
 	GetAppEnvVar(ctx context.Context, arg GetAppEnvVarParams) (AppEnvVar, error)
 	GetAppEnvVars(ctx context.Context, appID string) ([]AppEnvVar, error)
+	GetAppGitPoll(ctx context.Context, appID string) (AppGitPoll, error)
+	GetAppWebhook(ctx context.Context, appID string) (AppWebhook, error)
+	GetCurrentDeployment(ctx context.Context, appID string) (Deployment, error)
+	GetDeployment(ctx context.Context, id int64) (Deployment, error)
+	GetJob(ctx context.Context, id string) (Job, error)
+	ListApiTokens(ctx context.Context) ([]ApiToken, error)
+	ListAppDeployments(ctx context.Context, arg ListAppDeploymentsParams) ([]Deployment, error)
+	ListAppGitPolls(ctx context.Context) ([]AppGitPoll, error)
+	ListAppProcesses(ctx context.Context, appID string) ([]AppProcess, error)
+	ListAppsByRepoPath(ctx context.Context, arg ListAppsByRepoPathParams) ([]App, error)
+	ListImageApps(ctx context.Context) ([]App, error)
+	ListJobs(ctx context.Context, limit int64) ([]Job, error)
+	ListPendingAppJobs(ctx context.Context, appID string) ([]Job, error)
+	ListReconcileActions(ctx context.Context, limit int64) ([]ReconcileAction, error)
+	PruneWebhookDeliveries(ctx context.Context, receivedAt time.Time) error
+	RecordWebhookDelivery(ctx context.Context, arg RecordWebhookDeliveryParams) (int64, error)
+	ReleaseAppLock(ctx context.Context, arg ReleaseAppLockParams) error
+	RenewAppLock(ctx context.Context, arg RenewAppLockParams) (int64, error)
+	RequeueRunningJobs(ctx context.Context) (int64, error)
+	TouchApiToken(ctx context.Context, arg TouchApiTokenParams) error
 	UpdateApp(ctx context.Context, arg UpdateAppParams) error
+	UpdateAppDockerfilePath(ctx context.Context, arg UpdateAppDockerfilePathParams) error
 	UpdateAppEnvVar(ctx context.Context, arg UpdateAppEnvVarParams) error
+	UpdateAppGitPollCheck(ctx context.Context, arg UpdateAppGitPollCheckParams) error
+	UpdateAppGoBuild(ctx context.Context, arg UpdateAppGoBuildParams) error
+	UpdateAppHealthCheckPath(ctx context.Context, arg UpdateAppHealthCheckPathParams) error
+	UpdateAppImage(ctx context.Context, arg UpdateAppImageParams) error
+	UpdateAppLanguageOverride(ctx context.Context, arg UpdateAppLanguageOverrideParams) error
+	UpdateAppRef(ctx context.Context, arg UpdateAppRefParams) error
+	UpdateAppRuntimeConfig(ctx context.Context, arg UpdateAppRuntimeConfigParams) error
+	UpdateAppStartCommand(ctx context.Context, arg UpdateAppStartCommandParams) error
+	UpdateAppUpstreamPort(ctx context.Context, arg UpdateAppUpstreamPortParams) error
+	UpdateDeployment(ctx context.Context, arg UpdateDeploymentParams) error
+	// Git polling queries
+	UpsertAppGitPoll(ctx context.Context, arg UpsertAppGitPollParams) error
+	// Process scale queries
+	UpsertAppProcessScale(ctx context.Context, arg UpsertAppProcessScaleParams) error
+	// Webhook queries
+	UpsertAppWebhook(ctx context.Context, arg UpsertAppWebhookParams) error
 }
 
 var _ Querier = (*Queries)(nil)
@@ -1,19 +1,51 @@
 -- name: CreateApp :exec
-INSERT INTO apps (id, name, repo_url, language, port, container_id, image_id, status, error_msg, updated_at)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+INSERT INTO apps (id, name, repo_url, language, port, container_id, image_id, status, error_msg, updated_at, ref, path, image)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
 
 -- name: UpdateApp :exec
 UPDATE apps SET name = ?, repo_url = ?, language = ?, port = ?, container_id = ?, image_id = ?, status = ?, error_msg = ?, updated_at = ? WHERE id = ?;
 
+-- name: UpdateAppRuntimeConfig :exec
+UPDATE apps SET image_id = ?, runtime_config = ?, updated_at = ? WHERE id = ?;
+
+-- name: UpdateAppRef :exec
+UPDATE apps SET ref = ?, updated_at = ? WHERE id = ?;
+
+-- name: UpdateAppDockerfilePath :exec
+UPDATE apps SET dockerfile_path = ?, updated_at = ? WHERE id = ?;
+
+-- name: UpdateAppStartCommand :exec
+UPDATE apps SET start_command = ?, updated_at = ? WHERE id = ?;
+
+-- name: UpdateAppLanguageOverride :exec
+UPDATE apps SET language_override = ?, updated_at = ? WHERE id = ?;
+
+-- name: UpdateAppGoBuild :exec
+UPDATE apps SET go_target = ?, go_tags = ?, go_ldflags = ?, updated_at = ? WHERE id = ?;
+
+-- name: UpdateAppHealthCheckPath :exec
+UPDATE apps SET health_check_path = ?, updated_at = ? WHERE id = ?;
+
+-- name: UpdateAppImage :exec
+UPDATE apps SET image = ?, registry_username = ?, registry_password = ?, updated_at = ? WHERE id = ?;
+
+-- name: UpdateAppUpstreamPort :exec
+UPDATE apps SET upstream_port = ?, updated_at = ? WHERE id = ?;
+
 -- name: GetApp :one
 SELECT * FROM apps WHERE id = ?;
 
--- name: GetAppByRepoUrl :one
-SELECT * FROM apps WHERE repo_url = ?;
+-- name: ListAppsByRepoPath :many
+SELECT * FROM apps WHERE repo_url = sqlc.arg(repo_url) AND COALESCE(path, '') = CAST(sqlc.arg(path) AS TEXT) ORDER BY created_at;
+
+-- name: ListImageApps :many
+SELECT * FROM apps WHERE image IS NOT NULL ORDER BY created_at;
 
 -- name: GetAllApps :many
 SELECT id, name, repo_url, language, port, container_id, image_id,
-    status, error_msg, created_at, updated_at
+    status, error_msg, created_at, updated_at, runtime_config, ref, dockerfile_path, start_command,
+    language_override, path, go_target, go_tags, go_ldflags, image, registry_username, registry_password,
+    upstream_port, health_check_path
 FROM apps;
 
 -- name: DeleteApp :exec
@@ -39,4 +71,173 @@ UPDATE app_env_vars SET value = ?, is_secret = ?, updated_at = ? WHERE app_id =
 DELETE FROM app_env_vars WHERE app_id = ? AND key = ?;
 
 -- name: DeleteAllAppEnvVars :exec
-DELETE FROM app_env_vars WHERE app_id = ?;
\ No newline at end of file
+DELETE FROM app_env_vars WHERE app_id = ?;
+
+-- Reconciliation audit queries
+-- name: CreateReconcileAction :exec
+INSERT INTO reconcile_actions (app_id, container_id, runtime, action, result, detail)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: ListReconcileActions :many
+SELECT id, app_id, container_id, runtime, action, result, detail, created_at
+FROM reconcile_actions ORDER BY id DESC LIMIT ?;
+
+-- Deployment history queries
+-- name: CreateDeployment :execlastid
+INSERT INTO deployments (app_id, triggered_by, runtime, status, started_at)
+VALUES (?, ?, ?, ?, ?);
+
+-- name: UpdateDeployment :exec
+UPDATE deployments SET commit_sha = ?, image_tag = ?, runtime = ?, status = ?, error_msg = ?,
+    steps = ?, build_log = ?, finished_at = ?, duration_ms = ?, env_snapshot = ?,
+    dockerfile = ?, language_detection = ?
+WHERE id = ?;
+
+-- name: GetDeployment :one
+SELECT id, app_id, triggered_by, commit_sha, image_tag, runtime, status, error_msg,
+    steps, build_log, started_at, finished_at, duration_ms, env_snapshot, dockerfile,
+    language_detection
+FROM deployments WHERE id = ?;
+
+-- name: ListAppDeployments :many
+SELECT id, app_id, triggered_by, commit_sha, image_tag, runtime, status, error_msg,
+    steps, build_log, started_at, finished_at, duration_ms, env_snapshot, dockerfile,
+    language_detection
+FROM deployments WHERE app_id = ? ORDER BY id DESC LIMIT ?;
+
+-- name: GetCurrentDeployment :one
+SELECT id, app_id, triggered_by, commit_sha, image_tag, runtime, status, error_msg,
+    steps, build_log, started_at, finished_at, duration_ms, env_snapshot, dockerfile,
+    language_detection
+FROM deployments WHERE app_id = ? AND status = 'succeeded' ORDER BY id DESC LIMIT 1;
+
+-- name: FailRunningDeployments :exec
+UPDATE deployments SET status = 'failed', error_msg = ?, finished_at = ?
+WHERE status = 'running';
+
+-- Deploy job queue queries
+-- name: CreateJob :exec
+INSERT INTO jobs (id, app_id, kind, status, payload, created_at)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: ClaimNextJob :one
+UPDATE jobs SET status = 'running', started_at = sqlc.arg(started_at), attempts = attempts + 1
+WHERE id = (
+    SELECT j.id FROM jobs j
+    WHERE j.status = 'queued'
+      AND j.app_id NOT IN (
+        SELECT l.app_id FROM app_locks l
+        WHERE l.job_id != j.id AND l.expires_at > sqlc.arg(expires_at)
+      )
+    ORDER BY j.created_at, j.rowid LIMIT 1
+)
+RETURNING id, app_id, kind, status, payload, error_msg, attempts, created_at, started_at, finished_at;
+
+-- name: FinishJob :exec
+UPDATE jobs SET status = ?, error_msg = ?, finished_at = ? WHERE id = ?;
+
+-- name: CancelQueuedJob :execrows
+UPDATE jobs SET status = 'cancelled', error_msg = ?, finished_at = ?
+WHERE id = ? AND status = 'queued';
+
+-- name: GetJob :one
+SELECT id, app_id, kind, status, payload, error_msg, attempts, created_at, started_at, finished_at
+FROM jobs WHERE id = ?;
+
+-- name: ListJobs :many
+SELECT id, app_id, kind, status, payload, error_msg, attempts, created_at, started_at, finished_at
+FROM jobs ORDER BY created_at DESC LIMIT ?;
+
+-- name: RequeueRunningJobs :execrows
+UPDATE jobs SET status = 'queued', started_at = NULL WHERE status = 'running';
+
+-- name: CountPendingAppJobs :one
+SELECT COUNT(*) FROM jobs WHERE app_id = ? AND status IN ('queued', 'running');
+
+-- name: ListPendingAppJobs :many
+SELECT id, app_id, kind, status, payload, error_msg, attempts, created_at, started_at, finished_at
+FROM jobs WHERE app_id = ? AND status IN ('queued', 'running')
+ORDER BY created_at, rowid;
+
+-- Per-app deploy lock queries
+-- name: AcquireAppLock :execrows
+INSERT INTO app_locks (app_id, job_id, acquired_at, expires_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT(app_id) DO UPDATE SET job_id = excluded.job_id, acquired_at = excluded.acquired_at, expires_at = excluded.expires_at
+WHERE app_locks.job_id = excluded.job_id OR app_locks.expires_at <= excluded.acquired_at;
+
+-- name: RenewAppLock :execrows
+UPDATE app_locks SET expires_at = ? WHERE app_id = ? AND job_id = ?;
+
+-- name: ReleaseAppLock :exec
+DELETE FROM app_locks WHERE app_id = ? AND job_id = ?;
+
+-- API token queries
+-- name: CreateApiToken :execlastid
+INSERT INTO api_tokens (name, token_hash, created_at)
+VALUES (?, ?, ?);
+
+-- name: ListApiTokens :many
+SELECT id, name, token_hash, created_at, last_used_at FROM api_tokens ORDER BY id;
+
+-- name: GetApiTokenByHash :one
+SELECT id, name, token_hash, created_at, last_used_at FROM api_tokens WHERE token_hash = ?;
+
+-- name: TouchApiToken :exec
+UPDATE api_tokens SET last_used_at = ? WHERE id = ?;
+
+-- name: DeleteApiToken :execrows
+DELETE FROM api_tokens WHERE id = ?;
+
+-- Webhook queries
+-- name: UpsertAppWebhook :exec
+INSERT INTO app_webhooks (app_id, secret, branch, created_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT(app_id) DO UPDATE SET secret = excluded.secret, branch = excluded.branch, created_at = excluded.created_at;
+
+-- name: GetAppWebhook :one
+SELECT app_id, secret, branch, created_at FROM app_webhooks WHERE app_id = ?;
+
+-- name: DeleteAppWebhook :execrows
+DELETE FROM app_webhooks WHERE app_id = ?;
+
+-- name: RecordWebhookDelivery :execrows
+INSERT INTO webhook_deliveries (provider, delivery_id, app_id, received_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT(provider, delivery_id) DO NOTHING;
+
+-- name: DeleteWebhookDelivery :exec
+DELETE FROM webhook_deliveries WHERE provider = ? AND delivery_id = ?;
+
+-- name: PruneWebhookDeliveries :exec
+DELETE FROM webhook_deliveries WHERE received_at < ?;
+
+-- Git polling queries
+-- name: UpsertAppGitPoll :exec
+INSERT INTO app_git_polls (app_id, branch, interval_seconds, github_token, created_at)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT(app_id) DO UPDATE SET branch = excluded.branch, interval_seconds = excluded.interval_seconds,
+    github_token = excluded.github_token, last_commit = NULL, last_checked_at = NULL, last_error = NULL;
+
+-- name: GetAppGitPoll :one
+SELECT app_id, branch, interval_seconds, github_token, last_commit, last_checked_at, last_error, created_at
+FROM app_git_polls WHERE app_id = ?;
+
+-- name: ListAppGitPolls :many
+SELECT app_id, branch, interval_seconds, github_token, last_commit, last_checked_at, last_error, created_at
+FROM app_git_polls ORDER BY app_id;
+
+-- name: UpdateAppGitPollCheck :exec
+UPDATE app_git_polls SET last_commit = ?, last_checked_at = ?, last_error = ? WHERE app_id = ?;
+
+-- name: DeleteAppGitPoll :execrows
+DELETE FROM app_git_polls WHERE app_id = ?;
+
+-- Process scale queries
+-- name: UpsertAppProcessScale :exec
+INSERT INTO app_processes (app_id, type, scale, updated_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT(app_id, type) DO UPDATE SET scale = excluded.scale, updated_at = excluded.updated_at;
+
+-- name: ListAppProcesses :many
+SELECT app_id, type, scale, updated_at FROM app_processes WHERE app_id = ? ORDER BY type;
@@ -8,11 +8,127 @@ package database
 import (
 	"context"
 	"database/sql"
+	"time"
 )
 
+const AcquireAppLock = `-- name: AcquireAppLock :execrows
+INSERT INTO app_locks (app_id, job_id, acquired_at, expires_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT(app_id) DO UPDATE SET job_id = excluded.job_id, acquired_at = excluded.acquired_at, expires_at = excluded.expires_at
+WHERE app_locks.job_id = excluded.job_id OR app_locks.expires_at <= excluded.acquired_at
+`
+
+type AcquireAppLockParams struct {
+	AppID      string    `db:"app_id" json:"app_id"`
+	JobID      string    `db:"job_id" json:"job_id"`
+	AcquiredAt time.Time `db:"acquired_at" json:"acquired_at"`
+	ExpiresAt  time.Time `db:"expires_at" json:"expires_at"`
+}
+
+// Per-app deploy lock queries
+func (q *Queries) AcquireAppLock(ctx context.Context, arg AcquireAppLockParams) (int64, error) {
+	result, err := q.exec(ctx, q.acquireAppLockStmt, AcquireAppLock,
+		arg.AppID,
+		arg.JobID,
+		arg.AcquiredAt,
+		arg.ExpiresAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const CancelQueuedJob = `-- name: CancelQueuedJob :execrows
+UPDATE jobs SET status = 'cancelled', error_msg = ?, finished_at = ?
+WHERE id = ? AND status = 'queued'
+`
+
+type CancelQueuedJobParams struct {
+	ErrorMsg   sql.NullString `db:"error_msg" json:"error_msg"`
+	FinishedAt sql.NullTime   `db:"finished_at" json:"finished_at"`
+	ID         string         `db:"id" json:"id"`
+}
+
+func (q *Queries) CancelQueuedJob(ctx context.Context, arg CancelQueuedJobParams) (int64, error) {
+	result, err := q.exec(ctx, q.cancelQueuedJobStmt, CancelQueuedJob, arg.ErrorMsg, arg.FinishedAt, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const ClaimNextJob = `-- name: ClaimNextJob :one
+UPDATE jobs SET status = 'running', started_at = ?1, attempts = attempts + 1
+WHERE id = (
+    SELECT j.id FROM jobs j
+    WHERE j.status = 'queued'
+      AND j.app_id NOT IN (
+        SELECT l.app_id FROM app_locks l
+        WHERE l.job_id != j.id AND l.expires_at > ?2
+      )
+    ORDER BY j.created_at, j.rowid LIMIT 1
+)
+RETURNING id, app_id, kind, status, payload, error_msg, attempts, created_at, started_at, finished_at
+`
+
+type ClaimNextJobParams struct {
+	StartedAt sql.NullTime `db:"started_at" json:"started_at"`
+	ExpiresAt time.Time    `db:"expires_at" json:"expires_at"`
+}
+
+func (q *Queries) ClaimNextJob(ctx context.Context, arg ClaimNextJobParams) (Job, error) {
+	row := q.queryRow(ctx, q.claimNextJobStmt, ClaimNextJob, arg.StartedAt, arg.ExpiresAt)
+	var i Job
+	err := row.Scan(
+		&i.ID,
+		&i.AppID,
+		&i.Kind,
+		&i.Status,
+		&i.Payload,
+		&i.ErrorMsg,
+		&i.Attempts,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const CountPendingAppJobs = `-- name: CountPendingAppJobs :one
+SELECT COUNT(*) FROM jobs WHERE app_id = ? AND status IN ('queued', 'running')
+`
+
+func (q *Queries) CountPendingAppJobs(ctx context.Context, appID string) (int64, error) {
+	row := q.queryRow(ctx, q.countPendingAppJobsStmt, CountPendingAppJobs, appID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const CreateApiToken = `-- name: CreateApiToken :execlastid
+INSERT INTO api_tokens (name, token_hash, created_at)
+VALUES (?, ?, ?)
+`
+
+type CreateApiTokenParams struct {
+	Name      string    `db:"name" json:"name"`
+	TokenHash string    `db:"token_hash" json:"token_hash"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// API token queries
+func (q *Queries) CreateApiToken(ctx context.Context, arg CreateApiTokenParams) (int64, error) {
+	result, err := q.exec(ctx, q.createApiTokenStmt, CreateApiToken, arg.Name, arg.TokenHash, arg.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
 const CreateApp = `-- name: CreateApp :exec
-INSERT INTO apps (id, name, repo_url, language, port, container_id, image_id, status, error_msg, updated_at)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+INSERT INTO apps (id, name, repo_url, language, port, container_id, image_id, status, error_msg, updated_at, ref, path, image)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 `
 
 type CreateAppParams struct {
@@ -26,6 +142,9 @@ type CreateAppParams struct {
 	Status      sql.NullString `db:"status" json:"status"`
 	ErrorMsg    sql.NullString `db:"error_msg" json:"error_msg"`
 	UpdatedAt   sql.NullTime   `db:"updated_at" json:"updated_at"`
+	Ref         sql.NullString `db:"ref" json:"ref"`
+	Path        sql.NullString `db:"path" json:"path"`
+	Image       sql.NullString `db:"image" json:"image"`
 }
 
 func (q *Queries) CreateApp(ctx context.Context, arg CreateAppParams) error {
@@ -40,6 +159,9 @@ func (q *Queries) CreateApp(ctx context.Context, arg CreateAppParams) error {
 		arg.Status,
 		arg.ErrorMsg,
 		arg.UpdatedAt,
+		arg.Ref,
+		arg.Path,
+		arg.Image,
 	)
 	return err
 }
@@ -69,6 +191,88 @@ func (q *Queries) CreateAppEnvVar(ctx context.Context, arg CreateAppEnvVarParams
 	return err
 }
 
+const CreateDeployment = `-- name: CreateDeployment :execlastid
+INSERT INTO deployments (app_id, triggered_by, runtime, status, started_at)
+VALUES (?, ?, ?, ?, ?)
+`
+
+type CreateDeploymentParams struct {
+	AppID       string    `db:"app_id" json:"app_id"`
+	TriggeredBy string    `db:"triggered_by" json:"triggered_by"`
+	Runtime     string    `db:"runtime" json:"runtime"`
+	Status      string    `db:"status" json:"status"`
+	StartedAt   time.Time `db:"started_at" json:"started_at"`
+}
+
+// Deployment history queries
+func (q *Queries) CreateDeployment(ctx context.Context, arg CreateDeploymentParams) (int64, error) {
+	result, err := q.exec(ctx, q.createDeploymentStmt, CreateDeployment,
+		arg.AppID,
+		arg.TriggeredBy,
+		arg.Runtime,
+		arg.Status,
+		arg.StartedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+const CreateJob = `-- name: CreateJob :exec
+INSERT INTO jobs (id, app_id, kind, status, payload, created_at)
+VALUES (?, ?, ?, ?, ?, ?)
+`
+
+type CreateJobParams struct {
+	ID        string         `db:"id" json:"id"`
+	AppID     string         `db:"app_id" json:"app_id"`
+	Kind      string         `db:"kind" json:"kind"`
+	Status    string         `db:"status" json:"status"`
+	Payload   sql.NullString `db:"payload" json:"payload"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+}
+
+// Deploy job queue queries
+func (q *Queries) CreateJob(ctx context.Context, arg CreateJobParams) error {
+	_, err := q.exec(ctx, q.createJobStmt, CreateJob,
+		arg.ID,
+		arg.AppID,
+		arg.Kind,
+		arg.Status,
+		arg.Payload,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const CreateReconcileAction = `-- name: CreateReconcileAction :exec
+INSERT INTO reconcile_actions (app_id, container_id, runtime, action, result, detail)
+VALUES (?, ?, ?, ?, ?, ?)
+`
+
+type CreateReconcileActionParams struct {
+	AppID       sql.NullString `db:"app_id" json:"app_id"`
+	ContainerID sql.NullString `db:"container_id" json:"container_id"`
+	Runtime     string         `db:"runtime" json:"runtime"`
+	Action      string         `db:"action" json:"action"`
+	Result      string         `db:"result" json:"result"`
+	Detail      sql.NullString `db:"detail" json:"detail"`
+}
+
+// Reconciliation audit queries
+func (q *Queries) CreateReconcileAction(ctx context.Context, arg CreateReconcileActionParams) error {
+	_, err := q.exec(ctx, q.createReconcileActionStmt, CreateReconcileAction,
+		arg.AppID,
+		arg.ContainerID,
+		arg.Runtime,
+		arg.Action,
+		arg.Result,
+		arg.Detail,
+	)
+	return err
+}
+
 const DeleteAllAppEnvVars = `-- name: DeleteAllAppEnvVars :exec
 DELETE FROM app_env_vars WHERE app_id = ?
 `
@@ -78,6 +282,18 @@ func (q *Queries) DeleteAllAppEnvVars(ctx context.Context, appID string) error {
 	return err
 }
 
+const DeleteApiToken = `-- name: DeleteApiToken :execrows
+DELETE FROM api_tokens WHERE id = ?
+`
+
+func (q *Queries) DeleteApiToken(ctx context.Context, id int64) (int64, error) {
+	result, err := q.exec(ctx, q.deleteApiTokenStmt, DeleteApiToken, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const DeleteApp = `-- name: DeleteApp :exec
 DELETE FROM apps WHERE id = ?
 `
@@ -101,9 +317,85 @@ func (q *Queries) DeleteAppEnvVar(ctx context.Context, arg DeleteAppEnvVarParams
 	return err
 }
 
+const DeleteAppGitPoll = `-- name: DeleteAppGitPoll :execrows
+DELETE FROM app_git_polls WHERE app_id = ?
+`
+
+func (q *Queries) DeleteAppGitPoll(ctx context.Context, appID string) (int64, error) {
+	result, err := q.exec(ctx, q.deleteAppGitPollStmt, DeleteAppGitPoll, appID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const DeleteAppWebhook = `-- name: DeleteAppWebhook :execrows
+DELETE FROM app_webhooks WHERE app_id = ?
+`
+
+func (q *Queries) DeleteAppWebhook(ctx context.Context, appID string) (int64, error) {
+	result, err := q.exec(ctx, q.deleteAppWebhookStmt, DeleteAppWebhook, appID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const DeleteWebhookDelivery = `-- name: DeleteWebhookDelivery :exec
+DELETE FROM webhook_deliveries WHERE provider = ? AND delivery_id = ?
+`
+
+type DeleteWebhookDeliveryParams struct {
+	Provider   string `db:"provider" json:"provider"`
+	DeliveryID string `db:"delivery_id" json:"delivery_id"`
+}
+
+func (q *Queries) DeleteWebhookDelivery(ctx context.Context, arg DeleteWebhookDeliveryParams) error {
+	_, err := q.exec(ctx, q.deleteWebhookDeliveryStmt, DeleteWebhookDelivery, arg.Provider, arg.DeliveryID)
+	return err
+}
+
+const FailRunningDeployments = `-- name: FailRunningDeployments :exec
+UPDATE deployments SET status = 'failed', error_msg = ?, finished_at = ?
+WHERE status = 'running'
+`
+
+type FailRunningDeploymentsParams struct {
+	ErrorMsg   sql.NullString `db:"error_msg" json:"error_msg"`
+	FinishedAt sql.NullTime   `db:"finished_at" json:"finished_at"`
+}
+
+func (q *Queries) FailRunningDeployments(ctx context.Context, arg FailRunningDeploymentsParams) error {
+	_, err := q.exec(ctx, q.failRunningDeploymentsStmt, FailRunningDeployments, arg.ErrorMsg, arg.FinishedAt)
+	return err
+}
+
+const FinishJob = `-- name: FinishJob :exec
+UPDATE jobs SET status = ?, error_msg = ?, finished_at = ? WHERE id = ?
+`
+
+type FinishJobParams struct {
+	Status     string         `db:"status" json:"status"`
+	ErrorMsg   sql.NullString `db:"error_msg" json:"error_msg"`
+	FinishedAt sql.NullTime   `db:"finished_at" json:"finished_at"`
+	ID         string         `db:"id" json:"id"`
+}
+
+func (q *Queries) FinishJob(ctx context.Context, arg FinishJobParams) error {
+	_, err := q.exec(ctx, q.finishJobStmt, FinishJob,
+		arg.Status,
+		arg.ErrorMsg,
+		arg.FinishedAt,
+		arg.ID,
+	)
+	return err
+}
+
 const GetAllApps = `-- name: GetAllApps :many
 SELECT id, name, repo_url, language, port, container_id, image_id,
-    status, error_msg, created_at, updated_at
+    status, error_msg, created_at, updated_at, runtime_config, ref, dockerfile_path, start_command,
+    language_override, path, go_target, go_tags, go_ldflags, image, registry_username, registry_password,
+    upstream_port, health_check_path
 FROM apps
 `
 
@@ -128,6 +420,20 @@ func (q *Queries) GetAllApps(ctx context.Context) ([]App, error) {
 			&i.ErrorMsg,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.RuntimeConfig,
+			&i.Ref,
+			&i.DockerfilePath,
+			&i.StartCommand,
+			&i.LanguageOverride,
+			&i.Path,
+			&i.GoTarget,
+			&i.GoTags,
+			&i.GoLdflags,
+			&i.Image,
+			&i.RegistryUsername,
+			&i.RegistryPassword,
+			&i.UpstreamPort,
+			&i.HealthCheckPath,
 		); err != nil {
 			return nil, err
 		}
@@ -142,35 +448,29 @@ func (q *Queries) GetAllApps(ctx context.Context) ([]App, error) {
 	return items, nil
 }
 
-const GetApp = `-- name: GetApp :one
-SELECT id, name, repo_url, language, port, container_id, image_id, status, error_msg, created_at, updated_at FROM apps WHERE id = ?
+const GetApiTokenByHash = `-- name: GetApiTokenByHash :one
+SELECT id, name, token_hash, created_at, last_used_at FROM api_tokens WHERE token_hash = ?
 `
 
-func (q *Queries) GetApp(ctx context.Context, id string) (App, error) {
-	row := q.queryRow(ctx, q.getAppStmt, GetApp, id)
-	var i App
+func (q *Queries) GetApiTokenByHash(ctx context.Context, tokenHash string) (ApiToken, error) {
+	row := q.queryRow(ctx, q.getApiTokenByHashStmt, GetApiTokenByHash, tokenHash)
+	var i ApiToken
 	err := row.Scan(
 		&i.ID,
 		&i.Name,
-		&i.RepoUrl,
-		&i.Language,
-		&i.Port,
-		&i.ContainerID,
-		&i.ImageID,
-		&i.Status,
-		&i.ErrorMsg,
+		&i.TokenHash,
 		&i.CreatedAt,
-		&i.UpdatedAt,
+		&i.LastUsedAt,
 	)
 	return i, err
 }
 
-const GetAppByRepoUrl = `-- name: GetAppByRepoUrl :one
-SELECT id, name, repo_url, language, port, container_id, image_id, status, error_msg, created_at, updated_at FROM apps WHERE repo_url = ?
+const GetApp = `-- name: GetApp :one
+SELECT id, name, repo_url, language, port, container_id, image_id, status, error_msg, created_at, updated_at, runtime_config, ref, dockerfile_path, start_command, language_override, path, go_target, go_tags, go_ldflags, image, registry_username, registry_password, upstream_port, health_check_path FROM apps WHERE id = ?
 `
 
-func (q *Queries) GetAppByRepoUrl(ctx context.Context, repoUrl string) (App, error) {
-	row := q.queryRow(ctx, q.getAppByRepoUrlStmt, GetAppByRepoUrl, repoUrl)
+func (q *Queries) GetApp(ctx context.Context, id string) (App, error) {
+	row := q.queryRow(ctx, q.getAppStmt, GetApp, id)
 	var i App
 	err := row.Scan(
 		&i.ID,
@@ -184,6 +484,20 @@ func (q *Queries) GetAppByRepoUrl(ctx context.Context, repoUrl string) (App, err
 		&i.ErrorMsg,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.RuntimeConfig,
+		&i.Ref,
+		&i.DockerfilePath,
+		&i.StartCommand,
+		&i.LanguageOverride,
+		&i.Path,
+		&i.GoTarget,
+		&i.GoTags,
+		&i.GoLdflags,
+		&i.Image,
+		&i.RegistryUsername,
+		&i.RegistryPassword,
+		&i.UpstreamPort,
+		&i.HealthCheckPath,
 	)
 	return i, err
 }
@@ -249,58 +563,949 @@ func (q *Queries) GetAppEnvVars(ctx context.Context, appID string) ([]AppEnvVar,
 	return items, nil
 }
 
-const UpdateApp = `-- name: UpdateApp :exec
-UPDATE apps SET name = ?, repo_url = ?, language = ?, port = ?, container_id = ?, image_id = ?, status = ?, error_msg = ?, updated_at = ? WHERE id = ?
+const GetAppGitPoll = `-- name: GetAppGitPoll :one
+SELECT app_id, branch, interval_seconds, github_token, last_commit, last_checked_at, last_error, created_at
+FROM app_git_polls WHERE app_id = ?
 `
 
-type UpdateAppParams struct {
-	Name        string         `db:"name" json:"name"`
-	RepoUrl     string         `db:"repo_url" json:"repo_url"`
-	Language    sql.NullString `db:"language" json:"language"`
-	Port        int64          `db:"port" json:"port"`
-	ContainerID sql.NullString `db:"container_id" json:"container_id"`
-	ImageID     sql.NullString `db:"image_id" json:"image_id"`
-	Status      sql.NullString `db:"status" json:"status"`
-	ErrorMsg    sql.NullString `db:"error_msg" json:"error_msg"`
-	UpdatedAt   sql.NullTime   `db:"updated_at" json:"updated_at"`
-	ID          string         `db:"id" json:"id"`
+func (q *Queries) GetAppGitPoll(ctx context.Context, appID string) (AppGitPoll, error) {
+	row := q.queryRow(ctx, q.getAppGitPollStmt, GetAppGitPoll, appID)
+	var i AppGitPoll
+	err := row.Scan(
+		&i.AppID,
+		&i.Branch,
+		&i.IntervalSeconds,
+		&i.GithubToken,
+		&i.LastCommit,
+		&i.LastCheckedAt,
+		&i.LastError,
+		&i.CreatedAt,
+	)
+	return i, err
 }
 
-func (q *Queries) UpdateApp(ctx context.Context, arg UpdateAppParams) error {
-	_, err := q.exec(ctx, q.updateAppStmt, UpdateApp,
-		arg.Name,
-		arg.RepoUrl,
-		arg.Language,
-		arg.Port,
-		arg.ContainerID,
-		arg.ImageID,
-		arg.Status,
-		arg.ErrorMsg,
-		arg.UpdatedAt,
-		arg.ID,
+const GetAppWebhook = `-- name: GetAppWebhook :one
+SELECT app_id, secret, branch, created_at FROM app_webhooks WHERE app_id = ?
+`
+
+func (q *Queries) GetAppWebhook(ctx context.Context, appID string) (AppWebhook, error) {
+	row := q.queryRow(ctx, q.getAppWebhookStmt, GetAppWebhook, appID)
+	var i AppWebhook
+	err := row.Scan(
+		&i.AppID,
+		&i.Secret,
+		&i.Branch,
+		&i.CreatedAt,
 	)
-	return err
+	return i, err
 }
 
-const UpdateAppEnvVar = `-- name: UpdateAppEnvVar :exec
-UPDATE app_env_vars SET value = ?, is_secret = ?, updated_at = ? WHERE app_id = ? AND key = ?
+const GetCurrentDeployment = `-- name: GetCurrentDeployment :one
+SELECT id, app_id, triggered_by, commit_sha, image_tag, runtime, status, error_msg,
+    steps, build_log, started_at, finished_at, duration_ms, env_snapshot, dockerfile,
+    language_detection
+FROM deployments WHERE app_id = ? AND status = 'succeeded' ORDER BY id DESC LIMIT 1
 `
 
-type UpdateAppEnvVarParams struct {
-	Value     string       `db:"value" json:"value"`
-	IsSecret  sql.NullBool `db:"is_secret" json:"is_secret"`
-	UpdatedAt sql.NullTime `db:"updated_at" json:"updated_at"`
-	AppID     string       `db:"app_id" json:"app_id"`
-	Key       string       `db:"key" json:"key"`
+func (q *Queries) GetCurrentDeployment(ctx context.Context, appID string) (Deployment, error) {
+	row := q.queryRow(ctx, q.getCurrentDeploymentStmt, GetCurrentDeployment, appID)
+	var i Deployment
+	err := row.Scan(
+		&i.ID,
+		&i.AppID,
+		&i.TriggeredBy,
+		&i.CommitSha,
+		&i.ImageTag,
+		&i.Runtime,
+		&i.Status,
+		&i.ErrorMsg,
+		&i.Steps,
+		&i.BuildLog,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.DurationMs,
+		&i.EnvSnapshot,
+		&i.Dockerfile,
+		&i.LanguageDetection,
+	)
+	return i, err
 }
 
-func (q *Queries) UpdateAppEnvVar(ctx context.Context, arg UpdateAppEnvVarParams) error {
-	_, err := q.exec(ctx, q.updateAppEnvVarStmt, UpdateAppEnvVar,
-		arg.Value,
-		arg.IsSecret,
-		arg.UpdatedAt,
-		arg.AppID,
-		arg.Key,
+const GetDeployment = `-- name: GetDeployment :one
+SELECT id, app_id, triggered_by, commit_sha, image_tag, runtime, status, error_msg,
+    steps, build_log, started_at, finished_at, duration_ms, env_snapshot, dockerfile,
+    language_detection
+FROM deployments WHERE id = ?
+`
+
+func (q *Queries) GetDeployment(ctx context.Context, id int64) (Deployment, error) {
+	row := q.queryRow(ctx, q.getDeploymentStmt, GetDeployment, id)
+	var i Deployment
+	err := row.Scan(
+		&i.ID,
+		&i.AppID,
+		&i.TriggeredBy,
+		&i.CommitSha,
+		&i.ImageTag,
+		&i.Runtime,
+		&i.Status,
+		&i.ErrorMsg,
+		&i.Steps,
+		&i.BuildLog,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.DurationMs,
+		&i.EnvSnapshot,
+		&i.Dockerfile,
+		&i.LanguageDetection,
+	)
+	return i, err
+}
+
+const GetJob = `-- name: GetJob :one
+SELECT id, app_id, kind, status, payload, error_msg, attempts, created_at, started_at, finished_at
+FROM jobs WHERE id = ?
+`
+
+func (q *Queries) GetJob(ctx context.Context, id string) (Job, error) {
+	row := q.queryRow(ctx, q.getJobStmt, GetJob, id)
+	var i Job
+	err := row.Scan(
+		&i.ID,
+		&i.AppID,
+		&i.Kind,
+		&i.Status,
+		&i.Payload,
+		&i.ErrorMsg,
+		&i.Attempts,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const ListApiTokens = `-- name: ListApiTokens :many
+SELECT id, name, token_hash, created_at, last_used_at FROM api_tokens ORDER BY id
+`
+
+func (q *Queries) ListApiTokens(ctx context.Context) ([]ApiToken, error) {
+	rows, err := q.query(ctx, q.listApiTokensStmt, ListApiTokens)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ApiToken{}
+	for rows.Next() {
+		var i ApiToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.TokenHash,
+			&i.CreatedAt,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListAppDeployments = `-- name: ListAppDeployments :many
+SELECT id, app_id, triggered_by, commit_sha, image_tag, runtime, status, error_msg,
+    steps, build_log, started_at, finished_at, duration_ms, env_snapshot, dockerfile,
+    language_detection
+FROM deployments WHERE app_id = ? ORDER BY id DESC LIMIT ?
+`
+
+type ListAppDeploymentsParams struct {
+	AppID string `db:"app_id" json:"app_id"`
+	Limit int64  `db:"limit" json:"limit"`
+}
+
+func (q *Queries) ListAppDeployments(ctx context.Context, arg ListAppDeploymentsParams) ([]Deployment, error) {
+	rows, err := q.query(ctx, q.listAppDeploymentsStmt, ListAppDeployments, arg.AppID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Deployment{}
+	for rows.Next() {
+		var i Deployment
+		if err := rows.Scan(
+			&i.ID,
+			&i.AppID,
+			&i.TriggeredBy,
+			&i.CommitSha,
+			&i.ImageTag,
+			&i.Runtime,
+			&i.Status,
+			&i.ErrorMsg,
+			&i.Steps,
+			&i.BuildLog,
+			&i.StartedAt,
+			&i.FinishedAt,
+			&i.DurationMs,
+			&i.EnvSnapshot,
+			&i.Dockerfile,
+			&i.LanguageDetection,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListAppGitPolls = `-- name: ListAppGitPolls :many
+SELECT app_id, branch, interval_seconds, github_token, last_commit, last_checked_at, last_error, created_at
+FROM app_git_polls ORDER BY app_id
+`
+
+func (q *Queries) ListAppGitPolls(ctx context.Context) ([]AppGitPoll, error) {
+	rows, err := q.query(ctx, q.listAppGitPollsStmt, ListAppGitPolls)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AppGitPoll{}
+	for rows.Next() {
+		var i AppGitPoll
+		if err := rows.Scan(
+			&i.AppID,
+			&i.Branch,
+			&i.IntervalSeconds,
+			&i.GithubToken,
+			&i.LastCommit,
+			&i.LastCheckedAt,
+			&i.LastError,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListAppProcesses = `-- name: ListAppProcesses :many
+SELECT app_id, type, scale, updated_at FROM app_processes WHERE app_id = ? ORDER BY type
+`
+
+func (q *Queries) ListAppProcesses(ctx context.Context, appID string) ([]AppProcess, error) {
+	rows, err := q.query(ctx, q.listAppProcessesStmt, ListAppProcesses, appID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AppProcess{}
+	for rows.Next() {
+		var i AppProcess
+		if err := rows.Scan(
+			&i.AppID,
+			&i.Type,
+			&i.Scale,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListAppsByRepoPath = `-- name: ListAppsByRepoPath :many
+SELECT id, name, repo_url, language, port, container_id, image_id, status, error_msg, created_at, updated_at, runtime_config, ref, dockerfile_path, start_command, language_override, path, go_target, go_tags, go_ldflags, image, registry_username, registry_password, upstream_port, health_check_path FROM apps WHERE repo_url = ?1 AND COALESCE(path, '') = CAST(?2 AS TEXT) ORDER BY created_at
+`
+
+type ListAppsByRepoPathParams struct {
+	RepoUrl string `db:"repo_url" json:"repo_url"`
+	Path    string `db:"path" json:"path"`
+}
+
+func (q *Queries) ListAppsByRepoPath(ctx context.Context, arg ListAppsByRepoPathParams) ([]App, error) {
+	rows, err := q.query(ctx, q.listAppsByRepoPathStmt, ListAppsByRepoPath, arg.RepoUrl, arg.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []App{}
+	for rows.Next() {
+		var i App
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.RepoUrl,
+			&i.Language,
+			&i.Port,
+			&i.ContainerID,
+			&i.ImageID,
+			&i.Status,
+			&i.ErrorMsg,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.RuntimeConfig,
+			&i.Ref,
+			&i.DockerfilePath,
+			&i.StartCommand,
+			&i.LanguageOverride,
+			&i.Path,
+			&i.GoTarget,
+			&i.GoTags,
+			&i.GoLdflags,
+			&i.Image,
+			&i.RegistryUsername,
+			&i.RegistryPassword,
+			&i.UpstreamPort,
+			&i.HealthCheckPath,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListImageApps = `-- name: ListImageApps :many
+SELECT id, name, repo_url, language, port, container_id, image_id, status, error_msg, created_at, updated_at, runtime_config, ref, dockerfile_path, start_command, language_override, path, go_target, go_tags, go_ldflags, image, registry_username, registry_password, upstream_port, health_check_path FROM apps WHERE image IS NOT NULL ORDER BY created_at
+`
+
+func (q *Queries) ListImageApps(ctx context.Context) ([]App, error) {
+	rows, err := q.query(ctx, q.listImageAppsStmt, ListImageApps)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []App{}
+	for rows.Next() {
+		var i App
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.RepoUrl,
+			&i.Language,
+			&i.Port,
+			&i.ContainerID,
+			&i.ImageID,
+			&i.Status,
+			&i.ErrorMsg,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.RuntimeConfig,
+			&i.Ref,
+			&i.DockerfilePath,
+			&i.StartCommand,
+			&i.LanguageOverride,
+			&i.Path,
+			&i.GoTarget,
+			&i.GoTags,
+			&i.GoLdflags,
+			&i.Image,
+			&i.RegistryUsername,
+			&i.RegistryPassword,
+			&i.UpstreamPort,
+			&i.HealthCheckPath,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListJobs = `-- name: ListJobs :many
+SELECT id, app_id, kind, status, payload, error_msg, attempts, created_at, started_at, finished_at
+FROM jobs ORDER BY created_at DESC LIMIT ?
+`
+
+func (q *Queries) ListJobs(ctx context.Context, limit int64) ([]Job, error) {
+	rows, err := q.query(ctx, q.listJobsStmt, ListJobs, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Job{}
+	for rows.Next() {
+		var i Job
+		if err := rows.Scan(
+			&i.ID,
+			&i.AppID,
+			&i.Kind,
+			&i.Status,
+			&i.Payload,
+			&i.ErrorMsg,
+			&i.Attempts,
+			&i.CreatedAt,
+			&i.StartedAt,
+			&i.FinishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListPendingAppJobs = `-- name: ListPendingAppJobs :many
+SELECT id, app_id, kind, status, payload, error_msg, attempts, created_at, started_at, finished_at
+FROM jobs WHERE app_id = ? AND status IN ('queued', 'running')
+ORDER BY created_at, rowid
+`
+
+func (q *Queries) ListPendingAppJobs(ctx context.Context, appID string) ([]Job, error) {
+	rows, err := q.query(ctx, q.listPendingAppJobsStmt, ListPendingAppJobs, appID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Job{}
+	for rows.Next() {
+		var i Job
+		if err := rows.Scan(
+			&i.ID,
+			&i.AppID,
+			&i.Kind,
+			&i.Status,
+			&i.Payload,
+			&i.ErrorMsg,
+			&i.Attempts,
+			&i.CreatedAt,
+			&i.StartedAt,
+			&i.FinishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const ListReconcileActions = `-- name: ListReconcileActions :many
+SELECT id, app_id, container_id, runtime, action, result, detail, created_at
+FROM reconcile_actions ORDER BY id DESC LIMIT ?
+`
+
+func (q *Queries) ListReconcileActions(ctx context.Context, limit int64) ([]ReconcileAction, error) {
+	rows, err := q.query(ctx, q.listReconcileActionsStmt, ListReconcileActions, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ReconcileAction{}
+	for rows.Next() {
+		var i ReconcileAction
+		if err := rows.Scan(
+			&i.ID,
+			&i.AppID,
+			&i.ContainerID,
+			&i.Runtime,
+			&i.Action,
+			&i.Result,
+			&i.Detail,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const PruneWebhookDeliveries = `-- name: PruneWebhookDeliveries :exec
+DELETE FROM webhook_deliveries WHERE received_at < ?
+`
+
+func (q *Queries) PruneWebhookDeliveries(ctx context.Context, receivedAt time.Time) error {
+	_, err := q.exec(ctx, q.pruneWebhookDeliveriesStmt, PruneWebhookDeliveries, receivedAt)
+	return err
+}
+
+const RecordWebhookDelivery = `-- name: RecordWebhookDelivery :execrows
+INSERT INTO webhook_deliveries (provider, delivery_id, app_id, received_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT(provider, delivery_id) DO NOTHING
+`
+
+type RecordWebhookDeliveryParams struct {
+	Provider   string    `db:"provider" json:"provider"`
+	DeliveryID string    `db:"delivery_id" json:"delivery_id"`
+	AppID      string    `db:"app_id" json:"app_id"`
+	ReceivedAt time.Time `db:"received_at" json:"received_at"`
+}
+
+func (q *Queries) RecordWebhookDelivery(ctx context.Context, arg RecordWebhookDeliveryParams) (int64, error) {
+	result, err := q.exec(ctx, q.recordWebhookDeliveryStmt, RecordWebhookDelivery,
+		arg.Provider,
+		arg.DeliveryID,
+		arg.AppID,
+		arg.ReceivedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const ReleaseAppLock = `-- name: ReleaseAppLock :exec
+DELETE FROM app_locks WHERE app_id = ? AND job_id = ?
+`
+
+type ReleaseAppLockParams struct {
+	AppID string `db:"app_id" json:"app_id"`
+	JobID string `db:"job_id" json:"job_id"`
+}
+
+func (q *Queries) ReleaseAppLock(ctx context.Context, arg ReleaseAppLockParams) error {
+	_, err := q.exec(ctx, q.releaseAppLockStmt, ReleaseAppLock, arg.AppID, arg.JobID)
+	return err
+}
+
+const RenewAppLock = `-- name: RenewAppLock :execrows
+UPDATE app_locks SET expires_at = ? WHERE app_id = ? AND job_id = ?
+`
+
+type RenewAppLockParams struct {
+	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+	AppID     string    `db:"app_id" json:"app_id"`
+	JobID     string    `db:"job_id" json:"job_id"`
+}
+
+func (q *Queries) RenewAppLock(ctx context.Context, arg RenewAppLockParams) (int64, error) {
+	result, err := q.exec(ctx, q.renewAppLockStmt, RenewAppLock, arg.ExpiresAt, arg.AppID, arg.JobID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const RequeueRunningJobs = `-- name: RequeueRunningJobs :execrows
+UPDATE jobs SET status = 'queued', started_at = NULL WHERE status = 'running'
+`
+
+func (q *Queries) RequeueRunningJobs(ctx context.Context) (int64, error) {
+	result, err := q.exec(ctx, q.requeueRunningJobsStmt, RequeueRunningJobs)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const TouchApiToken = `-- name: TouchApiToken :exec
+UPDATE api_tokens SET last_used_at = ? WHERE id = ?
+`
+
+type TouchApiTokenParams struct {
+	LastUsedAt sql.NullTime `db:"last_used_at" json:"last_used_at"`
+	ID         int64        `db:"id" json:"id"`
+}
+
+func (q *Queries) TouchApiToken(ctx context.Context, arg TouchApiTokenParams) error {
+	_, err := q.exec(ctx, q.touchApiTokenStmt, TouchApiToken, arg.LastUsedAt, arg.ID)
+	return err
+}
+
+const UpdateApp = `-- name: UpdateApp :exec
+UPDATE apps SET name = ?, repo_url = ?, language = ?, port = ?, container_id = ?, image_id = ?, status = ?, error_msg = ?, updated_at = ? WHERE id = ?
+`
+
+type UpdateAppParams struct {
+	Name        string         `db:"name" json:"name"`
+	RepoUrl     string         `db:"repo_url" json:"repo_url"`
+	Language    sql.NullString `db:"language" json:"language"`
+	Port        int64          `db:"port" json:"port"`
+	ContainerID sql.NullString `db:"container_id" json:"container_id"`
+	ImageID     sql.NullString `db:"image_id" json:"image_id"`
+	Status      sql.NullString `db:"status" json:"status"`
+	ErrorMsg    sql.NullString `db:"error_msg" json:"error_msg"`
+	UpdatedAt   sql.NullTime   `db:"updated_at" json:"updated_at"`
+	ID          string         `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateApp(ctx context.Context, arg UpdateAppParams) error {
+	_, err := q.exec(ctx, q.updateAppStmt, UpdateApp,
+		arg.Name,
+		arg.RepoUrl,
+		arg.Language,
+		arg.Port,
+		arg.ContainerID,
+		arg.ImageID,
+		arg.Status,
+		arg.ErrorMsg,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	return err
+}
+
+const UpdateAppDockerfilePath = `-- name: UpdateAppDockerfilePath :exec
+UPDATE apps SET dockerfile_path = ?, updated_at = ? WHERE id = ?
+`
+
+type UpdateAppDockerfilePathParams struct {
+	DockerfilePath sql.NullString `db:"dockerfile_path" json:"dockerfile_path"`
+	UpdatedAt      sql.NullTime   `db:"updated_at" json:"updated_at"`
+	ID             string         `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateAppDockerfilePath(ctx context.Context, arg UpdateAppDockerfilePathParams) error {
+	_, err := q.exec(ctx, q.updateAppDockerfilePathStmt, UpdateAppDockerfilePath, arg.DockerfilePath, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const UpdateAppEnvVar = `-- name: UpdateAppEnvVar :exec
+UPDATE app_env_vars SET value = ?, is_secret = ?, updated_at = ? WHERE app_id = ? AND key = ?
+`
+
+type UpdateAppEnvVarParams struct {
+	Value     string       `db:"value" json:"value"`
+	IsSecret  sql.NullBool `db:"is_secret" json:"is_secret"`
+	UpdatedAt sql.NullTime `db:"updated_at" json:"updated_at"`
+	AppID     string       `db:"app_id" json:"app_id"`
+	Key       string       `db:"key" json:"key"`
+}
+
+func (q *Queries) UpdateAppEnvVar(ctx context.Context, arg UpdateAppEnvVarParams) error {
+	_, err := q.exec(ctx, q.updateAppEnvVarStmt, UpdateAppEnvVar,
+		arg.Value,
+		arg.IsSecret,
+		arg.UpdatedAt,
+		arg.AppID,
+		arg.Key,
+	)
+	return err
+}
+
+const UpdateAppGitPollCheck = `-- name: UpdateAppGitPollCheck :exec
+UPDATE app_git_polls SET last_commit = ?, last_checked_at = ?, last_error = ? WHERE app_id = ?
+`
+
+type UpdateAppGitPollCheckParams struct {
+	LastCommit    sql.NullString `db:"last_commit" json:"last_commit"`
+	LastCheckedAt sql.NullTime   `db:"last_checked_at" json:"last_checked_at"`
+	LastError     sql.NullString `db:"last_error" json:"last_error"`
+	AppID         string         `db:"app_id" json:"app_id"`
+}
+
+func (q *Queries) UpdateAppGitPollCheck(ctx context.Context, arg UpdateAppGitPollCheckParams) error {
+	_, err := q.exec(ctx, q.updateAppGitPollCheckStmt, UpdateAppGitPollCheck,
+		arg.LastCommit,
+		arg.LastCheckedAt,
+		arg.LastError,
+		arg.AppID,
+	)
+	return err
+}
+
+const UpdateAppGoBuild = `-- name: UpdateAppGoBuild :exec
+UPDATE apps SET go_target = ?, go_tags = ?, go_ldflags = ?, updated_at = ? WHERE id = ?
+`
+
+type UpdateAppGoBuildParams struct {
+	GoTarget  sql.NullString `db:"go_target" json:"go_target"`
+	GoTags    sql.NullString `db:"go_tags" json:"go_tags"`
+	GoLdflags sql.NullString `db:"go_ldflags" json:"go_ldflags"`
+	UpdatedAt sql.NullTime   `db:"updated_at" json:"updated_at"`
+	ID        string         `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateAppGoBuild(ctx context.Context, arg UpdateAppGoBuildParams) error {
+	_, err := q.exec(ctx, q.updateAppGoBuildStmt, UpdateAppGoBuild,
+		arg.GoTarget,
+		arg.GoTags,
+		arg.GoLdflags,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	return err
+}
+
+const UpdateAppHealthCheckPath = `-- name: UpdateAppHealthCheckPath :exec
+UPDATE apps SET health_check_path = ?, updated_at = ? WHERE id = ?
+`
+
+type UpdateAppHealthCheckPathParams struct {
+	HealthCheckPath sql.NullString `db:"health_check_path" json:"health_check_path"`
+	UpdatedAt       sql.NullTime   `db:"updated_at" json:"updated_at"`
+	ID              string         `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateAppHealthCheckPath(ctx context.Context, arg UpdateAppHealthCheckPathParams) error {
+	_, err := q.exec(ctx, q.updateAppHealthCheckPathStmt, UpdateAppHealthCheckPath, arg.HealthCheckPath, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const UpdateAppImage = `-- name: UpdateAppImage :exec
+UPDATE apps SET image = ?, registry_username = ?, registry_password = ?, updated_at = ? WHERE id = ?
+`
+
+type UpdateAppImageParams struct {
+	Image            sql.NullString `db:"image" json:"image"`
+	RegistryUsername sql.NullString `db:"registry_username" json:"registry_username"`
+	RegistryPassword sql.NullString `db:"registry_password" json:"registry_password"`
+	UpdatedAt        sql.NullTime   `db:"updated_at" json:"updated_at"`
+	ID               string         `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateAppImage(ctx context.Context, arg UpdateAppImageParams) error {
+	_, err := q.exec(ctx, q.updateAppImageStmt, UpdateAppImage,
+		arg.Image,
+		arg.RegistryUsername,
+		arg.RegistryPassword,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	return err
+}
+
+const UpdateAppLanguageOverride = `-- name: UpdateAppLanguageOverride :exec
+UPDATE apps SET language_override = ?, updated_at = ? WHERE id = ?
+`
+
+type UpdateAppLanguageOverrideParams struct {
+	LanguageOverride sql.NullString `db:"language_override" json:"language_override"`
+	UpdatedAt        sql.NullTime   `db:"updated_at" json:"updated_at"`
+	ID               string         `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateAppLanguageOverride(ctx context.Context, arg UpdateAppLanguageOverrideParams) error {
+	_, err := q.exec(ctx, q.updateAppLanguageOverrideStmt, UpdateAppLanguageOverride, arg.LanguageOverride, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const UpdateAppRef = `-- name: UpdateAppRef :exec
+UPDATE apps SET ref = ?, updated_at = ? WHERE id = ?
+`
+
+type UpdateAppRefParams struct {
+	Ref       sql.NullString `db:"ref" json:"ref"`
+	UpdatedAt sql.NullTime   `db:"updated_at" json:"updated_at"`
+	ID        string         `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateAppRef(ctx context.Context, arg UpdateAppRefParams) error {
+	_, err := q.exec(ctx, q.updateAppRefStmt, UpdateAppRef, arg.Ref, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const UpdateAppRuntimeConfig = `-- name: UpdateAppRuntimeConfig :exec
+UPDATE apps SET image_id = ?, runtime_config = ?, updated_at = ? WHERE id = ?
+`
+
+type UpdateAppRuntimeConfigParams struct {
+	ImageID       sql.NullString `db:"image_id" json:"image_id"`
+	RuntimeConfig sql.NullString `db:"runtime_config" json:"runtime_config"`
+	UpdatedAt     sql.NullTime   `db:"updated_at" json:"updated_at"`
+	ID            string         `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateAppRuntimeConfig(ctx context.Context, arg UpdateAppRuntimeConfigParams) error {
+	_, err := q.exec(ctx, q.updateAppRuntimeConfigStmt, UpdateAppRuntimeConfig,
+		arg.ImageID,
+		arg.RuntimeConfig,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	return err
+}
+
+const UpdateAppStartCommand = `-- name: UpdateAppStartCommand :exec
+UPDATE apps SET start_command = ?, updated_at = ? WHERE id = ?
+`
+
+type UpdateAppStartCommandParams struct {
+	StartCommand sql.NullString `db:"start_command" json:"start_command"`
+	UpdatedAt    sql.NullTime   `db:"updated_at" json:"updated_at"`
+	ID           string         `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateAppStartCommand(ctx context.Context, arg UpdateAppStartCommandParams) error {
+	_, err := q.exec(ctx, q.updateAppStartCommandStmt, UpdateAppStartCommand, arg.StartCommand, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const UpdateAppUpstreamPort = `-- name: UpdateAppUpstreamPort :exec
+UPDATE apps SET upstream_port = ?, updated_at = ? WHERE id = ?
+`
+
+type UpdateAppUpstreamPortParams struct {
+	UpstreamPort sql.NullInt64 `db:"upstream_port" json:"upstream_port"`
+	UpdatedAt    sql.NullTime  `db:"updated_at" json:"updated_at"`
+	ID           string        `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateAppUpstreamPort(ctx context.Context, arg UpdateAppUpstreamPortParams) error {
+	_, err := q.exec(ctx, q.updateAppUpstreamPortStmt, UpdateAppUpstreamPort, arg.UpstreamPort, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const UpdateDeployment = `-- name: UpdateDeployment :exec
+UPDATE deployments SET commit_sha = ?, image_tag = ?, runtime = ?, status = ?, error_msg = ?,
+    steps = ?, build_log = ?, finished_at = ?, duration_ms = ?, env_snapshot = ?,
+    dockerfile = ?, language_detection = ?
+WHERE id = ?
+`
+
+type UpdateDeploymentParams struct {
+	CommitSha         sql.NullString `db:"commit_sha" json:"commit_sha"`
+	ImageTag          sql.NullString `db:"image_tag" json:"image_tag"`
+	Runtime           string         `db:"runtime" json:"runtime"`
+	Status            string         `db:"status" json:"status"`
+	ErrorMsg          sql.NullString `db:"error_msg" json:"error_msg"`
+	Steps             sql.NullString `db:"steps" json:"steps"`
+	BuildLog          sql.NullString `db:"build_log" json:"build_log"`
+	FinishedAt        sql.NullTime   `db:"finished_at" json:"finished_at"`
+	DurationMs        sql.NullInt64  `db:"duration_ms" json:"duration_ms"`
+	EnvSnapshot       sql.NullString `db:"env_snapshot" json:"env_snapshot"`
+	Dockerfile        sql.NullString `db:"dockerfile" json:"dockerfile"`
+	LanguageDetection sql.NullString `db:"language_detection" json:"language_detection"`
+	ID                int64          `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateDeployment(ctx context.Context, arg UpdateDeploymentParams) error {
+	_, err := q.exec(ctx, q.updateDeploymentStmt, UpdateDeployment,
+		arg.CommitSha,
+		arg.ImageTag,
+		arg.Runtime,
+		arg.Status,
+		arg.ErrorMsg,
+		arg.Steps,
+		arg.BuildLog,
+		arg.FinishedAt,
+		arg.DurationMs,
+		arg.EnvSnapshot,
+		arg.Dockerfile,
+		arg.LanguageDetection,
+		arg.ID,
+	)
+	return err
+}
+
+const UpsertAppGitPoll = `-- name: UpsertAppGitPoll :exec
+INSERT INTO app_git_polls (app_id, branch, interval_seconds, github_token, created_at)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT(app_id) DO UPDATE SET branch = excluded.branch, interval_seconds = excluded.interval_seconds,
+    github_token = excluded.github_token, last_commit = NULL, last_checked_at = NULL, last_error = NULL
+`
+
+type UpsertAppGitPollParams struct {
+	AppID           string         `db:"app_id" json:"app_id"`
+	Branch          string         `db:"branch" json:"branch"`
+	IntervalSeconds int64          `db:"interval_seconds" json:"interval_seconds"`
+	GithubToken     sql.NullString `db:"github_token" json:"github_token"`
+	CreatedAt       time.Time      `db:"created_at" json:"created_at"`
+}
+
+// Git polling queries
+func (q *Queries) UpsertAppGitPoll(ctx context.Context, arg UpsertAppGitPollParams) error {
+	_, err := q.exec(ctx, q.upsertAppGitPollStmt, UpsertAppGitPoll,
+		arg.AppID,
+		arg.Branch,
+		arg.IntervalSeconds,
+		arg.GithubToken,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const UpsertAppProcessScale = `-- name: UpsertAppProcessScale :exec
+INSERT INTO app_processes (app_id, type, scale, updated_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT(app_id, type) DO UPDATE SET scale = excluded.scale, updated_at = excluded.updated_at
+`
+
+type UpsertAppProcessScaleParams struct {
+	AppID     string    `db:"app_id" json:"app_id"`
+	Type      string    `db:"type" json:"type"`
+	Scale     int64     `db:"scale" json:"scale"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// Process scale queries
+func (q *Queries) UpsertAppProcessScale(ctx context.Context, arg UpsertAppProcessScaleParams) error {
+	_, err := q.exec(ctx, q.upsertAppProcessScaleStmt, UpsertAppProcessScale,
+		arg.AppID,
+		arg.Type,
+		arg.Scale,
+		arg.UpdatedAt,
+	)
+	return err
+}
+
+const UpsertAppWebhook = `-- name: UpsertAppWebhook :exec
+INSERT INTO app_webhooks (app_id, secret, branch, created_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT(app_id) DO UPDATE SET secret = excluded.secret, branch = excluded.branch, created_at = excluded.created_at
+`
+
+type UpsertAppWebhookParams struct {
+	AppID     string    `db:"app_id" json:"app_id"`
+	Secret    string    `db:"secret" json:"secret"`
+	Branch    string    `db:"branch" json:"branch"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// Webhook queries
+func (q *Queries) UpsertAppWebhook(ctx context.Context, arg UpsertAppWebhookParams) error {
+	_, err := q.exec(ctx, q.upsertAppWebhookStmt, UpsertAppWebhook,
+		arg.AppID,
+		arg.Secret,
+		arg.Branch,
+		arg.CreatedAt,
 	)
 	return err
 }
@@ -4,6 +4,8 @@ import (
 	"archive/tar"
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,55 +16,93 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-// BuildImage builds a Docker image from a Dockerfile.
-func (d *Client) BuildImage(imageName, dockerfileContent string) (string, error) {
+// buildInputsLabel stores in each image the hash of the inputs it was built from.
+const buildInputsLabel = "diplo.build-inputs"
+
+// BuildInputsHash hashes the Dockerfile and the source tar an image is built from.
+func BuildInputsHash(dockerfileContent string, source []byte) string {
+	h := sha256.New()
+	h.Write([]byte(dockerfileContent))
+	h.Write([]byte{0})
+	h.Write(source)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// FindBuiltImage returns the ID of imageName if it exists locally and was built
+// from the same Dockerfile and sources, so the build can be skipped.
+func (d *Client) FindBuiltImage(ctx context.Context, imageName, dockerfileContent string, source []byte) (string, bool) {
+	inspect, _, err := d.cli.ImageInspectWithRaw(ctx, imageName)
+	if err != nil || inspect.Config == nil {
+		return "", false
+	}
+	if inspect.Config.Labels[buildInputsLabel] != BuildInputsHash(dockerfileContent, source) {
+		return "", false
+	}
+	return inspect.ID, true
+}
+
+// BuildImage builds a Docker image from a Dockerfile. source is an optional tar
+// with the application sources that is sent along with the Dockerfile. The
+// layer cache is used unless noCache is set. runConfig is stored in the image
+// for RunContainer.
+func (d *Client) BuildImage(ctx context.Context, imageName, dockerfileContent string, source []byte, noCache bool, runConfig RunConfig) (string, error) {
 	logrus.Infof("Building image: %s", imageName)
-	d.sendDockerEvent("build_start", "Starting image build", map[string]interface{}{"image_name": imageName})
+	d.sendDockerEvent(ctx, "build_start", "Starting image build", map[string]interface{}{"image_name": imageName})
 
-	buildCtx, err := d.createBuildContext(dockerfileContent)
+	buildCtx, err := d.createBuildContext(dockerfileContent, source)
 	if err != nil {
-		d.sendDockerEvent("build_error", "Error creating build context", map[string]interface{}{"error": err.Error()})
+		d.sendDockerEvent(ctx, "build_error", "Error creating build context", map[string]interface{}{"error": err.Error()})
 		return "", fmt.Errorf("error creating build context: %w", err)
 	}
 
+	labels := map[string]string{buildInputsLabel: BuildInputsHash(dockerfileContent, source)}
+	runLabel, err := runConfig.label()
+	if err != nil {
+		return "", fmt.Errorf("error encoding run config: %w", err)
+	}
+	if runLabel != "" {
+		labels[runConfigLabel] = runLabel
+	}
+
 	// Construir imagen sin tag para evitar problemas de tagging
 	buildOptions := types.ImageBuildOptions{
 		Dockerfile:  dockerfileName,
 		Remove:      true,
 		ForceRemove: true,
-		NoCache:     true,
+		NoCache:     noCache,
+		Labels:      labels,
 		// No incluir Tags aquí para evitar problemas
 	}
 
-	d.sendDockerEvent("build_step", "Building Docker image", map[string]interface{}{"step": "docker_build", "image_name": imageName})
-	buildResp, err := d.cli.ImageBuild(context.Background(), buildCtx, buildOptions)
+	d.sendDockerEvent(ctx, "build_step", "Building Docker image", map[string]interface{}{"step": "docker_build", "image_name": imageName})
+	buildResp, err := d.cli.ImageBuild(ctx, buildCtx, buildOptions)
 	if err != nil {
-		d.sendDockerEvent("build_error", "Error building image", map[string]interface{}{"error": err.Error()})
+		d.sendDockerEvent(ctx, "build_error", "Error building image", map[string]interface{}{"error": err.Error()})
 		return "", fmt.Errorf("error building image: %w", err)
 	}
 	defer buildResp.Body.Close()
 
 	// Capturar el ID de imagen del stream de build
 	var imageID string
-	if err := d.streamBuildOutputWithID(buildResp.Body, &imageID); err != nil {
-		d.sendDockerEvent("build_error", "Image build failed", map[string]interface{}{"error": err.Error()})
+	if err := d.streamBuildOutputWithID(ctx, buildResp.Body, &imageID); err != nil {
+		d.sendDockerEvent(ctx, "build_error", "Image build failed", map[string]interface{}{"error": err.Error()})
 		return "", err
 	}
 
 	if imageID == "" {
-		d.sendDockerEvent("build_error", "No image ID captured from build output", nil)
+		d.sendDockerEvent(ctx, "build_error", "No image ID captured from build output", nil)
 		return "", fmt.Errorf("no image ID captured from build output")
 	}
 
-	d.sendDockerEvent("build_step", "Tagging built image", map[string]interface{}{
+	d.sendDockerEvent(ctx, "build_step", "Tagging built image", map[string]interface{}{
 		"step":     "tag_image",
 		"image_id": imageID,
 		"tag":      imageName,
 	})
 
 	// Asignar tag manualmente después del build
-	if err := d.cli.ImageTag(context.Background(), imageID, imageName); err != nil {
-		d.sendDockerEvent("build_error", "Error tagging image", map[string]interface{}{
+	if err := d.cli.ImageTag(ctx, imageID, imageName); err != nil {
+		d.sendDockerEvent(ctx, "build_error", "Error tagging image", map[string]interface{}{
 			"error":    err.Error(),
 			"image_id": imageID,
 			"tag":      imageName,
@@ -71,11 +111,11 @@ func (d *Client) BuildImage(imageName, dockerfileContent string) (string, error)
 	}
 
 	// Verificar que el tag se asignó correctamente
-	d.sendDockerEvent("build_step", "Verifying tagged image", map[string]interface{}{"step": "verify_tag", "tag": imageName})
+	d.sendDockerEvent(ctx, "build_step", "Verifying tagged image", map[string]interface{}{"step": "verify_tag", "tag": imageName})
 	taggedImageID, err := d.findImageByTag(imageName)
 	if err != nil {
 		logrus.Warnf("Tag verification failed, using original image ID: %s", imageID)
-		d.sendDockerEvent("build_warning", "Tag verification failed, using original image ID", map[string]interface{}{
+		d.sendDockerEvent(ctx, "build_warning", "Tag verification failed, using original image ID", map[string]interface{}{
 			"image_id": imageID,
 			"tag":      imageName,
 		})
@@ -83,7 +123,7 @@ func (d *Client) BuildImage(imageName, dockerfileContent string) (string, error)
 		taggedImageID = imageID
 	}
 
-	d.sendDockerEvent("build_success", "Image built and tagged successfully", map[string]interface{}{
+	d.sendDockerEvent(ctx, "build_success", "Image built and tagged successfully", map[string]interface{}{
 		"image_name": imageName,
 		"image_id":   taggedImageID,
 	})
@@ -92,7 +132,7 @@ func (d *Client) BuildImage(imageName, dockerfileContent string) (string, error)
 }
 
 // createBuildContext creates a tar archive containing the Dockerfile.
-func (d *Client) createBuildContext(dockerfileContent string) (*bytes.Buffer, error) {
+func (d *Client) createBuildContext(dockerfileContent string, source []byte) (*bytes.Buffer, error) {
 	if strings.TrimSpace(dockerfileContent) == "" {
 		return nil, fmt.Errorf("dockerfile is empty")
 	}
@@ -101,6 +141,29 @@ func (d *Client) createBuildContext(dockerfileContent string) (*bytes.Buffer, er
 	tw := tar.NewWriter(&buf)
 	defer tw.Close()
 
+	// Copiar las fuentes; el Dockerfile generado reemplaza al del repositorio
+	if len(source) > 0 {
+		tr := tar.NewReader(bytes.NewReader(source))
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("error reading source tar: %w", err)
+			}
+			if header.Name == dockerfileName || header.Typeflag == tar.TypeXGlobalHeader {
+				continue
+			}
+			if err := tw.WriteHeader(header); err != nil {
+				return nil, fmt.Errorf("error writing tar header: %w", err)
+			}
+			if _, err := io.Copy(tw, tr); err != nil {
+				return nil, fmt.Errorf("error copying %s to build context: %w", header.Name, err)
+			}
+		}
+	}
+
 	header := &tar.Header{
 		Name: dockerfileName,
 		Mode: 0644,
@@ -118,8 +181,8 @@ func (d *Client) createBuildContext(dockerfileContent string) (*bytes.Buffer, er
 }
 
 // streamBuildOutputWithID processes the streaming output from an image build and captures the final image ID.
-func (d *Client) streamBuildOutputWithID(reader io.Reader, imageID *string) error {
-	d.sendDockerEvent("build_step", "Streaming build logs", map[string]interface{}{"step": "stream_logs"})
+func (d *Client) streamBuildOutputWithID(ctx context.Context, reader io.Reader, imageID *string) error {
+	d.sendDockerEvent(ctx, "build_step", "Streaming build logs", map[string]interface{}{"step": "stream_logs"})
 	decoder := json.NewDecoder(reader)
 	for {
 		var jsonMessage jsonmessage.JSONMessage
@@ -133,7 +196,7 @@ func (d *Client) streamBuildOutputWithID(reader io.Reader, imageID *string) erro
 		if jsonMessage.Stream != "" {
 			logMessage := strings.TrimSpace(jsonMessage.Stream)
 			logrus.Debug(logMessage)
-			d.sendDockerEvent("build_log", logMessage, nil)
+			d.sendDockerEvent(ctx, "build_log", logMessage, nil)
 
 			// Capturar el ID de imagen de diferentes formatos de output
 			if strings.Contains(logMessage, "Successfully built") {
@@ -203,3 +266,12 @@ func (d *Client) findImageByTag(imageName string) (string, error) {
 
 	return "", fmt.Errorf("image %s not found", imageName)
 }
+
+// GetImageID returns the ID of a local image, or an error if it no longer exists.
+func (d *Client) GetImageID(imageName string) (string, error) {
+	inspect, _, err := d.cli.ImageInspectWithRaw(context.Background(), imageName)
+	if err != nil {
+		return "", fmt.Errorf("image %s not found: %w", imageName, err)
+	}
+	return inspect.ID, nil
+}
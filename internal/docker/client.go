@@ -4,9 +4,9 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"os"
-	"os/exec"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/docker/docker/api/types"
@@ -28,7 +28,9 @@ type DockerEventCallback func(event DockerEvent)
 
 // Client manages interactions with the Docker daemon.
 type Client struct {
-	cli           *client.Client
+	cli *client.Client
+
+	mu            sync.RWMutex
 	eventCallback DockerEventCallback
 }
 
@@ -41,13 +43,18 @@ func NewClient() (*Client, error) {
 	return &Client{cli: cli}, nil
 }
 
-// SetEventCallback sets the callback for Docker events.
+// SetEventCallback sets the callback for Docker events whose context has no
+// callback of its own (see WithEventCallback).
 func (d *Client) SetEventCallback(callback DockerEventCallback) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	d.eventCallback = callback
 }
 
 // GetEventCallback gets the current Docker event callback.
 func (d *Client) GetEventCallback() DockerEventCallback {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 	return d.eventCallback
 }
 
@@ -89,6 +96,29 @@ func (d *Client) GetRunningContainers() ([]types.Container, error) {
 	return containers, nil
 }
 
+// ListManagedContainers returns every container (running or not) labeled diplo.managed=true.
+func (d *Client) ListManagedContainers() ([]types.Container, error) {
+	containers, err := d.cli.ContainerList(context.Background(), types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", "diplo.managed=true")),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing managed containers: %w", err)
+	}
+
+	return containers, nil
+}
+
+// RemoveContainer force-removes a container regardless of its state.
+func (d *Client) RemoveContainer(containerID string) error {
+	if err := d.cli.ContainerRemove(context.Background(), containerID, types.ContainerRemoveOptions{Force: true}); err != nil {
+		return fmt.Errorf("error removing container: %w", err)
+	}
+
+	logrus.Infof("Container removed: %s", containerID)
+	return nil
+}
+
 // GetContainerLogsStream gets a real-time stream of container logs.
 func (d *Client) GetContainerLogsStream(containerID string) (io.ReadCloser, error) {
 	logOptions := types.ContainerLogsOptions{
@@ -109,48 +139,8 @@ func (d *Client) Close() error {
 	return d.cli.Close()
 }
 
-// GetLastCommitHash gets the latest commit hash from a Git repository.
-func (d *Client) GetLastCommitHash(repoURL string) (string, error) {
-	tempDir, err := os.MkdirTemp("", "diplo-git-*")
-	if err != nil {
-		return "", fmt.Errorf("error creating temp dir: %w", err)
-	}
-	defer os.RemoveAll(tempDir)
-
-	cloneCmd := exec.Command("git", "clone", "--depth", "1", repoURL, tempDir)
-	if err := cloneCmd.Run(); err != nil {
-		return "", fmt.Errorf("error cloning repo: %w", err)
-	}
-
-	hashCmd := exec.Command("git", "rev-parse", "HEAD")
-	hashCmd.Dir = tempDir
-	hashOutput, err := hashCmd.Output()
-	if err != nil {
-		return "", fmt.Errorf("error getting commit hash: %w", err)
-	}
-
-	hash := strings.TrimSpace(string(hashOutput))
-	if hash == "" {
-		hash = fmt.Sprintf("commit_%d", time.Now().Unix())
-	}
-
-	logrus.Debugf("Latest commit hash for %s: %s", repoURL, hash)
-	return hash, nil
-}
-
-// GenerateImageTag creates a unique image tag based on the app ID and commit hash.
-func (d *Client) GenerateImageTag(appID, repoURL string) (string, error) {
-	d.sendDockerEvent("tag_start", "Generating unique image tag", map[string]interface{}{"app_id": appID, "repo_url": repoURL})
-
-	hash, err := d.GetLastCommitHash(repoURL)
-	if err != nil {
-		logrus.Warnf("Error getting commit hash, using fallback: %v", err)
-		d.sendDockerEvent("tag_warning", "Error getting commit hash, using fallback", map[string]interface{}{"warning": err.Error()})
-		hash = fmt.Sprintf("fallback_%d", time.Now().Unix())
-	} else {
-		d.sendDockerEvent("tag_step", "Commit hash obtained", map[string]interface{}{"step": "get_commit_hash", "hash": hash})
-	}
-
+// ImageTagForCommit builds the image tag of an app for a known commit hash.
+func ImageTagForCommit(appID, hash string) string {
 	// Limpiar el appID removiendo el prefijo 'app_' si existe
 	cleanAppID := strings.TrimPrefix(appID, "app_")
 
@@ -162,15 +152,13 @@ func (d *Client) GenerateImageTag(appID, repoURL string) (string, error) {
 	tag = strings.ReplaceAll(tag, "_", "-")
 
 	// Convertir a minúsculas para asegurar compatibilidad con Docker
-	tag = strings.ToLower(tag)
-
-	d.sendDockerEvent("tag_success", "Tag generated successfully", map[string]interface{}{"tag": tag, "hash": hash})
-	logrus.Infof("Generated image tag: %s", tag)
-	return tag, nil
+	return strings.ToLower(tag)
 }
 
-// CleanupOldImages cleans up old images for a specific application.
-func (d *Client) CleanupOldImages(appID string, keepCount int) error {
+// CleanupOldImages cleans up old images for a specific application. The image
+// tagged keep is never removed, even if it is older than the ones kept: a
+// reused image keeps its original creation date.
+func (d *Client) CleanupOldImages(appID string, keepCount int, keep string) error {
 	logrus.Infof("Cleaning up old images for app: %s (keeping %d)", appID, keepCount)
 
 	images, err := d.cli.ImageList(context.Background(), types.ImageListOptions{})
@@ -186,6 +174,9 @@ func (d *Client) CleanupOldImages(appID string, keepCount int) error {
 	prefix := fmt.Sprintf("diplo-%s-", cleanAppID)
 
 	for _, img := range images {
+		if keep != "" && (slices.Contains(img.RepoTags, keep) || slices.Contains(img.RepoTags, keep+":latest")) {
+			continue
+		}
 		for _, tag := range img.RepoTags {
 			if strings.HasPrefix(tag, prefix) {
 				appImages = append(appImages, img)
@@ -240,14 +231,14 @@ func (d *Client) PruneDanglingImages() error {
 // RemoveImage removes a specific image by ID or tag.
 func (d *Client) RemoveImage(imageIDOrTag string) error {
 	logrus.Infof("Removing image: %s", imageIDOrTag)
-	d.sendDockerEvent("image_remove_start", "Starting image removal", map[string]interface{}{"image": imageIDOrTag})
+	d.sendDockerEvent(context.Background(), "image_remove_start", "Starting image removal", map[string]interface{}{"image": imageIDOrTag})
 
 	removedImages, err := d.cli.ImageRemove(context.Background(), imageIDOrTag, types.ImageRemoveOptions{
 		Force:         true,
 		PruneChildren: true,
 	})
 	if err != nil {
-		d.sendDockerEvent("image_remove_error", "Error removing image", map[string]interface{}{
+		d.sendDockerEvent(context.Background(), "image_remove_error", "Error removing image", map[string]interface{}{
 			"error": err.Error(),
 			"image": imageIDOrTag,
 		})
@@ -264,7 +255,7 @@ func (d *Client) RemoveImage(imageIDOrTag string) error {
 		}
 	}
 
-	d.sendDockerEvent("image_remove_success", "Image removed successfully", map[string]interface{}{
+	d.sendDockerEvent(context.Background(), "image_remove_success", "Image removed successfully", map[string]interface{}{
 		"image":         imageIDOrTag,
 		"removed_count": len(removedImages),
 	})
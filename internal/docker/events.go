@@ -1,6 +1,9 @@
 package docker
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // DockerEvent represents an event from the Docker process.
 type DockerEvent struct {
@@ -10,15 +13,29 @@ type DockerEvent struct {
 	Time    time.Time              `json:"time"`
 }
 
-// sendDockerEvent sends a Docker event if a callback is configured.
-func (d *Client) sendDockerEvent(eventType, message string, data map[string]interface{}) {
-	if d.eventCallback != nil {
+type eventCallbackKey struct{}
+
+// WithEventCallback returns a context whose Docker events are sent to callback
+// instead of the client's callback, so concurrent deployments each receive
+// only their own events.
+func WithEventCallback(ctx context.Context, callback DockerEventCallback) context.Context {
+	return context.WithValue(ctx, eventCallbackKey{}, callback)
+}
+
+// sendDockerEvent sends a Docker event to the callback of ctx, or to the
+// client's callback if ctx has none.
+func (d *Client) sendDockerEvent(ctx context.Context, eventType, message string, data map[string]interface{}) {
+	callback, ok := ctx.Value(eventCallbackKey{}).(DockerEventCallback)
+	if !ok {
+		callback = d.GetEventCallback()
+	}
+	if callback != nil {
 		event := DockerEvent{
 			Type:    eventType,
 			Message: message,
 			Data:    data,
 			Time:    time.Now(),
 		}
-		d.eventCallback(event)
+		callback(event)
 	}
 }
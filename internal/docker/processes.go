@@ -0,0 +1,89 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/rodrwan/diplo/internal/database"
+	"github.com/rodrwan/diplo/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// WebProcess is the process type that serves the app's port. Each app runs
+// exactly one web container; the other process types run without a port.
+const WebProcess = "web"
+
+const (
+	processLabel      = "diplo.process"
+	processIndexLabel = "diplo.process.index"
+)
+
+// ProcessContainer is a container of one of the app's non-web process types.
+type ProcessContainer struct {
+	ID      string
+	Process string
+	Index   int
+	ImageID string
+	State   string
+	Status  string
+}
+
+// ProcessOf returns the process type of a Diplo container from its labels.
+// Containers created before process types existed are web containers.
+func ProcessOf(labels map[string]string) string {
+	if process := labels[processLabel]; process != "" {
+		return process
+	}
+	return WebProcess
+}
+
+// RunProcess creates and starts instance index of a non-web process type,
+// with the command the image's Procfile declares for it.
+func (d *Client) RunProcess(ctx context.Context, app *database.App, imageName string, envVars []models.EnvVar, process string, index int) (string, error) {
+	if process == WebProcess {
+		return "", fmt.Errorf("the web process runs with RunContainer")
+	}
+
+	containerID, err := d.runProcess(ctx, app, imageName, envVars, process, index)
+	if err != nil {
+		return "", err
+	}
+	logrus.Infof("Process %s.%d of app %s running (ID: %s)", process, index, app.Name, containerID)
+	return containerID, nil
+}
+
+// ListProcessContainers returns every container (running or not) of the app's
+// non-web process types.
+func (d *Client) ListProcessContainers(ctx context.Context, appID string) ([]ProcessContainer, error) {
+	containers, err := d.cli.ContainerList(ctx, types.ContainerListOptions{
+		All: true,
+		Filters: filters.NewArgs(
+			filters.Arg("label", "diplo.managed=true"),
+			filters.Arg("label", "diplo.app.id="+appID),
+		),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing process containers: %w", err)
+	}
+
+	processes := make([]ProcessContainer, 0, len(containers))
+	for _, c := range containers {
+		process := ProcessOf(c.Labels)
+		if process == WebProcess {
+			continue
+		}
+		index, _ := strconv.Atoi(c.Labels[processIndexLabel])
+		processes = append(processes, ProcessContainer{
+			ID:      c.ID,
+			Process: process,
+			Index:   index,
+			ImageID: c.ImageID,
+			State:   c.State,
+			Status:  c.Status,
+		})
+	}
+	return processes, nil
+}
@@ -0,0 +1,79 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/sirupsen/logrus"
+)
+
+// RegistryAuth holds the credentials used to pull an image. Empty credentials
+// pull anonymously.
+type RegistryAuth struct {
+	// ServerAddress is the registry the credentials belong to, e.g. ghcr.io.
+	ServerAddress string
+	Username      string
+	Password      string
+}
+
+// PullImage pulls a prebuilt image from its registry and returns its ID.
+func (d *Client) PullImage(ctx context.Context, reference string, auth RegistryAuth) (string, error) {
+	logrus.Infof("Pulling image: %s", reference)
+	d.sendDockerEvent(ctx, "pull_start", "Pulling image", map[string]interface{}{"image": reference})
+
+	options := types.ImagePullOptions{}
+	if auth.Username != "" || auth.Password != "" {
+		encoded, err := registry.EncodeAuthConfig(registry.AuthConfig{
+			Username:      auth.Username,
+			Password:      auth.Password,
+			ServerAddress: auth.ServerAddress,
+		})
+		if err != nil {
+			return "", fmt.Errorf("error encoding registry credentials: %w", err)
+		}
+		options.RegistryAuth = encoded
+	}
+
+	reader, err := d.cli.ImagePull(ctx, reference, options)
+	if err != nil {
+		d.sendDockerEvent(ctx, "pull_error", "Error pulling image", map[string]interface{}{"error": err.Error()})
+		return "", fmt.Errorf("error pulling image %s: %w", reference, err)
+	}
+	defer reader.Close()
+
+	// Los errores del registry (p. ej. credenciales inválidas) llegan en el stream
+	if err := jsonmessage.DisplayJSONMessagesStream(reader, io.Discard, 0, false, nil); err != nil {
+		d.sendDockerEvent(ctx, "pull_error", "Image pull failed", map[string]interface{}{"error": err.Error()})
+		return "", fmt.Errorf("error pulling image %s: %w", reference, err)
+	}
+
+	imageID, err := d.GetImageID(reference)
+	if err != nil {
+		return "", err
+	}
+	d.sendDockerEvent(ctx, "pull_success", "Image pulled successfully", map[string]interface{}{
+		"image":    reference,
+		"image_id": imageID,
+	})
+	logrus.Infof("Image pulled successfully: %s (ID: %s)", reference, imageID)
+	return imageID, nil
+}
+
+// TagImage tags a local image, e.g. a pulled image with the app's image tag so
+// it is kept, cleaned up and rolled back like the ones Diplo builds.
+func (d *Client) TagImage(ctx context.Context, imageID, imageName string) error {
+	if err := d.cli.ImageTag(ctx, imageID, imageName); err != nil {
+		return fmt.Errorf("error tagging image %s with tag %s: %w", imageID, imageName, err)
+	}
+	return nil
+}
+
+// ImageTagForImageID builds the image tag of an app for a pulled image.
+func ImageTagForImageID(appID, imageID string) string {
+	return ImageTagForCommit(appID, strings.TrimPrefix(imageID, "sha256:"))
+}
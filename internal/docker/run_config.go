@@ -0,0 +1,98 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+)
+
+// runConfigLabel stores in each image the runtime settings declared by the
+// repo's diplo.yaml, so rollbacks and recovered containers run the image the
+// same way it was first deployed.
+const runConfigLabel = "diplo.run-config"
+
+// volumeNameChars are the characters allowed in a Docker volume name.
+var volumeNameChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// RunConfig holds how a container of an image must run. Zero values keep the
+// defaults: the app's port, no resource limits and no volumes.
+type RunConfig struct {
+	// Port is the port the app listens on inside the container.
+	Port int `json:"port,omitempty"`
+	// HealthPath is the HTTP path probed before switching traffic.
+	HealthPath string `json:"health_path,omitempty"`
+	// HealthTimeout is how long a new version has to become healthy.
+	HealthTimeout time.Duration `json:"health_timeout,omitempty"`
+	MemoryBytes   int64         `json:"memory_bytes,omitempty"`
+	NanoCPUs      int64         `json:"nano_cpus,omitempty"`
+	Volumes       []Volume      `json:"volumes,omitempty"`
+	// Env holds default environment variables; the app's own variables win.
+	Env map[string]string `json:"env,omitempty"`
+	// Processes maps each Procfile process type to its command. A web entry
+	// replaces the image's command; other types run in their own containers.
+	Processes map[string]string `json:"processes,omitempty"`
+}
+
+// Volume is a named volume mounted at Path. It is shared by all the
+// containers of the app and survives redeploys.
+type Volume struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// VolumeName returns the Docker volume that backs volume for the app.
+func VolumeName(appID, volume string) string {
+	return volumeNameChars.ReplaceAllString(fmt.Sprintf("diplo-%s-%s", appID, volume), "-")
+}
+
+// ImageRunConfig returns the runtime settings stored in the image's labels, or
+// an empty config if the image declares none. An image Diplo did not build
+// runs on the port it exposes, if it exposes exactly one.
+func (d *Client) ImageRunConfig(ctx context.Context, imageName string) (RunConfig, error) {
+	var config RunConfig
+	inspect, _, err := d.cli.ImageInspectWithRaw(ctx, imageName)
+	if err != nil {
+		return config, fmt.Errorf("error inspecting image %s: %w", imageName, err)
+	}
+	if inspect.Config == nil {
+		return config, nil
+	}
+	if inspect.Config.Labels[runConfigLabel] == "" {
+		if inspect.Config.Labels[buildInputsLabel] == "" {
+			config.Port = exposedPort(inspect.Config.ExposedPorts)
+		}
+		return config, nil
+	}
+	if err := json.Unmarshal([]byte(inspect.Config.Labels[runConfigLabel]), &config); err != nil {
+		return config, fmt.Errorf("error decoding run config of image %s: %w", imageName, err)
+	}
+	return config, nil
+}
+
+// exposedPort returns the only TCP port in ports, or 0 if there is none or more than one.
+func exposedPort(ports nat.PortSet) int {
+	port := 0
+	for exposed := range ports {
+		if exposed.Proto() != "tcp" {
+			continue
+		}
+		if port != 0 {
+			return 0
+		}
+		port = exposed.Int()
+	}
+	return port
+}
+
+// label encodes the config for the image label; an empty config has no label.
+func (c RunConfig) label() (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil || string(data) == "{}" {
+		return "", err
+	}
+	return string(data), nil
+}
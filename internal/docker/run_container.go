@@ -3,9 +3,13 @@ package docker
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
+	"strconv"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/go-connections/nat"
 	"github.com/rodrwan/diplo/internal/database"
@@ -13,50 +17,87 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-// RunContainer creates and starts a Docker container for a given application.
-func (d *Client) RunContainer(app *database.App, imageName string, envVars []models.EnvVar) (string, error) {
+// RunContainer creates and starts the web container of an application,
+// applying the run config stored in the image.
+func (d *Client) RunContainer(ctx context.Context, app *database.App, imageName string, envVars []models.EnvVar) (string, error) {
 	logrus.Infof("Running container for app %s from image %s on port %d", app.Name, imageName, app.Port)
-	d.sendDockerEvent("container_start", "Starting container", map[string]interface{}{
+	d.sendDockerEvent(ctx, "container_start", "Starting container", map[string]interface{}{
 		"image_name":     imageName,
 		"port":           app.Port,
 		"env_vars_count": len(envVars),
 	})
 
-	hostConfig := d.buildHostConfig(app)
-	containerConfig := d.buildContainerConfig(app, imageName, envVars)
+	containerID, err := d.runProcess(ctx, app, imageName, envVars, WebProcess, 0)
+	if err != nil {
+		return "", err
+	}
+
+	d.sendDockerEvent(ctx, "container_success", "Container running successfully", map[string]interface{}{
+		"container_id": containerID,
+		"port":         app.Port,
+		"url":          fmt.Sprintf("http://localhost:%d", app.Port),
+	})
+	logrus.Infof("Container running successfully: %s (ID: %s, Port: %d)", imageName, containerID, app.Port)
+	return containerID, nil
+}
+
+// runProcess creates and starts one container of the given process type. Only
+// the web process publishes the app's port.
+func (d *Client) runProcess(ctx context.Context, app *database.App, imageName string, envVars []models.EnvVar, process string, index int) (string, error) {
+	runConfig, err := d.ImageRunConfig(ctx, imageName)
+	if err != nil {
+		logrus.Warnf("Ignoring run config of image %s: %v", imageName, err)
+	}
+	if process != WebProcess && runConfig.Processes[process] == "" {
+		return "", fmt.Errorf("image %s does not declare process type %q", imageName, process)
+	}
+
+	hostConfig := d.buildHostConfig(app, runConfig, process)
+	containerConfig := d.buildContainerConfig(app, imageName, envVars, runConfig, process, index)
 
-	d.sendDockerEvent("container_step", "Creating container", map[string]interface{}{"step": "create_container"})
-	resp, err := d.cli.ContainerCreate(context.Background(), containerConfig, hostConfig, &network.NetworkingConfig{}, nil, "")
+	d.sendDockerEvent(ctx, "container_step", "Creating container", map[string]interface{}{"step": "create_container", "process": process})
+	resp, err := d.cli.ContainerCreate(ctx, containerConfig, hostConfig, &network.NetworkingConfig{}, nil, "")
 	if err != nil {
-		d.sendDockerEvent("container_error", "Error creating container", map[string]interface{}{"error": err.Error()})
+		d.sendDockerEvent(ctx, "container_error", "Error creating container", map[string]interface{}{"error": err.Error(), "process": process})
 		return "", fmt.Errorf("error creating container: %w", err)
 	}
 
-	d.sendDockerEvent("container_step", "Starting container", map[string]interface{}{"step": "start_container", "container_id": resp.ID})
-	if err := d.cli.ContainerStart(context.Background(), resp.ID, types.ContainerStartOptions{}); err != nil {
-		d.sendDockerEvent("container_error", "Error starting container", map[string]interface{}{"error": err.Error(), "container_id": resp.ID})
+	d.sendDockerEvent(ctx, "container_step", "Starting container", map[string]interface{}{"step": "start_container", "container_id": resp.ID, "process": process})
+	if err := d.cli.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
+		d.sendDockerEvent(ctx, "container_error", "Error starting container", map[string]interface{}{"error": err.Error(), "container_id": resp.ID, "process": process})
 		return "", fmt.Errorf("error starting container: %w", err)
 	}
-
-	d.sendDockerEvent("container_success", "Container running successfully", map[string]interface{}{
-		"container_id": resp.ID,
-		"port":         app.Port,
-		"url":          fmt.Sprintf("http://localhost:%d", app.Port),
-	})
-	logrus.Infof("Container running successfully: %s (ID: %s, Port: %d)", imageName, resp.ID, app.Port)
 	return resp.ID, nil
 }
 
+// containerPort returns the port the app listens on inside the container: the
+// one declared by the image, or the app's host port.
+func containerPort(app *database.App, runConfig RunConfig) int64 {
+	if runConfig.Port > 0 {
+		return int64(runConfig.Port)
+	}
+	return app.Port
+}
+
 // buildHostConfig creates the host configuration for a container.
-func (d *Client) buildHostConfig(app *database.App) *container.HostConfig {
-	portBinding := nat.PortBinding{
-		HostIP:   defaultHostIP,
-		HostPort: fmt.Sprintf("%d", app.Port),
+func (d *Client) buildHostConfig(app *database.App, runConfig RunConfig, process string) *container.HostConfig {
+	portMap := nat.PortMap{}
+	if process == WebProcess {
+		portBinding := nat.PortBinding{
+			HostIP:   defaultHostIP,
+			HostPort: fmt.Sprintf("%d", app.Port),
+		}
+		internalPort := nat.Port(fmt.Sprintf("%d/tcp", containerPort(app, runConfig)))
+		portMap[internalPort] = []nat.PortBinding{portBinding}
 	}
-	// Use the internal port from the app model for the binding
-	internalPort := nat.Port(fmt.Sprintf("%d/tcp", app.Port))
-	portMap := nat.PortMap{
-		internalPort: []nat.PortBinding{portBinding},
+
+	mounts := make([]mount.Mount, 0, len(runConfig.Volumes))
+	for _, volume := range runConfig.Volumes {
+		mounts = append(mounts, mount.Mount{
+			Type:   mount.TypeVolume,
+			Source: VolumeName(app.ID, volume.Name),
+			Target: volume.Path,
+		})
 	}
 
 	return &container.HostConfig{
@@ -64,20 +105,36 @@ func (d *Client) buildHostConfig(app *database.App) *container.HostConfig {
 		RestartPolicy: container.RestartPolicy{
 			Name: "always",
 		},
+		Resources: container.Resources{
+			Memory:   runConfig.MemoryBytes,
+			NanoCPUs: runConfig.NanoCPUs,
+		},
+		Mounts: mounts,
 	}
 }
 
 // buildContainerConfig creates the container configuration.
-func (d *Client) buildContainerConfig(app *database.App, imageName string, envVars []models.EnvVar) *container.Config {
-	internalPort := nat.Port(fmt.Sprintf("%d/tcp", app.Port))
+func (d *Client) buildContainerConfig(app *database.App, imageName string, envVars []models.EnvVar, runConfig RunConfig, process string, index int) *container.Config {
+	port := containerPort(app, runConfig)
 
 	// Start with default environment variables
 	env := []string{
-		fmt.Sprintf("PORT=%d", app.Port),
+		fmt.Sprintf("PORT=%d", port),
 		fmt.Sprintf("DIPLO_APP_ID=%s", app.ID),
 		fmt.Sprintf("DIPLO_APP_NAME=%s", app.Name),
 	}
 
+	// Add the image's defaults that the app does not override
+	overridden := make(map[string]bool, len(envVars))
+	for _, envVar := range envVars {
+		overridden[envVar.Name] = true
+	}
+	for _, name := range slices.Sorted(maps.Keys(runConfig.Env)) {
+		if !overridden[name] && isValidEnvVarName(name) {
+			env = append(env, fmt.Sprintf("%s=%s", name, runConfig.Env[name]))
+		}
+	}
+
 	// Add user-defined environment variables
 	for _, envVar := range envVars {
 		// Validate environment variable name (basic security)
@@ -88,12 +145,9 @@ func (d *Client) buildContainerConfig(app *database.App, imageName string, envVa
 		}
 	}
 
-	return &container.Config{
+	config := &container.Config{
 		Image: imageName,
-		ExposedPorts: nat.PortSet{
-			internalPort: struct{}{},
-		},
-		Env: env,
+		Env:   env,
 		Labels: map[string]string{
 			// Etiquetas de identificación
 			"diplo.app.id":       app.ID,
@@ -115,8 +169,22 @@ func (d *Client) buildContainerConfig(app *database.App, imageName string, envVa
 			// Etiquetas para filtering y limpieza
 			"diplo.cleanup.enabled":    "true",
 			"diplo.monitoring.enabled": "true",
+
+			// Tipo de proceso del Procfile e instancia
+			processLabel:      process,
+			processIndexLabel: strconv.Itoa(index),
 		},
 	}
+
+	if process == WebProcess {
+		internalPort := nat.Port(fmt.Sprintf("%d/tcp", port))
+		config.ExposedPorts = nat.PortSet{internalPort: struct{}{}}
+	}
+	if command := runConfig.Processes[process]; command != "" {
+		// Same form as the templates' start command: exec lets the process receive stop signals
+		config.Cmd = []string{"sh", "-c", "exec " + command}
+	}
+	return config
 }
 
 // isValidEnvVarName validates environment variable names to prevent security issues
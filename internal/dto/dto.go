@@ -1,13 +1,114 @@
 package dto
 
+import "encoding/json"
+
 type App struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	RepoUrl     string `json:"repo_url"`
-	Language    string `json:"language"`
-	Port        int    `json:"port"`
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	RepoUrl    string `json:"repo_url"`
+	Image      string `json:"image,omitempty"`
+	Path       string `json:"path,omitempty"`
+	Ref        string `json:"ref,omitempty"`
+	Dockerfile string `json:"dockerfile_path,omitempty"`
+	Start      string `json:"start_command,omitempty"`
+	Language   string `json:"language"`
+	// LanguageOverride es el lenguaje fijado en la app; vacío si se detecta
+	LanguageOverride string `json:"language_override,omitempty"`
+	GoTarget         string `json:"go_target,omitempty"`
+	GoTags           string `json:"go_tags,omitempty"`
+	GoLDFlags        string `json:"go_ldflags,omitempty"`
+	HealthCheckPath  string `json:"health_check_path,omitempty"`
+	Port             int    `json:"port"`
+	ContainerID      string `json:"container_id"`
+	ImageID          string `json:"image_id"`
+	Status           string `json:"status"`
+	ErrorMsg         string `json:"error_msg"`
+	URL              string `json:"url"`
+}
+
+type ReconcileAction struct {
+	ID          int64  `json:"id"`
+	AppID       string `json:"app_id"`
+	ContainerID string `json:"container_id"`
+	Runtime     string `json:"runtime"`
+	Action      string `json:"action"`
+	Result      string `json:"result"`
+	Detail      string `json:"detail"`
+	CreatedAt   string `json:"created_at"`
+}
+
+type Deployment struct {
+	ID          int64  `json:"id"`
+	AppID       string `json:"app_id"`
+	TriggeredBy string `json:"triggered_by"`
+	CommitSHA   string `json:"commit_sha"`
+	ImageTag    string `json:"image_tag"`
+	Dockerfile  string `json:"dockerfile,omitempty"`
+	// Language es el lenguaje elegido, su origen y los candidatos detectados
+	Language    json.RawMessage `json:"language,omitempty"`
+	Runtime     string          `json:"runtime"`
+	Status      string          `json:"status"`
+	Error       string          `json:"error"`
+	Steps       json.RawMessage `json:"steps"`
+	BuildLog    string          `json:"build_log,omitempty"`
+	StartedAt   string          `json:"started_at"`
+	FinishedAt  string          `json:"finished_at"`
+	DurationMs  int64           `json:"duration_ms"`
+	CanRollback bool            `json:"can_rollback"`
+}
+
+type Job struct {
+	ID         string `json:"id"`
+	AppID      string `json:"app_id"`
+	Kind       string `json:"kind"`
+	Status     string `json:"status"`
+	Error      string `json:"error"`
+	Attempts   int64  `json:"attempts"`
+	CreatedAt  string `json:"created_at"`
+	StartedAt  string `json:"started_at"`
+	FinishedAt string `json:"finished_at"`
+}
+
+type APIToken struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	Token      string `json:"token,omitempty"`
+	CreatedAt  string `json:"created_at"`
+	LastUsedAt string `json:"last_used_at"`
+}
+
+type Webhook struct {
+	AppID     string            `json:"app_id"`
+	Branch    string            `json:"branch"`
+	Secret    string            `json:"secret,omitempty"`
+	URLs      map[string]string `json:"urls"`
+	CreatedAt string            `json:"created_at"`
+}
+
+type GitPolling struct {
+	AppID         string `json:"app_id"`
+	Branch        string `json:"branch"`
+	Interval      string `json:"interval"`
+	HasToken      bool   `json:"has_token"`
+	LastCommit    string `json:"last_commit"`
+	LastCheckedAt string `json:"last_checked_at"`
+	LastError     string `json:"last_error"`
+	CreatedAt     string `json:"created_at"`
+}
+
+type Process struct {
+	Type      string            `json:"type"`
+	Command   string            `json:"command,omitempty"`
+	Scale     int64             `json:"scale"`
+	Running   int               `json:"running"`
+	Instances []ProcessInstance `json:"instances"`
+	// Scalable es false para web: el proxy envía el tráfico a un solo contenedor
+	Scalable bool `json:"scalable"`
+}
+
+type ProcessInstance struct {
+	Index       int    `json:"index"`
 	ContainerID string `json:"container_id"`
-	ImageID     string `json:"image_id"`
-	Status      string `json:"status"`
-	ErrorMsg    string `json:"error_msg"`
+	State       string `json:"state"`
+	Status      string `json:"status,omitempty"`
 }
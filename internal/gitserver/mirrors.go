@@ -0,0 +1,149 @@
+package gitserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// mirrorHead es la referencia donde se guarda el HEAD del remoto en cada fetch
+	mirrorHead = "refs/diplo/HEAD"
+	// lsRemoteTimeout es el tiempo máximo para consultar un ref remoto
+	lsRemoteTimeout = 30 * time.Second
+)
+
+var (
+	// unsafeMirrorChars son los caracteres que no se usan en el nombre de un mirror
+	unsafeMirrorChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+	// scpLikeURL es la forma usuario@host:ruta de ssh (git@github.com:org/repo.git)
+	scpLikeURL = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*@[A-Za-z0-9][A-Za-z0-9.-]*:[A-Za-z0-9._~][^\s]*$`)
+)
+
+// Mirrors mantiene una copia bare de cada repositorio remoto desplegado. Cada
+// deploy la actualiza con fetch y de ella salen la detección del lenguaje, el
+// commit y el contexto de build, en vez de clonar el repositorio cada vez.
+type Mirrors struct {
+	dir string
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// NewMirrors crea (si hace falta) el directorio de mirrors
+func NewMirrors(dir string) (*Mirrors, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(abs, 0755); err != nil {
+		return nil, fmt.Errorf("error creando directorio de mirrors: %w", err)
+	}
+	return &Mirrors{dir: abs, locks: make(map[string]*sync.Mutex)}, nil
+}
+
+// Path devuelve la ruta del mirror de repoURL: el nombre del repo para
+// reconocerlo y un hash de la URL para que no choque con otro del mismo nombre
+func (m *Mirrors) Path(repoURL string) string {
+	sum := sha256.Sum256([]byte(repoURL))
+	name := strings.TrimSuffix(filepath.Base(strings.TrimRight(repoURL, "/")), ".git")
+	name = strings.Trim(unsafeMirrorChars.ReplaceAllString(name, "-"), "-.")
+	if name == "" {
+		name = "repo"
+	}
+	return filepath.Join(m.dir, fmt.Sprintf("%s-%s.git", name, hex.EncodeToString(sum[:6])))
+}
+
+// Sync trae las ramas y tags de repoURL a su mirror y devuelve su ruta. fetchURL
+// es la URL con credenciales si las necesita; no se guarda en el mirror. HEAD
+// queda en el commit del HEAD remoto. Los fetch del mismo repo se serializan.
+func (m *Mirrors) Sync(ctx context.Context, repoURL, fetchURL string) (string, error) {
+	if err := ValidateRemoteURL(repoURL); err != nil {
+		return "", err
+	}
+	path := m.Path(repoURL)
+	lock := m.lock(path)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if _, err := os.Stat(filepath.Join(path, "HEAD")); err != nil {
+		if _, err := runGit(ctx, "", "init", "--bare", "--quiet", path); err != nil {
+			return "", err
+		}
+		logrus.Infof("Mirror de %s creado en %s", repoURL, path)
+	}
+
+	if _, err := runGit(ctx, path, "fetch", "--quiet", "--prune", "--force", "--update-head-ok", "--no-write-fetch-head", "--", fetchURL,
+		"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*", "+HEAD:"+mirrorHead); err != nil {
+		return "", err
+	}
+	if _, err := runGit(ctx, path, "update-ref", "--no-deref", "HEAD", mirrorHead); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// ValidateRemoteURL acepta solo repositorios remotos por http(s), ssh:// o
+// usuario@host:ruta. Rechaza rutas locales, file://, transportes como ext::
+// (que ejecutan comandos) y hosts que git o ssh leerían como una opción.
+func ValidateRemoteURL(repoURL string) error {
+	invalid := fmt.Errorf("URL de repositorio inválida: %q (usa https://, ssh:// o usuario@host:ruta)", repoURL)
+
+	if scpLikeURL.MatchString(repoURL) {
+		return nil
+	}
+	parsed, err := url.Parse(repoURL)
+	if err != nil {
+		return invalid
+	}
+	switch parsed.Scheme {
+	case "http", "https", "ssh":
+	default:
+		return invalid
+	}
+	if host := parsed.Hostname(); host == "" || strings.HasPrefix(host, "-") || strings.Trim(parsed.Path, "/") == "" {
+		return invalid
+	}
+	return nil
+}
+
+// LsRemote devuelve el commit al que apunta ref en el repositorio remoto sin
+// clonarlo. No incluye la salida de git en el error: puede llevar la URL con
+// credenciales.
+func LsRemote(ctx context.Context, repoURL, ref string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, lsRemoteTimeout)
+	defer cancel()
+
+	// "--" evita que una URL o un ref que empiezan con "-" se lean como opciones
+	output, err := exec.CommandContext(ctx, "git", "ls-remote", "--", repoURL, ref).Output()
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("el repositorio no tiene %s", ref)
+	}
+	return fields[0], nil
+}
+
+func (m *Mirrors) lock(path string) *sync.Mutex {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	lock, ok := m.locks[path]
+	if !ok {
+		lock = &sync.Mutex{}
+		m.locks[path] = lock
+	}
+	return lock
+}
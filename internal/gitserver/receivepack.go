@@ -0,0 +1,284 @@
+package gitserver
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+const (
+	// zeroID es el SHA que git usa para una referencia creada o eliminada
+	zeroID = "0000000000000000000000000000000000000000"
+
+	// Tamaño máximo de datos de un paquete de sideband según la capacidad negociada
+	sidebandMax    = 999 - 5
+	sideband64kMax = 65519 - 5
+)
+
+// RefUpdate es una referencia actualizada por un push
+type RefUpdate struct {
+	Old string
+	New string
+	Ref string
+	// OK indica que git aplicó la actualización ("ok" en el report-status);
+	// las rechazadas por un hook, por no ser fast-forward, etc. quedan en false
+	OK bool
+}
+
+// Deleted indica si el push elimina la referencia
+func (u RefUpdate) Deleted() bool {
+	return u.New == zeroID
+}
+
+// Branch devuelve el nombre de la rama si la referencia es refs/heads/*
+func (u RefUpdate) Branch() (string, bool) {
+	return strings.CutPrefix(u.Ref, "refs/heads/")
+}
+
+// Push es el resultado de un git receive-pack. Si el cliente negoció
+// side-band, el canal de progreso queda abierto tras el report-status para
+// enviar mensajes "remote: ..." hasta llamar a Close.
+type Push struct {
+	Updates []RefUpdate
+
+	status      []byte
+	sidebandMax int
+}
+
+// AdvertiseReceivePack escribe la respuesta de GET info/refs?service=git-receive-pack
+func AdvertiseReceivePack(ctx context.Context, w io.Writer, repoDir string) error {
+	refs, err := runGit(ctx, "", "receive-pack", "--stateless-rpc", "--advertise-refs", repoDir)
+	if err != nil {
+		return err
+	}
+	if err := writePacket(w, []byte("# service=git-receive-pack\n")); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "0000"); err != nil {
+		return err
+	}
+	_, err = w.Write(refs)
+	return err
+}
+
+// ReceivePack ejecuta git receive-pack con el cuerpo de POST git-receive-pack
+func ReceivePack(ctx context.Context, repoDir string, body io.Reader, contentEncoding string) (*Push, error) {
+	if contentEncoding == "gzip" {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("cuerpo gzip inválido: %w", err)
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	// Leer los comandos (old new ref) antes del packfile sin perderlos para git
+	var commands bytes.Buffer
+	reader := bufio.NewReader(io.TeeReader(body, &commands))
+	push := &Push{}
+	first := true
+	reportStatus := false
+	for {
+		line, flush, err := readPacket(reader)
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo comandos del push: %w", err)
+		}
+		if flush {
+			break
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if first {
+			first = false
+			if command, caps, ok := strings.Cut(line, "\x00"); ok {
+				line = command
+				push.sidebandMax = negotiatedSideband(caps)
+				reportStatus = negotiatedReportStatus(caps)
+			}
+		}
+		if strings.HasPrefix(line, "shallow ") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("comando de push inválido: %q", line)
+		}
+		push.Updates = append(push.Updates, RefUpdate{Old: fields[0], New: fields[1], Ref: fields[2]})
+	}
+
+	// git recibe todo lo ya leído (comandos y el inicio del packfile) y el resto del cuerpo
+	input := io.MultiReader(bytes.NewReader(commands.Bytes()), body)
+
+	cmd := exec.CommandContext(ctx, "git", "receive-pack", "--stateless-rpc", repoDir)
+	cmd.Stdin = input
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git receive-pack: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	push.status = stdout.Bytes()
+	if !reportStatus {
+		push.resolveAccepted(ctx, repoDir)
+	} else if err := push.markAccepted(); err != nil {
+		return nil, err
+	}
+	return push, nil
+}
+
+// markAccepted marca como OK las referencias que el report-status de git
+// acepta; si el packfile no se pudo desempaquetar no se acepta ninguna
+func (p *Push) markAccepted() error {
+	report := p.status
+	if p.sidebandMax > 0 {
+		// El report-status viaja dentro del canal 1 del side-band
+		var data bytes.Buffer
+		reader := bufio.NewReader(bytes.NewReader(p.status))
+		for {
+			packet, flush, err := readPacket(reader)
+			if err != nil {
+				return fmt.Errorf("error leyendo report-status: %w", err)
+			}
+			if flush {
+				break
+			}
+			if len(packet) > 0 && packet[0] == 1 {
+				data.WriteString(packet[1:])
+			}
+		}
+		report = data.Bytes()
+	}
+
+	accepted := make(map[string]bool)
+	reader := bufio.NewReader(bytes.NewReader(report))
+	for {
+		line, flush, err := readPacket(reader)
+		if err != nil {
+			return fmt.Errorf("error leyendo report-status: %w", err)
+		}
+		if flush {
+			break
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if unpack, ok := strings.CutPrefix(line, "unpack "); ok && unpack != "ok" {
+			return nil
+		}
+		if ref, ok := strings.CutPrefix(line, "ok "); ok {
+			accepted[ref] = true
+		}
+	}
+
+	for i := range p.Updates {
+		p.Updates[i].OK = accepted[p.Updates[i].Ref]
+	}
+	return nil
+}
+
+// resolveAccepted marca como OK las referencias que quedaron en el commit del
+// push, para clientes que no piden report-status
+func (p *Push) resolveAccepted(ctx context.Context, repoDir string) {
+	for i := range p.Updates {
+		update := &p.Updates[i]
+		commit, err := ResolveCommit(ctx, repoDir, update.Ref)
+		if update.Deleted() {
+			update.OK = err != nil
+		} else {
+			update.OK = err == nil && commit == update.New
+		}
+	}
+}
+
+// WriteStatus escribe el report-status de git; con side-band deja el canal
+// abierto para Progress
+func (p *Push) WriteStatus(w io.Writer) error {
+	status := p.status
+	if p.sidebandMax > 0 {
+		status = bytes.TrimSuffix(status, []byte("0000"))
+	}
+	_, err := w.Write(status)
+	return err
+}
+
+// Progress envía un mensaje al cliente, que lo muestra como "remote: ..."; sin
+// side-band negociado no hace nada
+func (p *Push) Progress(w io.Writer, message string) error {
+	if p.sidebandMax == 0 {
+		return nil
+	}
+	data := []byte(strings.TrimRight(message, "\n") + "\n")
+	for len(data) > 0 {
+		n := min(len(data), p.sidebandMax)
+		if err := writePacket(w, append([]byte{2}, data[:n]...)); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// Close termina la respuesta cerrando el canal de side-band
+func (p *Push) Close(w io.Writer) error {
+	if p.sidebandMax == 0 {
+		return nil
+	}
+	_, err := io.WriteString(w, "0000")
+	return err
+}
+
+func negotiatedSideband(caps string) int {
+	for _, capability := range strings.Fields(caps) {
+		switch capability {
+		case "side-band-64k":
+			return sideband64kMax
+		case "side-band":
+			return sidebandMax
+		}
+	}
+	return 0
+}
+
+func negotiatedReportStatus(caps string) bool {
+	for _, capability := range strings.Fields(caps) {
+		if capability == "report-status" || capability == "report-status-v2" {
+			return true
+		}
+	}
+	return false
+}
+
+func writePacket(w io.Writer, data []byte) error {
+	if _, err := fmt.Fprintf(w, "%04x", len(data)+4); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// readPacket lee un pkt-line; flush indica el paquete 0000
+func readPacket(r *bufio.Reader) (line string, flush bool, err error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return "", false, err
+	}
+	size, err := strconv.ParseUint(string(header[:]), 16, 16)
+	if err != nil {
+		return "", false, fmt.Errorf("longitud de paquete inválida %q", header)
+	}
+	if size == 0 {
+		return "", true, nil
+	}
+	if size < 4 {
+		return "", false, fmt.Errorf("longitud de paquete inválida %d", size)
+	}
+	data := make([]byte, size-4)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", false, err
+	}
+	return string(data), false, nil
+}
@@ -0,0 +1,173 @@
+package gitserver
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultBranch es la rama inicial de los repositorios alojados
+const DefaultBranch = "main"
+
+// validRepoName limita los nombres a etiquetas DNS: el repo da nombre a la app
+// y la app se publica como <nombre>.<dominio>
+var validRepoName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// Repos gestiona los repositorios bare que Diplo aloja, uno por app
+type Repos struct {
+	dir string
+}
+
+// NewRepos crea (si hace falta) el directorio de repositorios
+func NewRepos(dir string) (*Repos, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(abs, 0755); err != nil {
+		return nil, fmt.Errorf("error creando directorio de repositorios: %w", err)
+	}
+	return &Repos{dir: abs}, nil
+}
+
+// ValidName indica si name sirve como nombre de repositorio alojado
+func ValidName(name string) bool {
+	return validRepoName.MatchString(name)
+}
+
+// Path devuelve la ruta del repositorio bare de name
+func (r *Repos) Path(name string) string {
+	return filepath.Join(r.dir, name+".git")
+}
+
+// Hosts indica si repoURL es un repositorio alojado por Diplo
+func (r *Repos) Hosts(repoURL string) bool {
+	return r != nil && filepath.Dir(repoURL) == r.dir && strings.HasSuffix(repoURL, ".git")
+}
+
+// Init crea el repositorio bare de name si no existe y devuelve su ruta
+func (r *Repos) Init(ctx context.Context, name string) (string, error) {
+	if !ValidName(name) {
+		return "", fmt.Errorf("nombre de repositorio inválido: %q", name)
+	}
+
+	path := r.Path(name)
+	if _, err := os.Stat(filepath.Join(path, "HEAD")); err == nil {
+		return path, nil
+	}
+
+	if _, err := runGit(ctx, "", "init", "--bare", "--quiet", "--initial-branch="+DefaultBranch, path); err != nil {
+		return "", err
+	}
+	logrus.Infof("Repositorio alojado creado: %s", path)
+	return path, nil
+}
+
+// CloneBare clona un repositorio remoto como bare en dest, para construir un
+// commit concreto con las mismas funciones que los repos alojados
+func CloneBare(ctx context.Context, repoURL, dest string) error {
+	_, err := runGit(ctx, "", "clone", "--bare", "--quiet", "--", repoURL, dest)
+	return err
+}
+
+// ResolveCommit devuelve el SHA del commit al que apunta rev
+func ResolveCommit(ctx context.Context, repoDir, rev string) (string, error) {
+	out, err := runGit(ctx, repoDir, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("commit %s no encontrado: %w", rev, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// SetHead hace que HEAD apunte a la rama indicada (la que se clona por defecto)
+func SetHead(ctx context.Context, repoDir, branch string) error {
+	_, err := runGit(ctx, repoDir, "symbolic-ref", "HEAD", "refs/heads/"+branch)
+	return err
+}
+
+// HasFile indica si el commit rev tiene name en la raíz del repositorio
+func HasFile(ctx context.Context, repoDir, rev, name string) (bool, error) {
+	out, err := runGit(ctx, repoDir, "ls-tree", "--name-only", rev, "--", name)
+	if err != nil {
+		return false, err
+	}
+	return len(bytes.TrimSpace(out)) > 0, nil
+}
+
+// Archive devuelve el árbol de commit como tar, listo para un contexto de build.
+// Con path solo incluye ese subdirectorio, con sus archivos en la raíz del tar.
+func Archive(ctx context.Context, repoDir, commit, path string) ([]byte, error) {
+	tree := commit
+	if path != "" {
+		tree = commit + ":" + path
+		out, err := runGit(ctx, repoDir, "cat-file", "-t", tree)
+		if err != nil || strings.TrimSpace(string(out)) != "tree" {
+			return nil, fmt.Errorf("el commit %s no tiene el directorio %s", commit, path)
+		}
+	}
+	return runGit(ctx, repoDir, "archive", "--format=tar", tree)
+}
+
+// Extract descomprime en dest un tar producido por Archive
+func Extract(archive []byte, dest string) error {
+	tr := tar.NewReader(bytes.NewReader(archive))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error leyendo archivo: %w", err)
+		}
+
+		target := filepath.Join(dest, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(target, filepath.Clean(dest)+string(os.PathSeparator)) {
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode)&0777)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(file, tr)
+			file.Close()
+			if err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func runGit(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
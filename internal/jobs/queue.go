@@ -0,0 +1,332 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rodrwan/diplo/internal/database"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// pollInterval es cada cuánto los workers revisan la cola aunque nadie los despierte
+	pollInterval = 5 * time.Second
+
+	// lockTTL es la duración del lease de una app; el worker lo renueva cada
+	// lockRenewInterval mientras el trabajo corre
+	lockTTL           = 2 * time.Minute
+	lockRenewInterval = 30 * time.Second
+)
+
+var (
+	// ErrCancelled es la causa con la que se cancela el contexto de un trabajo cancelado por API
+	ErrCancelled = errors.New("trabajo cancelado")
+	// ErrFinished indica que el trabajo ya terminó y no se puede cancelar
+	ErrFinished = errors.New("el trabajo ya finalizó")
+)
+
+// Handler ejecuta un trabajo; ctx se cancela si el trabajo se cancela o el servidor se detiene
+type Handler func(ctx context.Context, job database.Job) error
+
+// Queue es una cola persistente de trabajos (tabla jobs) atendida por un
+// número fijo de workers. Un lease por app (tabla app_locks) impide que dos
+// trabajos de la misma app corran a la vez: el segundo espera en la cola.
+type Queue struct {
+	queries database.Querier
+	workers int
+	handler Handler
+
+	// wake despierta a un worker cuando se encola un trabajo
+	wake chan struct{}
+
+	// mu protege running y serializa la toma de trabajos con su cancelación
+	mu      sync.Mutex
+	running map[string]context.CancelCauseFunc
+}
+
+// New crea una cola con el número de workers indicado (mínimo 1)
+func New(queries database.Querier, workers int) *Queue {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Queue{
+		queries: queries,
+		workers: workers,
+		wake:    make(chan struct{}, 1),
+		running: make(map[string]context.CancelCauseFunc),
+	}
+}
+
+// SetHandler define la función que ejecuta los trabajos; debe llamarse antes de Start
+func (q *Queue) SetHandler(handler Handler) {
+	q.handler = handler
+}
+
+// Workers devuelve el número de trabajos que se ejecutan en paralelo
+func (q *Queue) Workers() int {
+	return q.workers
+}
+
+// Enqueue guarda un trabajo nuevo en estado queued y despierta a un worker
+func (q *Queue) Enqueue(ctx context.Context, appID, kind, payload string) (database.Job, error) {
+	job := database.Job{
+		ID:        database.GenerateJobID(),
+		AppID:     appID,
+		Kind:      kind,
+		Status:    database.JobQueued,
+		Payload:   sql.NullString{String: payload, Valid: payload != ""},
+		CreatedAt: time.Now(),
+	}
+
+	if err := q.queries.CreateJob(ctx, database.CreateJobParams{
+		ID:        job.ID,
+		AppID:     job.AppID,
+		Kind:      job.Kind,
+		Status:    job.Status,
+		Payload:   job.Payload,
+		CreatedAt: job.CreatedAt,
+	}); err != nil {
+		return database.Job{}, err
+	}
+
+	logrus.Infof("Trabajo %s (%s) encolado para la app %s", job.ID, job.Kind, job.AppID)
+	q.notify()
+	return job, nil
+}
+
+// Cancel cancela un trabajo: si está en cola no llega a ejecutarse y si está
+// corriendo se cancela su contexto (git, build y runtime se interrumpen)
+func (q *Queue) Cancel(ctx context.Context, id string) (database.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, err := q.queries.GetJob(ctx, id)
+	if err != nil {
+		return database.Job{}, err
+	}
+
+	switch job.Status {
+	case database.JobQueued:
+		if _, err := q.queries.CancelQueuedJob(ctx, database.CancelQueuedJobParams{
+			ErrorMsg:   sql.NullString{String: ErrCancelled.Error(), Valid: true},
+			FinishedAt: sql.NullTime{Time: time.Now(), Valid: true},
+			ID:         id,
+		}); err != nil {
+			return database.Job{}, err
+		}
+		logrus.Infof("Trabajo %s cancelado antes de ejecutarse", id)
+		return q.queries.GetJob(ctx, id)
+	case database.JobRunning:
+		cancel, ok := q.running[id]
+		if !ok {
+			return job, ErrFinished
+		}
+		cancel(ErrCancelled)
+		logrus.Infof("Cancelando trabajo %s en ejecución", id)
+		return job, nil
+	default:
+		return job, ErrFinished
+	}
+}
+
+// CancelApp cancela todos los trabajos pendientes de una app y devuelve cuántos canceló
+func (q *Queue) CancelApp(ctx context.Context, appID string) (int, error) {
+	pending, err := q.queries.ListPendingAppJobs(ctx, appID)
+	if err != nil {
+		return 0, err
+	}
+
+	cancelled := 0
+	for _, job := range pending {
+		if _, err := q.Cancel(ctx, job.ID); err != nil {
+			if errors.Is(err, ErrFinished) {
+				continue
+			}
+			return cancelled, err
+		}
+		cancelled++
+	}
+	return cancelled, nil
+}
+
+// Recover vuelve a encolar los trabajos que quedaron corriendo cuando el
+// servidor se detuvo; se llama al arrancar, antes de Start. Cada trabajo
+// conserva el lease de su app y lo recupera al volver a ejecutarse.
+func (q *Queue) Recover(ctx context.Context) error {
+	requeued, err := q.queries.RequeueRunningJobs(ctx)
+	if err != nil {
+		return err
+	}
+	if requeued > 0 {
+		logrus.Infof("♻️  %d trabajos interrumpidos vueltos a encolar", requeued)
+	}
+	return nil
+}
+
+// Start ejecuta los workers hasta que el contexto se cancele
+func (q *Queue) Start(ctx context.Context) {
+	logrus.Infof("📦 Cola de deploys con %d workers", q.workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < q.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx)
+		}()
+	}
+	q.notify()
+	wg.Wait()
+
+	logrus.Info("Cola de deploys detenida")
+}
+
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *Queue) work(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for q.runNext(ctx) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// runNext toma y ejecuta el trabajo más antiguo de la cola; devuelve false si
+// no había trabajo que ejecutar
+func (q *Queue) runNext(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	jobCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	now := time.Now()
+	q.mu.Lock()
+	// Solo se toman trabajos de apps cuyo lease está libre, vencido o es del propio trabajo
+	job, err := q.queries.ClaimNextJob(ctx, database.ClaimNextJobParams{
+		StartedAt: sql.NullTime{Time: now, Valid: true},
+		ExpiresAt: now,
+	})
+	if err != nil {
+		q.mu.Unlock()
+		if err != sql.ErrNoRows && ctx.Err() == nil {
+			logrus.Errorf("Error tomando trabajo de la cola: %v", err)
+		}
+		return false
+	}
+	acquired, err := q.queries.AcquireAppLock(ctx, database.AcquireAppLockParams{
+		AppID:      job.AppID,
+		JobID:      job.ID,
+		AcquiredAt: now,
+		ExpiresAt:  now.Add(lockTTL),
+	})
+	if err != nil || acquired == 0 {
+		q.mu.Unlock()
+		if err == nil {
+			err = fmt.Errorf("la app %s tiene otro deploy en curso", job.AppID)
+		}
+		q.finish(job, database.JobFailed, fmt.Sprintf("no se pudo obtener el lock de la app: %v", err))
+		return true
+	}
+	q.running[job.ID] = cancel
+	q.mu.Unlock()
+
+	defer func() {
+		q.mu.Lock()
+		delete(q.running, job.ID)
+		q.mu.Unlock()
+	}()
+
+	renewDone := make(chan struct{})
+	defer close(renewDone)
+	go q.renewLock(job, renewDone)
+
+	// Puede haber más trabajos esperando a otro worker
+	q.notify()
+
+	logrus.Infof("Ejecutando trabajo %s (%s) de la app %s, intento %d", job.ID, job.Kind, job.AppID, job.Attempts)
+	err = q.handler(jobCtx, job)
+
+	status := database.JobSucceeded
+	errorMsg := ""
+	switch {
+	case errors.Is(context.Cause(jobCtx), ErrCancelled):
+		status = database.JobCancelled
+		errorMsg = ErrCancelled.Error()
+	case ctx.Err() != nil:
+		// El servidor se está deteniendo: el trabajo queda en running con su
+		// lease y Recover lo vuelve a encolar al arrancar
+		logrus.Warnf("Trabajo %s interrumpido por el apagado, se reanudará al reiniciar", job.ID)
+		return false
+	case err != nil:
+		status = database.JobFailed
+		errorMsg = err.Error()
+	}
+
+	q.finish(job, status, errorMsg)
+	return true
+}
+
+// finish guarda el resultado del trabajo y libera el lease de su app
+func (q *Queue) finish(job database.Job, status, errorMsg string) {
+	if err := q.queries.FinishJob(context.Background(), database.FinishJobParams{
+		Status:     status,
+		ErrorMsg:   sql.NullString{String: errorMsg, Valid: errorMsg != ""},
+		FinishedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		ID:         job.ID,
+	}); err != nil {
+		logrus.Errorf("Error guardando resultado del trabajo %s: %v", job.ID, err)
+	}
+
+	if err := q.queries.ReleaseAppLock(context.Background(), database.ReleaseAppLockParams{
+		AppID: job.AppID,
+		JobID: job.ID,
+	}); err != nil {
+		logrus.Errorf("Error liberando lock de la app %s: %v", job.AppID, err)
+	}
+
+	logrus.Infof("Trabajo %s finalizado: %s", job.ID, status)
+	// El siguiente trabajo de la app ya puede ejecutarse
+	q.notify()
+}
+
+// renewLock extiende el lease de la app mientras el trabajo sigue corriendo
+func (q *Queue) renewLock(job database.Job, done <-chan struct{}) {
+	ticker := time.NewTicker(lockRenewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			renewed, err := q.queries.RenewAppLock(context.Background(), database.RenewAppLockParams{
+				ExpiresAt: time.Now().Add(lockTTL),
+				AppID:     job.AppID,
+				JobID:     job.ID,
+			})
+			if err != nil || renewed == 0 {
+				logrus.Warnf("No se pudo renovar el lock de la app %s para el trabajo %s: %v", job.AppID, job.ID, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,224 @@
+// Package manifest lee diplo.yaml y el Procfile, los archivos opcionales en la
+// raíz del repo con los que una app declara cómo se construye y se ejecuta.
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/docker/go-units"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName es el nombre del manifiesto en la raíz del repositorio
+const FileName = "diplo.yaml"
+
+const (
+	// maxHealthTimeout acota cuánto se espera a que una versión nueva responda
+	maxHealthTimeout = 10 * time.Minute
+	// minMemoryBytes es el mínimo de memoria que acepta Docker
+	minMemoryBytes = 6 * 1024 * 1024
+)
+
+var (
+	versionPattern    = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	envNamePattern    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	volumeNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+)
+
+// Manifest es el contenido de diplo.yaml. Los campos omitidos mantienen lo que
+// Diplo detecta o sus valores por defecto.
+type Manifest struct {
+	// Language elige la plantilla del Dockerfile en vez de detectarla
+	Language string `yaml:"language"`
+	// Version es la versión del toolchain, p. ej. "3.12" para Python
+	Version string `yaml:"version"`
+	// Build se ejecuta en la etapa de build, tras instalar dependencias
+	Build string `yaml:"build"`
+	// Start reemplaza el comando de inicio de la plantilla
+	Start string `yaml:"start"`
+	// Port es el puerto en el que escucha la app dentro del contenedor
+	Port        int          `yaml:"port"`
+	HealthCheck *HealthCheck `yaml:"health_check"`
+	Resources   Resources    `yaml:"resources"`
+	Volumes     []Volume     `yaml:"volumes"`
+	// Env son valores por defecto no secretos; las variables de la app los reemplazan
+	Env map[string]string `yaml:"env"`
+}
+
+// HealthCheck configura la comprobación HTTP antes de enviar tráfico a una versión nueva
+type HealthCheck struct {
+	Path    string `yaml:"path"`
+	Timeout string `yaml:"timeout"`
+}
+
+// Resources limita la memoria (p. ej. "512m") y las CPUs (p. ej. 0.5) del contenedor
+type Resources struct {
+	Memory string  `yaml:"memory"`
+	CPUs   float64 `yaml:"cpus"`
+}
+
+// Volume es un volumen con nombre montado en Path que se conserva entre deploys
+type Volume struct {
+	Name string `yaml:"name"`
+	Path string `yaml:"path"`
+}
+
+// ValidationError reúne los problemas encontrados en diplo.yaml o en el Procfile
+type ValidationError struct {
+	// File es el archivo con problemas
+	File     string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s inválido: %s", e.File, strings.Join(e.Problems, "; "))
+}
+
+// Load lee diplo.yaml de dir; devuelve nil si el repo no tiene manifiesto
+func Load(dir string) (*Manifest, error) {
+	path := filepath.Join(dir, FileName)
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo %s: %w", FileName, err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil, &ValidationError{File: FileName, Problems: []string{"no es un archivo regular"}}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo %s: %w", FileName, err)
+	}
+	return Parse(data)
+}
+
+// Parse decodifica y valida un manifiesto. Los campos desconocidos son un
+// error para que una errata no se ignore en silencio.
+func Parse(data []byte) (*Manifest, error) {
+	var m Manifest
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&m); err != nil && !errors.Is(err, io.EOF) {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			return nil, &ValidationError{File: FileName, Problems: typeErr.Errors}
+		}
+		return nil, &ValidationError{File: FileName, Problems: []string{strings.TrimPrefix(err.Error(), "yaml: ")}}
+	}
+
+	if problems := m.validate(); len(problems) > 0 {
+		return nil, &ValidationError{File: FileName, Problems: problems}
+	}
+	return &m, nil
+}
+
+func (m *Manifest) validate() []string {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	m.Language = strings.ToLower(strings.TrimSpace(m.Language))
+	m.Version = strings.TrimSpace(m.Version)
+	if m.Version != "" && !versionPattern.MatchString(m.Version) {
+		add("version: %q no es un tag de imagen válido", m.Version)
+	}
+
+	m.Build = strings.TrimSpace(m.Build)
+	m.Start = strings.TrimSpace(m.Start)
+	for _, command := range []struct{ field, value string }{{"build", m.Build}, {"start", m.Start}} {
+		if strings.ContainsAny(command.value, "\r\n") {
+			add("%s: debe ser un solo comando en una línea (usa && para encadenar)", command.field)
+		}
+	}
+
+	if m.Port != 0 && (m.Port < 1 || m.Port > 65535) {
+		add("port: %d no está entre 1 y 65535", m.Port)
+	}
+
+	if m.HealthCheck != nil {
+		if m.HealthCheck.Path == "" {
+			m.HealthCheck.Path = "/"
+		}
+		if !strings.HasPrefix(m.HealthCheck.Path, "/") || strings.ContainsAny(m.HealthCheck.Path, " \t\r\n") {
+			add("health_check.path: %q debe empezar con / y no tener espacios", m.HealthCheck.Path)
+		}
+		if timeout, err := m.HealthCheckTimeout(); err != nil {
+			add("health_check.timeout: %q no es una duración válida (p. ej. 90s)", m.HealthCheck.Timeout)
+		} else if timeout < 0 || timeout > maxHealthTimeout {
+			add("health_check.timeout: debe estar entre 0 y %s", maxHealthTimeout)
+		}
+	}
+
+	if m.Resources.Memory != "" {
+		if memory, err := units.RAMInBytes(m.Resources.Memory); err != nil {
+			add("resources.memory: %q no es un tamaño válido (p. ej. 512m)", m.Resources.Memory)
+		} else if memory < minMemoryBytes {
+			add("resources.memory: debe ser de al menos 6m")
+		}
+	}
+	if m.Resources.CPUs < 0 {
+		add("resources.cpus: debe ser mayor que 0")
+	}
+
+	names := make(map[string]bool)
+	paths := make(map[string]bool)
+	for i, volume := range m.Volumes {
+		if !volumeNamePattern.MatchString(volume.Name) {
+			add("volumes[%d].name: %q debe usar letras, números, '.', '_' o '-'", i, volume.Name)
+		} else if names[volume.Name] {
+			add("volumes[%d].name: %q está repetido", i, volume.Name)
+		}
+		names[volume.Name] = true
+
+		clean := filepath.ToSlash(filepath.Clean(volume.Path))
+		if !strings.HasPrefix(volume.Path, "/") || clean == "/" {
+			add("volumes[%d].path: %q debe ser una ruta absoluta del contenedor distinta de /", i, volume.Path)
+		} else if paths[clean] {
+			add("volumes[%d].path: %q está repetido", i, volume.Path)
+		}
+		paths[clean] = true
+		m.Volumes[i].Path = clean
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(m.Env)) {
+		switch {
+		case !envNamePattern.MatchString(name):
+			add("env: %q no es un nombre de variable válido", name)
+		case name == "PORT" || strings.HasPrefix(name, "DIPLO_"):
+			add("env: %s la define Diplo", name)
+		}
+	}
+
+	return problems
+}
+
+// HealthCheckTimeout devuelve el timeout del health check; 0 usa el de Diplo
+func (m *Manifest) HealthCheckTimeout() (time.Duration, error) {
+	if m.HealthCheck == nil || m.HealthCheck.Timeout == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(m.HealthCheck.Timeout)
+}
+
+// MemoryBytes devuelve el límite de memoria en bytes; 0 es sin límite
+func (m *Manifest) MemoryBytes() int64 {
+	if m.Resources.Memory == "" {
+		return 0
+	}
+	memory, _ := units.RAMInBytes(m.Resources.Memory)
+	return memory
+}
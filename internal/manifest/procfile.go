@@ -0,0 +1,82 @@
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ProcfileName es el nombre del Procfile en la raíz del repositorio
+const ProcfileName = "Procfile"
+
+// processTypePattern acota los nombres de proceso: se usan en etiquetas y en la API
+var processTypePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,29}$`)
+
+// LoadProcfile lee el Procfile de dir; devuelve nil si el repo no tiene uno
+func LoadProcfile(dir string) (map[string]string, error) {
+	path := filepath.Join(dir, ProcfileName)
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo %s: %w", ProcfileName, err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil, &ValidationError{File: ProcfileName, Problems: []string{"no es un archivo regular"}}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo %s: %w", ProcfileName, err)
+	}
+	return ParseProcfile(data)
+}
+
+// ParseProcfile decodifica líneas "tipo: comando" en un mapa de tipo de proceso
+// a comando. Las líneas vacías y las que empiezan con # se ignoran.
+func ParseProcfile(data []byte) (map[string]string, error) {
+	processes := make(map[string]string)
+	var problems []string
+	add := func(line int, format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf("línea %d: ", line)+fmt.Sprintf(format, args...))
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		name, command, ok := strings.Cut(text, ":")
+		name, command = strings.ToLower(strings.TrimSpace(name)), strings.TrimSpace(command)
+		switch {
+		case !ok:
+			add(line, "se esperaba \"tipo: comando\"")
+		case !processTypePattern.MatchString(name):
+			add(line, "%q no es un tipo de proceso válido (letras, números, '_' o '-')", name)
+		case command == "":
+			add(line, "el proceso %s no tiene comando", name)
+		case processes[name] != "":
+			add(line, "el proceso %s está repetido", name)
+		default:
+			processes[name] = command
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error leyendo %s: %w", ProcfileName, err)
+	}
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{File: ProcfileName, Problems: problems}
+	}
+	if len(processes) == 0 {
+		return nil, nil
+	}
+	return processes, nil
+}
@@ -6,10 +6,54 @@ type EnvVar struct {
 }
 
 type DeployRequest struct {
-	RepoURL     string   `json:"repo_url"`
-	Name        string   `json:"name,omitempty"`
-	RuntimeType string   `json:"runtime_type,omitempty"`
+	RepoURL     string `json:"repo_url,omitempty"`
+	Name        string `json:"name,omitempty"`
+	RuntimeType string `json:"runtime_type,omitempty"`
+	// Language fija el lenguaje de la app sobre el de diplo.yaml y la
+	// detección; "auto" vuelve a detectarlo y si se omite se mantiene el de la app
 	Language    string   `json:"language,omitempty"`
 	EnvVars     []EnvVar `json:"env_vars,omitempty"`
 	GitHubToken string   `json:"github_token,omitempty"`
+	// OnConflict decide qué pasa si la app ya tiene un deploy en curso:
+	// "queue" (por defecto) espera a que termine, "replace" lo cancela
+	OnConflict string `json:"on_conflict,omitempty"`
+	// Ref fija la rama, tag o commit a desplegar y, junto al repo y Path,
+	// identifica a la app; "HEAD" es la app que sigue la rama por defecto y si
+	// se omite se usa la app del repo y Path con el ref que tenga
+	Ref string `json:"ref,omitempty"`
+	// ForceRebuild construye la imagen desde cero aunque ya exista una del
+	// mismo commit y Dockerfile
+	ForceRebuild bool `json:"force_rebuild,omitempty"`
+	// DockerfilePath es el Dockerfile del repo con el que construir, relativo a
+	// la raíz; "Dockerfile" vuelve a la detección automática (el de la raíz si
+	// existe, si no la plantilla del lenguaje) y si se omite se mantiene el de la app
+	DockerfilePath string `json:"dockerfile_path,omitempty"`
+	// StartCommand reemplaza el comando de inicio de la plantilla (se ejecuta
+	// con sh -c y tiene prioridad sobre diplo.yaml); "auto" vuelve a la
+	// detección por framework y si se omite se mantiene el de la app
+	StartCommand string `json:"start_command,omitempty"`
+	// Path es el subdirectorio del repo con el código de la app: se usa para
+	// detectar el lenguaje y como contexto de build. Junto al repo y el ref
+	// identifica a la app, así que un mismo repo puede tener varias
+	Path string `json:"path,omitempty"`
+	// GoTarget es el paquete main que compila la plantilla de Go, p. ej.
+	// "./cmd/api"; GoTags y GoLDFlags se pasan a go build. Si se omiten se
+	// mantienen los de la app y "" los quita (GoTarget vuelve a detectarse)
+	GoTarget  *string `json:"go_target,omitempty"`
+	GoTags    *string `json:"go_tags,omitempty"`
+	GoLDFlags *string `json:"go_ldflags,omitempty"`
+	// Image despliega una imagen ya construida en vez de RepoURL, p. ej.
+	// "ghcr.io/org/tool:1.2": no se detecta ni se construye nada. El
+	// repositorio de la imagen identifica a la app, así que desplegar otro tag
+	// la redespliega
+	Image string `json:"image,omitempty"`
+	// RegistryUsername y RegistryPassword autentican la descarga de Image. Si
+	// se omiten se mantienen los de la app y "" los quita
+	RegistryUsername *string `json:"registry_username,omitempty"`
+	RegistryPassword *string `json:"registry_password,omitempty"`
+	// HealthCheckPath es la ruta que debe responder 2xx o 3xx antes de que un
+	// redeploy reciba tráfico; gana a la de diplo.yaml y sirve también para
+	// apps de imagen. "auto" la quita (cualquier respuesta HTTP basta) y si se
+	// omite se mantiene la de la app
+	HealthCheckPath string `json:"health_check_path,omitempty"`
 }
@@ -0,0 +1,114 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/rodrwan/diplo/internal/database"
+	"github.com/sirupsen/logrus"
+)
+
+// forwardDialTimeout es el tiempo máximo para conectar con el contenedor activo
+const forwardDialTimeout = 5 * time.Second
+
+// forwarder escucha en el puerto estable de una app y reenvía cada conexión
+// TCP al puerto del contenedor activo. Cambiar upstream solo afecta a las
+// conexiones nuevas: las abiertas siguen con la versión anterior mientras drena.
+type forwarder struct {
+	port     int64
+	listener net.Listener
+	upstream atomic.Int64
+}
+
+// syncForwarders abre un forwarder por cada app cuyo contenedor activo no
+// escucha en su puerto estable y cierra los que ya no hacen falta. Si el
+// puerto sigue ocupado (la versión anterior aún drena) se reintenta en el
+// siguiente Refresh.
+func (p *Proxy) syncForwarders(apps []database.App) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	wanted := make(map[string]bool, len(apps))
+	for _, app := range apps {
+		upstream := upstreamPort(app)
+		if app.Port <= 0 || upstream == app.Port {
+			continue
+		}
+		wanted[app.ID] = true
+
+		if fw, ok := p.forwarders[app.ID]; ok && fw.port == app.Port {
+			if fw.upstream.Swap(upstream) != upstream {
+				logrus.Infof("Proxy: puerto %d de la app %s ahora reenvía a %d", app.Port, app.ID, upstream)
+			}
+			continue
+		}
+
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", app.Port))
+		if err != nil {
+			logrus.Debugf("Proxy: puerto %d de la app %s aún ocupado, se reintenta: %v", app.Port, app.ID, err)
+			continue
+		}
+		fw := &forwarder{port: app.Port, listener: listener}
+		fw.upstream.Store(upstream)
+		p.forwarders[app.ID] = fw
+		go p.serveForwarder(fw)
+		logrus.Infof("Proxy: puerto %d de la app %s reenvía a %d", app.Port, app.ID, upstream)
+	}
+
+	for appID, fw := range p.forwarders {
+		if !wanted[appID] {
+			fw.listener.Close()
+			delete(p.forwarders, appID)
+		}
+	}
+}
+
+// Close cierra los puertos estables que publica el proxy
+func (p *Proxy) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for appID, fw := range p.forwarders {
+		fw.listener.Close()
+		delete(p.forwarders, appID)
+	}
+}
+
+func (p *Proxy) serveForwarder(fw *forwarder) {
+	for {
+		conn, err := fw.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logrus.Warnf("Proxy: error aceptando conexión en el puerto %d: %v", fw.port, err)
+			}
+			return
+		}
+		go p.forward(conn, fw.upstream.Load())
+	}
+}
+
+// forward copia la conexión en ambos sentidos hasta que el contenedor termina
+// de responder
+func (p *Proxy) forward(client net.Conn, port int64) {
+	defer client.Close()
+	defer p.track(port)()
+
+	upstream, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), forwardDialTimeout)
+	if err != nil {
+		logrus.Warnf("Proxy: error conectando con el puerto %d: %v", port, err)
+		return
+	}
+	defer upstream.Close()
+
+	go func() {
+		io.Copy(upstream, client)
+		if tcp, ok := upstream.(*net.TCPConn); ok {
+			tcp.CloseWrite()
+		}
+	}()
+	io.Copy(client, upstream)
+}
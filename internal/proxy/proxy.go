@@ -0,0 +1,372 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rodrwan/diplo/internal/database"
+	"github.com/sirupsen/logrus"
+)
+
+// PathPrefix es el prefijo bajo el que se publica cada app en el host de Diplo
+// (ej. /apps/mi-app/ → contenedor de "mi-app")
+const PathPrefix = "/apps/"
+
+// Route describe cómo se publica una app a través del proxy
+type Route struct {
+	AppID      string `json:"app_id"`
+	Name       string `json:"name"`
+	Host       string `json:"host"`
+	PathPrefix string `json:"path_prefix"`
+	Target     string `json:"target"`
+	URL        string `json:"url"`
+}
+
+// Config define el dominio base y el puerto público bajo los que se publican las apps
+type Config struct {
+	// BaseDomain es el dominio del que cuelgan las apps: <app>.<BaseDomain>
+	BaseDomain string
+	// PublicPort es el puerto por el que los clientes llegan al proxy (para construir URLs)
+	PublicPort int
+	// TLS indica que las URLs públicas usan https
+	TLS bool
+}
+
+// Proxy es un proxy inverso que enruta por hostname (<app>.<dominio>) y por
+// prefijo de ruta (/apps/<app>/) al puerto del contenedor de cada app. También
+// publica el puerto estable de las apps cuyo contenedor activo escucha en otro
+// puerto tras un redeploy blue/green.
+type Proxy struct {
+	queries database.Querier
+	config  Config
+
+	mu         sync.RWMutex
+	byHost     map[string]*appRoute
+	byName     map[string]*appRoute
+	byAppID    map[string]*appRoute
+	forwarders map[string]*forwarder
+
+	// active cuenta las peticiones y conexiones en curso por puerto de destino
+	activeMu sync.Mutex
+	active   map[int64]int
+}
+
+type appRoute struct {
+	Route
+	port    int64
+	handler *httputil.ReverseProxy
+}
+
+// New crea un proxy sin rutas; llamar a Refresh para cargarlas desde la BD
+func New(queries database.Querier, config Config) *Proxy {
+	if config.BaseDomain == "" {
+		config.BaseDomain = "localhost"
+	}
+	config.BaseDomain = strings.ToLower(strings.Trim(config.BaseDomain, "."))
+
+	return &Proxy{
+		queries:    queries,
+		config:     config,
+		byHost:     make(map[string]*appRoute),
+		byName:     make(map[string]*appRoute),
+		byAppID:    make(map[string]*appRoute),
+		forwarders: make(map[string]*forwarder),
+		active:     make(map[int64]int),
+	}
+}
+
+// BaseDomain devuelve el dominio base configurado
+func (p *Proxy) BaseDomain() string {
+	return p.config.BaseDomain
+}
+
+// Refresh reconstruye la tabla de rutas a partir de las apps de la BD. Se llama
+// tras cada deploy, redeploy o eliminación, y en cada pasada de reconciliación.
+func (p *Proxy) Refresh(ctx context.Context) error {
+	apps, err := p.queries.GetAllApps(ctx)
+	if err != nil {
+		return fmt.Errorf("error obteniendo aplicaciones para el proxy: %v", err)
+	}
+
+	// Las apps más antiguas conservan el nombre en caso de colisión
+	sort.SliceStable(apps, func(i, j int) bool {
+		return apps[i].CreatedAt.Time.Before(apps[j].CreatedAt.Time)
+	})
+
+	byHost := make(map[string]*appRoute, len(apps))
+	byName := make(map[string]*appRoute, len(apps))
+	byAppID := make(map[string]*appRoute, len(apps))
+
+	p.mu.RLock()
+	previous := p.byAppID
+	p.mu.RUnlock()
+
+	for _, app := range apps {
+		if app.Port <= 0 {
+			continue
+		}
+
+		name := routeName(app.Name, app.ID)
+		if _, taken := byName[name]; taken {
+			name = routeName(name+"-"+shortID(app.ID), app.ID)
+		}
+
+		port := upstreamPort(app)
+		target := fmt.Sprintf("http://127.0.0.1:%d", port)
+		route := &appRoute{
+			Route: Route{
+				AppID:      app.ID,
+				Name:       name,
+				Host:       name + "." + p.config.BaseDomain,
+				PathPrefix: PathPrefix + name + "/",
+				Target:     target,
+			},
+			port: port,
+		}
+		route.URL = p.hostURL(route.Host)
+
+		// Reutilizar el handler si el destino no cambió para no cortar conexiones
+		if old, ok := previous[app.ID]; ok && old.Target == target {
+			route.handler = old.handler
+		} else {
+			handler, err := newReverseProxy(target)
+			if err != nil {
+				logrus.Errorf("Error creando ruta de proxy para app %s: %v", app.ID, err)
+				continue
+			}
+			route.handler = handler
+		}
+
+		byHost[route.Host] = route
+		byName[name] = route
+		byAppID[app.ID] = route
+	}
+
+	p.mu.Lock()
+	p.byHost = byHost
+	p.byName = byName
+	p.byAppID = byAppID
+	p.mu.Unlock()
+
+	p.syncForwarders(apps)
+
+	logrus.Debugf("Proxy: %d rutas activas", len(byAppID))
+	return nil
+}
+
+// upstreamPort devuelve el puerto del host del contenedor activo de la app
+func upstreamPort(app database.App) int64 {
+	if app.UpstreamPort.Valid && app.UpstreamPort.Int64 > 0 {
+		return app.UpstreamPort.Int64
+	}
+	return app.Port
+}
+
+// ActiveConnections devuelve cuántas peticiones HTTP y conexiones reenviadas
+// hacia port siguen en curso
+func (p *Proxy) ActiveConnections(port int64) int {
+	p.activeMu.Lock()
+	defer p.activeMu.Unlock()
+	return p.active[port]
+}
+
+// track cuenta una petición o conexión hacia port hasta que se llame a la
+// función devuelta
+func (p *Proxy) track(port int64) func() {
+	p.activeMu.Lock()
+	p.active[port]++
+	p.activeMu.Unlock()
+
+	return func() {
+		p.activeMu.Lock()
+		defer p.activeMu.Unlock()
+		if p.active[port]--; p.active[port] <= 0 {
+			delete(p.active, port)
+		}
+	}
+}
+
+// Routes devuelve las rutas activas ordenadas por nombre
+func (p *Proxy) Routes() []Route {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	routes := make([]Route, 0, len(p.byName))
+	for _, route := range p.byName {
+		routes = append(routes, route.Route)
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].Name < routes[j].Name })
+
+	return routes
+}
+
+// HasHost indica si host corresponde a alguna app publicada
+func (p *Proxy) HasHost(host string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	_, ok := p.byHost[strings.ToLower(host)]
+	return ok
+}
+
+// AppURL devuelve la URL pública de una app, o "" si no tiene ruta
+func (p *Proxy) AppURL(appID string) string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if route, ok := p.byAppID[appID]; ok {
+		return route.URL
+	}
+	return ""
+}
+
+// Handler envuelve next: las peticiones a <app>.<dominio> o /apps/<app>/ se
+// envían al contenedor de la app y el resto sigue hacia next
+func (p *Proxy) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if route := p.matchHost(r.Host); route != nil {
+			p.serve(route, w, r, "")
+			return
+		}
+
+		if route, prefix := p.matchPath(r.URL.Path); route != nil {
+			if r.URL.Path == strings.TrimSuffix(prefix, "/") {
+				http.Redirect(w, r, prefix, http.StatusMovedPermanently)
+				return
+			}
+			p.serve(route, w, r, prefix)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (p *Proxy) matchHost(hostport string) *appRoute {
+	host := strings.ToLower(hostport)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.byHost[host]
+}
+
+func (p *Proxy) matchPath(path string) (*appRoute, string) {
+	if !strings.HasPrefix(path, PathPrefix) {
+		return nil, ""
+	}
+
+	name := strings.TrimPrefix(path, PathPrefix)
+	if i := strings.Index(name, "/"); i >= 0 {
+		name = name[:i]
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if route, ok := p.byName[name]; ok {
+		return route, route.PathPrefix
+	}
+	return nil, ""
+}
+
+// serve reenvía la petición a la app. Con prefix != "" se elimina el prefijo
+// de la ruta y se informa en X-Forwarded-Prefix.
+func (p *Proxy) serve(route *appRoute, w http.ResponseWriter, r *http.Request, prefix string) {
+	// WebSockets y SSE mantienen la conexión abierta más allá del WriteTimeout del servidor
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		logrus.Debugf("Proxy: no se pudo quitar el write deadline: %v", err)
+	}
+	if isUpgrade(r) {
+		if err := rc.SetReadDeadline(time.Time{}); err != nil {
+			logrus.Debugf("Proxy: no se pudo quitar el read deadline: %v", err)
+		}
+	}
+
+	r = r.Clone(r.Context())
+	r.Header.Del("X-Forwarded-Prefix")
+	if prefix != "" {
+		r.URL.Path = "/" + strings.TrimPrefix(r.URL.Path, prefix)
+		if r.URL.RawPath != "" {
+			r.URL.RawPath = "/" + strings.TrimPrefix(r.URL.RawPath, prefix)
+		}
+		r.Header.Set("X-Forwarded-Prefix", strings.TrimSuffix(prefix, "/"))
+	}
+
+	defer p.track(route.port)()
+	route.handler.ServeHTTP(w, r)
+}
+
+func (p *Proxy) hostURL(host string) string {
+	scheme, defaultPort := "http", 80
+	if p.config.TLS {
+		scheme, defaultPort = "https", 443
+	}
+
+	if p.config.PublicPort == 0 || p.config.PublicPort == defaultPort {
+		return fmt.Sprintf("%s://%s", scheme, host)
+	}
+	return fmt.Sprintf("%s://%s:%d", scheme, host, p.config.PublicPort)
+}
+
+// newReverseProxy crea el proxy hacia el contenedor. FlushInterval -1 envía
+// cada escritura de inmediato (SSE); las conexiones Upgrade (WebSocket) las
+// gestiona httputil.ReverseProxy de forma nativa.
+func newReverseProxy(target string) (*httputil.ReverseProxy, error) {
+	targetURL, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(targetURL)
+			pr.SetXForwarded()
+			pr.Out.Host = pr.In.Host
+		},
+		FlushInterval: -1,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			logrus.Warnf("Proxy: error conectando con %s para %s: %v", target, r.Host, err)
+			http.Error(w, "Aplicación no disponible", http.StatusBadGateway)
+		},
+	}, nil
+}
+
+func isUpgrade(r *http.Request) bool {
+	return strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade")
+}
+
+var invalidLabelChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// routeName convierte el nombre de la app en una etiqueta DNS válida; si queda
+// vacío se usa el ID de la app
+func routeName(name, appID string) string {
+	label := invalidLabelChars.ReplaceAllString(strings.ToLower(name), "-")
+	label = strings.Trim(label, "-")
+	if len(label) > 63 {
+		label = strings.Trim(label[:63], "-")
+	}
+	if label == "" && appID != "" {
+		return routeName(strings.ReplaceAll(appID, "_", "-"), "")
+	}
+	return label
+}
+
+// shortID devuelve el sufijo final del ID de la app (app_<ts>_<n> → <n>)
+func shortID(appID string) string {
+	if i := strings.LastIndex(appID, "_"); i >= 0 && i < len(appID)-1 {
+		return appID[i+1:]
+	}
+	return appID
+}
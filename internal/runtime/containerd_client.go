@@ -2,11 +2,14 @@ package runtime
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -124,11 +127,16 @@ func (c *ContainerdClient) CreateContainer(req *CreateContainerRequest) (*Contai
 		// Continuar sin hacer pull, puede que la imagen ya exista
 	}
 
-	// Crear el contenedor
-	createCmd := exec.Command("ctr", "-n", c.namespace, "run", "-d",
-		"--net-host", // Usar red del host por simplicidad
-		baseImage, containerID)
+	// Crear el contenedor con la configuración solicitada
+	args, envFile, err := c.buildRunArgs(req, baseImage, containerID)
+	if err != nil {
+		return nil, err
+	}
+	if envFile != "" {
+		defer os.Remove(envFile)
+	}
 
+	createCmd := exec.Command("ctr", args...)
 	output, err := createCmd.CombinedOutput()
 	if err != nil {
 		errorMsg := fmt.Sprintf("Error creando container: %v, output: %s", err, string(output))
@@ -177,6 +185,81 @@ func (c *ContainerdClient) CreateContainer(req *CreateContainerRequest) (*Contai
 	return container, nil
 }
 
+// buildRunArgs construye los argumentos de "ctr run" a partir del request. Las
+// variables de entorno se pasan por un archivo temporal (0600) para no exponer
+// secretos en la línea de comandos; el llamador debe eliminarlo.
+func (c *ContainerdClient) buildRunArgs(req *CreateContainerRequest, image, containerID string) ([]string, string, error) {
+	args := []string{"-n", c.namespace, "run", "-d",
+		"--net-host", // Usar red del host por simplicidad
+	}
+
+	if req.WorkingDir != "" {
+		args = append(args, "--cwd", req.WorkingDir)
+	}
+
+	labelKeys := make([]string, 0, len(req.Labels))
+	for key := range req.Labels {
+		labelKeys = append(labelKeys, key)
+	}
+	sort.Strings(labelKeys)
+	for _, key := range labelKeys {
+		args = append(args, "--label", fmt.Sprintf("%s=%s", key, req.Labels[key]))
+	}
+
+	if req.Resources != nil {
+		if req.Resources.Memory > 0 {
+			args = append(args, "--memory-limit", strconv.FormatInt(req.Resources.Memory, 10))
+		}
+		if req.Resources.CPUShares > 0 {
+			args = append(args, "--cpu-shares", strconv.FormatInt(req.Resources.CPUShares, 10))
+		}
+	}
+
+	envFile := ""
+	if len(req.Environment) > 0 {
+		file, err := os.CreateTemp("", "diplo-env-*")
+		if err != nil {
+			return nil, "", fmt.Errorf("error creando archivo de entorno: %w", err)
+		}
+		envFile = file.Name()
+
+		envKeys := make([]string, 0, len(req.Environment))
+		for key := range req.Environment {
+			envKeys = append(envKeys, key)
+		}
+		sort.Strings(envKeys)
+		for _, key := range envKeys {
+			if _, err := fmt.Fprintf(file, "%s=%s\n", key, req.Environment[key]); err != nil {
+				file.Close()
+				os.Remove(envFile)
+				return nil, "", fmt.Errorf("error escribiendo archivo de entorno: %w", err)
+			}
+		}
+		if err := file.Close(); err != nil {
+			os.Remove(envFile)
+			return nil, "", fmt.Errorf("error cerrando archivo de entorno: %w", err)
+		}
+		args = append(args, "--env-file", envFile)
+	}
+
+	args = append(args, image, containerID)
+	args = append(args, req.Command...)
+
+	return args, envFile, nil
+}
+
+// isTaskRunning indica si la salida de "ctr tasks list" contiene la task del
+// contenedor en estado RUNNING (una task detenida sigue apareciendo en la lista)
+func isTaskRunning(tasksOutput, containerID string) bool {
+	for _, line := range strings.Split(tasksOutput, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && fields[0] == containerID {
+			return strings.EqualFold(fields[2], "RUNNING")
+		}
+	}
+	return false
+}
+
 // GetRunningContainers returns a list of all running containers
 func (c *ContainerdClient) GetRunningContainers() ([]*Container, error) {
 	c.mu.RLock()
@@ -210,8 +293,7 @@ func (c *ContainerdClient) GetContainerStatus(containerID string) (string, error
 	}
 
 	// Verificar si el contenedor está corriendo
-	isRunning := strings.Contains(string(output), containerID)
-	if isRunning {
+	if isTaskRunning(string(output), containerID) {
 		return "running", nil
 	}
 
@@ -245,13 +327,19 @@ func (c *ContainerdClient) StartContainer(ctx context.Context, containerID strin
 	}
 
 	// Si el contenedor ya está corriendo, no hacer nada
-	if strings.Contains(string(output), containerID) {
+	if isTaskRunning(string(output), containerID) {
 		logrus.Infof("Container %s ya está corriendo", containerID)
 		return nil
 	}
 
-	// Iniciar el contenedor
-	startCmd := exec.Command("ctr", "-n", c.namespace, "tasks", "start", containerID)
+	// Eliminar una task detenida previa (ej. tras un reinicio del proceso), si existe
+	deleteTaskCmd := exec.Command("ctr", "-n", c.namespace, "tasks", "delete", containerID)
+	if err := deleteTaskCmd.Run(); err != nil {
+		logrus.Debugf("No había task previa para %s: %v", containerID, err)
+	}
+
+	// Iniciar el contenedor en background
+	startCmd := exec.Command("ctr", "-n", c.namespace, "tasks", "start", "-d", containerID)
 	if err := startCmd.Run(); err != nil {
 		errorMsg := fmt.Sprintf("Error iniciando container: %v", err)
 		logrus.Error(errorMsg)
@@ -483,9 +571,8 @@ func (c *ContainerdClient) GetContainer(containerID string) (*Container, error)
 	}
 
 	// Verificar si el contenedor está corriendo
-	isRunning := strings.Contains(string(output), containerID)
 	containerStatus := ContainerStatusStopped
-	if isRunning {
+	if isTaskRunning(string(output), containerID) {
 		containerStatus = ContainerStatusRunning
 	}
 
@@ -650,7 +737,7 @@ func (c *ContainerdClient) waitForContainerReady(containerID string, timeout tim
 			continue
 		}
 
-		if strings.Contains(string(output), containerID) {
+		if isTaskRunning(string(output), containerID) {
 			logrus.Infof("Contenedor %s está corriendo después de %d intentos", containerID, attempts)
 			return nil
 		}
@@ -686,10 +773,9 @@ func (c *ContainerdClient) refreshContainers() error {
 		// Verificar si está corriendo
 		taskCmd := exec.Command("ctr", "-n", c.namespace, "tasks", "list")
 		taskOutput, _ := taskCmd.Output()
-		isRunning := strings.Contains(string(taskOutput), name)
 
 		status := ContainerStatusStopped
-		if isRunning {
+		if isTaskRunning(string(taskOutput), name) {
 			status = ContainerStatusRunning
 		}
 
@@ -703,7 +789,7 @@ func (c *ContainerdClient) refreshContainers() error {
 				Image:     image,
 				Status:    status,
 				Runtime:   RuntimeTypeContainerd,
-				CreatedAt: time.Now(),
+				CreatedAt: c.containerCreatedAt(name),
 				Config: &ContainerConfig{
 					Environment: make(map[string]string),
 					Labels:      make(map[string]string),
@@ -724,6 +810,23 @@ func (c *ContainerdClient) refreshContainers() error {
 	return nil
 }
 
+// containerCreatedAt lee la fecha de creación que registra containerd para el
+// contenedor. Devuelve el tiempo cero si no se puede determinar
+func (c *ContainerdClient) containerCreatedAt(name string) time.Time {
+	output, err := exec.Command("ctr", "-n", c.namespace, "containers", "info", name).Output()
+	if err != nil {
+		return time.Time{}
+	}
+
+	var info struct {
+		CreatedAt time.Time `json:"CreatedAt"`
+	}
+	if err := json.Unmarshal(output, &info); err != nil {
+		return time.Time{}
+	}
+	return info.CreatedAt
+}
+
 func (c *ContainerdClient) sendEvent(eventType, message, containerID string, metadata map[string]interface{}) {
 	if c.eventCallback != nil {
 		event := Event{
@@ -1,20 +1,29 @@
 package runtime
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 	texttemplate "text/template"
 )
 
+// ErrNoTemplate indica que no hay template para el lenguaje pedido
+var ErrNoTemplate = errors.New("no hay plantilla de Dockerfile para el lenguaje")
+
 // DockerTemplate representa un template de Docker para un lenguaje específico
 type DockerTemplate struct {
-	Language    string
-	BaseImage   string
-	BuildSteps  []string
-	RunCommand  string
-	ExposedPort int
-	WorkDir     string
-	Template    string
+	Language  string
+	BaseImage string
+	// DefaultVersion es la versión del toolchain si diplo.yaml no define otra
+	DefaultVersion string
+	BuildSteps     []string
+	RunCommand     string
+	ExposedPort    int
+	WorkDir        string
+	Template       string
 }
 
 // DockerTemplateManager maneja los templates de Docker
@@ -35,14 +44,15 @@ func NewDockerTemplateManager() *DockerTemplateManager {
 func (tm *DockerTemplateManager) initializeTemplates() {
 	// Template para Go
 	tm.templates["go"] = &DockerTemplate{
-		Language:    "go",
-		BaseImage:   "golang:1.24-alpine",
-		BuildSteps:  []string{"go mod download", "go build -o app ."},
-		RunCommand:  "./app",
-		ExposedPort: 8080,
-		WorkDir:     "/app",
+		Language:       "go",
+		BaseImage:      "golang:1.24-alpine",
+		DefaultVersion: "1.24",
+		BuildSteps:     []string{"go mod download", "go build -o app ."},
+		RunCommand:     "./app",
+		ExposedPort:    8080,
+		WorkDir:        "/app",
 		Template: `# Multi-stage build para Go
-FROM golang:1.24-alpine AS builder
+FROM golang:{{.Version}}-alpine AS builder
 
 # Instalar dependencias del sistema
 RUN apk add --no-cache git ca-certificates
@@ -50,14 +60,19 @@ RUN apk add --no-cache git ca-certificates
 # Configurar directorio de trabajo
 WORKDIR /app
 
-# Clonar repositorio
-RUN git clone {{.RepoURL}} .
+# Copiar las fuentes del contexto de build
+COPY . .
 
 # Descargar dependencias
 RUN go mod download
 
 # Compilar aplicación
-RUN CGO_ENABLED=0 GOOS=linux go build -a -installsuffix cgo -o app .
+RUN CGO_ENABLED=0 GOOS=linux go build -a -installsuffix cgo{{if .GoTags}} -tags {{.GoTags}}{{end}}{{if .GoLDFlags}} -ldflags {{quote .GoLDFlags}}{{end}} -o app {{or .GoPackage "."}}
+{{- if .BuildCommand}}
+
+# Comando de build de diplo.yaml
+RUN {{.BuildCommand}}
+{{- end}}
 
 # Imagen final
 FROM alpine:3.18
@@ -84,20 +99,21 @@ USER appuser
 EXPOSE {{.Port}}
 
 # Comando por defecto
-CMD ["./app"]
+CMD {{start "./app"}}
 `,
 	}
 
 	// Template para Node.js
 	tm.templates["javascript"] = &DockerTemplate{
-		Language:    "javascript",
-		BaseImage:   "node:22-alpine",
-		BuildSteps:  []string{"npm ci --only=production"},
-		RunCommand:  "npm start",
-		ExposedPort: 3000,
-		WorkDir:     "/app",
+		Language:       "javascript",
+		BaseImage:      "node:22-alpine",
+		DefaultVersion: "22",
+		BuildSteps:     []string{"npm ci --only=production"},
+		RunCommand:     "npm start",
+		ExposedPort:    3000,
+		WorkDir:        "/app",
 		Template: `# Multi-stage build para Node.js
-FROM node:22-alpine AS builder
+FROM node:{{.Version}}-alpine AS builder
 
 # Instalar dependencias del sistema
 RUN apk add --no-cache git
@@ -105,17 +121,17 @@ RUN apk add --no-cache git
 # Configurar directorio de trabajo
 WORKDIR /app
 
-# Clonar repositorio
-RUN git clone {{.RepoURL}} .
+# Copiar las fuentes del contexto de build
+COPY . .
 
 # Instalar dependencias (incluyendo dev)
 RUN npm ci
 
 # Compilar aplicación (si aplica)
-RUN npm run build || true
+RUN {{if .BuildCommand}}{{.BuildCommand}}{{else}}npm run build || true{{end}}
 
 # Imagen final
-FROM node:22-alpine
+FROM node:{{.Version}}-alpine
 
 # Instalar dumb-init para manejo de señales
 RUN apk add --no-cache dumb-init
@@ -143,20 +159,21 @@ EXPOSE {{.Port}}
 
 # Comando por defecto
 ENTRYPOINT ["dumb-init", "--"]
-CMD ["npm", "start"]
+CMD {{start "npm" "start"}}
 `,
 	}
 
 	// Template para Python
 	tm.templates["python"] = &DockerTemplate{
-		Language:    "python",
-		BaseImage:   "python:3.13-alpine",
-		BuildSteps:  []string{"pip install -r requirements.txt"},
-		RunCommand:  "python app.py",
-		ExposedPort: 5000,
-		WorkDir:     "/app",
+		Language:       "python",
+		BaseImage:      "python:3.13-alpine",
+		DefaultVersion: "3.13",
+		BuildSteps:     []string{"pip install -r requirements.txt"},
+		RunCommand:     "python app.py",
+		ExposedPort:    5000,
+		WorkDir:        "/app",
 		Template: `# Multi-stage build para Python
-FROM python:3.13-alpine AS builder
+FROM python:{{.Version}}-alpine AS builder
 
 # Instalar dependencias del sistema para compilación
 RUN apk add --no-cache git gcc musl-dev libffi-dev
@@ -164,14 +181,19 @@ RUN apk add --no-cache git gcc musl-dev libffi-dev
 # Configurar directorio de trabajo
 WORKDIR /app
 
-# Clonar repositorio
-RUN git clone {{.RepoURL}} .
+# Copiar las fuentes del contexto de build
+COPY . .
 
 # Instalar dependencias en directorio local
 RUN pip install --user -r requirements.txt
+{{- if .BuildCommand}}
+
+# Comando de build de diplo.yaml
+RUN {{.BuildCommand}}
+{{- end}}
 
 # Imagen final
-FROM python:3.13-alpine
+FROM python:{{.Version}}-alpine
 
 # Instalar dependencias runtime
 RUN apk add --no-cache libffi
@@ -201,20 +223,21 @@ ENV PATH=/home/appuser/.local/bin:$PATH
 EXPOSE {{.Port}}
 
 # Comando por defecto
-CMD ["python", "app.py"]
+CMD {{start "python" "app.py"}}
 `,
 	}
 
 	// Template para Rust
 	tm.templates["rust"] = &DockerTemplate{
-		Language:    "rust",
-		BaseImage:   "rust:1.83-alpine",
-		BuildSteps:  []string{"cargo build --release"},
-		RunCommand:  "./target/release/app",
-		ExposedPort: 8080,
-		WorkDir:     "/app",
+		Language:       "rust",
+		BaseImage:      "rust:1.83-alpine",
+		DefaultVersion: "1.83",
+		BuildSteps:     []string{"cargo build --release"},
+		RunCommand:     "./target/release/app",
+		ExposedPort:    8080,
+		WorkDir:        "/app",
 		Template: `# Multi-stage build para Rust
-FROM rust:1.83-alpine AS builder
+FROM rust:{{.Version}}-alpine AS builder
 
 # Instalar dependencias del sistema
 RUN apk add --no-cache git musl-dev
@@ -222,11 +245,16 @@ RUN apk add --no-cache git musl-dev
 # Configurar directorio de trabajo
 WORKDIR /app
 
-# Clonar repositorio
-RUN git clone {{.RepoURL}} .
+# Copiar las fuentes del contexto de build
+COPY . .
 
 # Compilar aplicación
 RUN cargo build --release
+{{- if .BuildCommand}}
+
+# Comando de build de diplo.yaml
+RUN {{.BuildCommand}}
+{{- end}}
 
 # Imagen final
 FROM alpine:3.18
@@ -253,37 +281,58 @@ USER appuser
 EXPOSE {{.Port}}
 
 # Comando por defecto
-CMD ["./app"]
+CMD {{start "./app"}}
 `,
 	}
 
-	// Template genérico
-	tm.templates["generic"] = &DockerTemplate{
-		Language:    "generic",
-		BaseImage:   "ubuntu:22.04",
-		BuildSteps:  []string{"echo 'Generic build'"},
-		RunCommand:  "echo 'Generic run'",
-		ExposedPort: 8080,
-		WorkDir:     "/app",
-		Template: `# Imagen genérica para aplicaciones
-FROM ubuntu:22.04
-
-# Instalar dependencias básicas
-RUN apt-get update && apt-get install -y \
-    git \
-    curl \
-    wget \
-    ca-certificates \
-    && rm -rf /var/lib/apt/lists/*
+	// Template para Java (Maven o Gradle con wrapper)
+	tm.templates["java"] = &DockerTemplate{
+		Language:       "java",
+		BaseImage:      "eclipse-temurin:21-jre-alpine",
+		DefaultVersion: "21",
+		BuildSteps:     []string{"mvn -B -DskipTests package", "./gradlew --no-daemon build -x test"},
+		RunCommand:     "java -jar app.jar",
+		ExposedPort:    8080,
+		WorkDir:        "/app",
+		Template: `# Multi-stage build para Java
+FROM maven:3.9-eclipse-temurin-{{.Version}}-alpine AS builder
+
+# Configurar directorio de trabajo
+WORKDIR /app
+
+# Copiar las fuentes del contexto de build
+COPY . .
+
+# Compilar con el wrapper de Gradle o con Maven
+RUN if [ -f gradlew ]; then \
+        chmod +x gradlew && ./gradlew --no-daemon build -x test; \
+    elif [ -f build.gradle ] || [ -f build.gradle.kts ]; then \
+        echo "Los proyectos Gradle necesitan el wrapper (gradlew) en el repositorio" && exit 1; \
+    else \
+        mvn -B -DskipTests package; \
+    fi
+{{- if .BuildCommand}}
+
+# Comando de build de diplo.yaml
+RUN {{.BuildCommand}}
+{{- end}}
+
+# Elegir el jar ejecutable (sin los de fuentes, javadoc o plain)
+RUN jar=$(find target build/libs -maxdepth 1 -name '*.jar' ! -name '*-plain.jar' ! -name '*-sources.jar' ! -name '*-javadoc.jar' ! -name 'original-*.jar' 2>/dev/null | head -n 1) && \
+    if [ -z "$jar" ]; then echo "El build no generó un jar en target/ ni en build/libs/" && exit 1; fi && \
+    cp "$jar" /app/app.jar
+
+# Imagen final
+FROM eclipse-temurin:{{.Version}}-jre-alpine
 
 # Crear usuario no privilegiado
-RUN useradd -m -s /bin/bash appuser
+RUN adduser -D -s /bin/sh appuser
 
 # Configurar directorio de trabajo
 WORKDIR /app
 
-# Clonar repositorio
-RUN git clone {{.RepoURL}} .
+# Copiar jar desde builder
+COPY --from=builder /app/app.jar .
 
 # Cambiar propietario
 RUN chown -R appuser:appuser /app
@@ -295,62 +344,287 @@ USER appuser
 EXPOSE {{.Port}}
 
 # Comando por defecto
-CMD ["echo", "Aplicación Docker genérica iniciada"]
+CMD {{start "java" "-jar" "app.jar"}}
 `,
 	}
-}
 
-// GetTemplate obtiene un template para un lenguaje específico
-func (tm *DockerTemplateManager) GetTemplate(language string) (*DockerTemplate, error) {
-	// Normalizar el nombre del lenguaje
-	language = strings.ToLower(language)
+	// Template para PHP (Composer + Apache con mod_php)
+	tm.templates["php"] = &DockerTemplate{
+		Language:       "php",
+		BaseImage:      "php:8.3-apache",
+		DefaultVersion: "8.3",
+		BuildSteps:     []string{"composer install --no-dev --optimize-autoloader"},
+		RunCommand:     "apache2-foreground",
+		ExposedPort:    8080,
+		WorkDir:        "/var/www/html",
+		Template: `# Multi-stage build para PHP
+FROM composer:2 AS builder
+
+# Configurar directorio de trabajo
+WORKDIR /app
+
+# Copiar las fuentes del contexto de build
+COPY . .
+
+# Instalar dependencias de producción (si hay composer.json)
+RUN if [ -f composer.json ]; then \
+        composer install --no-dev --optimize-autoloader --no-interaction --no-progress --ignore-platform-reqs; \
+    fi
+
+# Imagen final
+FROM php:{{.Version}}-apache
+
+# Apache lee $PORT al arrancar: el puerto puede cambiar entre deploys
+ENV PORT={{.Port}}
+RUN a2enmod rewrite && \
+    sed -i 's/Listen 80$/Listen ${PORT}/' /etc/apache2/ports.conf && \
+    sed -i 's/<VirtualHost \*:80>/<VirtualHost *:${PORT}>/' /etc/apache2/sites-available/000-default.conf && \
+    printf '<Directory /var/www/html>\n    AllowOverride All\n</Directory>\n' > /etc/apache2/conf-available/diplo.conf && \
+    a2enconf diplo
+
+# Configurar directorio de trabajo
+WORKDIR /var/www/html
+
+# Copiar aplicación desde builder
+COPY --from=builder /app .
+
+# Servir public/ si existe (Laravel, Symfony)
+RUN if [ -d public ]; then \
+        sed -i 's|DocumentRoot /var/www/html$|DocumentRoot /var/www/html/public|' /etc/apache2/sites-available/000-default.conf; \
+    fi
+{{- if .BuildCommand}}
+
+# Comando de build de diplo.yaml
+RUN {{.BuildCommand}}
+{{- end}}
+
+# Apache arranca como root y atiende las peticiones como www-data
+RUN chown -R www-data:www-data /var/www/html
+
+# Exponer puerto
+EXPOSE {{.Port}}
+
+# Comando por defecto
+CMD {{start "apache2-foreground"}}
+`,
+	}
+
+	// Template para Ruby (Bundler + Rails o Rack)
+	tm.templates["ruby"] = &DockerTemplate{
+		Language:       "ruby",
+		BaseImage:      "ruby:3.3-alpine",
+		DefaultVersion: "3.3",
+		BuildSteps:     []string{"bundle install"},
+		RunCommand:     "bundle exec rackup",
+		ExposedPort:    9292,
+		WorkDir:        "/app",
+		Template: `# Multi-stage build para Ruby
+FROM ruby:{{.Version}}-alpine AS builder
+
+# Instalar dependencias del sistema para compilar gemas nativas
+RUN apk add --no-cache build-base git tzdata yaml-dev
+
+# Configurar directorio de trabajo
+WORKDIR /app
+
+# Instalar gemas sin los grupos de desarrollo
+ENV BUNDLE_WITHOUT=development:test \
+    RAILS_ENV=production \
+    RACK_ENV=production
+
+# Copiar las fuentes del contexto de build
+COPY . .
+
+# Instalar dependencias
+RUN bundle install --jobs 4
+
+# Precompilar assets de Rails (si aplica)
+RUN if [ -x bin/rails ] && grep -qE '^ +(sprockets|propshaft) ' Gemfile.lock 2>/dev/null; then \
+        SECRET_KEY_BASE_DUMMY=1 bundle exec rails assets:precompile; \
+    fi
+{{- if .BuildCommand}}
+
+# Comando de build de diplo.yaml
+RUN {{.BuildCommand}}
+{{- end}}
+
+# Imagen final
+FROM ruby:{{.Version}}-alpine
+
+# Instalar dependencias runtime
+RUN apk add --no-cache tzdata yaml
+
+# Crear usuario no privilegiado
+RUN adduser -D -s /bin/sh appuser
+
+# Configurar directorio de trabajo
+WORKDIR /app
+
+ENV BUNDLE_WITHOUT=development:test \
+    RAILS_ENV=production \
+    RACK_ENV=production \
+    RAILS_LOG_TO_STDOUT=1 \
+    RAILS_SERVE_STATIC_FILES=1
+
+# Copiar gemas instaladas y código fuente
+COPY --from=builder /usr/local/bundle /usr/local/bundle
+COPY --from=builder /app .
+
+# Cambiar propietario
+RUN chown -R appuser:appuser /app
+
+# Cambiar a usuario no privilegiado
+USER appuser
+
+# Exponer puerto
+EXPOSE {{.Port}}
+
+# Comando por defecto: Rails si trae bin/rails, si no rackup con config.ru
+CMD {{start "sh" "-c" "if [ -x bin/rails ]; then exec bin/rails server -b 0.0.0.0 -p \"$PORT\"; else exec bundle exec rackup -o 0.0.0.0 -p \"$PORT\"; fi"}}
+`,
+	}
+
+	// Template para sitios estáticos servidos con nginx
+	tm.templates["static"] = &DockerTemplate{
+		Language:       "static",
+		BaseImage:      "nginx:1.27-alpine",
+		DefaultVersion: "1.27",
+		RunCommand:     "nginx -g 'daemon off;'",
+		ExposedPort:    8080,
+		WorkDir:        "/usr/share/nginx/html",
+		Template: `# Sitio estático servido con nginx
+FROM nginx:{{.Version}}-alpine
+
+# Configurar directorio de trabajo
+WORKDIR /usr/share/nginx/html
+
+# Copiar las fuentes del contexto de build
+COPY . .
 
-	// Mapear variaciones de nombres
+# Los archivos de Diplo no se publican
+RUN rm -f Dockerfile Procfile diplo.yaml
+{{- if .BuildCommand}}
+
+# Comando de build de diplo.yaml
+RUN {{.BuildCommand}}
+{{- end}}
+
+# La imagen de nginx sustituye $PORT en los templates al arrancar
+ENV PORT={{.Port}}
+RUN mkdir -p /etc/nginx/templates && \
+    printf 'server {\n    listen ${PORT};\n    root /usr/share/nginx/html;\n    index index.html;\n    location / {\n        try_files $uri $uri/ =404;\n    }\n}\n' > /etc/nginx/templates/default.conf.template
+
+# Exponer puerto
+EXPOSE {{.Port}}
+
+# Comando por defecto
+CMD {{start "nginx" "-g" "daemon off;"}}
+`,
+	}
+}
+
+// NormalizeLanguage lleva las variaciones del nombre de un lenguaje al nombre
+// de su template (p. ej. "node" a "javascript")
+func NormalizeLanguage(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
 	switch language {
 	case "node", "nodejs", "js":
-		language = "javascript"
+		return "javascript"
 	case "py":
-		language = "python"
+		return "python"
 	case "rs":
-		language = "rust"
+		return "rust"
 	case "golang":
-		language = "go"
+		return "go"
+	case "rb":
+		return "ruby"
+	case "html", "web":
+		return "static"
 	}
+	return language
+}
 
-	template, exists := tm.templates[language]
+// HasTemplate indica si hay un template específico para el lenguaje
+func (tm *DockerTemplateManager) HasTemplate(language string) bool {
+	_, exists := tm.templates[NormalizeLanguage(language)]
+	return exists
+}
+
+// SupportedLanguages devuelve los lenguajes con template, ordenados
+func (tm *DockerTemplateManager) SupportedLanguages() []string {
+	return slices.Sorted(maps.Keys(tm.templates))
+}
+
+// GetTemplate obtiene un template para un lenguaje específico. Sin template no
+// hay forma genérica de construir la app: el error explica cómo resolverlo.
+func (tm *DockerTemplateManager) GetTemplate(language string) (*DockerTemplate, error) {
+	template, exists := tm.templates[NormalizeLanguage(language)]
 	if !exists {
-		// Devolver template genérico si no existe uno específico
-		return tm.templates["generic"], nil
+		return nil, fmt.Errorf("%w %q (soportados: %s): agrega un Dockerfile al repositorio o define language en diplo.yaml",
+			ErrNoTemplate, language, strings.Join(tm.SupportedLanguages(), ", "))
 	}
 
 	return template, nil
 }
 
-// RenderTemplate renderiza un template con los parámetros dados
-func (tm *DockerTemplateManager) RenderTemplate(language string, port int, repoURL string) (string, error) {
+// TemplateOptions son los parámetros con los que se renderiza un template; los
+// campos vacíos usan los valores por defecto del template
+type TemplateOptions struct {
+	Port int
+	// Version es la versión del toolchain (el tag de la imagen base)
+	Version string
+	// BuildCommand se ejecuta en la etapa de build, tras instalar dependencias
+	BuildCommand string
+	// StartCommand reemplaza el comando de inicio; se ejecuta con sh -c
+	StartCommand string
+	// GoPackage es el paquete main que compila la plantilla de Go, p. ej. "./cmd/api"
+	GoPackage string
+	// GoTags y GoLDFlags se pasan a go build como -tags y -ldflags
+	GoTags    string
+	GoLDFlags string
+}
+
+// RenderTemplate renderiza un template con los parámetros dados; el Dockerfile
+// copia las fuentes del contexto de build
+func (tm *DockerTemplateManager) RenderTemplate(language string, opts TemplateOptions) (string, error) {
 	template, err := tm.GetTemplate(language)
 	if err != nil {
-		return "", fmt.Errorf("error obteniendo template: %w", err)
+		return "", err
+	}
+
+	if opts.Version == "" {
+		opts.Version = template.DefaultVersion
+	}
+
+	// start devuelve el CMD en forma exec: el de diplo.yaml o el del template
+	funcs := texttemplate.FuncMap{
+		"quote": ShellQuote,
+		"start": func(defaults ...string) (string, error) {
+			command := defaults
+			if opts.StartCommand != "" {
+				command = []string{"sh", "-c", "exec " + opts.StartCommand}
+			}
+			args := make([]string, len(command))
+			for i, arg := range command {
+				data, err := json.Marshal(arg)
+				if err != nil {
+					return "", err
+				}
+				args[i] = string(data)
+			}
+			return "[" + strings.Join(args, ", ") + "]", nil
+		},
 	}
 
 	// Crear template de Go
-	tmpl, err := texttemplate.New("dockerfile").Parse(template.Template)
+	tmpl, err := texttemplate.New("dockerfile").Funcs(funcs).Parse(template.Template)
 	if err != nil {
 		return "", fmt.Errorf("error parseando template: %w", err)
 	}
 
-	// Preparar datos para el template
-	data := struct {
-		Port    int
-		RepoURL string
-	}{
-		Port:    port,
-		RepoURL: repoURL,
-	}
-
 	// Renderizar template
 	var rendered strings.Builder
-	if err := tmpl.Execute(&rendered, data); err != nil {
+	if err := tmpl.Execute(&rendered, opts); err != nil {
 		return "", fmt.Errorf("error renderizando template: %w", err)
 	}
 
@@ -0,0 +1,142 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	goModulePath   = regexp.MustCompile(`(?m)^module\s+"?([^\s"]+)"?`)
+	goBuildTags    = regexp.MustCompile(`^[A-Za-z0-9_.]+(,[A-Za-z0-9_.]+)*$`)
+	goMajorVersion = regexp.MustCompile(`^v\d+$`)
+)
+
+// ErrAmbiguousGoTarget indica que el repo tiene varios paquetes main y ninguno configurado
+var ErrAmbiguousGoTarget = errors.New("el repositorio tiene varios paquetes main")
+
+// GoTarget es el paquete main que se compila y por qué se eligió
+type GoTarget struct {
+	// Package es la ruta del paquete relativa a la raíz, p. ej. "./cmd/api"
+	Package string
+	// Reason explica la elección para el log del deploy
+	Reason string
+}
+
+// FindGoMainPackages devuelve los paquetes main de dir: la raíz y los
+// subdirectorios de cmd/, en el formato de go build ("." o "./cmd/<nombre>")
+func FindGoMainPackages(dir string) []string {
+	var packages []string
+	if isGoMainPackage(dir) {
+		packages = append(packages, ".")
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "cmd"))
+	if err != nil {
+		return packages
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && isGoMainPackage(filepath.Join(dir, "cmd", entry.Name())) {
+			packages = append(packages, "./cmd/"+entry.Name())
+		}
+	}
+	return packages
+}
+
+// isGoMainPackage indica si los archivos .go de dir (sin tests) son del paquete main
+func isGoMainPackage(dir string) bool {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return false
+	}
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		parsed, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.PackageClauseOnly)
+		if err == nil && parsed.Name.Name == "main" {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeGoTarget deja un paquete configurado en el formato de go build:
+// "cmd/api" y "./cmd/api/" son "./cmd/api"; vacío o "." es la raíz
+func NormalizeGoTarget(target string) (string, error) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return "", nil
+	}
+	clean := path.Clean(strings.TrimPrefix(filepath.ToSlash(target), "./"))
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") || !shellSafe.MatchString(clean) {
+		return "", fmt.Errorf("go_target inválido: %q (debe ser un paquete del repositorio, p. ej. ./cmd/api)", target)
+	}
+	if clean == "." {
+		return ".", nil
+	}
+	return "./" + clean, nil
+}
+
+// ValidateGoBuildTags comprueba que tags sea una lista de tags separada por comas
+func ValidateGoBuildTags(tags string) error {
+	if tags != "" && !goBuildTags.MatchString(tags) {
+		return fmt.Errorf("go_tags inválido: %q (usa tags separados por comas, p. ej. netgo,sqlite_omit_load_extension)", tags)
+	}
+	return nil
+}
+
+// SelectGoTarget elige el paquete main a compilar: el configurado, el único
+// que hay o, entre varios, el de cmd/ que se llama como el módulo. Sin ninguno
+// se compila la raíz y go build reporta el error.
+func SelectGoTarget(dir, configured string) (GoTarget, error) {
+	packages := FindGoMainPackages(dir)
+	if configured != "" {
+		if !slices.Contains(packages, configured) && !isGoMainPackage(filepath.Join(dir, filepath.FromSlash(configured))) {
+			return GoTarget{}, fmt.Errorf("go_target %s no es un paquete main del repositorio (encontrados: %s)", configured, goPackageList(packages))
+		}
+		return GoTarget{Package: configured, Reason: "go_target de la app"}, nil
+	}
+
+	switch len(packages) {
+	case 0:
+		return GoTarget{Package: ".", Reason: "no se encontró ningún paquete main"}, nil
+	case 1:
+		return GoTarget{Package: packages[0], Reason: "único paquete main"}, nil
+	}
+	if module := goModuleName(dir); module != "" && slices.Contains(packages, "./cmd/"+module) {
+		return GoTarget{Package: "./cmd/" + module, Reason: "se llama como el módulo"}, nil
+	}
+	return GoTarget{}, fmt.Errorf("%w (%s): elige uno con go_target", ErrAmbiguousGoTarget, goPackageList(packages))
+}
+
+// goModuleName devuelve el último elemento del módulo de go.mod
+func goModuleName(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	match := goModulePath.FindSubmatch(data)
+	if match == nil {
+		return ""
+	}
+	// En github.com/org/app/v2 el nombre es app
+	module := string(match[1])
+	if base := path.Base(module); !goMajorVersion.MatchString(base) {
+		return base
+	}
+	return path.Base(path.Dir(module))
+}
+
+func goPackageList(packages []string) string {
+	if len(packages) == 0 {
+		return "ninguno"
+	}
+	return strings.Join(packages, ", ")
+}
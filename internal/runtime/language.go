@@ -0,0 +1,156 @@
+package runtime
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// maxLanguageCandidates es la cantidad de candidatos que se reportan
+const maxLanguageCandidates = 3
+
+// languageIndicator es un archivo que suma Weight puntos a Language si existe.
+// Los patrones con * se buscan en la raíz y en src/.
+type languageIndicator struct {
+	Language string
+	Path     string
+	Weight   int
+}
+
+// languageIndicators pesa los archivos característicos de cada lenguaje: los
+// manifiestos de dependencias valen más que los lockfiles, los entrypoints y
+// las fuentes sueltas. El orden de la lista desempata a igual puntaje.
+var languageIndicators = []languageIndicator{
+	{"go", "go.mod", 10},
+	{"go", "go.sum", 4},
+	{"go", "main.go", 3},
+	{"go", "*.go", 2},
+
+	{"javascript", "package.json", 8},
+	{"javascript", "package-lock.json", 4},
+	{"javascript", "yarn.lock", 4},
+	{"javascript", "pnpm-lock.yaml", 4},
+	{"javascript", "server.js", 2},
+	{"javascript", "app.js", 2},
+	{"javascript", "index.js", 2},
+
+	{"python", "pyproject.toml", 8},
+	{"python", "requirements.txt", 8},
+	{"python", "setup.py", 8},
+	{"python", "Pipfile", 8},
+	{"python", "poetry.lock", 4},
+	{"python", "manage.py", 3},
+	{"python", "main.py", 3},
+	{"python", "app.py", 3},
+	{"python", "*.py", 2},
+
+	{"rust", "Cargo.toml", 10},
+	{"rust", "Cargo.lock", 4},
+	{"rust", "src/main.rs", 3},
+	{"rust", "src/lib.rs", 2},
+
+	{"java", "pom.xml", 10},
+	{"java", "build.gradle", 10},
+	{"java", "build.gradle.kts", 10},
+	{"java", "gradlew", 3},
+	{"java", "src/main/java", 3},
+
+	{"php", "composer.json", 10},
+	{"php", "composer.lock", 4},
+	{"php", "index.php", 3},
+	{"php", "*.php", 2},
+
+	{"ruby", "Gemfile", 10},
+	{"ruby", "Gemfile.lock", 4},
+	{"ruby", "config.ru", 3},
+	{"ruby", "*.rb", 2},
+
+	{"static", "index.html", 1},
+}
+
+// LanguageCandidate es un lenguaje posible para el código y los archivos que lo indican
+type LanguageCandidate struct {
+	Language string `json:"language"`
+	Score    int    `json:"score"`
+	// Confidence es la fracción del puntaje total que se lleva el candidato
+	Confidence float64  `json:"confidence"`
+	Matches    []string `json:"matches"`
+}
+
+// LanguageDetection es el lenguaje elegido para un deploy y por qué
+type LanguageDetection struct {
+	// Language es el lenguaje elegido; vacío si no se reconoce ninguno
+	Language string `json:"language"`
+	// Source indica de dónde sale: "detected", "diplo.yaml" o "app"
+	Source string `json:"source"`
+	// Reason explica la elección para el log del deploy
+	Reason     string              `json:"reason"`
+	Confidence float64             `json:"confidence"`
+	Candidates []LanguageCandidate `json:"candidates,omitempty"`
+}
+
+// Orígenes del lenguaje de un deploy
+const (
+	LanguageSourceDetected = "detected"
+	LanguageSourceManifest = "diplo.yaml"
+	LanguageSourceApp      = "app"
+)
+
+// DetectLanguage puntúa cada lenguaje según los archivos de dir y elige el de
+// mayor puntaje. El resultado es determinista: a igual puntaje gana el que
+// aparece antes en languageIndicators. Sin indicadores Language queda vacío.
+func DetectLanguage(dir string) LanguageDetection {
+	var candidates []LanguageCandidate
+	total := 0
+	for _, indicator := range languageIndicators {
+		if !indicatorPresent(dir, indicator.Path) {
+			continue
+		}
+		total += indicator.Weight
+		i := slices.IndexFunc(candidates, func(c LanguageCandidate) bool { return c.Language == indicator.Language })
+		if i < 0 {
+			candidates = append(candidates, LanguageCandidate{Language: indicator.Language})
+			i = len(candidates) - 1
+		}
+		candidates[i].Score += indicator.Weight
+		candidates[i].Matches = append(candidates[i].Matches, indicator.Path)
+	}
+	if len(candidates) == 0 {
+		return LanguageDetection{Source: LanguageSourceDetected, Reason: "no se encontró ningún archivo característico"}
+	}
+
+	// SortStableFunc mantiene el orden de languageIndicators en los empates
+	slices.SortStableFunc(candidates, func(a, b LanguageCandidate) int { return b.Score - a.Score })
+	for i := range candidates {
+		candidates[i].Confidence = math.Round(float64(candidates[i].Score)/float64(total)*100) / 100
+	}
+	if len(candidates) > maxLanguageCandidates {
+		candidates = candidates[:maxLanguageCandidates]
+	}
+
+	best := candidates[0]
+	return LanguageDetection{
+		Language:   best.Language,
+		Source:     LanguageSourceDetected,
+		Reason:     fmt.Sprintf("%s, confianza %.0f%%", strings.Join(best.Matches, ", "), best.Confidence*100),
+		Confidence: best.Confidence,
+		Candidates: candidates,
+	}
+}
+
+// indicatorPresent indica si el archivo o directorio del indicador existe en dir
+func indicatorPresent(dir, path string) bool {
+	if strings.Contains(path, "*") {
+		for _, base := range []string{dir, filepath.Join(dir, "src")} {
+			if matches, err := filepath.Glob(filepath.Join(base, path)); err == nil && len(matches) > 0 {
+				return true
+			}
+		}
+		return false
+	}
+	_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(path)))
+	return err == nil
+}
@@ -0,0 +1,50 @@
+package runtime
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name           string
+		files          []string
+		want           string
+		wantConfidence float64
+		wantCandidates []string
+	}{
+		{"package.json pesa más que main.go", []string{"package.json", "main.go"}, "javascript", 0.62, []string{"javascript", "go"}},
+		{"empate según el orden de los indicadores", []string{"composer.json", "Gemfile"}, "php", 0.5, []string{"php", "ruby"}},
+		{"empate entre go.mod y Cargo.toml", []string{"Cargo.toml", "go.mod"}, "go", 0.5, []string{"go", "rust"}},
+		{"solo index.html es estático", []string{"index.html"}, "static", 1, []string{"static"}},
+		{"index.html con package.json", []string{"index.html", "package.json"}, "javascript", 0.89, []string{"javascript", "static"}},
+		{"sin indicadores", []string{"README.md"}, "", 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, file := range tt.files {
+				writeFile(t, dir, file, "")
+			}
+
+			got := DetectLanguage(dir)
+			if got.Language != tt.want {
+				t.Errorf("Language = %q, se esperaba %q", got.Language, tt.want)
+			}
+			if got.Confidence != tt.wantConfidence {
+				t.Errorf("Confidence = %v, se esperaba %v", got.Confidence, tt.wantConfidence)
+			}
+			var candidates []string
+			for _, candidate := range got.Candidates {
+				candidates = append(candidates, candidate.Language)
+			}
+			if !slices.Equal(candidates, tt.wantCandidates) {
+				t.Errorf("Candidates = %v, se esperaba %v", candidates, tt.wantCandidates)
+			}
+			if got.Source != LanguageSourceDetected || got.Reason == "" {
+				t.Errorf("Source = %q, Reason = %q: se esperaba el origen detectado y un motivo", got.Source, got.Reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// pythonEntrypoints son los módulos donde se busca la app, en orden
+var pythonEntrypoints = []string{"main.py", "app.py", "server.py", "wsgi.py", "asgi.py", "app/main.py", "src/main.py", "api/main.py"}
+
+// nodeEntrypoints son los scripts que se ejecutan con node si no hay otro comando
+var nodeEntrypoints = []string{"server.js", "app.js", "index.js", "main.js"}
+
+var (
+	djangoSettings = regexp.MustCompile(`DJANGO_SETTINGS_MODULE["']\s*,\s*["']([\w.]+)\.settings["']`)
+	fastAPIApp     = regexp.MustCompile(`(?m)^(\w+)\s*(?::\s*\w+\s*)?=\s*FastAPI\(`)
+	flaskApp       = regexp.MustCompile(`(?m)^(\w+)\s*(?::\s*\w+\s*)?=\s*Flask\(`)
+	pythonPackage  = regexp.MustCompile(`(?mi)^\s*["']?([a-z0-9][a-z0-9._-]*)`)
+	shellSafe      = regexp.MustCompile(`^[\w./-]+$`)
+)
+
+// pythonServers son los paquetes que se buscan en pyproject.toml y Pipfile
+var pythonServers = map[string]*regexp.Regexp{}
+
+func init() {
+	for _, name := range []string{"django", "fastapi", "starlette", "flask", "gunicorn", "uvicorn"} {
+		pythonServers[name] = regexp.MustCompile(`(^|["'\s])` + name + `(\[[^\]]*\])?\s*([<>=~!^;"',]|$)`)
+	}
+}
+
+// StartCommand es el comando de inicio detectado para una app y el motivo
+type StartCommand struct {
+	// Command se ejecuta con sh -c; vacío mantiene el de la plantilla
+	Command string
+	// Build es el build que el framework necesita para arrancar; a diferencia
+	// del de la plantilla, si falla el deploy falla
+	Build string
+	// Reason explica la elección, p. ej. "Django con gunicorn"
+	Reason string
+}
+
+// DetectStartCommand deduce el comando de inicio de las apps Node y Python a
+// partir de sus dependencias y entrypoints. Los comandos escuchan en $PORT.
+func DetectStartCommand(dir, language string) StartCommand {
+	switch NormalizeLanguage(language) {
+	case "javascript":
+		return detectNodeStart(dir)
+	case "python":
+		return detectPythonStart(dir)
+	}
+	return StartCommand{}
+}
+
+func detectNodeStart(dir string) StartCommand {
+	var pkg struct {
+		Main            string            `json:"main"`
+		Scripts         map[string]string `json:"scripts"`
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil || json.Unmarshal(data, &pkg) != nil {
+		return StartCommand{}
+	}
+	has := func(dependency string) bool {
+		_, ok := pkg.Dependencies[dependency]
+		return ok
+	}
+	// Next.js, Nuxt y NestJS no arrancan sin compilar
+	build := ""
+	if pkg.Scripts["build"] != "" {
+		build = "npm run build"
+	}
+
+	switch {
+	case has("next"):
+		return StartCommand{Command: "npx next start -H 0.0.0.0 -p $PORT", Build: build, Reason: "Next.js"}
+	case has("nuxt"):
+		return StartCommand{Command: "HOST=0.0.0.0 node .output/server/index.mjs", Build: build, Reason: "Nuxt"}
+	case has("@nestjs/core") && pkg.Scripts["start:prod"] != "":
+		return StartCommand{Command: "npm run start:prod", Build: build, Reason: "NestJS (scripts.start:prod)"}
+	case has("@nestjs/core"):
+		return StartCommand{Command: "node dist/main", Build: build, Reason: "NestJS"}
+	case pkg.Scripts["start"] != "":
+		return StartCommand{Command: "npm start", Reason: "scripts.start de package.json"}
+	case pkg.Main != "" && fileExists(dir, pkg.Main):
+		return StartCommand{Command: "node " + ShellQuote(pkg.Main), Reason: "main de package.json"}
+	}
+	for _, entrypoint := range nodeEntrypoints {
+		if fileExists(dir, entrypoint) {
+			return StartCommand{Command: "node " + entrypoint, Reason: entrypoint}
+		}
+	}
+	return StartCommand{}
+}
+
+func detectPythonStart(dir string) StartCommand {
+	deps := pythonDependencies(dir)
+
+	// Django: el módulo del proyecto sale de DJANGO_SETTINGS_MODULE en manage.py
+	if deps["django"] && fileExists(dir, "manage.py") {
+		project := ""
+		if data, err := os.ReadFile(filepath.Join(dir, "manage.py")); err == nil {
+			if match := djangoSettings.FindSubmatch(data); match != nil {
+				project = string(match[1])
+			}
+		}
+		switch {
+		case project != "" && deps["gunicorn"]:
+			return StartCommand{Command: fmt.Sprintf("gunicorn %s.wsgi:application --bind 0.0.0.0:$PORT", project), Reason: "Django con gunicorn"}
+		case project != "" && deps["uvicorn"]:
+			return StartCommand{Command: fmt.Sprintf("uvicorn %s.asgi:application --host 0.0.0.0 --port $PORT", project), Reason: "Django con uvicorn"}
+		default:
+			return StartCommand{Command: "python manage.py runserver 0.0.0.0:$PORT --noreload", Reason: "Django sin servidor de producción (agrega gunicorn)"}
+		}
+	}
+
+	if deps["fastapi"] || deps["starlette"] {
+		if module, variable := findPythonApp(dir, fastAPIApp); module != "" {
+			app := module + ":" + variable
+			if deps["uvicorn"] {
+				return StartCommand{Command: fmt.Sprintf("uvicorn %s --host 0.0.0.0 --port $PORT", app), Reason: "FastAPI con uvicorn"}
+			}
+			if deps["gunicorn"] {
+				return StartCommand{Command: fmt.Sprintf("gunicorn %s -k uvicorn.workers.UvicornWorker --bind 0.0.0.0:$PORT", app), Reason: "FastAPI con gunicorn"}
+			}
+		}
+	}
+
+	if deps["flask"] {
+		if module, variable := findPythonApp(dir, flaskApp); module != "" {
+			app := module + ":" + variable
+			if deps["gunicorn"] {
+				return StartCommand{Command: fmt.Sprintf("gunicorn %s --bind 0.0.0.0:$PORT", app), Reason: "Flask con gunicorn"}
+			}
+			return StartCommand{Command: fmt.Sprintf("flask --app %s run --host 0.0.0.0 --port $PORT", app), Reason: "Flask sin servidor de producción (agrega gunicorn)"}
+		}
+	}
+
+	// Sin framework reconocido: el primer entrypoint si no es el app.py de la plantilla
+	for _, entrypoint := range pythonEntrypoints {
+		if fileExists(dir, entrypoint) {
+			if entrypoint == "app.py" {
+				return StartCommand{}
+			}
+			return StartCommand{Command: "python " + entrypoint, Reason: entrypoint}
+		}
+	}
+	return StartCommand{}
+}
+
+// pythonDependencies devuelve los paquetes declarados en requirements.txt,
+// pyproject.toml o Pipfile, en minúsculas
+func pythonDependencies(dir string) map[string]bool {
+	deps := make(map[string]bool)
+	if data, err := os.ReadFile(filepath.Join(dir, "requirements.txt")); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+				continue
+			}
+			if match := pythonPackage.FindStringSubmatch(line); match != nil {
+				deps[normalizePythonPackage(match[1])] = true
+			}
+		}
+	}
+	// En pyproject.toml y Pipfile basta con buscar los paquetes que importan
+	for _, file := range []string{"pyproject.toml", "Pipfile"} {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(strings.ToLower(string(data)), "\n") {
+			for name, pattern := range pythonServers {
+				if pattern.MatchString(strings.TrimSpace(line)) {
+					deps[name] = true
+				}
+			}
+		}
+	}
+	return deps
+}
+
+// normalizePythonPackage quita extras y versiones: "uvicorn[standard]" es uvicorn
+func normalizePythonPackage(name string) string {
+	name, _, _ = strings.Cut(strings.ToLower(name), "[")
+	return strings.ReplaceAll(name, "_", "-")
+}
+
+// findPythonApp busca el módulo y la variable de la app en los entrypoints comunes
+func findPythonApp(dir string, pattern *regexp.Regexp) (module, variable string) {
+	for _, entrypoint := range pythonEntrypoints {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(entrypoint)))
+		if err != nil {
+			continue
+		}
+		if match := pattern.FindSubmatch(data); match != nil {
+			module = strings.ReplaceAll(strings.TrimSuffix(entrypoint, ".py"), "/", ".")
+			return module, string(match[1])
+		}
+	}
+	return "", ""
+}
+
+func fileExists(dir, name string) bool {
+	info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name)))
+	return err == nil && info.Mode().IsRegular()
+}
+
+// ShellQuote protege un argumento para sh -c
+func ShellQuote(value string) string {
+	if shellSafe.MatchString(value) {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
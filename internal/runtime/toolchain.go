@@ -0,0 +1,362 @@
+package runtime
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Versiones que se consideran al resolver un rango (engines.node,
+// requires-python); se elige la más alta que lo cumpla
+var (
+	nodeVersions   = []string{"18", "20", "22"}
+	pythonVersions = []string{"3.9", "3.10", "3.11", "3.12", "3.13"}
+)
+
+var (
+	numericVersion   = regexp.MustCompile(`^\d+(\.\d+)*$`)
+	goModDirective   = regexp.MustCompile(`^(go|toolchain)\s+(?:go)?(\d+(?:\.\d+)*)`)
+	requiresPython   = regexp.MustCompile(`^requires-python\s*=\s*["']([^"']+)["']`)
+	poetryPython     = regexp.MustCompile(`^python\s*=\s*["']([^"']+)["']`)
+	rustChannel      = regexp.MustCompile(`^channel\s*=\s*["']([^"']+)["']`)
+	gemfileRuby      = regexp.MustCompile(`^ruby\s+["']([^"']+)["']`)
+	constraintClause = regexp.MustCompile(`^(>=|<=|!=|==|~=|>|<|=|\^|~)?\s*v?([\d.xX*]+)$`)
+)
+
+// ToolchainVersion es la versión del toolchain que pide el proyecto y de dónde sale
+type ToolchainVersion struct {
+	// Version es el tag de la imagen base; vacío si el proyecto no pide una
+	Version string
+	// Source es el archivo del que se tomó, p. ej. ".nvmrc"
+	Source string
+	// Ignored explica un valor encontrado que no se pudo usar
+	Ignored string
+}
+
+// DetectToolchainVersion busca en dir la versión del toolchain que declara el
+// proyecto: la directiva go de go.mod, .nvmrc o engines.node, .python-version o
+// requires-python, rust-toolchain.toml o .ruby-version. Los archivos se revisan
+// en ese orden de prioridad y gana el primero con una versión utilizable.
+func DetectToolchainVersion(dir, language string) ToolchainVersion {
+	var result ToolchainVersion
+	try := func(file string, parse func(string) (string, error)) bool {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return false
+		}
+		version, err := parse(string(data))
+		if err != nil {
+			if result.Ignored == "" {
+				result.Ignored = fmt.Sprintf("%s: %v", file, err)
+			}
+			return false
+		}
+		if version == "" {
+			return false
+		}
+		result.Version, result.Source = version, file
+		return true
+	}
+
+	switch NormalizeLanguage(language) {
+	case "go":
+		try("go.mod", parseGoMod)
+	case "javascript":
+		_ = try(".nvmrc", parseNodeVersionFile) || try(".node-version", parseNodeVersionFile) || try("package.json", parseEnginesNode)
+	case "python":
+		_ = try(".python-version", parsePythonVersionFile) || try("runtime.txt", parseRuntimeTxt) || try("pyproject.toml", parsePyproject)
+	case "rust":
+		_ = try("rust-toolchain.toml", parseRustToolchain) || try("rust-toolchain", parseRustToolchain)
+	case "ruby":
+		_ = try(".ruby-version", parseRubyVersion) || try("Gemfile", parseGemfile)
+	}
+	return result
+}
+
+// parseGoMod usa la directiva toolchain o, si no hay, la directiva go
+func parseGoMod(content string) (string, error) {
+	var goVersion, toolchain string
+	scanLines(content, func(line string) {
+		if match := goModDirective.FindStringSubmatch(line); match != nil {
+			if match[1] == "toolchain" {
+				toolchain = match[2]
+			} else {
+				goVersion = match[2]
+			}
+		}
+	})
+	if toolchain != "" {
+		return majorMinor(toolchain), nil
+	}
+	return majorMinor(goVersion), nil
+}
+
+// parseNodeVersionFile lee .nvmrc o .node-version: "20", "v20.11.0" o "20.x"
+func parseNodeVersionFile(content string) (string, error) {
+	value := firstLine(content)
+	if value == "" {
+		return "", nil
+	}
+	version := strings.TrimSuffix(strings.TrimPrefix(strings.ToLower(value), "v"), ".x")
+	if !numericVersion.MatchString(version) {
+		return "", fmt.Errorf("%q no es una versión numérica", value)
+	}
+	return strings.SplitN(version, ".", 2)[0], nil
+}
+
+// parseEnginesNode resuelve el rango de engines.node de package.json
+func parseEnginesNode(content string) (string, error) {
+	var pkg struct {
+		Engines struct {
+			Node string `json:"node"`
+		} `json:"engines"`
+	}
+	if err := json.Unmarshal([]byte(content), &pkg); err != nil || pkg.Engines.Node == "" {
+		return "", nil
+	}
+	return resolveConstraint("engines.node", pkg.Engines.Node, nodeVersions)
+}
+
+// parsePythonVersionFile lee .python-version: "3.12" o "3.12.1"
+func parsePythonVersionFile(content string) (string, error) {
+	value := firstLine(content)
+	if value == "" {
+		return "", nil
+	}
+	if !numericVersion.MatchString(value) {
+		return "", fmt.Errorf("%q no es una versión de CPython", value)
+	}
+	return majorMinor(value), nil
+}
+
+// parseRuntimeTxt lee el runtime.txt de Heroku: "python-3.11.4"
+func parseRuntimeTxt(content string) (string, error) {
+	value, ok := strings.CutPrefix(firstLine(content), "python-")
+	if !ok {
+		return "", nil
+	}
+	return parsePythonVersionFile(value)
+}
+
+// parsePyproject resuelve requires-python de [project] o python de [tool.poetry.dependencies]
+func parsePyproject(content string) (string, error) {
+	var section, constraint string
+	scanLines(content, func(line string) {
+		if strings.HasPrefix(line, "[") {
+			section = line
+			return
+		}
+		if section == "[project]" {
+			if match := requiresPython.FindStringSubmatch(line); match != nil {
+				constraint = match[1]
+			}
+		}
+		if section == "[tool.poetry.dependencies]" && constraint == "" {
+			if match := poetryPython.FindStringSubmatch(line); match != nil {
+				constraint = match[1]
+			}
+		}
+	})
+	if constraint == "" {
+		return "", nil
+	}
+	return resolveConstraint("requires-python", constraint, pythonVersions)
+}
+
+// parseRustToolchain lee rust-toolchain.toml (channel = "1.80.0") o el formato
+// antiguo de una línea. "stable" usa la versión por defecto.
+func parseRustToolchain(content string) (string, error) {
+	channel := firstLine(content)
+	scanLines(content, func(line string) {
+		if match := rustChannel.FindStringSubmatch(line); match != nil {
+			channel = match[1]
+		}
+	})
+	switch {
+	case channel == "" || channel == "stable" || strings.HasPrefix(channel, "["):
+		return "", nil
+	case numericVersion.MatchString(channel):
+		return majorMinor(channel), nil
+	}
+	return "", fmt.Errorf("el canal %q no está soportado: las imágenes de Rust son estables", channel)
+}
+
+// parseRubyVersion lee .ruby-version: "3.2.2" o "ruby-3.2.2"
+func parseRubyVersion(content string) (string, error) {
+	value := strings.TrimPrefix(firstLine(content), "ruby-")
+	if value == "" {
+		return "", nil
+	}
+	if !numericVersion.MatchString(value) {
+		return "", fmt.Errorf("%q no es una versión de Ruby", value)
+	}
+	return majorMinor(value), nil
+}
+
+// parseGemfile usa la directiva ruby "3.2.2" del Gemfile
+func parseGemfile(content string) (string, error) {
+	var version string
+	scanLines(content, func(line string) {
+		if match := gemfileRuby.FindStringSubmatch(line); match != nil && version == "" {
+			version = match[1]
+		}
+	})
+	if version == "" {
+		return "", nil
+	}
+	return parseRubyVersion(version)
+}
+
+// resolveConstraint elige la versión más alta de candidates que cumple el
+// rango. Admite la sintaxis de npm (^20, ~20.1, 18.x, >=18 <21, a || b) y de
+// PEP 440 (>=3.10,<3.13, ~=3.11, ==3.12.*).
+func resolveConstraint(field, constraint string, candidates []string) (string, error) {
+	for i := len(candidates) - 1; i >= 0; i-- {
+		ok, err := satisfies(candidates[i], constraint)
+		if err != nil {
+			return "", fmt.Errorf("%s %q: %v", field, constraint, err)
+		}
+		if ok {
+			return candidates[i], nil
+		}
+	}
+	return "", fmt.Errorf("%s %q no admite ninguna de las versiones %s", field, constraint, strings.Join(candidates, ", "))
+}
+
+// satisfies indica si la línea de versiones candidate (p. ej. "3.12", cuya
+// imagen trae el último parche) cumple alguna de las alternativas de constraint
+func satisfies(candidate, constraint string) (bool, error) {
+	version := parseVersionParts(candidate, math.MaxInt)
+	for _, alternative := range strings.Split(constraint, "||") {
+		clauses := strings.FieldsFunc(alternative, func(r rune) bool { return r == ',' || r == ' ' })
+		if len(clauses) == 0 {
+			continue
+		}
+		matched := true
+		for i := 0; i < len(clauses); i++ {
+			clause := clauses[i]
+			// ">= 18" separado por espacio
+			if strings.Trim(clause, "<>=!~^") == "" && i+1 < len(clauses) {
+				i++
+				clause += clauses[i]
+			}
+			ok, err := matchClause(version, clause)
+			if err != nil {
+				return false, err
+			}
+			matched = matched && ok
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func matchClause(version []int, clause string) (bool, error) {
+	match := constraintClause.FindStringSubmatch(clause)
+	if match == nil {
+		return false, fmt.Errorf("no se entiende %q", clause)
+	}
+	op, raw := match[1], strings.TrimRight(match[2], ".")
+
+	// Los comodines (3.12.*, 20.x) y las versiones sin operador fijan un prefijo
+	wildcard := strings.ContainsAny(raw, "xX*")
+	raw = strings.TrimRight(strings.Split(strings.NewReplacer("X", "x", "*", "x").Replace(raw), "x")[0], ".")
+	if raw == "" {
+		return true, nil
+	}
+	bound := parseVersionParts(raw, 0)
+	prefix := len(strings.Split(raw, "."))
+
+	switch op {
+	case "", "=", "==":
+		return hasPrefix(version, bound, prefix), nil
+	case "!=":
+		return !hasPrefix(version, bound, prefix), nil
+	case ">=":
+		return wildcard || compareVersions(version, bound) >= 0, nil
+	case ">":
+		return compareVersions(version, bound) > 0, nil
+	case "<=":
+		return compareVersions(version, bound) <= 0 || hasPrefix(version, bound, prefix), nil
+	case "<":
+		return compareVersions(version, bound) < 0, nil
+	case "^":
+		// Compatible con la mayor (o la menor si la mayor es 0)
+		fixed := 1
+		if bound[0] == 0 {
+			fixed = 2
+		}
+		return compareVersions(version, bound) >= 0 && hasPrefix(version, bound, fixed), nil
+	case "~":
+		fixed := min(prefix, 2)
+		return compareVersions(version, bound) >= 0 && hasPrefix(version, bound, fixed), nil
+	case "~=":
+		// ~=3.11 es >=3.11 y ==3.*; ~=3.11.2 es >=3.11.2 y ==3.11.*
+		return compareVersions(version, bound) >= 0 && hasPrefix(version, bound, max(prefix-1, 1)), nil
+	}
+	return false, fmt.Errorf("operador %q no soportado", op)
+}
+
+// parseVersionParts convierte "3.12" en [3, 12, pad]: los componentes que
+// faltan valen pad
+func parseVersionParts(version string, pad int) []int {
+	parts := []int{pad, pad, pad}
+	for i, field := range strings.SplitN(version, ".", 3) {
+		if n, err := strconv.Atoi(field); err == nil {
+			parts[i] = n
+		}
+	}
+	return parts
+}
+
+func compareVersions(a, b []int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// hasPrefix indica si los primeros n componentes de version coinciden con los de
+// bound; un componente sin fijar en version (la línea completa) coincide
+func hasPrefix(version, bound []int, n int) bool {
+	for i := 0; i < n && i < len(version); i++ {
+		if version[i] != math.MaxInt && version[i] != bound[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// majorMinor recorta "1.22.3" a "1.22"
+func majorMinor(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) > 2 {
+		parts = parts[:2]
+	}
+	return strings.Join(parts, ".")
+}
+
+func firstLine(content string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(content), "\n")
+	return strings.TrimSpace(line)
+}
+
+func scanLines(content string, fn func(line string)) {
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		fn(strings.TrimSpace(scanner.Text()))
+	}
+}
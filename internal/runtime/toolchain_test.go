@@ -0,0 +1,105 @@
+package runtime
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveConstraint(t *testing.T) {
+	tests := []struct {
+		name       string
+		constraint string
+		candidates []string
+		want       string
+		wantErr    bool
+	}{
+		{"caret de npm", "^20", nodeVersions, "20", false},
+		{"rango separado por espacios", ">= 18 <21", nodeVersions, "20", false},
+		{"mínimo abierto", ">=18", nodeVersions, "22", false},
+		{"comodín x", "18.x", nodeVersions, "18", false},
+		{"alternativas", "^18 || ^20", nodeVersions, "20", false},
+		{"tilde con menor", "~20.1", nodeVersions, "20", false},
+		{"versión exacta", "20", nodeVersions, "20", false},
+		{"cualquiera", "*", nodeVersions, "22", false},
+		{"sin versión que cumpla", "<18", nodeVersions, "", true},
+		{"rango ilegible", "latest", nodeVersions, "", true},
+		{"compatible de PEP 440", "~=3.11", pythonVersions, "3.13", false},
+		{"compatible con parche", "~=3.11.2", pythonVersions, "3.11", false},
+		{"comodín sin operador", "3.12.*", pythonVersions, "3.12", false},
+		{"igualdad con comodín", "==3.12.*", pythonVersions, "3.12", false},
+		{"rango con coma", ">=3.10,<3.13", pythonVersions, "3.12", false},
+		{"excluye una versión", ">=3.9,!=3.13", pythonVersions, "3.12", false},
+		{"máximo inclusivo", "<=3.11", pythonVersions, "3.11", false},
+		{"caret de poetry", "^3.10", pythonVersions, "3.13", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveConstraint("campo", tt.constraint, tt.candidates)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolveConstraint(%q) = %q, se esperaba %q", tt.constraint, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToolchainFileParsers(t *testing.T) {
+	tests := []struct {
+		name    string
+		parse   func(string) (string, error)
+		content string
+		want    string
+		wantErr bool
+	}{
+		{".nvmrc con v y parche", parseNodeVersionFile, "v20.11.0\n", "20", false},
+		{".nvmrc con x", parseNodeVersionFile, "18.x", "18", false},
+		{".nvmrc con alias", parseNodeVersionFile, "lts/iron", "", true},
+		{".nvmrc vacío", parseNodeVersionFile, "\n", "", false},
+		{"runtime.txt", parseRuntimeTxt, "python-3.11.4\n", "3.11", false},
+		{"runtime.txt de otro lenguaje", parseRuntimeTxt, "ruby-3.2.2", "", false},
+		{"runtime.txt sin versión", parseRuntimeTxt, "python-latest", "", true},
+		{"rust-toolchain.toml", parseRustToolchain, "[toolchain]\nchannel = \"1.80.0\"\n", "1.80", false},
+		{"rust-toolchain.toml estable", parseRustToolchain, "[toolchain]\nchannel = \"stable\"\n", "", false},
+		{"rust-toolchain de una línea", parseRustToolchain, "1.79\n", "1.79", false},
+		{"rust-toolchain nightly", parseRustToolchain, "nightly-2024-01-01", "", true},
+		{"go.mod con toolchain", parseGoMod, "module x\n\ngo 1.22\n\ntoolchain go1.23.4\n", "1.23", false},
+		{"go.mod sin toolchain", parseGoMod, "module x\n\ngo 1.22.1\n", "1.22", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.parse(tt.content)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("versión = %q, se esperaba %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectToolchainVersionPriority(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, ".nvmrc", "lts/iron\n")
+	writeFile(t, dir, "package.json", `{"engines": {"node": ">=18 <21"}}`)
+
+	got := DetectToolchainVersion(dir, "node")
+	if got.Version != "20" || got.Source != "package.json" {
+		t.Errorf("versión = %q de %q, se esperaba 20 de package.json", got.Version, got.Source)
+	}
+	if got.Ignored == "" {
+		t.Error("no se informó el .nvmrc ignorado")
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatalf("escribiendo %s: %v", name, err)
+	}
+}
@@ -4,22 +4,22 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
 	"net"
-	"os"
-	"os/exec"
-	"path/filepath"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 
-	"bytes"
-
+	"github.com/rodrwan/diplo/internal/certs"
 	"github.com/rodrwan/diplo/internal/database"
 	"github.com/rodrwan/diplo/internal/docker"
+	"github.com/rodrwan/diplo/internal/gitserver"
+	"github.com/rodrwan/diplo/internal/jobs"
+	"github.com/rodrwan/diplo/internal/manifest"
 	"github.com/rodrwan/diplo/internal/models"
+	"github.com/rodrwan/diplo/internal/proxy"
 	runtimePkg "github.com/rodrwan/diplo/internal/runtime"
 	"github.com/sirupsen/logrus"
 )
@@ -30,6 +30,21 @@ type Context struct {
 	// Para SSE - canales de logs por app
 	logChannels map[string]chan string
 	logMu       sync.RWMutex
+	// Reconciliador compartido para los endpoints de mantenimiento
+	reconciler *Reconciler
+	// Proxy inverso que publica las apps por hostname y prefijo de ruta
+	proxy *proxy.Proxy
+	// Gestor de certificados TLS (nil si HTTPS no está habilitado)
+	certs *certs.Manager
+	// Cola persistente donde se encolan deploys, redeploys y rollbacks
+	jobs *jobs.Queue
+	// Repositorios alojados que reciben git push (nil si no está habilitado)
+	gitRepos *gitserver.Repos
+	// Mirrors de los repos remotos de los que salen los builds (nil si no hay caché)
+	gitMirrors *gitserver.Mirrors
+	// Tiempo máximo que la versión anterior de un redeploy espera a que
+	// terminen sus conexiones antes de eliminarse
+	drainTimeout time.Duration
 }
 
 // HybridContext extends Context with runtime factory support
@@ -46,6 +61,62 @@ func NewContext(docker *docker.Client, queries database.Querier, logChannels map
 	}
 }
 
+// SetReconciler asocia el reconciliador usado por los endpoints de mantenimiento
+func (c *Context) SetReconciler(reconciler *Reconciler) {
+	c.reconciler = reconciler
+}
+
+// SetProxy asocia el proxy inverso cuyas rutas se actualizan tras cada deploy
+func (c *Context) SetProxy(p *proxy.Proxy) {
+	c.proxy = p
+}
+
+// SetCertManager asocia el gestor de certificados TLS expuesto por la API
+func (c *Context) SetCertManager(m *certs.Manager) {
+	c.certs = m
+}
+
+// SetJobQueue asocia la cola de trabajos en la que se encolan los deploys
+func (c *Context) SetJobQueue(queue *jobs.Queue) {
+	c.jobs = queue
+}
+
+// SetGitRepos asocia los repositorios alojados que reciben git push
+func (c *Context) SetGitRepos(repos *gitserver.Repos) {
+	c.gitRepos = repos
+}
+
+// SetGitMirrors asocia la caché de mirrors de los repositorios remotos
+func (c *Context) SetGitMirrors(mirrors *gitserver.Mirrors) {
+	c.gitMirrors = mirrors
+}
+
+// SetDrainTimeout fija cuánto espera como máximo la versión anterior de un
+// redeploy a que terminen sus conexiones en curso
+func (c *Context) SetDrainTimeout(timeout time.Duration) {
+	c.drainTimeout = timeout
+}
+
+// refreshRoutes actualiza las rutas del proxy tras un cambio en las apps
+func refreshRoutes(ctx *Context) {
+	if ctx.proxy == nil {
+		return
+	}
+	if err := ctx.proxy.Refresh(context.Background()); err != nil {
+		logrus.Errorf("Error actualizando rutas del proxy: %v", err)
+	}
+}
+
+// appURL devuelve la URL pública de la app (proxy) o, sin proxy, la del puerto directo
+func appURL(ctx *Context, app *database.App) string {
+	if ctx.proxy != nil {
+		if url := ctx.proxy.AppURL(app.ID); url != "" {
+			return url
+		}
+	}
+	return fmt.Sprintf("http://localhost:%d", app.Port)
+}
+
 // NewHybridContext creates a new HybridContext with runtime factory
 func NewHybridContext(docker *docker.Client, queries database.Querier, logChannels map[string]chan string, runtimeFactory interface{}) *HybridContext {
 	return &HybridContext{
@@ -57,16 +128,16 @@ func NewHybridContext(docker *docker.Client, queries database.Querier, logChanne
 // DeployHandler ha sido DEPRECADO - usa UnifiedDeployHandler que detecta automáticamente el runtime
 // Esta función se mantiene solo para referencia histórica y será eliminada en versiones futuras
 
-func deployApp(ctx *Context, app *database.App, envVars []models.EnvVar) {
+func deployApp(jobCtx context.Context, ctx *Context, app *database.App, envVars []models.EnvVar, opts deployOptions) error {
 	logrus.Infof("Iniciando deployment de: %s (%s) con %d variables de entorno", app.Name, app.ID, len(envVars))
 
-	// Configurar callback específico para esta aplicación
-	originalCallback := ctx.docker.GetEventCallback()
-	ctx.docker.SetEventCallback(func(event docker.DockerEvent) {
-		// Enviar evento específico para esta aplicación
+	// Los eventos Docker de este job van solo a esta aplicación
+	jobCtx = docker.WithEventCallback(jobCtx, func(event docker.DockerEvent) {
 		sendDockerEventToApp(ctx, app.ID, event)
 	})
-	defer ctx.docker.SetEventCallback(originalCallback)
+
+	recordDeploymentRuntime(app.ID, string(runtimePkg.RuntimeTypeDocker))
+	recordDeploymentEnv(app.ID, envVars)
 
 	// Enviar log inicial
 	sendLogMessage(ctx, app.ID, "info", "Iniciando deployment...")
@@ -82,110 +153,127 @@ func deployApp(ctx *Context, app *database.App, envVars []models.EnvVar) {
 		Status:   app.Status,
 	})
 
-	// Detectar lenguaje
-	sendLogMessage(ctx, app.ID, "info", "Detectando lenguaje...")
-	language, err := detectLanguage(app.RepoUrl, "") // Pass an empty string for githubToken
-	if err != nil {
-		logrus.Errorf("Error detectando lenguaje: %v", err)
-		app.Status = database.StatusError
-		app.ErrorMsg = sql.NullString{String: fmt.Sprintf("Error detectando lenguaje: %v", err), Valid: true}
-		ctx.queries.UpdateApp(context.Background(), database.UpdateAppParams{
-			ID:       app.ID,
-			Name:     app.Name,
-			RepoUrl:  app.RepoUrl,
-			Language: sql.NullString{String: "Go", Valid: true},
-			Port:     app.Port,
-			Status:   app.Status,
-			ErrorMsg: app.ErrorMsg,
-		})
-		sendLogMessage(ctx, app.ID, "error", fmt.Sprintf("Error detectando lenguaje: %v", err))
-		return
-	}
-	app.Language = sql.NullString{String: language, Valid: true}
-	logrus.Infof("Lenguaje detectado: %s", language)
-	sendLogMessage(ctx, app.ID, "info", fmt.Sprintf("Lenguaje detectado: %s", language))
-
-	// Generar Dockerfile
-	sendLogMessage(ctx, app.ID, "info", "Generando Dockerfile...")
-	dockerfile, err := generateDockerfile(app.RepoUrl, strconv.Itoa(int(app.Port)), language)
-	if err != nil {
-		logrus.Errorf("Error generando Dockerfile: %v", err)
-		app.Status = database.StatusError
-		app.ErrorMsg = sql.NullString{String: fmt.Sprintf("Error generando Dockerfile: %v", err), Valid: true}
-		ctx.queries.UpdateApp(context.Background(), database.UpdateAppParams{
-			ID:       app.ID,
-			Name:     app.Name,
-			RepoUrl:  app.RepoUrl,
-			Language: app.Language,
-			Port:     app.Port,
-			Status:   app.Status,
-			ErrorMsg: app.ErrorMsg,
-		})
-		sendLogMessage(ctx, app.ID, "error", fmt.Sprintf("Error generando Dockerfile: %v", err))
-		return
-	}
-
-	logrus.Debugf("Dockerfile generado:\n%s", dockerfile)
-	sendLogMessage(ctx, app.ID, "info", "Dockerfile generado exitosamente")
-
-	// Generar tag único basado en el hash del commit
-	sendLogMessage(ctx, app.ID, "info", "Obteniendo hash del último commit...")
-	imageTag, err := ctx.docker.GenerateImageTag(app.ID, app.RepoUrl)
-	if err != nil {
-		logrus.Errorf("Error generando tag de imagen: %v", err)
-		app.Status = database.StatusError
-		app.ErrorMsg = sql.NullString{String: fmt.Sprintf("Error generando tag de imagen: %v", err), Valid: true}
-		ctx.queries.UpdateApp(context.Background(), database.UpdateAppParams{
-			ID:       app.ID,
-			Name:     app.Name,
-			RepoUrl:  app.RepoUrl,
-			Language: app.Language,
-			Port:     app.Port,
-			Status:   app.Status,
-			ErrorMsg: app.ErrorMsg,
-		})
-		sendLogMessage(ctx, app.ID, "error", fmt.Sprintf("Error generando tag de imagen: %v", err))
-		return
-	}
-
-	sendLogMessage(ctx, app.ID, "info", fmt.Sprintf("Tag de imagen generado: %s", imageTag))
-
-	// Construir imagen
-	logrus.Infof("Construyendo imagen: %s", imageTag)
-	sendLogMessage(ctx, app.ID, "info", fmt.Sprintf("Construyendo imagen Docker: %s", imageTag))
+	var imageTag, imageID string
+	if isImageApp(app) {
+		// Las apps de imagen no se detectan ni se construyen: se descarga la imagen
+		var err error
+		imageTag, imageID, err = pullAppImage(jobCtx, ctx, app)
+		if err != nil {
+			logrus.Errorf("Error descargando imagen: %v", err)
+			app.Status = database.StatusError
+			app.ErrorMsg = sql.NullString{String: fmt.Sprintf("Error descargando imagen: %v", err), Valid: true}
+			ctx.queries.UpdateApp(context.Background(), database.UpdateAppParams{
+				ID:       app.ID,
+				Name:     app.Name,
+				RepoUrl:  app.RepoUrl,
+				Language: app.Language,
+				Port:     app.Port,
+				Status:   app.Status,
+				ErrorMsg: app.ErrorMsg,
+			})
+			sendLogMessage(ctx, app.ID, "error", fmt.Sprintf("Error descargando imagen: %v", err))
+			return fmt.Errorf("Error descargando imagen: %v", err)
+		}
+	} else {
+		// Detectar lenguaje
+		sendLogMessage(ctx, app.ID, "info", "Detectando lenguaje...")
+		source, err := prepareBuildSource(jobCtx, ctx, app, opts)
+		if err != nil {
+			logrus.Errorf("Error detectando lenguaje: %v", err)
+			app.Status = database.StatusError
+			app.ErrorMsg = sql.NullString{String: fmt.Sprintf("Error detectando lenguaje: %v", err), Valid: true}
+			ctx.queries.UpdateApp(context.Background(), database.UpdateAppParams{
+				ID:       app.ID,
+				Name:     app.Name,
+				RepoUrl:  app.RepoUrl,
+				Language: sql.NullString{String: "Go", Valid: true},
+				Port:     app.Port,
+				Status:   app.Status,
+				ErrorMsg: app.ErrorMsg,
+			})
+			sendLogMessage(ctx, app.ID, "error", fmt.Sprintf("Error detectando lenguaje: %v", err))
+			return fmt.Errorf("Error detectando lenguaje: %v", err)
+		}
+		defer source.cleanup()
+		detection := source.detectLanguage()
+		language := detection.Language
+		app.Language = appLanguage(language)
+		reportLanguage(ctx, app.ID, detection)
+
+		// Elegir Dockerfile: el del repo o la plantilla del lenguaje
+		recordDeploymentStep(app.ID, "dockerfile")
+		sendLogMessage(ctx, app.ID, "info", "Preparando Dockerfile...")
+		dockerfile, dockerfileOrigin, err := source.dockerfile(app, language)
+		if err != nil {
+			logrus.Errorf("Error preparando Dockerfile: %v", err)
+			app.Status = database.StatusError
+			app.ErrorMsg = sql.NullString{String: fmt.Sprintf("Error preparando Dockerfile: %v", err), Valid: true}
+			ctx.queries.UpdateApp(context.Background(), database.UpdateAppParams{
+				ID:       app.ID,
+				Name:     app.Name,
+				RepoUrl:  app.RepoUrl,
+				Language: app.Language,
+				Port:     app.Port,
+				Status:   app.Status,
+				ErrorMsg: app.ErrorMsg,
+			})
+			sendLogMessage(ctx, app.ID, "error", fmt.Sprintf("Error preparando Dockerfile: %v", err))
+			return fmt.Errorf("Error preparando Dockerfile: %v", err)
+		}
 
-	imageID, err := ctx.docker.BuildImage(imageTag, dockerfile)
-	if err != nil {
-		logrus.Errorf("Error construyendo imagen: %v", err)
-		app.Status = database.StatusError
-		app.ErrorMsg = sql.NullString{String: fmt.Sprintf("Error construyendo imagen Docker: %v", err), Valid: true}
-		ctx.queries.UpdateApp(context.Background(), database.UpdateAppParams{
-			ID:       app.ID,
-			Name:     app.Name,
-			RepoUrl:  app.RepoUrl,
-			Language: app.Language,
-			Port:     app.Port,
-			Status:   app.Status,
-			ErrorMsg: app.ErrorMsg,
-		})
-		sendLogMessage(ctx, app.ID, "error", fmt.Sprintf("Error construyendo imagen Docker: %v", err))
+		logrus.Debugf("Dockerfile (%s):\n%s", dockerfileOrigin, dockerfile)
+		reportDockerfile(ctx, app.ID, source, dockerfileOrigin)
+
+		// Generar tag único basado en el hash del commit
+		recordDeploymentStep(app.ID, "image_tag")
+		imageTag = source.imageTag(app.ID)
+		sendLogMessage(ctx, app.ID, "info", fmt.Sprintf("Tag de imagen generado: %s", imageTag))
+		recordDeploymentImage(app.ID, imageTag)
+
+		// Construir imagen
+		recordDeploymentStep(app.ID, "build")
+		logrus.Infof("Construyendo imagen: %s", imageTag)
+		sendLogMessage(ctx, app.ID, "info", fmt.Sprintf("Construyendo imagen Docker: %s", imageTag))
+
+		var reused bool
+		imageID, reused, err = source.buildImage(jobCtx, ctx, imageTag, dockerfile, opts.ForceRebuild)
+		if err != nil {
+			logrus.Errorf("Error construyendo imagen: %v", err)
+			app.Status = database.StatusError
+			app.ErrorMsg = sql.NullString{String: fmt.Sprintf("Error construyendo imagen Docker: %v", err), Valid: true}
+			ctx.queries.UpdateApp(context.Background(), database.UpdateAppParams{
+				ID:       app.ID,
+				Name:     app.Name,
+				RepoUrl:  app.RepoUrl,
+				Language: app.Language,
+				Port:     app.Port,
+				Status:   app.Status,
+				ErrorMsg: app.ErrorMsg,
+			})
+			sendLogMessage(ctx, app.ID, "error", fmt.Sprintf("Error construyendo imagen Docker: %v", err))
+
+			// Limpiar imágenes dangling después de build fallido
+			go func() {
+				if err := ctx.docker.PruneDanglingImages(); err != nil {
+					logrus.Warnf("Error limpiando imágenes dangling después de build fallido: %v", err)
+				}
+			}()
 
-		// Limpiar imágenes dangling después de build fallido
-		go func() {
-			if err := ctx.docker.PruneDanglingImages(); err != nil {
-				logrus.Warnf("Error limpiando imágenes dangling después de build fallido: %v", err)
-			}
-		}()
+			return fmt.Errorf("Error construyendo imagen Docker: %v", err)
+		}
 
-		return
+		if reused {
+			sendLogMessage(ctx, app.ID, "success", "Imagen reutilizada: ya existe una construida con el mismo commit y Dockerfile")
+		} else {
+			sendLogMessage(ctx, app.ID, "success", "Imagen construida exitosamente")
+		}
 	}
 
-	sendLogMessage(ctx, app.ID, "success", "Imagen construida exitosamente")
-
 	// Ejecutar contenedor
+	recordDeploymentStep(app.ID, "run")
 	logrus.Infof("Ejecutando contenedor en puerto %d", app.Port)
 	sendLogMessage(ctx, app.ID, "info", fmt.Sprintf("Ejecutando contenedor en puerto %d", app.Port))
-	containerID, err := ctx.docker.RunContainer(app, imageTag, envVars)
+	containerID, err := ctx.docker.RunContainer(jobCtx, app, imageTag, envVars)
 	if err != nil {
 		logrus.Errorf("Error ejecutando contenedor: %v", err)
 		app.Status = database.StatusError
@@ -200,7 +288,7 @@ func deployApp(ctx *Context, app *database.App, envVars []models.EnvVar) {
 			ErrorMsg: app.ErrorMsg,
 		})
 		sendLogMessage(ctx, app.ID, "error", fmt.Sprintf("Error ejecutando contenedor: %v", err))
-		return
+		return fmt.Errorf("Error ejecutando contenedor: %v", err)
 	}
 
 	// Actualizar aplicación
@@ -225,9 +313,12 @@ func deployApp(ctx *Context, app *database.App, envVars []models.EnvVar) {
 		logrus.Errorf("Error actualizando aplicación: %v", err)
 	}
 
+	// Los procesos del Procfile usan la misma imagen que el contenedor web
+	syncDeployProcesses(jobCtx, ctx, app, envVars)
+
 	// Limpiar imágenes antiguas (mantener solo las 3 más recientes)
 	go func() {
-		if err := ctx.docker.CleanupOldImages(app.ID, 3); err != nil {
+		if err := ctx.docker.CleanupOldImages(app.ID, 3, imageTag); err != nil {
 			logrus.Warnf("Error limpiando imágenes antiguas: %v", err)
 		}
 
@@ -237,20 +328,23 @@ func deployApp(ctx *Context, app *database.App, envVars []models.EnvVar) {
 		}
 	}()
 
+	refreshRoutes(ctx)
+
 	logrus.Infof("Deployment completado exitosamente: %s en puerto %d", app.ID, app.Port)
 	sendLogMessage(ctx, app.ID, "success", fmt.Sprintf("Deployment completado exitosamente en puerto %d", app.Port))
-	sendLogMessage(ctx, app.ID, "success", fmt.Sprintf("Aplicación disponible en: http://localhost:%d", app.Port))
+	sendLogMessage(ctx, app.ID, "success", fmt.Sprintf("Aplicación disponible en: %s", appURL(ctx, app)))
+	return nil
 }
 
-func redeployExistingApp(ctx *Context, app *database.App) {
+func redeployExistingApp(jobCtx context.Context, ctx *Context, app *database.App, opts deployOptions) error {
 	logrus.Infof("Iniciando redeploy de aplicación existente: %s (%s)", app.Name, app.ID)
 
-	// Configurar callback específico para esta aplicación
-	originalCallback := ctx.docker.GetEventCallback()
-	ctx.docker.SetEventCallback(func(event docker.DockerEvent) {
+	// Los eventos Docker de este job van solo a esta aplicación
+	jobCtx = docker.WithEventCallback(jobCtx, func(event docker.DockerEvent) {
 		sendDockerEventToApp(ctx, app.ID, event)
 	})
-	defer ctx.docker.SetEventCallback(originalCallback)
+
+	recordDeploymentRuntime(app.ID, string(runtimePkg.RuntimeTypeDocker))
 
 	// Enviar log inicial
 	sendLogMessage(ctx, app.ID, "info", "Iniciando redeploy de aplicación existente...")
@@ -273,66 +367,74 @@ func redeployExistingApp(ctx *Context, app *database.App) {
 		logrus.Errorf("Error actualizando estado de redeploy: %v", err)
 	}
 
-	// Parar contenedor anterior si existe
+	// La versión anterior sigue atendiendo tráfico hasta que la nueva esté sana
 	if app.ContainerID.String != "" {
-		sendLogMessage(ctx, app.ID, "info", "Deteniendo contenedor anterior...")
-		if err := ctx.docker.StopContainer(app.ContainerID.String); err != nil {
-			logrus.Warnf("Error deteniendo contenedor anterior %s: %v", app.ContainerID.String, err)
-			sendLogMessage(ctx, app.ID, "warning", fmt.Sprintf("Error deteniendo contenedor anterior: %v", err))
-		} else {
-			sendLogMessage(ctx, app.ID, "info", "Contenedor anterior detenido exitosamente")
-		}
-		// Limpiar container ID
-		app.ContainerID = sql.NullString{String: "", Valid: true}
-	}
-
-	// Detectar lenguaje
-	sendLogMessage(ctx, app.ID, "info", "Detectando lenguaje...")
-	language, err := detectLanguage(app.RepoUrl, "") // Pass an empty string for githubToken
-	if err != nil {
-		logrus.Errorf("Error detectando lenguaje en redeploy: %v", err)
-		handleRedeployError(ctx, app, fmt.Sprintf("Error detectando lenguaje: %v", err))
-		return
-	}
-	app.Language = sql.NullString{String: language, Valid: true}
-	sendLogMessage(ctx, app.ID, "info", fmt.Sprintf("Lenguaje detectado: %s", language))
-
-	// Generar Dockerfile
-	sendLogMessage(ctx, app.ID, "info", "Generando Dockerfile...")
-	dockerfile, err := generateDockerfile(app.RepoUrl, strconv.Itoa(int(app.Port)), language)
-	if err != nil {
-		logrus.Errorf("Error generando Dockerfile en redeploy: %v", err)
-		handleRedeployError(ctx, app, fmt.Sprintf("Error generando Dockerfile: %v", err))
-		return
-	}
-	sendLogMessage(ctx, app.ID, "info", "Dockerfile generado exitosamente")
-
-	// Generar nuevo tag único basado en el hash del commit actual
-	sendLogMessage(ctx, app.ID, "info", "Obteniendo hash del último commit...")
-	imageTag, err := ctx.docker.GenerateImageTag(app.ID, app.RepoUrl)
-	if err != nil {
-		logrus.Errorf("Error generando tag de imagen en redeploy: %v", err)
-		handleRedeployError(ctx, app, fmt.Sprintf("Error generando tag de imagen: %v", err))
-		return
+		sendLogMessage(ctx, app.ID, "info", "La versión actual seguirá activa hasta que la nueva esté lista")
 	}
-	sendLogMessage(ctx, app.ID, "info", fmt.Sprintf("Nuevo tag de imagen generado: %s", imageTag))
 
-	// Construir nueva imagen
-	sendLogMessage(ctx, app.ID, "info", fmt.Sprintf("Construyendo nueva imagen: %s", imageTag))
-	imageID, err := ctx.docker.BuildImage(imageTag, dockerfile)
-	if err != nil {
-		logrus.Errorf("Error construyendo imagen en redeploy: %v", err)
-		handleRedeployError(ctx, app, fmt.Sprintf("Error construyendo imagen Docker: %v", err))
-
-		// Limpiar imágenes dangling después de build fallido
-		go func() {
-			if err := ctx.docker.PruneDanglingImages(); err != nil {
-				logrus.Warnf("Error limpiando imágenes dangling después de build fallido: %v", err)
-			}
-		}()
-		return
+	var imageTag, imageID string
+	if isImageApp(app) {
+		// Las apps de imagen no se detectan ni se construyen: se descarga la imagen
+		var err error
+		imageTag, imageID, err = pullAppImage(jobCtx, ctx, app)
+		if err != nil {
+			logrus.Errorf("Error descargando imagen en redeploy: %v", err)
+			return handleRedeployError(ctx, app, fmt.Sprintf("Error descargando imagen: %v", err))
+		}
+	} else {
+		// Detectar lenguaje
+		recordDeploymentStep(app.ID, "detect_language")
+		sendLogMessage(ctx, app.ID, "info", "Detectando lenguaje...")
+		source, err := prepareBuildSource(jobCtx, ctx, app, opts)
+		if err != nil {
+			logrus.Errorf("Error detectando lenguaje en redeploy: %v", err)
+			return handleRedeployError(ctx, app, fmt.Sprintf("Error detectando lenguaje: %v", err))
+		}
+		defer source.cleanup()
+		detection := source.detectLanguage()
+		language := detection.Language
+		app.Language = appLanguage(language)
+		reportLanguage(ctx, app.ID, detection)
+
+		// Elegir Dockerfile: el del repo o la plantilla del lenguaje
+		recordDeploymentStep(app.ID, "dockerfile")
+		sendLogMessage(ctx, app.ID, "info", "Preparando Dockerfile...")
+		dockerfile, dockerfileOrigin, err := source.dockerfile(app, language)
+		if err != nil {
+			logrus.Errorf("Error preparando Dockerfile en redeploy: %v", err)
+			return handleRedeployError(ctx, app, fmt.Sprintf("Error preparando Dockerfile: %v", err))
+		}
+		reportDockerfile(ctx, app.ID, source, dockerfileOrigin)
+
+		// Generar nuevo tag único basado en el hash del commit actual
+		recordDeploymentStep(app.ID, "image_tag")
+		imageTag = source.imageTag(app.ID)
+		sendLogMessage(ctx, app.ID, "info", fmt.Sprintf("Nuevo tag de imagen generado: %s", imageTag))
+		recordDeploymentImage(app.ID, imageTag)
+
+		// Construir nueva imagen
+		recordDeploymentStep(app.ID, "build")
+		sendLogMessage(ctx, app.ID, "info", fmt.Sprintf("Construyendo nueva imagen: %s", imageTag))
+		var reused bool
+		imageID, reused, err = source.buildImage(jobCtx, ctx, imageTag, dockerfile, opts.ForceRebuild)
+		if err != nil {
+			logrus.Errorf("Error construyendo imagen en redeploy: %v", err)
+			buildErr := handleRedeployError(ctx, app, fmt.Sprintf("Error construyendo imagen Docker: %v", err))
+
+			// Limpiar imágenes dangling después de build fallido
+			go func() {
+				if err := ctx.docker.PruneDanglingImages(); err != nil {
+					logrus.Warnf("Error limpiando imágenes dangling después de build fallido: %v", err)
+				}
+			}()
+			return buildErr
+		}
+		if reused {
+			sendLogMessage(ctx, app.ID, "success", "Imagen reutilizada: ya existe una construida con el mismo commit y Dockerfile")
+		} else {
+			sendLogMessage(ctx, app.ID, "success", "Nueva imagen construida exitosamente")
+		}
 	}
-	sendLogMessage(ctx, app.ID, "success", "Nueva imagen construida exitosamente")
 
 	// Cargar variables de entorno existentes de la base de datos
 	existingEnvVars, err := ctx.queries.GetAppEnvVars(context.Background(), app.ID)
@@ -362,16 +464,40 @@ func redeployExistingApp(ctx *Context, app *database.App) {
 		})
 	}
 
-	// Ejecutar nuevo contenedor
-	sendLogMessage(ctx, app.ID, "info", fmt.Sprintf("Ejecutando nuevo contenedor en puerto %d", app.Port))
-	containerID, err := ctx.docker.RunContainer(app, imageTag, envVars)
+	recordDeploymentEnv(app.ID, envVars)
+
+	// Ejecutar nuevo contenedor junto al anterior en un puerto temporal
+	recordDeploymentStep(app.ID, "run")
+	candidatePort, err := findCandidatePort(app)
+	if err != nil {
+		return handleRedeployError(ctx, app, fmt.Sprintf("Error asignando puerto temporal: %v", err))
+	}
+	candidate := *app
+	candidate.Port = candidatePort
+
+	sendLogMessage(ctx, app.ID, "info", fmt.Sprintf("Ejecutando nuevo contenedor en puerto temporal %d", candidatePort))
+	containerID, err := ctx.docker.RunContainer(jobCtx, &candidate, imageTag, envVars)
 	if err != nil {
 		logrus.Errorf("Error ejecutando contenedor en redeploy: %v", err)
-		handleRedeployError(ctx, app, fmt.Sprintf("Error ejecutando contenedor: %v", err))
-		return
+		return handleRedeployError(ctx, app, fmt.Sprintf("Error ejecutando contenedor: %v", err))
+	}
+
+	// Esperar a que la nueva versión responda antes de cambiar el tráfico
+	recordDeploymentStep(app.ID, "health_check")
+	healthPath, healthTimeout := imageHealthCheck(jobCtx, ctx, app, imageTag)
+	sendLogMessage(ctx, app.ID, "info", fmt.Sprintf("Esperando a que la nueva versión responda %s...", describeHealthCheck(healthPath)))
+	if err := waitForHTTPHealthy(jobCtx, candidatePort, healthPath, healthTimeout); err != nil {
+		logrus.Errorf("Nueva versión de %s no saludable: %v", app.ID, err)
+		if stopErr := ctx.docker.StopContainer(containerID); stopErr != nil {
+			logrus.Warnf("Error eliminando contenedor fallido %s: %v", containerID, stopErr)
+		}
+		return handleRedeployError(ctx, app, fmt.Sprintf("Health check fallido: %v", err))
 	}
+	sendLogMessage(ctx, app.ID, "success", "Nueva versión saludable, cambiando tráfico")
+	recordDeploymentStep(app.ID, "switch_traffic")
 
-	// Actualizar aplicación con nueva información
+	// Actualizar aplicación con nueva información; el puerto de la app no cambia
+	oldContainerID, oldPort := app.ContainerID.String, activePort(app)
 	app.Status = database.StatusRunning
 	app.ContainerID = sql.NullString{String: containerID, Valid: true}
 	app.ImageID = sql.NullString{String: imageID, Valid: true}
@@ -392,10 +518,17 @@ func redeployExistingApp(ctx *Context, app *database.App) {
 	}); err != nil {
 		logrus.Errorf("Error actualizando aplicación después del redeploy: %v", err)
 	}
+	if err := promoteUpstream(ctx.queries, app, candidatePort); err != nil {
+		logrus.Errorf("Error guardando el puerto de la nueva versión de %s: %v", app.ID, err)
+	}
+	refreshRoutes(ctx)
+	syncDeployProcesses(jobCtx, ctx, app, envVars)
 
-	// Limpiar imágenes antiguas (mantener solo las 3 más recientes)
+	// Drenar la versión anterior y luego limpiar imágenes antiguas (mantener solo las 3 más recientes)
 	go func() {
-		if err := ctx.docker.CleanupOldImages(app.ID, 3); err != nil {
+		drainContainer(ctx, app.ID, oldContainerID, oldPort, ctx.docker.StopContainer)
+
+		if err := ctx.docker.CleanupOldImages(app.ID, 3, imageTag); err != nil {
 			logrus.Warnf("Error limpiando imágenes antiguas después del redeploy: %v", err)
 		}
 
@@ -407,12 +540,13 @@ func redeployExistingApp(ctx *Context, app *database.App) {
 
 	logrus.Infof("Redeploy completado exitosamente: %s en puerto %d", app.ID, app.Port)
 	sendLogMessage(ctx, app.ID, "success", fmt.Sprintf("Redeploy completado exitosamente en puerto %d", app.Port))
-	sendLogMessage(ctx, app.ID, "success", fmt.Sprintf("Aplicación actualizada disponible en: http://localhost:%d", app.Port))
+	sendLogMessage(ctx, app.ID, "success", fmt.Sprintf("Aplicación actualizada disponible en: %s", appURL(ctx, app)))
+	return nil
 }
 
-// handleRedeployError maneja errores durante el redeploy
-func handleRedeployError(ctx *Context, app *database.App, errorMsg string) {
-	app.Status = database.StatusError
+// handleRedeployError maneja errores durante el redeploy; la versión anterior, si existe, sigue activa
+func handleRedeployError(ctx *Context, app *database.App, errorMsg string) error {
+	errorMsg = redeployFailureStatus(app, errorMsg)
 	app.ErrorMsg = sql.NullString{String: errorMsg, Valid: true}
 	if err := ctx.queries.UpdateApp(context.Background(), database.UpdateAppParams{
 		ID:          app.ID,
@@ -429,10 +563,13 @@ func handleRedeployError(ctx *Context, app *database.App, errorMsg string) {
 		logrus.Errorf("Error actualizando aplicación con error de redeploy: %v", err)
 	}
 	sendLogMessage(ctx, app.ID, "error", errorMsg)
+	return errors.New(errorMsg)
 }
 
 // sendLogMessage envía un mensaje de log a todos los clientes conectados
 func sendLogMessage(ctx *Context, appID, logType, message string) {
+	recordDeploymentLog(appID, logType, message)
+
 	ctx.logMu.RLock()
 	if logChan, exists := ctx.logChannels[appID]; exists {
 		logMsg := createLogMessage(logType, message)
@@ -447,6 +584,9 @@ func sendLogMessage(ctx *Context, appID, logType, message string) {
 
 // sendDockerEventToApp envía un evento Docker específico a una aplicación
 func sendDockerEventToApp(ctx *Context, appID string, event docker.DockerEvent) {
+	// La salida del build y los pasos de Docker también quedan en el build_log
+	recordDeploymentLog(appID, dockerEventLogType(event.Type), event.Message)
+
 	// Sanitizar el mensaje del evento
 	sanitizedMessage := sanitizeString(event.Message)
 
@@ -490,6 +630,20 @@ func sendDockerEventToApp(ctx *Context, appID string, event docker.DockerEvent)
 	ctx.logMu.RUnlock()
 }
 
+// dockerEventLogType traduce el tipo de un evento Docker (build_error,
+// container_success, ...) al tipo de log del deployment
+func dockerEventLogType(eventType string) string {
+	switch {
+	case strings.HasSuffix(eventType, "_error"):
+		return "error"
+	case strings.HasSuffix(eventType, "_warning"):
+		return "warning"
+	case strings.HasSuffix(eventType, "_success"):
+		return "success"
+	}
+	return "info"
+}
+
 // sanitizeString limpia una cadena de caracteres de control y caracteres especiales
 func sanitizeString(s string) string {
 	// Reemplazar caracteres de control comunes
@@ -572,129 +726,87 @@ func isPortAvailable(port int) bool {
 	return true
 }
 
-func detectLanguage(repoURL string, githubToken string) (string, error) {
-	logrus.Debugf("Detectando lenguaje para repo: %s", repoURL)
-
-	// Crear directorio temporal
-	tempDir, err := os.MkdirTemp("", "diplo-detect-*")
-	if err != nil {
-		return "", fmt.Errorf("error creando directorio temporal: %w", err)
-	}
-	defer os.RemoveAll(tempDir)
-
-	// Preparar comando de clonación
-	var cloneCmd *exec.Cmd
-	if githubToken != "" {
-		// Usar token para repositorios privados
-		// Formato: https://token@github.com/usuario/repo
-		repoURLWithToken := strings.Replace(repoURL, "https://github.com/", fmt.Sprintf("https://%s@github.com/", githubToken), 1)
-		cloneCmd = exec.Command("git", "clone", "--depth", "1", repoURLWithToken, tempDir)
-		logrus.Debugf("Clonando repositorio privado con token: %s", repoURLWithToken)
-	} else {
-		// Clonación normal para repositorios públicos
-		cloneCmd = exec.Command("git", "clone", "--depth", "1", repoURL, tempDir)
-		logrus.Debugf("Clonando repositorio público: %s", repoURL)
-	}
-
-	// Capturar stderr para mejor diagnóstico
-	var stderr bytes.Buffer
-	cloneCmd.Stderr = &stderr
-
-	if err := cloneCmd.Run(); err != nil {
-		errorOutput := stderr.String()
-		logrus.Errorf("Error clonando repositorio: %v", err)
-		logrus.Errorf("Stderr output: %s", errorOutput)
-
-		// Detectar errores específicos de autenticación
-		if strings.Contains(errorOutput, "Authentication failed") || strings.Contains(errorOutput, "Invalid username or password") {
-			if githubToken != "" {
-				logrus.Errorf("Error de autenticación con token de GitHub. Verificar:")
-				logrus.Errorf("1. El token es válido y no ha expirado")
-				logrus.Errorf("2. El token tiene permisos de 'repo' para acceder a repositorios privados")
-				logrus.Errorf("3. El usuario tiene acceso al repositorio %s", repoURL)
-				return "", fmt.Errorf("error de autenticación con token de GitHub: %s", errorOutput)
-			}
-		}
+// reportLanguage registra en el deployment y en los logs el lenguaje elegido,
+// su origen y los demás candidatos detectados
+func reportLanguage(ctx *Context, appID string, detection runtimePkg.LanguageDetection) {
+	recordDeploymentLanguage(appID, detection)
+	if detection.Language == "" {
+		sendLogMessage(ctx, appID, "warning", fmt.Sprintf("No se pudo detectar el lenguaje: %s", detection.Reason))
+		return
+	}
 
-		// Si falla con token, intentar sin token (por si es público)
-		if githubToken != "" {
-			logrus.Infof("Intentando clonación sin token (repositorio público)...")
-			cloneCmdPublic := exec.Command("git", "clone", "--depth", "1", repoURL, tempDir)
-			var stderrPublic bytes.Buffer
-			cloneCmdPublic.Stderr = &stderrPublic
-
-			if err := cloneCmdPublic.Run(); err != nil {
-				errorOutputPublic := stderrPublic.String()
-				logrus.Errorf("Error clonando sin token también: %v", err)
-				logrus.Errorf("Stderr output sin token: %s", errorOutputPublic)
-				return "", fmt.Errorf("error clonando repositorio (con y sin token): %w", err)
-			} else {
-				logrus.Infof("Repositorio clonado exitosamente sin token (es público)")
-			}
-		} else {
-			return "", fmt.Errorf("error clonando repositorio: %w", err)
+	logrus.Infof("Lenguaje de %s: %s (%s)", appID, detection.Language, detection.Reason)
+	sendLogMessage(ctx, appID, "info", fmt.Sprintf("Lenguaje detectado: %s (%s)", detection.Language, detection.Reason))
+	if len(detection.Candidates) > 1 {
+		others := make([]string, 0, len(detection.Candidates)-1)
+		for _, candidate := range detection.Candidates[1:] {
+			others = append(others, fmt.Sprintf("%s (%d puntos: %s)", candidate.Language, candidate.Score, strings.Join(candidate.Matches, ", ")))
 		}
+		sendLogMessage(ctx, appID, "info", fmt.Sprintf("Otros candidatos: %s", strings.Join(others, "; ")))
 	}
+}
 
-	// Definir archivos característicos por lenguaje
-	languageIndicators := map[string][]string{
-		"go":         {"go.mod", "go.sum", "main.go", "*.go"},
-		"javascript": {"package.json", "yarn.lock", "package-lock.json", "app.js", "index.js", "server.js"},
-		"python":     {"requirements.txt", "setup.py", "pyproject.toml", "Pipfile", "app.py", "main.py", "*.py"},
-		"rust":       {"Cargo.toml", "Cargo.lock", "src/main.rs", "src/lib.rs"},
-		"java":       {"pom.xml", "build.gradle", "gradlew", "src/main/java"},
-		"php":        {"composer.json", "composer.lock", "index.php", "*.php"},
-		"ruby":       {"Gemfile", "Gemfile.lock", "config.ru", "*.rb"},
-	}
-
-	// Buscar archivos característicos en orden de prioridad
-	for language, indicators := range languageIndicators {
-		for _, indicator := range indicators {
-			var found bool
-			if strings.Contains(indicator, "*") {
-				// Usar pattern matching para archivos con wildcards
-				matches, err := filepath.Glob(filepath.Join(tempDir, indicator))
-				if err == nil && len(matches) > 0 {
-					found = true
-				}
-				// También buscar en subdirectorios comunes
-				matches, err = filepath.Glob(filepath.Join(tempDir, "src", indicator))
-				if err == nil && len(matches) > 0 {
-					found = true
-				}
-			} else {
-				// Buscar archivo específico
-				if _, err := os.Stat(filepath.Join(tempDir, indicator)); err == nil {
-					found = true
-				}
-			}
+// appLanguage es el lenguaje que se guarda en la app; "unknown" si no se reconoce
+func appLanguage(language string) sql.NullString {
+	if language == "" {
+		language = "unknown"
+	}
+	return sql.NullString{String: language, Valid: true}
+}
 
-			if found {
-				logrus.Infof("Lenguaje detectado: %s (encontrado: %s)", language, indicator)
-				return language, nil
-			}
+// reportDockerfile registra en el deployment y en los logs el origen del
+// Dockerfile elegido
+func reportDockerfile(ctx *Context, appID string, source *buildSource, origin string) {
+	recordDeploymentDockerfile(appID, origin)
+	path, fromRepo := strings.CutPrefix(origin, "repo:")
+	if !fromRepo {
+		language := strings.TrimPrefix(origin, "template:")
+		sendLogMessage(ctx, appID, "info", fmt.Sprintf("El repositorio no trae Dockerfile: usando la plantilla de %s", language))
+		reportToolchain(ctx, appID, language, source.toolchain)
+		if source.start.Command != "" {
+			sendLogMessage(ctx, appID, "info", fmt.Sprintf("Comando de inicio: %s (%s)", source.start.Command, source.start.Reason))
+		}
+		if source.goTarget.Package != "" {
+			sendLogMessage(ctx, appID, "info", fmt.Sprintf("Paquete de Go: %s (%s)", source.goTarget.Package, source.goTarget.Reason))
 		}
+		return
 	}
 
-	// Si no se detecta ningún lenguaje, usar Go como fallback
-	logrus.Warnf("No se pudo detectar el lenguaje para %s, usando Go como fallback", repoURL)
-	return "go", nil
+	sendLogMessage(ctx, appID, "info", fmt.Sprintf("Usando el Dockerfile del repositorio: %s", path))
+	if fields := source.ignoredManifestFields(); len(fields) > 0 {
+		sendLogMessage(ctx, appID, "warning", fmt.Sprintf("%s ignora %s de %s: solo aplican a las plantillas", path, strings.Join(fields, ", "), manifest.FileName))
+	}
+	if source.appStart != "" {
+		sendLogMessage(ctx, appID, "warning", fmt.Sprintf("%s ignora el start_command de la app: solo aplica a las plantillas", path))
+	}
+	if source.appGoBuild {
+		sendLogMessage(ctx, appID, "warning", fmt.Sprintf("%s ignora go_target, go_tags y go_ldflags de la app: solo aplican a la plantilla de Go", path))
+	}
+}
+
+// reportToolchain informa en el log del deploy la versión de la imagen base y
+// de dónde sale
+func reportToolchain(ctx *Context, appID, language string, toolchain runtimePkg.ToolchainVersion) {
+	if toolchain.Ignored != "" {
+		sendLogMessage(ctx, appID, "warning", fmt.Sprintf("Versión ignorada en %s", toolchain.Ignored))
+	}
+	if toolchain.Version != "" {
+		sendLogMessage(ctx, appID, "info", fmt.Sprintf("Versión de %s: %s (de %s)", language, toolchain.Version, toolchain.Source))
+		return
+	}
+	if template, err := runtimePkg.NewDockerTemplateManager().GetTemplate(language); err == nil && template.DefaultVersion != "" {
+		sendLogMessage(ctx, appID, "info", fmt.Sprintf("Versión de %s: %s (por defecto de la plantilla)", language, template.DefaultVersion))
+	}
 }
 
-func generateDockerfile(repoURL, port, language string) (string, error) {
-	logrus.Debugf("Generando Dockerfile para lenguaje: %s, puerto: %s", language, port)
+func generateDockerfile(language string, opts runtimePkg.TemplateOptions) (string, error) {
+	logrus.Debugf("Generando Dockerfile para lenguaje: %s, puerto: %d", language, opts.Port)
 
 	// Crear manager de templates Docker
 	templateManager := runtimePkg.NewDockerTemplateManager()
 
-	// Parsear puerto como entero
-	portInt, err := strconv.Atoi(port)
-	if err != nil {
-		return "", fmt.Errorf("puerto inválido: %s", port)
-	}
-
 	// Renderizar el Dockerfile usando el template
-	dockerfile, err := templateManager.RenderTemplate(language, portInt, repoURL)
+	dockerfile, err := templateManager.RenderTemplate(language, opts)
 	if err != nil {
 		return "", fmt.Errorf("error renderizando template para %s: %w", language, err)
 	}
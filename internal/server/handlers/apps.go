@@ -28,15 +28,26 @@ func ListAppsHandler(ctx *Context, w http.ResponseWriter, r *http.Request) (Resp
 	appsDTO := make([]*dto.App, 0, len(apps))
 	for _, app := range apps {
 		appsDTO = append(appsDTO, &dto.App{
-			ID:          app.ID,
-			Name:        app.Name,
-			RepoUrl:     app.RepoUrl,
-			Language:    app.Language.String,
-			Port:        int(app.Port),
-			ContainerID: app.ContainerID.String,
-			ImageID:     app.ImageID.String,
-			Status:      app.Status.String,
-			ErrorMsg:    app.ErrorMsg.String,
+			ID:               app.ID,
+			Name:             app.Name,
+			RepoUrl:          app.RepoUrl,
+			Image:            app.Image.String,
+			Path:             app.Path.String,
+			Ref:              app.Ref.String,
+			Dockerfile:       app.DockerfilePath.String,
+			Start:            app.StartCommand.String,
+			Language:         app.Language.String,
+			LanguageOverride: app.LanguageOverride.String,
+			GoTarget:         app.GoTarget.String,
+			GoTags:           app.GoTags.String,
+			GoLDFlags:        app.GoLdflags.String,
+			HealthCheckPath:  app.HealthCheckPath.String,
+			Port:             int(app.Port),
+			ContainerID:      app.ContainerID.String,
+			ImageID:          app.ImageID.String,
+			Status:           app.Status.String,
+			ErrorMsg:         app.ErrorMsg.String,
+			URL:              appURL(ctx, &app),
 		})
 	}
 
@@ -100,6 +111,13 @@ func DeleteAppHandler(ctx *Context, w http.ResponseWriter, r *http.Request) (Res
 		logrus.Infof("ℹ️  No hay contenedor asociado a la aplicación %s", app.ID)
 	}
 
+	// Los procesos del Procfile usan la imagen de la app: eliminarlos antes que ella
+	if app.ImageID.String != "" && inferRuntimeFromContainerID(app.ContainerID.String) == runtimePkg.RuntimeTypeDocker {
+		if err := removeProcessContainers(r.Context(), ctx.docker, app.ID); err != nil {
+			logrus.Warnf("Error eliminando procesos de la aplicación %s: %v", app.ID, err)
+		}
+	}
+
 	// Eliminar imagen si existe usando el método híbrido
 	if app.ImageID.String != "" {
 		logrus.Infof("Eliminando imagen para aplicación %s: %s", app.ID, app.ImageID.String)
@@ -141,6 +159,8 @@ func DeleteAppHandler(ctx *Context, w http.ResponseWriter, r *http.Request) (Res
 		return Response{Code: http.StatusInternalServerError, Message: "Error eliminando aplicación"}, err
 	}
 
+	refreshRoutes(ctx)
+
 	logrus.Infof("Aplicación eliminada exitosamente: %s (%s)", app.Name, app.ID)
 	return Response{Code: http.StatusOK, Message: "Aplicación eliminada exitosamente"}, nil
 }
@@ -252,7 +272,7 @@ func deleteImageHybrid(ctx *Context, app *database.App) error {
 
 			// Fallback: intentar limpiar todas las imágenes de la app
 			logrus.Infof("Intentando limpiar todas las imágenes de la app %s", app.ID)
-			if err := ctx.docker.CleanupOldImages(app.ID, 0); err != nil {
+			if err := ctx.docker.CleanupOldImages(app.ID, 0, ""); err != nil {
 				logrus.Warnf("Error usando CleanupOldImages como fallback: %v", err)
 			} else {
 				logrus.Infof("Limpieza de imágenes completada para app %s", app.ID)
@@ -437,8 +457,8 @@ func performHealthCheck(ctx *Context, app *database.App) (map[string]interface{}
 		}, nil
 	}
 
-	// Usar localhost para healthcheck
-	healthcheckURL := fmt.Sprintf("http://localhost:%d", app.Port)
+	// Usar localhost para healthcheck, directo al contenedor activo
+	healthcheckURL := fmt.Sprintf("http://localhost:%d", activePort(app))
 
 	// Hacer ping HTTP interno al contenedor
 	httpClient := &http.Client{
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rodrwan/diplo/internal/database"
+	"github.com/rodrwan/diplo/internal/docker"
+	"github.com/sirupsen/logrus"
+)
+
+// Parámetros de los redeploys blue/green: la versión nueva arranca en un
+// puerto propio junto a la anterior y solo recibe tráfico cuando responde HTTP
+const (
+	healthCheckTimeout  = 2 * time.Minute
+	healthCheckInterval = 2 * time.Second
+	// drainPollInterval es cada cuánto se revisa si la versión anterior aún
+	// tiene conexiones en curso
+	drainPollInterval = 500 * time.Millisecond
+)
+
+// drainingContainers son contenedores reemplazados que aún drenan conexiones;
+// el reconciliador no los trata como huérfanos
+var drainingContainers sync.Map
+
+func isDraining(containerID string) bool {
+	_, ok := drainingContainers.Load(containerID)
+	return ok
+}
+
+// activePort devuelve el puerto del host en el que escucha el contenedor activo
+// de la app: el de la versión que promovió el último redeploy o, si no hubo
+// ninguno, el puerto de la app
+func activePort(app *database.App) int64 {
+	if app.UpstreamPort.Valid && app.UpstreamPort.Int64 > 0 {
+		return app.UpstreamPort.Int64
+	}
+	return app.Port
+}
+
+// withActivePort devuelve una copia de la app con Port en el puerto del
+// contenedor activo, para recrear o reiniciar ese contenedor
+func withActivePort(app *database.App) *database.App {
+	active := *app
+	active.Port = activePort(app)
+	return &active
+}
+
+// findCandidatePort busca un puerto libre para la versión nueva, distinto del
+// puerto estable de la app y del de la versión actual
+func findCandidatePort(app *database.App) (int64, error) {
+	for attempt := 0; attempt < 5; attempt++ {
+		port, err := findFreePort()
+		if err != nil {
+			return 0, err
+		}
+		if int64(port) != app.Port && int64(port) != activePort(app) {
+			return int64(port), nil
+		}
+	}
+	return 0, fmt.Errorf("no se pudo encontrar un puerto temporal libre")
+}
+
+// promoteUpstream hace de port el puerto del contenedor activo. El puerto de
+// la app no cambia: el proxy lo reenvía a la versión nueva.
+func promoteUpstream(queries database.Querier, app *database.App, port int64) error {
+	app.UpstreamPort = sql.NullInt64{Int64: port, Valid: true}
+	return queries.UpdateAppUpstreamPort(context.Background(), database.UpdateAppUpstreamPortParams{
+		UpstreamPort: app.UpstreamPort,
+		UpdatedAt:    sql.NullTime{Time: time.Now(), Valid: true},
+		ID:           app.ID,
+	})
+}
+
+// autoHealthCheckPath quita la ruta del health check fijada en la app
+const autoHealthCheckPath = "auto"
+
+// validateHealthCheckPath valida la ruta del health check fijada en la app,
+// con las mismas reglas que health_check.path de diplo.yaml
+func validateHealthCheckPath(path string) error {
+	if !strings.HasPrefix(path, "/") || strings.ContainsAny(path, " \t\r\n") {
+		return fmt.Errorf("health_check_path: %q debe empezar con / y no tener espacios", path)
+	}
+	return nil
+}
+
+// imageHealthCheck devuelve la ruta y el timeout del health check de la app
+// con la configuración que declara la imagen (desde diplo.yaml)
+func imageHealthCheck(jobCtx context.Context, ctx *Context, app *database.App, image string) (string, time.Duration) {
+	config, err := ctx.docker.ImageRunConfig(jobCtx, image)
+	if err != nil {
+		logrus.Warnf("Usando el health check por defecto para %s: %v", image, err)
+		return runConfigHealthCheck(app, docker.RunConfig{})
+	}
+	return runConfigHealthCheck(app, config)
+}
+
+// runConfigHealthCheck devuelve la ruta y el timeout del health check: la ruta
+// fijada en la app o, si no tiene, la de config; vacía si ninguna declara una.
+// Sin timeout en config se usa healthCheckTimeout.
+func runConfigHealthCheck(app *database.App, config docker.RunConfig) (string, time.Duration) {
+	path, timeout := config.HealthPath, healthCheckTimeout
+	if app.HealthCheckPath.String != "" {
+		path = app.HealthCheckPath.String
+	}
+	if config.HealthTimeout > 0 {
+		timeout = config.HealthTimeout
+	}
+	return path, timeout
+}
+
+// describeHealthCheck describe para el log lo que espera el health check en path
+func describeHealthCheck(path string) string {
+	if path == "" {
+		return "HTTP en /"
+	}
+	return fmt.Sprintf("2xx o 3xx en %s", path)
+}
+
+// waitForHTTPHealthy espera a que la app responda 2xx o 3xx en path. Las
+// redirecciones no se siguen: basta con que la app las emita. Sin path basta
+// cualquier respuesta HTTP en /: una app que no sirve / ya está escuchando
+// aunque responda 404.
+func waitForHTTPHealthy(ctx context.Context, port int64, path string, timeout time.Duration) error {
+	strict := path != ""
+	if !strict {
+		path = "/"
+	}
+	url := fmt.Sprintf("http://127.0.0.1:%d%s", port, path)
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	deadline := time.Now().Add(timeout)
+
+	var lastErr error
+	for time.Now().Before(deadline) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if !strict || (resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusBadRequest) {
+				return nil
+			}
+			lastErr = fmt.Errorf("respondió %d", resp.StatusCode)
+		} else {
+			lastErr = err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(healthCheckInterval):
+		}
+	}
+
+	return fmt.Errorf("la nueva versión no respondió en %s: %v", timeout, lastErr)
+}
+
+// drainContainer deja que la versión anterior, que escucha en port, termine
+// sus conexiones en curso y luego la elimina con remove. Al liberarse el puerto
+// se refrescan las rutas: si la versión anterior ocupaba el puerto estable de
+// la app, el proxy empieza a publicarlo.
+func drainContainer(ctx *Context, appID, containerID string, port int64, remove func(containerID string) error) {
+	if containerID == "" {
+		return
+	}
+
+	drainingContainers.Store(containerID, appID)
+	defer drainingContainers.Delete(containerID)
+
+	logrus.Infof("Drenando contenedor anterior %s de la app %s (máximo %s)", containerID, appID, ctx.drainTimeout)
+	waitForDrain(ctx, port)
+
+	if err := remove(containerID); err != nil {
+		logrus.Warnf("Error eliminando contenedor anterior %s: %v", containerID, err)
+		return
+	}
+	logrus.Infof("Contenedor anterior %s eliminado", containerID)
+	refreshRoutes(ctx)
+}
+
+// waitForDrain espera a que el proxy no tenga peticiones ni conexiones en curso
+// hacia port, como máximo el drain timeout
+func waitForDrain(ctx *Context, port int64) {
+	if ctx.proxy == nil {
+		return
+	}
+
+	deadline := time.Now().Add(ctx.drainTimeout)
+	for ctx.proxy.ActiveConnections(port) > 0 {
+		if time.Now().After(deadline) {
+			logrus.Warnf("El puerto %d sigue con %d conexiones tras %s, se cortan", port, ctx.proxy.ActiveConnections(port), ctx.drainTimeout)
+			return
+		}
+		time.Sleep(drainPollInterval)
+	}
+}
+
+// redeployFailureStatus decide el estado tras un redeploy fallido: si la
+// versión anterior sigue desplegada, la app sigue "running" con el error
+func redeployFailureStatus(app *database.App, errorMsg string) string {
+	if app.ContainerID.String == "" {
+		app.Status = database.StatusError
+		return errorMsg
+	}
+
+	app.Status = database.StatusRunning
+	return fmt.Sprintf("Redeploy fallido, la versión anterior sigue activa: %s", errorMsg)
+}
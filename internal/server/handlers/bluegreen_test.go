@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWaitForHTTPHealthyRequiresSuccess(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.WriteHeader(http.StatusOK)
+		case "/login":
+			http.Redirect(w, r, "https://example.com/", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := strconv.ParseInt(u.Port(), 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path    string
+		healthy bool
+	}{
+		{"/health", true},
+		{"/login", true},
+		{"/", false},
+		// Sin ruta configurada basta con que la app responda, aunque sea 404
+		{"", true},
+	}
+	for _, tt := range tests {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		err := waitForHTTPHealthy(ctx, port, tt.path, time.Second)
+		cancel()
+		if (err == nil) != tt.healthy {
+			t.Errorf("waitForHTTPHealthy(%q) = %v, se esperaba sana=%v", tt.path, err, tt.healthy)
+		}
+	}
+}
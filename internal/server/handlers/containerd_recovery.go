@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rodrwan/diplo/internal/database"
+	"github.com/rodrwan/diplo/internal/models"
+	runtimePkg "github.com/rodrwan/diplo/internal/runtime"
+	"github.com/sirupsen/logrus"
+)
+
+// containerdBuildCommand compila dentro del contenedor la app clonada en
+// /app/src: el paquete main goPackage del subdirectorio path, con los tags y
+// ldflags de la app
+func containerdBuildCommand(path, goPackage, tags, ldflags string) string {
+	dir := "/app/src"
+	if path != "" {
+		dir += "/" + path
+	}
+	build := []string{"go", "build", "-v"}
+	if tags != "" {
+		build = append(build, "-tags", tags)
+	}
+	if ldflags != "" {
+		build = append(build, "-ldflags", ldflags)
+	}
+	build = append(build, "-o", "/app/app", goPackage)
+	for i, arg := range build {
+		build[i] = runtimePkg.ShellQuote(arg)
+	}
+	return fmt.Sprintf("cd %s && go mod tidy && %s", runtimePkg.ShellQuote(dir), strings.Join(build, " "))
+}
+
+// containerdAppSpec es la configuración persistida en apps.runtime_config para
+// recrear una app containerd tras un reinicio. Las variables de entorno no se
+// guardan aquí: se reconstruyen desde app_env_vars para no duplicar secretos.
+// Commit es el commit desplegado, que la recuperación vuelve a compilar, y
+// GitHubToken el token cifrado con el que se clonó el repo.
+type containerdAppSpec struct {
+	Image        string                     `json:"image"`
+	Command      []string                   `json:"command"`
+	WorkingDir   string                     `json:"working_dir"`
+	Labels       map[string]string          `json:"labels"`
+	Ports        []runtimePkg.PortMapping   `json:"ports"`
+	Resources    *runtimePkg.ResourceConfig `json:"resources"`
+	NetworkMode  string                     `json:"network_mode"`
+	BuildCommand string                     `json:"build_command"`
+	StartCommand string                     `json:"start_command"`
+	Commit       string                     `json:"commit,omitempty"`
+	GitHubToken  string                     `json:"github_token,omitempty"`
+}
+
+// errContainerdCommitUnknown indica que la configuración guardada no tiene el
+// commit desplegado: recrear la app compilaría un commit que no se desplegó
+var errContainerdCommitUnknown = errors.New("no se conoce el commit desplegado: redespliega la app para poder recrearla")
+
+// containerdStartCommand lanza la app compilada en background
+func containerdStartCommand(port int64) string {
+	return fmt.Sprintf("cd /app && PORT=%d nohup ./app > /app/app.log 2>&1 &", port)
+}
+
+// containerdEnvironment combina las variables de la app con las del sistema
+func containerdEnvironment(app *database.App, envVars []models.EnvVar) map[string]string {
+	env := convertEnvVarsToMap(envVars)
+	env["PORT"] = fmt.Sprintf("%d", app.Port)
+	env["DIPLO_APP_ID"] = app.ID
+	env["DIPLO_APP_NAME"] = app.Name
+	env["DIPLO_APP_PORT"] = fmt.Sprintf("%d", app.Port)
+	return env
+}
+
+// newContainerdRequest construye el request de contenedor containerd para una app
+func newContainerdRequest(app *database.App, containerName, image string, envVars []models.EnvVar) *runtimePkg.CreateContainerRequest {
+	return &runtimePkg.CreateContainerRequest{
+		Name:        containerName,
+		Image:       image,
+		Command:     []string{"sleep", "infinity"}, // Mantiene el contenedor vivo; la app se lanza con exec
+		WorkingDir:  "/app",
+		Environment: containerdEnvironment(app, envVars),
+		Ports: []runtimePkg.PortMapping{
+			{
+				HostPort:      int(app.Port),
+				ContainerPort: int(app.Port), // Usar el mismo puerto que el host
+				Protocol:      "tcp",
+			},
+		},
+		NetworkMode: "host", // Usar host networking para containerd
+		Labels: map[string]string{
+			"app.id":         app.ID,
+			"app.name":       app.Name,
+			"runtime":        "containerd",
+			"diplo.app.id":   app.ID,
+			"diplo.app.name": app.Name,
+			"diplo.app.port": fmt.Sprintf("%d", app.Port),
+			"diplo.managed":  "true",
+		},
+		Resources: &runtimePkg.ResourceConfig{
+			Memory:    512 * 1024 * 1024, // 512MB
+			CPUShares: 512,
+		},
+	}
+}
+
+// cloneContainerdSource clona el repo en /app/src del contenedor y, si commit
+// no está vacío, deja ese commit en vez del HEAD de la rama por defecto.
+// Devuelve el commit clonado.
+func cloneContainerdSource(ctx context.Context, runtime runtimePkg.ContainerRuntime, containerID, repoURL, commit string) (string, error) {
+	// El URL del repositorio y el commit se pasan como argumentos posicionales para no interpolarlos en el script
+	script := `rm -rf /app/src && git clone -- "$1" /app/src`
+	args := []string{"sh", repoURL}
+	if commit != "" {
+		script += ` && git -C /app/src checkout --detach "$2"`
+		args = append(args, commit)
+	}
+	result, err := runtime.ExecuteCommand(ctx, containerID, append([]string{"sh", "-c", script}, args...))
+	if err != nil {
+		return "", fmt.Errorf("error clonando repositorio: %v", err)
+	}
+	if result.ExitCode != 0 {
+		if commit != "" {
+			return "", fmt.Errorf("error clonando el commit %s: %s\nOutput: %s", commit, result.Error, result.Output)
+		}
+		return "", fmt.Errorf("error clonando repositorio: %s\nOutput: %s", result.Error, result.Output)
+	}
+	return containerdClonedCommit(ctx, runtime, containerID)
+}
+
+// containerdClonedCommit devuelve el commit clonado en /app/src
+func containerdClonedCommit(ctx context.Context, runtime runtimePkg.ContainerRuntime, containerID string) (string, error) {
+	result, err := runtime.ExecuteCommand(ctx, containerID, []string{"git", "-C", "/app/src", "rev-parse", "HEAD"})
+	if err != nil {
+		return "", err
+	}
+	commit := strings.TrimSpace(result.Output)
+	if result.ExitCode != 0 || len(commit) != 40 || !isCommitSHA(commit) {
+		return "", fmt.Errorf("git rev-parse HEAD: %s %s", result.Error, commit)
+	}
+	return commit, nil
+}
+
+// saveContainerdSpec persiste la imagen y la configuración con la que se creó
+// el contenedor, junto con el commit clonado y el token de GitHub cifrado
+func saveContainerdSpec(queries database.Querier, app *database.App, req *runtimePkg.CreateContainerRequest, source *containerdSource, gitHubToken string) error {
+	encryptedToken := ""
+	if gitHubToken != "" {
+		var err error
+		if encryptedToken, err = encryptValue(gitHubToken); err != nil {
+			return fmt.Errorf("error cifrando token de GitHub: %v", err)
+		}
+	}
+
+	spec := containerdAppSpec{
+		Image:        req.Image,
+		Command:      req.Command,
+		WorkingDir:   req.WorkingDir,
+		Labels:       req.Labels,
+		Ports:        req.Ports,
+		Resources:    req.Resources,
+		NetworkMode:  req.NetworkMode,
+		BuildCommand: source.buildCommand,
+		StartCommand: containerdStartCommand(activePort(app)),
+		Commit:       source.commit,
+		GitHubToken:  encryptedToken,
+	}
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return fmt.Errorf("error serializando configuración containerd: %v", err)
+	}
+
+	app.ImageID = sql.NullString{String: req.Image, Valid: true}
+	app.RuntimeConfig = sql.NullString{String: string(data), Valid: true}
+
+	return queries.UpdateAppRuntimeConfig(context.Background(), database.UpdateAppRuntimeConfigParams{
+		ImageID:       app.ImageID,
+		RuntimeConfig: app.RuntimeConfig,
+		UpdatedAt:     sql.NullTime{Time: time.Now(), Valid: true},
+		ID:            app.ID,
+	})
+}
+
+// loadContainerdSpec lee la configuración almacenada de la app. Las apps
+// desplegadas antes de guardarse la configuración usan la de un deploy normal.
+func loadContainerdSpec(app *database.App) (*containerdAppSpec, error) {
+	if !app.RuntimeConfig.Valid || app.RuntimeConfig.String == "" {
+		logrus.Warnf("App %s sin configuración containerd almacenada, usando la configuración por defecto", app.ID)
+		image := app.ImageID.String
+		if image == "" {
+			image = getContainerdBaseImage(app.Language.String)
+		}
+		req := newContainerdRequest(app, app.ID, image, nil)
+		return &containerdAppSpec{
+			Image:        req.Image,
+			Command:      req.Command,
+			WorkingDir:   req.WorkingDir,
+			Labels:       req.Labels,
+			Ports:        req.Ports,
+			Resources:    req.Resources,
+			NetworkMode:  req.NetworkMode,
+			BuildCommand: containerdBuildCommand(app.Path.String, cmp.Or(app.GoTarget.String, "."), app.GoTags.String, app.GoLdflags.String),
+			StartCommand: containerdStartCommand(app.Port),
+		}, nil
+	}
+
+	var spec containerdAppSpec
+	if err := json.Unmarshal([]byte(app.RuntimeConfig.String), &spec); err != nil {
+		return nil, fmt.Errorf("configuración containerd inválida: %v", err)
+	}
+	if spec.Image == "" {
+		return nil, fmt.Errorf("configuración containerd sin imagen")
+	}
+
+	return &spec, nil
+}
+
+// restartContainerdApp vuelve a iniciar la task de un contenedor containerd
+// existente (ej. tras reiniciar el host) y relanza la app ya compilada
+func restartContainerdApp(ctx context.Context, runtime runtimePkg.ContainerRuntime, app *database.App, containerID string) error {
+	spec, err := loadContainerdSpec(app)
+	if err != nil {
+		return err
+	}
+
+	if err := runtime.StartContainer(ctx, containerID); err != nil {
+		return fmt.Errorf("error iniciando contenedor %s: %v", containerID, err)
+	}
+
+	return runContainerdStep(ctx, runtime, containerID, "iniciando aplicación", spec.StartCommand)
+}
+
+// recreateContainerdApp crea un contenedor nuevo desde la imagen y configuración
+// almacenadas, con las variables de entorno originales, y reprovisiona la app
+func recreateContainerdApp(ctx context.Context, runtime runtimePkg.ContainerRuntime, app *database.App, envVars []models.EnvVar) (string, error) {
+	spec, err := loadContainerdSpec(app)
+	if err != nil {
+		return "", err
+	}
+	if spec.Commit == "" {
+		return "", errContainerdCommitUnknown
+	}
+
+	req := &runtimePkg.CreateContainerRequest{
+		Name:        fmt.Sprintf("%s_%d", app.ID, time.Now().Unix()),
+		Image:       spec.Image,
+		Command:     spec.Command,
+		WorkingDir:  spec.WorkingDir,
+		Environment: containerdEnvironment(app, envVars),
+		Labels:      spec.Labels,
+		Ports:       spec.Ports,
+		Resources:   spec.Resources,
+		NetworkMode: spec.NetworkMode,
+	}
+
+	container, err := runtime.CreateContainer(req)
+	if err != nil {
+		return "", fmt.Errorf("error creando contenedor containerd: %v", err)
+	}
+
+	if err := runtime.StartContainer(ctx, container.ID); err != nil {
+		return container.ID, fmt.Errorf("error iniciando contenedor containerd: %v", err)
+	}
+
+	if err := provisionContainerdApp(ctx, runtime, container.ID, app, spec); err != nil {
+		if removeErr := runtime.RemoveContainer(ctx, container.ID); removeErr != nil {
+			logrus.Warnf("Error eliminando contenedor containerd fallido %s: %v", container.ID, removeErr)
+		}
+		return "", err
+	}
+
+	return container.ID, nil
+}
+
+// provisionContainerdApp instala git si falta, clona el repositorio en el
+// commit desplegado, compila y lanza la app
+func provisionContainerdApp(ctx context.Context, runtime runtimePkg.ContainerRuntime, containerID string, app *database.App, spec *containerdAppSpec) error {
+	gitHubToken := ""
+	if spec.GitHubToken != "" {
+		var err error
+		if gitHubToken, err = decryptValue(spec.GitHubToken); err != nil {
+			return fmt.Errorf("error descifrando token de GitHub: %v", err)
+		}
+	}
+
+	installGit := `if ! command -v git >/dev/null 2>&1; then
+  if command -v apk >/dev/null 2>&1; then apk add --no-cache git
+  elif command -v apt-get >/dev/null 2>&1; then apt-get update && apt-get install -y git
+  elif command -v yum >/dev/null 2>&1; then yum install -y git
+  else echo "No se pudo detectar el gestor de paquetes (apk/apt/yum) para instalar git" >&2; exit 1
+  fi
+fi`
+	if err := runContainerdStep(ctx, runtime, containerID, "instalando git", installGit); err != nil {
+		return err
+	}
+
+	if _, err := cloneContainerdSource(ctx, runtime, containerID, repoURLWithToken(app.RepoUrl, gitHubToken), spec.Commit); err != nil {
+		if gitHubToken == "" {
+			return fmt.Errorf("%w (si el repositorio es privado se requieren credenciales: redespliega con github_token)", err)
+		}
+		return err
+	}
+
+	if err := runContainerdStep(ctx, runtime, containerID, "compilando aplicación", spec.BuildCommand); err != nil {
+		return err
+	}
+
+	return runContainerdStep(ctx, runtime, containerID, "iniciando aplicación", spec.StartCommand)
+}
+
+// runContainerdStep ejecuta un script de shell dentro del contenedor
+func runContainerdStep(ctx context.Context, runtime runtimePkg.ContainerRuntime, containerID, step, script string) error {
+	logrus.Infof("Contenedor %s: %s", containerID, step)
+
+	result, err := runtime.ExecuteCommand(ctx, containerID, []string{"sh", "-c", script})
+	if err != nil {
+		return fmt.Errorf("error %s: %v", step, err)
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("error %s: %s\nOutput: %s", step, result.Error, result.Output)
+	}
+
+	return nil
+}
+
+// removeContainerdContainer detiene y elimina un contenedor containerd con un cliente propio
+func removeContainerdContainer(containerID string) error {
+	client, err := runtimePkg.NewContainerdClient("", "")
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.StopContainer(context.Background(), containerID); err != nil {
+		logrus.Debugf("Error deteniendo contenedor containerd %s: %v", containerID, err)
+	}
+	return client.RemoveContainer(context.Background(), containerID)
+}
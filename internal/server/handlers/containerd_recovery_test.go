@@ -0,0 +1,43 @@
+package handlers
+
+import "testing"
+
+func TestContainerdBuildCommand(t *testing.T) {
+	tests := []struct {
+		name                           string
+		path, goPackage, tags, ldflags string
+		want                           string
+	}{
+		{
+			name:      "raíz",
+			goPackage: ".",
+			want:      "cd /app/src && go mod tidy && go build -v -o /app/app .",
+		},
+		{
+			name:      "path y paquete de cmd",
+			path:      "services/api",
+			goPackage: "./cmd/api",
+			want:      "cd /app/src/services/api && go mod tidy && go build -v -o /app/app ./cmd/api",
+		},
+		{
+			name:      "tags y ldflags",
+			goPackage: ".",
+			tags:      "netgo,osusergo",
+			ldflags:   "-s -w -X main.version=1.2.0",
+			want:      "cd /app/src && go mod tidy && go build -v -tags 'netgo,osusergo' -ldflags '-s -w -X main.version=1.2.0' -o /app/app .",
+		},
+		{
+			name:      "comillas en ldflags",
+			goPackage: ".",
+			ldflags:   "-X 'main.name=it's'",
+			want:      `cd /app/src && go mod tidy && go build -v -ldflags '-X '\''main.name=it'\''s'\''' -o /app/app .`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := containerdBuildCommand(tt.path, tt.goPackage, tt.tags, tt.ldflags); got != tt.want {
+				t.Errorf("containerdBuildCommand = %q, se esperaba %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -11,14 +11,16 @@ import (
 	"os"
 )
 
+// errEncryptionKeyMissing indica que no se definió DIPLO_ENCRYPTION_KEY. No hay
+// clave por defecto: una publicada en el repo no protegería los secretos.
+var errEncryptionKeyMissing = errors.New("DIPLO_ENCRYPTION_KEY no está definida: define una clave de 32 caracteres para cifrar secretos")
+
 // getEncryptionKey obtiene la clave de cifrado desde variables de entorno
 // En producción, esto debería ser gestionado de forma más segura
 func getEncryptionKey() ([]byte, error) {
 	key := os.Getenv("DIPLO_ENCRYPTION_KEY")
 	if key == "" {
-		// Generar una clave por defecto para desarrollo
-		// En producción, esto debería fallar si no se proporciona una clave
-		key = "diplo-default-key-32-chars-long"
+		return nil, errEncryptionKeyMissing
 	}
 
 	if len(key) != 32 {
@@ -28,6 +30,12 @@ func getEncryptionKey() ([]byte, error) {
 	return []byte(key), nil
 }
 
+// CheckEncryptionKey valida DIPLO_ENCRYPTION_KEY al iniciar el servidor
+func CheckEncryptionKey() error {
+	_, err := getEncryptionKey()
+	return err
+}
+
 // encryptValue cifra un valor usando AES-GCM
 func encryptValue(plaintext string) (string, error) {
 	if plaintext == "" {
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEncryptValueWithoutKey(t *testing.T) {
+	t.Setenv("DIPLO_ENCRYPTION_KEY", "")
+
+	if _, err := encryptValue("secreto"); !errors.Is(err, errEncryptionKeyMissing) {
+		t.Fatalf("encryptValue err = %v, se esperaba %v", err, errEncryptionKeyMissing)
+	}
+	if err := CheckEncryptionKey(); !errors.Is(err, errEncryptionKeyMissing) {
+		t.Errorf("CheckEncryptionKey err = %v, se esperaba %v", err, errEncryptionKeyMissing)
+	}
+}
+
+func TestEncryptValueWithInvalidKeyLength(t *testing.T) {
+	t.Setenv("DIPLO_ENCRYPTION_KEY", "demasiado-corta")
+
+	if _, err := encryptValue("secreto"); err == nil {
+		t.Fatal("encryptValue con una clave de 15 caracteres no falló")
+	}
+}
+
+func TestEncryptValueRoundTrip(t *testing.T) {
+	t.Setenv("DIPLO_ENCRYPTION_KEY", "0123456789abcdef0123456789abcdef")
+
+	encrypted, err := encryptValue("secreto")
+	if err != nil {
+		t.Fatalf("encryptValue: %v", err)
+	}
+	decrypted, err := decryptValue(encrypted)
+	if err != nil {
+		t.Fatalf("decryptValue: %v", err)
+	}
+	if decrypted != "secreto" {
+		t.Errorf("decryptValue = %q, se esperaba %q", decrypted, "secreto")
+	}
+}
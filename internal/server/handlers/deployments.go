@@ -0,0 +1,446 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rodrwan/diplo/internal/database"
+	"github.com/rodrwan/diplo/internal/dto"
+	"github.com/rodrwan/diplo/internal/models"
+	runtimePkg "github.com/rodrwan/diplo/internal/runtime"
+	"github.com/sirupsen/logrus"
+)
+
+// Origen de un deployment
+const (
+	DeploymentTriggerAPI          = "api"
+	DeploymentTriggerRollback     = "rollback"
+	DeploymentTriggerAutoRollback = "auto_rollback"
+	DeploymentTriggerGitPush      = "git_push"
+	DeploymentTriggerWebhook      = "webhook"
+	DeploymentTriggerPoll         = "git_poll"
+)
+
+const (
+	// maxBuildLogBytes limita el log de build guardado por deployment
+	maxBuildLogBytes = 256 * 1024
+	// defaultDeploymentsLimit es el tamaño por defecto del historial
+	defaultDeploymentsLimit = 20
+	maxDeploymentsLimit     = 200
+)
+
+// deploymentStep es un paso cronometrado de un deployment
+type deploymentStep struct {
+	Name       string     `json:"name"`
+	Status     string     `json:"status"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	DurationMs int64      `json:"duration_ms"`
+}
+
+// deploymentRecorder acumula pasos y log de un deployment en curso y los
+// persiste en la tabla deployments
+type deploymentRecorder struct {
+	mu        sync.Mutex
+	queries   database.Querier
+	id        int64
+	runtime   string
+	commitSHA string
+	imageTag  string
+	// dockerfile es el origen del Dockerfile: "repo:<ruta>" o "template:<lenguaje>"
+	dockerfile string
+	// language es la detección de lenguaje en JSON (runtime.LanguageDetection)
+	language  string
+	envSnap   string
+	errorMsg  string
+	startedAt time.Time
+	steps     []deploymentStep
+	buildLog  strings.Builder
+	truncated bool
+}
+
+// activeDeployments indexa por app ID el deployment en curso
+var activeDeployments sync.Map
+
+// beginDeployment registra un deployment nuevo para la app; los helpers
+// recordDeployment* lo alimentan hasta que finishDeployment lo cierra. Sin
+// commitSHA, el commit se guarda al preparar el código (recordDeploymentCommit).
+func beginDeployment(queries database.Querier, appID, trigger, runtime, commitSHA string) {
+	now := time.Now()
+	id, err := queries.CreateDeployment(context.Background(), database.CreateDeploymentParams{
+		AppID:       appID,
+		TriggeredBy: trigger,
+		Runtime:     runtime,
+		Status:      database.DeploymentRunning,
+		StartedAt:   now,
+	})
+	if err != nil {
+		logrus.Errorf("Error registrando deployment de la app %s: %v", appID, err)
+		return
+	}
+
+	recorder := &deploymentRecorder{
+		queries:   queries,
+		id:        id,
+		runtime:   runtime,
+		commitSHA: commitSHA,
+		startedAt: now,
+	}
+	activeDeployments.Store(appID, recorder)
+	recorder.save()
+}
+
+func activeDeployment(appID string) *deploymentRecorder {
+	if value, ok := activeDeployments.Load(appID); ok {
+		return value.(*deploymentRecorder)
+	}
+	return nil
+}
+
+// recordDeploymentStep cierra el paso en curso y abre uno nuevo
+func recordDeploymentStep(appID, name string) {
+	recorder := activeDeployment(appID)
+	if recorder == nil {
+		return
+	}
+
+	recorder.mu.Lock()
+	now := time.Now()
+	recorder.closeStep(now, database.DeploymentSucceeded)
+	recorder.steps = append(recorder.steps, deploymentStep{
+		Name:      name,
+		Status:    database.DeploymentRunning,
+		StartedAt: now,
+	})
+	recorder.mu.Unlock()
+
+	recorder.save()
+}
+
+// recordDeploymentRuntime guarda el runtime efectivo (p. ej. tras un fallback a Docker)
+func recordDeploymentRuntime(appID, runtime string) {
+	if recorder := activeDeployment(appID); recorder != nil {
+		recorder.mu.Lock()
+		recorder.runtime = runtime
+		recorder.mu.Unlock()
+	}
+}
+
+// recordDeploymentCommit guarda el commit que se construye, una vez resuelto el ref
+func recordDeploymentCommit(appID, commitSHA string) {
+	if recorder := activeDeployment(appID); recorder != nil {
+		recorder.mu.Lock()
+		recorder.commitSHA = commitSHA
+		recorder.mu.Unlock()
+	}
+}
+
+// recordDeploymentImage guarda la imagen desplegada
+func recordDeploymentImage(appID, imageTag string) {
+	if recorder := activeDeployment(appID); recorder != nil {
+		recorder.mu.Lock()
+		recorder.imageTag = imageTag
+		recorder.mu.Unlock()
+	}
+}
+
+// recordDeploymentDockerfile guarda de dónde salió el Dockerfile construido
+func recordDeploymentDockerfile(appID, origin string) {
+	if recorder := activeDeployment(appID); recorder != nil {
+		recorder.mu.Lock()
+		recorder.dockerfile = origin
+		recorder.mu.Unlock()
+	}
+}
+
+// recordDeploymentLanguage guarda el lenguaje elegido y los candidatos detectados
+func recordDeploymentLanguage(appID string, detection runtimePkg.LanguageDetection) {
+	data, err := json.Marshal(detection)
+	if err != nil {
+		logrus.Warnf("Error serializando la detección de lenguaje de %s: %v", appID, err)
+		return
+	}
+	if recorder := activeDeployment(appID); recorder != nil {
+		recorder.mu.Lock()
+		recorder.language = string(data)
+		recorder.mu.Unlock()
+	}
+}
+
+// recordDeploymentEnv guarda, cifradas, las variables de entorno del deployment
+// para poder repetirlo en un rollback
+func recordDeploymentEnv(appID string, envVars []models.EnvVar) {
+	recorder := activeDeployment(appID)
+	if recorder == nil {
+		return
+	}
+
+	data, err := json.Marshal(envVars)
+	if err != nil {
+		logrus.Warnf("Error serializando variables de entorno del deployment: %v", err)
+		return
+	}
+	encrypted, err := encryptValue(string(data))
+	if err != nil {
+		logrus.Warnf("Error cifrando variables de entorno del deployment: %v", err)
+		return
+	}
+
+	recorder.mu.Lock()
+	recorder.envSnap = encrypted
+	recorder.mu.Unlock()
+}
+
+// deploymentEnvVars descifra las variables de entorno guardadas en un deployment
+func deploymentEnvVars(deployment database.Deployment) ([]models.EnvVar, error) {
+	if !deployment.EnvSnapshot.Valid || deployment.EnvSnapshot.String == "" {
+		return nil, fmt.Errorf("el deployment %d no tiene variables de entorno guardadas", deployment.ID)
+	}
+	data, err := decryptValue(deployment.EnvSnapshot.String)
+	if err != nil {
+		return nil, err
+	}
+	var envVars []models.EnvVar
+	if err := json.Unmarshal([]byte(data), &envVars); err != nil {
+		return nil, err
+	}
+	return envVars, nil
+}
+
+// logWatchers reparte los logs de deploy de cada app a quienes los siguen
+// fuera del SSE, como la respuesta de un git push
+var (
+	logWatchersMu sync.Mutex
+	logWatchers   = make(map[string]map[chan string]struct{})
+)
+
+// watchDeploymentLogs suscribe a los logs de deploy de la app; la función
+// devuelta cancela la suscripción. Si el lector se atrasa se descartan líneas.
+func watchDeploymentLogs(appID string) (<-chan string, func()) {
+	ch := make(chan string, 64)
+
+	logWatchersMu.Lock()
+	if logWatchers[appID] == nil {
+		logWatchers[appID] = make(map[chan string]struct{})
+	}
+	logWatchers[appID][ch] = struct{}{}
+	logWatchersMu.Unlock()
+
+	return ch, func() {
+		logWatchersMu.Lock()
+		delete(logWatchers[appID], ch)
+		if len(logWatchers[appID]) == 0 {
+			delete(logWatchers, appID)
+		}
+		logWatchersMu.Unlock()
+	}
+}
+
+func notifyLogWatchers(appID, line string) {
+	logWatchersMu.Lock()
+	defer logWatchersMu.Unlock()
+
+	for ch := range logWatchers[appID] {
+		select {
+		case ch <- line:
+		default:
+		}
+	}
+}
+
+// recordDeploymentLog añade una línea al log de build
+func recordDeploymentLog(appID, logType, message string) {
+	notifyLogWatchers(appID, fmt.Sprintf("[%s] %s", logType, message))
+
+	recorder := activeDeployment(appID)
+	if recorder == nil {
+		return
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	if recorder.truncated {
+		return
+	}
+	line := fmt.Sprintf("[%s] [%s] %s\n", time.Now().Format("15:04:05"), logType, message)
+	if recorder.buildLog.Len()+len(line) > maxBuildLogBytes {
+		recorder.buildLog.WriteString("... log truncado\n")
+		recorder.truncated = true
+		return
+	}
+	recorder.buildLog.WriteString(line)
+}
+
+// finishDeployment cierra el deployment en curso de la app; deployErr es el
+// error con el que terminó el deploy, nil si salió bien. Si falló devuelve el
+// paso en el que falló.
+func finishDeployment(appID string, deployErr error) string {
+	value, ok := activeDeployments.LoadAndDelete(appID)
+	if !ok {
+		return ""
+	}
+	recorder := value.(*deploymentRecorder)
+
+	recorder.mu.Lock()
+	now := time.Now()
+	status := database.DeploymentSucceeded
+	if deployErr != nil {
+		status = database.DeploymentFailed
+		recorder.errorMsg = deployErr.Error()
+	}
+	recorder.closeStep(now, status)
+	failedStep := ""
+	if status == database.DeploymentFailed && len(recorder.steps) > 0 {
+		failedStep = recorder.steps[len(recorder.steps)-1].Name
+	}
+	recorder.mu.Unlock()
+
+	recorder.persist(status, sql.NullTime{Time: now, Valid: true},
+		sql.NullInt64{Int64: now.Sub(recorder.startedAt).Milliseconds(), Valid: true})
+	logrus.Infof("Deployment %d de la app %s finalizado: %s", recorder.id, appID, status)
+	return failedStep
+}
+
+// closeStep cierra el último paso si sigue en curso; requiere mu tomado
+func (r *deploymentRecorder) closeStep(now time.Time, status string) {
+	if len(r.steps) == 0 {
+		return
+	}
+	last := &r.steps[len(r.steps)-1]
+	if last.FinishedAt != nil {
+		return
+	}
+	last.FinishedAt = &now
+	last.DurationMs = now.Sub(last.StartedAt).Milliseconds()
+	last.Status = status
+}
+
+// save persiste el progreso de un deployment que sigue en curso
+func (r *deploymentRecorder) save() {
+	r.persist(database.DeploymentRunning, sql.NullTime{}, sql.NullInt64{})
+}
+
+func (r *deploymentRecorder) persist(status string, finishedAt sql.NullTime, duration sql.NullInt64) {
+	r.mu.Lock()
+	steps, err := json.Marshal(r.steps)
+	if err != nil {
+		logrus.Warnf("Error serializando pasos del deployment %d: %v", r.id, err)
+	}
+	params := database.UpdateDeploymentParams{
+		CommitSha:         sql.NullString{String: r.commitSHA, Valid: r.commitSHA != ""},
+		ImageTag:          sql.NullString{String: r.imageTag, Valid: r.imageTag != ""},
+		Runtime:           r.runtime,
+		Status:            status,
+		ErrorMsg:          sql.NullString{String: r.errorMsg, Valid: r.errorMsg != ""},
+		Steps:             sql.NullString{String: string(steps), Valid: err == nil},
+		BuildLog:          sql.NullString{String: r.buildLog.String(), Valid: true},
+		FinishedAt:        finishedAt,
+		DurationMs:        duration,
+		EnvSnapshot:       sql.NullString{String: r.envSnap, Valid: r.envSnap != ""},
+		Dockerfile:        sql.NullString{String: r.dockerfile, Valid: r.dockerfile != ""},
+		ID:                r.id,
+		LanguageDetection: sql.NullString{String: r.language, Valid: r.language != ""},
+	}
+	r.mu.Unlock()
+
+	if err := r.queries.UpdateDeployment(context.Background(), params); err != nil {
+		logrus.Errorf("Error guardando deployment %d: %v", r.id, err)
+	}
+}
+
+// ListAppDeploymentsHandler devuelve el historial de deployments de una app
+func ListAppDeploymentsHandler(ctx *Context, w http.ResponseWriter, r *http.Request) (Response, error) {
+	appID := mux.Vars(r)["id"]
+
+	if _, err := ctx.queries.GetApp(r.Context(), appID); err != nil {
+		if err == sql.ErrNoRows {
+			return Response{Code: http.StatusNotFound, Message: "Aplicación no encontrada"}, nil
+		}
+		return Response{Code: http.StatusInternalServerError, Message: "Error obteniendo aplicación"}, err
+	}
+
+	limit := int64(defaultDeploymentsLimit)
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed <= 0 {
+			return Response{Code: http.StatusBadRequest, Message: "limit inválido"}, nil
+		}
+		limit = min(parsed, maxDeploymentsLimit)
+	}
+
+	deployments, err := ctx.queries.ListAppDeployments(r.Context(), database.ListAppDeploymentsParams{
+		AppID: appID,
+		Limit: limit,
+	})
+	if err != nil {
+		return Response{Code: http.StatusInternalServerError, Message: "Error obteniendo deployments"}, err
+	}
+
+	// El listado omite el log de build; se obtiene con GET /api/v1/deployments/{id}
+	result := make([]dto.Deployment, 0, len(deployments))
+	for _, deployment := range deployments {
+		item := deploymentDTO(deployment)
+		item.BuildLog = ""
+		result = append(result, item)
+	}
+
+	return Response{Code: http.StatusOK, Data: result}, nil
+}
+
+// GetDeploymentHandler devuelve un deployment con sus pasos y log de build
+func GetDeploymentHandler(ctx *Context, w http.ResponseWriter, r *http.Request) (Response, error) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		return Response{Code: http.StatusBadRequest, Message: "ID de deployment inválido"}, nil
+	}
+
+	deployment, err := ctx.queries.GetDeployment(r.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Response{Code: http.StatusNotFound, Message: "Deployment no encontrado"}, nil
+		}
+		return Response{Code: http.StatusInternalServerError, Message: "Error obteniendo deployment"}, err
+	}
+
+	return Response{Code: http.StatusOK, Data: deploymentDTO(deployment)}, nil
+}
+
+func deploymentDTO(deployment database.Deployment) dto.Deployment {
+	item := dto.Deployment{
+		ID:          deployment.ID,
+		AppID:       deployment.AppID,
+		TriggeredBy: deployment.TriggeredBy,
+		CommitSHA:   deployment.CommitSha.String,
+		ImageTag:    deployment.ImageTag.String,
+		Dockerfile:  deployment.Dockerfile.String,
+		Runtime:     deployment.Runtime,
+		Status:      deployment.Status,
+		Error:       deployment.ErrorMsg.String,
+		Steps:       json.RawMessage("[]"),
+		BuildLog:    deployment.BuildLog.String,
+		StartedAt:   deployment.StartedAt.Format(time.RFC3339),
+		CanRollback: canRollbackTo(deployment),
+	}
+	if deployment.Steps.Valid && deployment.Steps.String != "" && deployment.Steps.String != "null" {
+		item.Steps = json.RawMessage(deployment.Steps.String)
+	}
+	if deployment.LanguageDetection.Valid && deployment.LanguageDetection.String != "" {
+		item.Language = json.RawMessage(deployment.LanguageDetection.String)
+	}
+	if deployment.FinishedAt.Valid {
+		item.FinishedAt = deployment.FinishedAt.Time.Format(time.RFC3339)
+	}
+	if deployment.DurationMs.Valid {
+		item.DurationMs = deployment.DurationMs.Int64
+	}
+	return item
+}
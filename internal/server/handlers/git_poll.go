@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rodrwan/diplo/internal/database"
+	"github.com/rodrwan/diplo/internal/dto"
+	"github.com/rodrwan/diplo/internal/gitserver"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// gitPollTick es cada cuánto el poller busca apps cuyo intervalo venció
+	gitPollTick = 15 * time.Second
+	// defaultGitPollInterval y minGitPollInterval acotan el intervalo por app
+	defaultGitPollInterval = 5 * time.Minute
+	minGitPollInterval     = 30 * time.Second
+	// defaultGitPollBranch es la rama que se revisa si no se configura otra
+	defaultGitPollBranch = "main"
+)
+
+// GitPoller revisa con git ls-remote la rama de las apps con polling activado
+// y encola un redeploy cuando aparece un commit nuevo. Sirve para repos que no
+// pueden enviar webhooks, p. ej. con Diplo detrás de NAT.
+type GitPoller struct {
+	ctx *Context
+}
+
+// NewGitPoller crea el poller; encola los redeploys en la cola de ctx
+func NewGitPoller(ctx *Context) *GitPoller {
+	return &GitPoller{ctx: ctx}
+}
+
+// Start ejecuta el loop de polling hasta que el contexto se cancele
+func (p *GitPoller) Start(ctx context.Context) {
+	logrus.Infof("🔎 Polling de repositorios git cada %s", gitPollTick)
+	ticker := time.NewTicker(gitPollTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("Deteniendo polling de repositorios git")
+			return
+		case <-ticker.C:
+			p.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce revisa las apps cuyo intervalo de polling venció
+func (p *GitPoller) RunOnce(ctx context.Context) {
+	polls, err := p.ctx.queries.ListAppGitPolls(ctx)
+	if err != nil {
+		logrus.Errorf("Error obteniendo apps con polling: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, poll := range polls {
+		if ctx.Err() != nil {
+			return
+		}
+		interval := time.Duration(poll.IntervalSeconds) * time.Second
+		if poll.LastCheckedAt.Valid && now.Sub(poll.LastCheckedAt.Time) < interval {
+			continue
+		}
+		p.check(ctx, poll)
+	}
+}
+
+// check compara el commit de la rama remota con el último visto. Si cambió y
+// no es el que está corriendo, encola un redeploy de ese commit. El último
+// commit visto evita redesplegar tras un rollback manual mientras la rama no avance.
+func (p *GitPoller) check(ctx context.Context, poll database.AppGitPoll) {
+	app, err := p.ctx.queries.GetApp(ctx, poll.AppID)
+	if err != nil {
+		logrus.Warnf("Polling de la app %s: %v", poll.AppID, err)
+		return
+	}
+
+	// Con un deploy pendiente se revisa en la siguiente vuelta, cuando ya haya terminado
+	pending, err := p.ctx.queries.CountPendingAppJobs(ctx, app.ID)
+	if err != nil || pending > 0 {
+		return
+	}
+
+	result := database.UpdateAppGitPollCheckParams{
+		LastCommit:    poll.LastCommit,
+		LastCheckedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		AppID:         app.ID,
+	}
+	defer func() {
+		if err := p.ctx.queries.UpdateAppGitPollCheck(context.Background(), result); err != nil {
+			logrus.Errorf("Error guardando polling de la app %s: %v", app.ID, err)
+		}
+	}()
+
+	gitHubToken := ""
+	if poll.GithubToken.Valid {
+		if gitHubToken, err = decryptValue(poll.GithubToken.String); err != nil {
+			result.LastError = sql.NullString{String: fmt.Sprintf("error descifrando token: %v", err), Valid: true}
+			return
+		}
+	}
+
+	// Pollings configurados antes de validar la rama contra el ref de la app
+	if _, err := pinnedBranch(&app, poll.Branch); err != nil {
+		result.LastError = sql.NullString{String: err.Error(), Valid: true}
+		return
+	}
+
+	commit, err := gitserver.LsRemote(ctx, repoURLWithToken(app.RepoUrl, gitHubToken), "refs/heads/"+poll.Branch)
+	if err != nil {
+		result.LastError = sql.NullString{String: fmt.Sprintf("error consultando la rama %s: %v", poll.Branch, err), Valid: true}
+		logrus.Warnf("Polling de la app %s: %s", app.ID, result.LastError.String)
+		return
+	}
+	if commit == poll.LastCommit.String {
+		return
+	}
+	result.LastCommit = sql.NullString{String: commit, Valid: true}
+
+	current, err := p.ctx.queries.GetCurrentDeployment(ctx, app.ID)
+	if err == nil && current.CommitSha.String == commit {
+		return
+	}
+
+	job, _, err := enqueueAppDeployJob(ctx, p.ctx, app.ID, JobKindRedeploy, DeployConflictQueue, deployJobPayload{
+		deployOptions: deployOptions{GitHubToken: gitHubToken, Trigger: DeploymentTriggerPoll, CommitSHA: commit},
+	})
+	if err != nil {
+		// Sin encolar no se da el commit por visto: se reintenta en la siguiente vuelta
+		result.LastCommit = poll.LastCommit
+		result.LastError = sql.NullString{String: fmt.Sprintf("error encolando redeploy: %v", err), Valid: true}
+		return
+	}
+	logrus.Infof("Polling: nuevo commit %s en %s de la app %s, trabajo %s", commit, poll.Branch, app.ID, job.ID)
+}
+
+// ConfigureGitPollingRequest es el cuerpo de PUT /api/v1/apps/{id}/polling
+type ConfigureGitPollingRequest struct {
+	Branch string `json:"branch"`
+	// Interval es una duración de Go, p. ej. "5m" (mínimo 30s)
+	Interval    string `json:"interval"`
+	GitHubToken string `json:"github_token,omitempty"`
+}
+
+// ConfigureGitPollingHandler activa o actualiza el polling de la rama de la app
+func ConfigureGitPollingHandler(ctx *Context, w http.ResponseWriter, r *http.Request) (Response, error) {
+	app, err := ctx.queries.GetApp(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		return Response{Code: http.StatusNotFound, Message: "Aplicación no encontrada"}, nil
+	}
+	if ctx.gitRepos.Hosts(app.RepoUrl) {
+		return Response{Code: http.StatusBadRequest, Message: "Las apps desplegadas con git push no necesitan polling"}, nil
+	}
+	if isImageApp(&app) {
+		return Response{Code: http.StatusBadRequest, Message: "Las apps desplegadas desde una imagen no tienen repositorio: redespliega con la nueva imagen"}, nil
+	}
+
+	var req ConfigureGitPollingRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return Response{Code: http.StatusBadRequest, Message: "JSON inválido"}, nil
+		}
+	}
+
+	req.Branch = strings.TrimPrefix(strings.TrimSpace(req.Branch), "refs/heads/")
+	if req.Branch, err = pinnedBranch(&app, req.Branch); err != nil {
+		return Response{Code: http.StatusBadRequest, Message: err.Error()}, nil
+	}
+	if req.Branch == "" {
+		req.Branch = defaultGitPollBranch
+	}
+	interval := defaultGitPollInterval
+	if req.Interval != "" {
+		if interval, err = time.ParseDuration(req.Interval); err != nil {
+			return Response{Code: http.StatusBadRequest, Message: fmt.Sprintf("interval inválido: %q", req.Interval)}, nil
+		}
+		if interval < minGitPollInterval {
+			return Response{Code: http.StatusBadRequest, Message: fmt.Sprintf("interval debe ser de al menos %s", minGitPollInterval)}, nil
+		}
+	}
+
+	var token sql.NullString
+	if req.GitHubToken != "" {
+		encrypted, err := encryptValue(req.GitHubToken)
+		if err != nil {
+			return Response{Code: http.StatusInternalServerError, Message: "Error cifrando token"}, err
+		}
+		token = sql.NullString{String: encrypted, Valid: true}
+	}
+
+	// Validar la rama antes de guardar para no dejar un polling que siempre falla
+	if _, err := gitserver.LsRemote(r.Context(), repoURLWithToken(app.RepoUrl, req.GitHubToken), "refs/heads/"+req.Branch); err != nil {
+		return Response{Code: http.StatusBadRequest, Message: fmt.Sprintf("No se pudo consultar la rama %s: %v", req.Branch, err)}, nil
+	}
+
+	if err := ctx.queries.UpsertAppGitPoll(r.Context(), database.UpsertAppGitPollParams{
+		AppID:           app.ID,
+		Branch:          req.Branch,
+		IntervalSeconds: int64(interval / time.Second),
+		GithubToken:     token,
+		CreatedAt:       time.Now(),
+	}); err != nil {
+		return Response{Code: http.StatusInternalServerError, Message: "Error guardando polling"}, err
+	}
+
+	poll, err := ctx.queries.GetAppGitPoll(r.Context(), app.ID)
+	if err != nil {
+		return Response{Code: http.StatusInternalServerError, Message: "Error obteniendo polling"}, err
+	}
+
+	logrus.Infof("Polling de la app %s activado para la rama %s cada %s", app.ID, req.Branch, interval)
+	return Response{Code: http.StatusOK, Data: gitPollDTO(poll), Message: "Polling activado"}, nil
+}
+
+// GetGitPollingHandler devuelve la configuración y la última revisión del polling
+func GetGitPollingHandler(ctx *Context, w http.ResponseWriter, r *http.Request) (Response, error) {
+	poll, err := ctx.queries.GetAppGitPoll(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Response{Code: http.StatusNotFound, Message: "La aplicación no tiene polling"}, nil
+		}
+		return Response{Code: http.StatusInternalServerError, Message: "Error obteniendo polling"}, err
+	}
+
+	return Response{Code: http.StatusOK, Data: gitPollDTO(poll)}, nil
+}
+
+// DeleteGitPollingHandler desactiva el polling de la app
+func DeleteGitPollingHandler(ctx *Context, w http.ResponseWriter, r *http.Request) (Response, error) {
+	deleted, err := ctx.queries.DeleteAppGitPoll(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		return Response{Code: http.StatusInternalServerError, Message: "Error eliminando polling"}, err
+	}
+	if deleted == 0 {
+		return Response{Code: http.StatusNotFound, Message: "La aplicación no tiene polling"}, nil
+	}
+
+	return Response{Code: http.StatusOK, Message: "Polling desactivado"}, nil
+}
+
+func gitPollDTO(poll database.AppGitPoll) dto.GitPolling {
+	item := dto.GitPolling{
+		AppID:      poll.AppID,
+		Branch:     poll.Branch,
+		Interval:   (time.Duration(poll.IntervalSeconds) * time.Second).String(),
+		HasToken:   poll.GithubToken.Valid,
+		LastCommit: poll.LastCommit.String,
+		LastError:  poll.LastError.String,
+		CreatedAt:  poll.CreatedAt.Format(time.RFC3339),
+	}
+	if poll.LastCheckedAt.Valid {
+		item.LastCheckedAt = poll.LastCheckedAt.Time.Format(time.RFC3339)
+	}
+	return item
+}
@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rodrwan/diplo/internal/database"
+	"github.com/rodrwan/diplo/internal/gitserver"
+	"github.com/sirupsen/logrus"
+)
+
+// pushJobPollInterval es cada cuánto se revisa el trabajo de un push mientras
+// se transmite su progreso al cliente git
+const pushJobPollInterval = time.Second
+
+// defaultDeployBranches son las ramas que despliegan la app sin ref fijado;
+// las apps con ref se despliegan con los push a esa rama
+var defaultDeployBranches = []string{"main", "master"}
+
+// GitInfoRefsHandler responde GET /git/{app}.git/info/refs, el primer paso de git push
+func GitInfoRefsHandler(ctx *HybridContext, w http.ResponseWriter, r *http.Request) error {
+	name, ok := authorizeGitRequest(ctx.Context, w, r)
+	if !ok {
+		return nil
+	}
+	if r.URL.Query().Get("service") != "git-receive-pack" {
+		http.Error(w, "Diplo solo admite git push", http.StatusForbidden)
+		return nil
+	}
+
+	repoDir, err := ctx.gitRepos.Init(r.Context(), name)
+	if err != nil {
+		http.Error(w, "Error preparando repositorio", http.StatusInternalServerError)
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/x-git-receive-pack-advertisement")
+	w.Header().Set("Cache-Control", "no-cache")
+	return gitserver.AdvertiseReceivePack(r.Context(), w, repoDir)
+}
+
+// GitReceivePackHandler responde POST /git/{app}.git/git-receive-pack: guarda
+// los commits, encola el deploy de cada rama aceptada que despliega una app y
+// transmite su progreso como mensajes "remote:" hasta que termina
+func GitReceivePackHandler(ctx *HybridContext, w http.ResponseWriter, r *http.Request) error {
+	name, ok := authorizeGitRequest(ctx.Context, w, r)
+	if !ok {
+		return nil
+	}
+
+	// El push y el deploy pueden tardar más que los timeouts del servidor
+	controller := http.NewResponseController(w)
+	controller.SetReadDeadline(time.Time{})
+	controller.SetWriteDeadline(time.Time{})
+
+	repoDir, err := ctx.gitRepos.Init(r.Context(), name)
+	if err != nil {
+		http.Error(w, "Error preparando repositorio", http.StatusInternalServerError)
+		return err
+	}
+
+	push, err := gitserver.ReceivePack(r.Context(), repoDir, r.Body, r.Header.Get("Content-Encoding"))
+	if err != nil {
+		http.Error(w, "Error recibiendo push", http.StatusInternalServerError)
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/x-git-receive-pack-result")
+	w.Header().Set("Cache-Control", "no-cache")
+	if err := push.WriteStatus(w); err != nil {
+		return err
+	}
+	defer push.Close(w)
+
+	progress := func(format string, args ...interface{}) {
+		push.Progress(w, fmt.Sprintf(format, args...))
+		controller.Flush()
+	}
+
+	for _, update := range push.Updates {
+		branch, isBranch := update.Branch()
+		if !isBranch || update.Deleted() || !update.OK {
+			continue
+		}
+
+		app, created, err := appForPushedBranch(r.Context(), ctx.Context, name, repoDir, branch)
+		if errors.Is(err, sql.ErrNoRows) {
+			progress("Rama %s recibida; se despliegan %v y las ramas fijadas como ref de una app", branch, defaultDeployBranches)
+			continue
+		}
+		if err != nil {
+			progress("Error preparando la aplicación: %v", err)
+			return err
+		}
+		if app.Ref.String == "" {
+			if err := gitserver.SetHead(r.Context(), repoDir, branch); err != nil {
+				logrus.Warnf("Error actualizando HEAD de %s: %v", repoDir, err)
+			}
+		}
+
+		if err := deployPushedCommit(r.Context(), ctx, progress, app, created, branch, update.New); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appForPushedBranch devuelve la app del repositorio alojado que despliega
+// branch: la fijada a esa rama o, con main y master, la que no tiene ref, que
+// se crea en el primer push. Devuelve sql.ErrNoRows si ninguna la despliega.
+func appForPushedBranch(ctx context.Context, c *Context, name, repoDir, branch string) (database.App, bool, error) {
+	app, err := findAppForDeploy(ctx, c.queries, repoDir, "", branch)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return app, false, err
+	}
+	if !slices.Contains(defaultDeployBranches, branch) {
+		return database.App{}, false, sql.ErrNoRows
+	}
+	return appForHostedRepo(ctx, c, name, repoDir)
+}
+
+// deployPushedCommit encola el deploy del commit enviado y transmite su
+// progreso al cliente git hasta que termina
+func deployPushedCommit(ctx context.Context, c *HybridContext, progress func(string, ...interface{}), app database.App, created bool, branch, commit string) error {
+	// Suscribirse antes de encolar para no perder los primeros logs
+	logs, stop := watchDeploymentLogs(app.ID)
+	defer stop()
+
+	kind := JobKindRedeploy
+	if created {
+		kind = JobKindDeploy
+	}
+	job, ahead, err := enqueueAppDeployJob(ctx, c.Context, app.ID, kind, DeployConflictQueue, deployJobPayload{
+		deployOptions: deployOptions{Trigger: DeploymentTriggerGitPush, CommitSHA: commit},
+	})
+	if err != nil {
+		if created {
+			handleUnifiedDeployError(c, &app, fmt.Sprintf("Error encolando deployment: %v", err))
+		}
+		progress("Error encolando deploy: %v", err)
+		return err
+	}
+
+	logrus.Infof("Push a %s (%s) encoló el trabajo %s para %s", app.Name, branch, job.ID, commit)
+	progress("Deploy de %s (%s) encolado como trabajo %s", commit[:7], branch, job.ID)
+	if ahead > 0 {
+		progress("Esperando %d deploys anteriores de la app...", ahead)
+	}
+
+	streamPushDeploy(ctx, c.Context, progress, logs, job.ID, ahead > 0)
+
+	final, err := c.queries.GetJob(context.Background(), job.ID)
+	if err != nil {
+		return err
+	}
+	switch final.Status {
+	case database.JobSucceeded:
+		if current, err := c.queries.GetApp(context.Background(), app.ID); err == nil {
+			progress("Aplicación disponible en: %s", appURL(c.Context, &current))
+		}
+	case database.JobFailed, database.JobCancelled:
+		progress("Deploy %s: %s", final.Status, final.ErrorMsg.String)
+	default:
+		progress("El deploy sigue en curso; consulta GET /api/v1/jobs/%s", job.ID)
+	}
+	return nil
+}
+
+// authorizeGitRequest valida el token y el nombre del repositorio; si falla
+// responde al cliente git y devuelve false
+func authorizeGitRequest(ctx *Context, w http.ResponseWriter, r *http.Request) (string, bool) {
+	if ctx.gitRepos == nil {
+		http.NotFound(w, r)
+		return "", false
+	}
+
+	if _, err := authenticateToken(ctx, r); err != nil {
+		if err != errInvalidToken {
+			logrus.Errorf("Error validando token de git push: %v", err)
+		}
+		w.Header().Set("WWW-Authenticate", `Basic realm="Diplo"`)
+		http.Error(w, "Se requiere un token de API de Diplo", http.StatusUnauthorized)
+		return "", false
+	}
+
+	name := mux.Vars(r)["app"]
+	if !gitserver.ValidName(name) {
+		http.Error(w, "Nombre de repositorio inválido: usa minúsculas, números y guiones", http.StatusNotFound)
+		return "", false
+	}
+	return name, true
+}
+
+// appForHostedRepo devuelve la app asociada al repositorio alojado o la crea
+// en el primer push
+func appForHostedRepo(ctx context.Context, c *Context, name, repoDir string) (database.App, bool, error) {
+	app, err := findAppForDeploy(ctx, c.queries, repoDir, "", "HEAD")
+	if err == nil {
+		return app, false, nil
+	}
+	if err != sql.ErrNoRows {
+		return database.App{}, false, err
+	}
+
+	port, err := findFreePort()
+	if err != nil {
+		return database.App{}, false, fmt.Errorf("no hay puertos disponibles: %v", err)
+	}
+
+	app = database.App{
+		ID:       database.GenerateAppID(),
+		Name:     name,
+		RepoUrl:  repoDir,
+		Language: sql.NullString{String: "unknown", Valid: true},
+		Port:     int64(port),
+		Status:   database.StatusDeploying,
+	}
+	if err := c.queries.CreateApp(ctx, database.CreateAppParams{
+		ID:       app.ID,
+		Name:     app.Name,
+		RepoUrl:  app.RepoUrl,
+		Language: app.Language,
+		Port:     app.Port,
+		Status:   app.Status,
+	}); err != nil {
+		return database.App{}, false, err
+	}
+
+	logrus.Infof("Aplicación %s (%s) creada por git push", app.Name, app.ID)
+	refreshRoutes(c)
+	return app, true, nil
+}
+
+// streamPushDeploy transmite los logs del deploy hasta que el trabajo termina
+// o el cliente se desconecta. Si el trabajo esperaba a otros de la misma app,
+// los logs de esos trabajos se descartan hasta que el nuestro empieza.
+func streamPushDeploy(ctx context.Context, c *Context, progress func(string, ...interface{}), logs <-chan string, jobID string, waiting bool) {
+	ticker := time.NewTicker(pushJobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case line := <-logs:
+			if !waiting {
+				progress("%s", line)
+			}
+		case <-ticker.C:
+			job, err := c.queries.GetJob(ctx, jobID)
+			if err != nil {
+				return
+			}
+			switch job.Status {
+			case database.JobQueued:
+				continue
+			case database.JobRunning:
+				waiting = false
+				continue
+			}
+
+			// Vaciar los logs que quedaron pendientes antes de terminar
+			for {
+				select {
+				case line := <-logs:
+					progress("%s", line)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/rodrwan/diplo/internal/database"
+)
+
+func TestAppForPushedBranch(t *testing.T) {
+	const repoDir = "/var/lib/diplo/repos/web.git"
+	ctx := newTestContext(t, database.CreateAppParams{
+		ID:      "app_staging",
+		Name:    "web-staging",
+		RepoUrl: repoDir,
+		Port:    3000,
+		Ref:     sql.NullString{String: "staging", Valid: true},
+	})
+
+	app, created, err := appForPushedBranch(context.Background(), ctx, "web", repoDir, "staging")
+	if err != nil || created || app.ID != "app_staging" {
+		t.Errorf("staging: app %q, created %v, err %v; se esperaba la app fijada a staging", app.ID, created, err)
+	}
+
+	if _, _, err := appForPushedBranch(context.Background(), ctx, "web", repoDir, "feature"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("feature: err = %v, se esperaba sql.ErrNoRows", err)
+	}
+
+	app, created, err = appForPushedBranch(context.Background(), ctx, "web", repoDir, "main")
+	if err != nil || !created || app.Ref.String != "" {
+		t.Fatalf("main: app %+v, created %v, err %v; se esperaba crear la app sin ref", app, created, err)
+	}
+	again, created, err := appForPushedBranch(context.Background(), ctx, "web", repoDir, "master")
+	if err != nil || created || again.ID != app.ID {
+		t.Errorf("master: app %q, created %v, err %v; se esperaba la app sin ref %q", again.ID, created, err, app.ID)
+	}
+}
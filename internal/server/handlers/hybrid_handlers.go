@@ -4,15 +4,19 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"runtime"
+	"slices"
 	"strings"
 	"time"
 
 	"io"
 
+	"github.com/distribution/reference"
 	"github.com/rodrwan/diplo/internal/database"
+	"github.com/rodrwan/diplo/internal/gitserver"
 	"github.com/rodrwan/diplo/internal/models"
 	runtimePkg "github.com/rodrwan/diplo/internal/runtime"
 	"github.com/sirupsen/logrus"
@@ -37,7 +41,7 @@ func UnifiedStatusHandler(ctx *HybridContext, w http.ResponseWriter, r *http.Req
 		"runtime": map[string]interface{}{
 			"available":           factory.GetAvailableRuntimes(),
 			"preferred":           factory.GetPreferredRuntime(),
-			"supported_languages": []string{"go", "javascript", "python", "rust", "java"},
+			"supported_languages": runtimePkg.NewDockerTemplateManager().SupportedLanguages(),
 			"supported_images":    getSupportedImages(factory.GetPreferredRuntime()),
 		},
 		"applications": []interface{}{},
@@ -53,9 +57,97 @@ func UnifiedDeployHandler(ctx *HybridContext, w http.ResponseWriter, r *http.Req
 		return Response{Code: http.StatusBadRequest, Message: "JSON inválido"}, nil
 	}
 
-	// Validar campos requeridos
-	if req.RepoURL == "" {
-		return Response{Code: http.StatusBadRequest, Message: "repo_url es requerido"}, nil
+	// Validar campos requeridos: la app sale de un repo o de una imagen ya construida
+	if req.RepoURL == "" && req.Image == "" {
+		return Response{Code: http.StatusBadRequest, Message: "repo_url o image es requerido"}, nil
+	}
+	if req.RepoURL != "" && req.Image != "" {
+		return Response{Code: http.StatusBadRequest, Message: "usa repo_url o image, no ambos"}, nil
+	}
+	var image reference.Named
+	if req.Image != "" {
+		var err error
+		if image, err = parseImageRef(req.Image); err != nil {
+			return Response{Code: http.StatusBadRequest, Message: err.Error()}, nil
+		}
+		if field := repoOnlyField(req); field != "" {
+			return Response{Code: http.StatusBadRequest, Message: fmt.Sprintf("%s no aplica a apps desplegadas desde una imagen", field)}, nil
+		}
+	} else {
+		req.RepoURL = strings.TrimSpace(req.RepoURL)
+		// Los repos alojados (recibidos con git push) son rutas locales
+		if !ctx.gitRepos.Hosts(req.RepoURL) {
+			if err := gitserver.ValidateRemoteURL(req.RepoURL); err != nil {
+				return Response{Code: http.StatusBadRequest, Message: err.Error()}, nil
+			}
+		}
+		if req.RegistryUsername != nil || req.RegistryPassword != nil {
+			return Response{Code: http.StatusBadRequest, Message: "registry_username y registry_password solo aplican con image"}, nil
+		}
+	}
+	switch req.OnConflict {
+	case "":
+		req.OnConflict = DeployConflictQueue
+	case DeployConflictQueue, DeployConflictReplace:
+	default:
+		return Response{Code: http.StatusBadRequest, Message: "on_conflict debe ser queue o replace"}, nil
+	}
+
+	// Validar el ref antes de guardarlo; "HEAD" quita el ref fijado
+	req.Ref = strings.TrimSpace(req.Ref)
+	ref := sql.NullString{String: req.Ref, Valid: req.Ref != "" && req.Ref != "HEAD"}
+	if ref.Valid {
+		if err := validateRef(r.Context(), req.RepoURL, req.Ref, req.GitHubToken); err != nil {
+			return Response{Code: http.StatusBadRequest, Message: fmt.Sprintf("No se encontró el ref %s en el repositorio: %v", req.Ref, err)}, nil
+		}
+	}
+
+	// El subdirectorio de la app es el contexto de build y parte de su identidad
+	appPath, err := normalizeAppPath(req.Path)
+	if err != nil {
+		return Response{Code: http.StatusBadRequest, Message: err.Error()}, nil
+	}
+
+	// "Dockerfile" vuelve a la detección automática del Dockerfile de la raíz
+	req.DockerfilePath = strings.TrimSpace(req.DockerfilePath)
+	dockerfilePath := sql.NullString{String: req.DockerfilePath, Valid: req.DockerfilePath != "" && req.DockerfilePath != defaultDockerfilePath}
+	if dockerfilePath.Valid {
+		if err := validateDockerfilePath(req.DockerfilePath); err != nil {
+			return Response{Code: http.StatusBadRequest, Message: err.Error()}, nil
+		}
+	}
+
+	// "auto" vuelve a deducir el comando de inicio del framework
+	req.StartCommand = strings.TrimSpace(req.StartCommand)
+	startCommand := sql.NullString{String: req.StartCommand, Valid: req.StartCommand != "" && req.StartCommand != autoStartCommand}
+	if strings.ContainsAny(req.StartCommand, "\n\r") {
+		return Response{Code: http.StatusBadRequest, Message: "start_command debe ser una sola línea"}, nil
+	}
+
+	// "auto" vuelve a la ruta de diplo.yaml o, sin ella, a aceptar cualquier respuesta HTTP
+	req.HealthCheckPath = strings.TrimSpace(req.HealthCheckPath)
+	healthCheckPath := sql.NullString{String: req.HealthCheckPath, Valid: req.HealthCheckPath != "" && req.HealthCheckPath != autoHealthCheckPath}
+	if healthCheckPath.Valid {
+		if err := validateHealthCheckPath(req.HealthCheckPath); err != nil {
+			return Response{Code: http.StatusBadRequest, Message: err.Error()}, nil
+		}
+	}
+
+	// Un language explícito gana a diplo.yaml y a la detección; "auto" vuelve a detectarlo
+	req.Language = strings.ToLower(strings.TrimSpace(req.Language))
+	if req.Language != "" && req.Language != autoLanguage {
+		req.Language = runtimePkg.NormalizeLanguage(req.Language)
+		templates := runtimePkg.NewDockerTemplateManager()
+		if !templates.HasTemplate(req.Language) {
+			return Response{Code: http.StatusBadRequest, Message: fmt.Sprintf("language no soportado: %q (soportados: %s)", req.Language, strings.Join(templates.SupportedLanguages(), ", "))}, nil
+		}
+	}
+	languageOverride := sql.NullString{String: req.Language, Valid: req.Language != "" && req.Language != autoLanguage}
+
+	// Paquete main y flags de go build; un campo omitido mantiene el de la app
+	goBuild, err := parseGoBuild(req)
+	if err != nil {
+		return Response{Code: http.StatusBadRequest, Message: err.Error()}, nil
 	}
 
 	factory, ok := ctx.runtimeFactory.(runtimePkg.RuntimeFactory)
@@ -63,15 +155,27 @@ func UnifiedDeployHandler(ctx *HybridContext, w http.ResponseWriter, r *http.Req
 		logrus.Error("Runtime factory no es del tipo correcto")
 		return Response{Code: http.StatusInternalServerError, Message: "Error interno del servidor"}, nil
 	}
+	// Sin Docker, las opciones que solo aplica su build harían fallar el deploy
+	if field := dockerOnlyField(req); field != "" && !runtimeAvailable(factory, runtimePkg.RuntimeTypeDocker) {
+		return Response{Code: http.StatusBadRequest, Message: fmt.Sprintf("%s necesita Docker, que no está disponible en este host (runtime %s)", field, factory.GetPreferredRuntime())}, nil
+	}
 
-	// Verificar si ya existe una aplicación con este repo_url
-	existingApp, err := ctx.queries.GetAppByRepoUrl(r.Context(), req.RepoURL)
+	// Verificar si ya existe una aplicación con este repo, path y ref (o con el mismo repositorio de imagen)
+	var existingApp database.App
+	if image != nil {
+		existingApp, err = findImageApp(r.Context(), ctx.queries, image)
+	} else {
+		existingApp, err = findAppForDeploy(r.Context(), ctx.queries, req.RepoURL, appPath, req.Ref)
+	}
+	if errors.Is(err, errAmbiguousApp) {
+		return Response{Code: http.StatusConflict, Message: err.Error()}, nil
+	}
 	if err != nil && err != sql.ErrNoRows {
 		logrus.Errorf("Error verificando aplicación existente: %v", err)
 		return Response{Code: http.StatusInternalServerError, Message: "Error verificando aplicación existente"}, err
 	}
 
-	// Si existe una app con el mismo repo_url, hacer redeploy
+	// Si existe una app con el mismo repo, path y ref, hacer redeploy
 	if existingApp.ID != "" {
 		logrus.Infof("Aplicación existente encontrada: %s (%s), iniciando redeploy", existingApp.Name, existingApp.ID)
 
@@ -140,18 +244,99 @@ func UnifiedDeployHandler(ctx *HybridContext, w http.ResponseWriter, r *http.Req
 			}
 		}
 
-		// Iniciar redeploy en background
-		go unifiedRedeployApp(ctx, &existingApp, factory, req.GitHubToken)
+		if req.DockerfilePath != "" {
+			if err := ctx.queries.UpdateAppDockerfilePath(r.Context(), database.UpdateAppDockerfilePathParams{
+				DockerfilePath: dockerfilePath,
+				UpdatedAt:      sql.NullTime{Time: time.Now(), Valid: true},
+				ID:             existingApp.ID,
+			}); err != nil {
+				return Response{Code: http.StatusInternalServerError, Message: "Error guardando dockerfile_path"}, err
+			}
+			existingApp.DockerfilePath = dockerfilePath
+		}
+		if req.StartCommand != "" {
+			if err := ctx.queries.UpdateAppStartCommand(r.Context(), database.UpdateAppStartCommandParams{
+				StartCommand: startCommand,
+				UpdatedAt:    sql.NullTime{Time: time.Now(), Valid: true},
+				ID:           existingApp.ID,
+			}); err != nil {
+				return Response{Code: http.StatusInternalServerError, Message: "Error guardando start_command"}, err
+			}
+			existingApp.StartCommand = startCommand
+		}
+		if req.Language != "" {
+			if err := ctx.queries.UpdateAppLanguageOverride(r.Context(), database.UpdateAppLanguageOverrideParams{
+				LanguageOverride: languageOverride,
+				UpdatedAt:        sql.NullTime{Time: time.Now(), Valid: true},
+				ID:               existingApp.ID,
+			}); err != nil {
+				return Response{Code: http.StatusInternalServerError, Message: "Error guardando language"}, err
+			}
+			existingApp.LanguageOverride = languageOverride
+		}
+		if req.HealthCheckPath != "" {
+			if err := ctx.queries.UpdateAppHealthCheckPath(r.Context(), database.UpdateAppHealthCheckPathParams{
+				HealthCheckPath: healthCheckPath,
+				UpdatedAt:       sql.NullTime{Time: time.Now(), Valid: true},
+				ID:              existingApp.ID,
+			}); err != nil {
+				return Response{Code: http.StatusInternalServerError, Message: "Error guardando health_check_path"}, err
+			}
+			existingApp.HealthCheckPath = healthCheckPath
+		}
+		if goBuild.set() {
+			params := goBuild.apply(existingApp)
+			if err := ctx.queries.UpdateAppGoBuild(r.Context(), params); err != nil {
+				return Response{Code: http.StatusInternalServerError, Message: "Error guardando la configuración de Go"}, err
+			}
+			existingApp.GoTarget, existingApp.GoTags, existingApp.GoLdflags = params.GoTarget, params.GoTags, params.GoLdflags
+		}
+		if image != nil {
+			// Un tag nuevo del mismo repositorio de imagen redespliega la app
+			params, err := imageUpdate(req, image, existingApp)
+			if err != nil {
+				return Response{Code: http.StatusInternalServerError, Message: "Error guardando registry_password"}, err
+			}
+			if err := ctx.queries.UpdateAppImage(r.Context(), params); err != nil {
+				return Response{Code: http.StatusInternalServerError, Message: "Error guardando image"}, err
+			}
+			existingApp.Image, existingApp.RegistryUsername, existingApp.RegistryPassword = params.Image, params.RegistryUsername, params.RegistryPassword
+		}
+
+		// Encolar el redeploy; lo ejecuta un worker de la cola cuando la app no
+		// tenga otro deploy en curso (o tras cancelarlo con on_conflict=replace)
+		job, ahead, err := enqueueAppDeployJob(r.Context(), ctx.Context, existingApp.ID, JobKindRedeploy, req.OnConflict, deployJobPayload{
+			deployOptions: deployOptions{GitHubToken: req.GitHubToken, Trigger: DeploymentTriggerAPI, ForceRebuild: req.ForceRebuild},
+		})
+		if err != nil {
+			logrus.Errorf("Error encolando redeploy de %s: %v", existingApp.ID, err)
+			return Response{Code: http.StatusInternalServerError, Message: "Error encolando redeploy"}, err
+		}
 
 		response := map[string]interface{}{
-			"id":           existingApp.ID,
-			"name":         existingApp.Name,
-			"repo_url":     existingApp.RepoUrl,
-			"port":         existingApp.Port,
-			"url":          fmt.Sprintf("http://localhost:%d", existingApp.Port),
-			"status":       "redeploying",
-			"runtime_type": factory.GetPreferredRuntime(),
-			"message":      "Redeploy iniciado para aplicación existente",
+			"job_id":            job.ID,
+			"on_conflict":       req.OnConflict,
+			"id":                existingApp.ID,
+			"name":              existingApp.Name,
+			"repo_url":          existingApp.RepoUrl,
+			"image":             existingApp.Image.String,
+			"path":              existingApp.Path.String,
+			"ref":               existingApp.Ref.String,
+			"dockerfile_path":   existingApp.DockerfilePath.String,
+			"start_command":     existingApp.StartCommand.String,
+			"language":          existingApp.LanguageOverride.String,
+			"go_target":         existingApp.GoTarget.String,
+			"health_check_path": existingApp.HealthCheckPath.String,
+			"port":              existingApp.Port,
+			"url":               appURL(ctx.Context, &existingApp),
+			"status":            "redeploying",
+			"runtime_type":      factory.GetPreferredRuntime(),
+			"message":           "Redeploy iniciado para aplicación existente",
+		}
+		if req.OnConflict == DeployConflictReplace {
+			response["replaced_jobs"] = ahead
+		} else {
+			response["queued_behind"] = ahead
 		}
 
 		return Response{Code: http.StatusOK, Data: response}, nil
@@ -162,6 +347,11 @@ func UnifiedDeployHandler(ctx *HybridContext, w http.ResponseWriter, r *http.Req
 		ID:      database.GenerateAppID(),
 		Name:    req.Name,
 		RepoUrl: req.RepoURL,
+		Ref:     ref,
+		Path:    sql.NullString{String: appPath, Valid: appPath != ""},
+	}
+	if image != nil {
+		app.Image = sql.NullString{String: reference.FamiliarString(image), Valid: true}
 	}
 
 	// Asignar puerto libre
@@ -198,10 +388,82 @@ func UnifiedDeployHandler(ctx *HybridContext, w http.ResponseWriter, r *http.Req
 		Language: sql.NullString{String: "unknown", Valid: true}, // Se detectará durante el deployment
 		Port:     int64(port),
 		Status:   database.StatusDeploying,
+		Ref:      app.Ref,
+		Path:     app.Path,
+		Image:    app.Image,
 	}); err != nil {
 		logrus.Errorf("Error guardando aplicación: %v", err)
 		return Response{Code: http.StatusInternalServerError, Message: "Error guardando aplicación"}, err
 	}
+	if dockerfilePath.Valid {
+		if err := ctx.queries.UpdateAppDockerfilePath(r.Context(), database.UpdateAppDockerfilePathParams{
+			DockerfilePath: dockerfilePath,
+			UpdatedAt:      sql.NullTime{Time: time.Now(), Valid: true},
+			ID:             app.ID,
+		}); err != nil {
+			logrus.Errorf("Error guardando dockerfile_path: %v", err)
+			handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error guardando dockerfile_path: %v", err))
+			return Response{Code: http.StatusInternalServerError, Message: "Error guardando dockerfile_path"}, err
+		}
+		app.DockerfilePath = dockerfilePath
+	}
+	if startCommand.Valid {
+		if err := ctx.queries.UpdateAppStartCommand(r.Context(), database.UpdateAppStartCommandParams{
+			StartCommand: startCommand,
+			UpdatedAt:    sql.NullTime{Time: time.Now(), Valid: true},
+			ID:           app.ID,
+		}); err != nil {
+			logrus.Errorf("Error guardando start_command: %v", err)
+			handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error guardando start_command: %v", err))
+			return Response{Code: http.StatusInternalServerError, Message: "Error guardando start_command"}, err
+		}
+		app.StartCommand = startCommand
+	}
+	if languageOverride.Valid {
+		if err := ctx.queries.UpdateAppLanguageOverride(r.Context(), database.UpdateAppLanguageOverrideParams{
+			LanguageOverride: languageOverride,
+			UpdatedAt:        sql.NullTime{Time: time.Now(), Valid: true},
+			ID:               app.ID,
+		}); err != nil {
+			logrus.Errorf("Error guardando language: %v", err)
+			handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error guardando language: %v", err))
+			return Response{Code: http.StatusInternalServerError, Message: "Error guardando language"}, err
+		}
+		app.LanguageOverride = languageOverride
+	}
+	if healthCheckPath.Valid {
+		if err := ctx.queries.UpdateAppHealthCheckPath(r.Context(), database.UpdateAppHealthCheckPathParams{
+			HealthCheckPath: healthCheckPath,
+			UpdatedAt:       sql.NullTime{Time: time.Now(), Valid: true},
+			ID:              app.ID,
+		}); err != nil {
+			logrus.Errorf("Error guardando health_check_path: %v", err)
+			handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error guardando health_check_path: %v", err))
+			return Response{Code: http.StatusInternalServerError, Message: "Error guardando health_check_path"}, err
+		}
+		app.HealthCheckPath = healthCheckPath
+	}
+	if goBuild.set() {
+		params := goBuild.apply(*app)
+		if err := ctx.queries.UpdateAppGoBuild(r.Context(), params); err != nil {
+			logrus.Errorf("Error guardando la configuración de Go: %v", err)
+			handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error guardando la configuración de Go: %v", err))
+			return Response{Code: http.StatusInternalServerError, Message: "Error guardando la configuración de Go"}, err
+		}
+		app.GoTarget, app.GoTags, app.GoLdflags = params.GoTarget, params.GoTags, params.GoLdflags
+	}
+	if image != nil && (req.RegistryUsername != nil || req.RegistryPassword != nil) {
+		params, err := imageUpdate(req, image, *app)
+		if err == nil {
+			err = ctx.queries.UpdateAppImage(r.Context(), params)
+		}
+		if err != nil {
+			logrus.Errorf("Error guardando las credenciales del registry: %v", err)
+			handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error guardando las credenciales del registry: %v", err))
+			return Response{Code: http.StatusInternalServerError, Message: "Error guardando las credenciales del registry"}, err
+		}
+		app.RegistryUsername, app.RegistryPassword = params.RegistryUsername, params.RegistryPassword
+	}
 
 	// Guardar variables de entorno si se proporcionaron
 	if len(req.EnvVars) > 0 {
@@ -254,20 +516,39 @@ func UnifiedDeployHandler(ctx *HybridContext, w http.ResponseWriter, r *http.Req
 		}
 	}
 
-	// Iniciar deployment en background usando runtime factory
-	go unifiedDeployApp(ctx, app, factory, req.GitHubToken)
+	// Publicar la ruta en el proxy desde ya (responde 502 hasta que la app arranque)
+	refreshRoutes(ctx.Context)
+
+	// Encolar el deployment; lo ejecuta un worker de la cola usando runtime factory
+	job, err := enqueueDeployJob(r.Context(), ctx.Context, app.ID, JobKindDeploy, deployJobPayload{
+		deployOptions: deployOptions{GitHubToken: req.GitHubToken, Trigger: DeploymentTriggerAPI, ForceRebuild: req.ForceRebuild},
+	})
+	if err != nil {
+		logrus.Errorf("Error encolando deployment de %s: %v", app.ID, err)
+		handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error encolando deployment: %v", err))
+		return Response{Code: http.StatusInternalServerError, Message: "Error encolando deployment"}, err
+	}
 
 	// Responder inmediatamente
 	response := map[string]interface{}{
-		"id":           app.ID,
-		"name":         app.Name,
-		"repo_url":     app.RepoUrl,
-		"port":         app.Port,
-		"url":          fmt.Sprintf("http://localhost:%d", app.Port),
-		"status":       "deploying",
-		"runtime_type": selectedRuntime,
-		"env_vars":     len(req.EnvVars),
-		"message":      "Deployment iniciado exitosamente",
+		"job_id":            job.ID,
+		"id":                app.ID,
+		"name":              app.Name,
+		"repo_url":          app.RepoUrl,
+		"image":             app.Image.String,
+		"path":              app.Path.String,
+		"ref":               app.Ref.String,
+		"dockerfile_path":   app.DockerfilePath.String,
+		"start_command":     app.StartCommand.String,
+		"language":          app.LanguageOverride.String,
+		"go_target":         app.GoTarget.String,
+		"health_check_path": app.HealthCheckPath.String,
+		"port":              app.Port,
+		"url":               appURL(ctx.Context, app),
+		"status":            "deploying",
+		"runtime_type":      selectedRuntime,
+		"env_vars":          len(req.EnvVars),
+		"message":           "Deployment iniciado exitosamente",
 	}
 
 	return Response{Code: http.StatusOK, Data: response}, nil
@@ -337,10 +618,55 @@ func getSupportedImages(runtimeType runtimePkg.RuntimeType) []string {
 	}
 }
 
-// unifiedDeployApp ejecuta el deployment usando el runtime factory
-func unifiedDeployApp(ctx *HybridContext, app *database.App, factory runtimePkg.RuntimeFactory, gitHubToken string) {
-	// Obtener runtime preferido del factory
-	selectedRuntime := factory.GetPreferredRuntime()
+// deployRuntime elige el runtime del deploy: el preferido, salvo que la app o
+// su repo tengan configuración que solo aplica el build con Docker. Si Docker
+// no está disponible en el host ese deploy falla aquí, antes de construir. Con
+// containerd devuelve el commit ya preparado que el contenedor compila.
+func deployRuntime(jobCtx context.Context, ctx *HybridContext, app *database.App, opts deployOptions, factory runtimePkg.RuntimeFactory) (runtimePkg.RuntimeType, *containerdSource, error) {
+	preferred := factory.GetPreferredRuntime()
+	if preferred == runtimePkg.RuntimeTypeDocker {
+		return preferred, nil, nil
+	}
+
+	// containerd clona el repo dentro del contenedor: un Dockerfile configurado se construye con Docker
+	reason := dockerOnlyReason(ctx.Context, app)
+	if reason == "" {
+		recordDeploymentStep(app.ID, "detect_language")
+		sendHybridLogMessage(ctx, app.ID, "info", "Detectando lenguaje...")
+		source, sourceReason, err := prepareContainerdSource(jobCtx, ctx.Context, app, opts)
+		if err != nil {
+			return preferred, nil, fmt.Errorf("error detectando lenguaje: %w", err)
+		}
+		if sourceReason == "" {
+			return preferred, source, nil
+		}
+		reason = sourceReason
+	}
+	if !runtimeAvailable(factory, runtimePkg.RuntimeTypeDocker) {
+		return preferred, nil, fmt.Errorf("%s: %s no lo soporta y este deploy necesita Docker, que no está disponible en este host", reason, preferred)
+	}
+	sendHybridLogMessage(ctx, app.ID, "warning", fmt.Sprintf("%s: se construye con Docker en vez de %s", reason, preferred))
+	return runtimePkg.RuntimeTypeDocker, nil, nil
+}
+
+// runtimeAvailable indica si el runtime se detectó en el host
+func runtimeAvailable(factory runtimePkg.RuntimeFactory, runtimeType runtimePkg.RuntimeType) bool {
+	return slices.Contains(factory.GetAvailableRuntimes(), runtimeType)
+}
+
+// unifiedDeployApp ejecuta el deployment usando el runtime factory y lo
+// registra en el historial con el origen trigger
+func unifiedDeployApp(jobCtx context.Context, ctx *HybridContext, app *database.App, factory runtimePkg.RuntimeFactory, opts deployOptions) (err error) {
+	beginDeployment(ctx.queries, app.ID, opts.Trigger, string(factory.GetPreferredRuntime()), opts.CommitSHA)
+	defer func() { finishDeployment(app.ID, err) }()
+
+	// Obtener runtime del deploy: el preferido o Docker si la app lo necesita
+	selectedRuntime, source, err := deployRuntime(jobCtx, ctx, app, opts, factory)
+	if err != nil {
+		logrus.Errorf("Error eligiendo runtime para %s: %v", app.ID, err)
+		return handleUnifiedDeployError(ctx, app, err.Error())
+	}
+	recordDeploymentRuntime(app.ID, string(selectedRuntime))
 	logrus.Infof("Iniciando deployment unificado de: %s (%s) con runtime %s", app.Name, app.ID, selectedRuntime)
 
 	// Cargar variables de entorno de la base de datos
@@ -385,16 +711,14 @@ func unifiedDeployApp(ctx *HybridContext, app *database.App, factory runtimePkg.
 		Status:   app.Status,
 	})
 
-	// Detectar lenguaje
-	sendHybridLogMessage(ctx, app.ID, "info", "Detectando lenguaje...")
-	language, err := detectLanguage(app.RepoUrl, gitHubToken)
-	if err != nil {
-		logrus.Errorf("Error detectando lenguaje: %v", err)
-		handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error detectando lenguaje: %v", err))
-		return
+	// Con Docker deployApp detecta el lenguaje en el mismo commit que construye
+	// y las apps de imagen no tienen código que detectar
+	if source == nil && !isImageApp(app) {
+		recordDeploymentStep(app.ID, "detect_language")
+	}
+	if source != nil {
+		app.Language = sql.NullString{String: source.language, Valid: true}
 	}
-	app.Language = sql.NullString{String: language, Valid: true}
-	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("Lenguaje detectado: %s", language))
 
 	// Crear runtime específico según el tipo seleccionado
 	runtime, err := factory.CreateRuntime(selectedRuntime)
@@ -421,12 +745,10 @@ func unifiedDeployApp(ctx *HybridContext, app *database.App, factory runtimePkg.
 				logrus.Infof("Cambiando a runtime Docker para deployment")
 				sendHybridLogMessage(ctx, app.ID, "info", "Cambiando a runtime Docker")
 			} else {
-				handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error creando runtime %s y no hay fallback disponible: %v", selectedRuntime, err))
-				return
+				return handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error creando runtime %s y no hay fallback disponible: %v", selectedRuntime, err))
 			}
 		} else {
-			handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error creando runtime %s: %v", selectedRuntime, err))
-			return
+			return handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error creando runtime %s: %v", selectedRuntime, err))
 		}
 	}
 
@@ -447,26 +769,27 @@ func unifiedDeployApp(ctx *HybridContext, app *database.App, factory runtimePkg.
 	case runtimePkg.RuntimeTypeDocker:
 		// Usar el sistema Docker existente
 		regularCtx := ctx.Context
-		deployApp(regularCtx, app, envVars)
+		return deployApp(jobCtx, regularCtx, app, envVars, opts)
 	case runtimePkg.RuntimeTypeContainerd:
 		if runtime != nil {
-			deployWithContainerd(ctx, app, runtime, envVars, language, gitHubToken)
+			return deployWithContainerd(jobCtx, ctx, app, runtime, envVars, source, opts)
 		} else {
 			// Fallback a Docker si no se pudo crear el runtime containerd
 			logrus.Warnf("No se pudo crear runtime containerd, usando Docker como fallback")
 			regularCtx := ctx.Context
-			deployApp(regularCtx, app, envVars)
+			return deployApp(jobCtx, regularCtx, app, envVars, opts)
 		}
 	default:
 		// Fallback a Docker
 		logrus.Warnf("Runtime %s no implementado, usando Docker como fallback", selectedRuntime)
 		regularCtx := ctx.Context
-		deployApp(regularCtx, app, envVars)
+		return deployApp(jobCtx, regularCtx, app, envVars, opts)
 	}
 }
 
 // deployWithContainerd ejecuta el deployment usando containerd
-func deployWithContainerd(ctx *HybridContext, app *database.App, runtime runtimePkg.ContainerRuntime, envVars []models.EnvVar, language string, gitHubToken string) {
+func deployWithContainerd(jobCtx context.Context, ctx *HybridContext, app *database.App, runtime runtimePkg.ContainerRuntime, envVars []models.EnvVar, source *containerdSource, opts deployOptions) error {
+	language := source.language
 	sendHybridLogMessage(ctx, app.ID, "info", "🚀 Iniciando deployment con containerd...")
 	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("📦 Aplicación: %s (%s)", app.Name, app.ID))
 	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("🔗 Repositorio: %s", app.RepoUrl))
@@ -477,67 +800,38 @@ func deployWithContainerd(ctx *HybridContext, app *database.App, runtime runtime
 		logrus.Warnf("Runtime containerd es nil, usando Docker como fallback")
 		sendHybridLogMessage(ctx, app.ID, "warning", "Runtime containerd no disponible, usando Docker como fallback")
 		regularCtx := ctx.Context
-		deployApp(regularCtx, app, envVars)
-		return
+		return deployApp(jobCtx, regularCtx, app, envVars, opts)
 	}
 
+	recordDeploymentRuntime(app.ID, string(runtimePkg.RuntimeTypeContainerd))
+	recordDeploymentEnv(app.ID, envVars)
+
 	// Implementación real de deployment con containerd
 	sendHybridLogMessage(ctx, app.ID, "info", "Implementando deployment con containerd...")
 
 	// Determinar imagen base según el lenguaje
 	baseImage := getContainerdBaseImage(language)
 	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("Usando imagen base: %s", baseImage))
+	recordDeploymentImage(app.ID, baseImage)
 
-	// Preparar variables de entorno con el puerto correcto
-	envVarsMap := convertEnvVarsToMap(envVars)
-
-	// Agregar variables de entorno del sistema
-	envVarsMap["PORT"] = fmt.Sprintf("%d", app.Port)
-	envVarsMap["DIPLO_APP_ID"] = app.ID
-	envVarsMap["DIPLO_APP_NAME"] = app.Name
-	envVarsMap["DIPLO_APP_PORT"] = fmt.Sprintf("%d", app.Port)
-
-	// Crear request para el contenedor
-	containerReq := &runtimePkg.CreateContainerRequest{
-		Name:        app.ID, // Usar app ID como nombre del contenedor
-		Image:       baseImage,
-		Command:     []string{"sleep", "infinity"}, // Comando temporal para mantener contenedor vivo
-		WorkingDir:  "/app",
-		Environment: envVarsMap,
-		Ports: []runtimePkg.PortMapping{
-			{
-				HostPort:      int(app.Port),
-				ContainerPort: int(app.Port), // Usar el mismo puerto que el host
-				Protocol:      "tcp",
-			},
-		},
-		NetworkMode: "host", // Usar host networking para containerd
-		Labels: map[string]string{
-			"app.id":   app.ID,
-			"app.name": app.Name,
-			"runtime":  "containerd",
-		},
-		Resources: &runtimePkg.ResourceConfig{
-			Memory:    512 * 1024 * 1024, // 512MB
-			CPUShares: 512,
-		},
-	}
+	// Crear request para el contenedor (app ID como nombre del contenedor)
+	containerReq := newContainerdRequest(app, app.ID, baseImage, envVars)
 
 	// Crear contenedor
+	recordDeploymentStep(app.ID, "create_container")
 	sendHybridLogMessage(ctx, app.ID, "info", "Creando contenedor containerd...")
 	container, err := runtime.CreateContainer(containerReq)
 	if err != nil {
 		logrus.Errorf("Error creando contenedor containerd: %v", err)
-		handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error creando contenedor containerd: %v", err))
-		return
+		return handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error creando contenedor containerd: %v", err))
 	}
 
 	// Iniciar contenedor
+	recordDeploymentStep(app.ID, "start_container")
 	sendHybridLogMessage(ctx, app.ID, "info", "Iniciando contenedor containerd...")
-	if err := runtime.StartContainer(context.Background(), container.ID); err != nil {
+	if err := runtime.StartContainer(jobCtx, container.ID); err != nil {
 		logrus.Errorf("Error iniciando contenedor containerd: %v", err)
-		handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error iniciando contenedor containerd: %v", err))
-		return
+		return handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error iniciando contenedor containerd: %v", err))
 	}
 
 	// Esperar a que el contenedor esté corriendo
@@ -593,34 +887,31 @@ func deployWithContainerd(ctx *HybridContext, app *database.App, runtime runtime
 		}
 		errorMsg := fmt.Sprintf("No se pudo obtener IP del contenedor containerd después de múltiples intentos. Logs:\n%s", logOutput)
 		logrus.Error(errorMsg)
-		handleUnifiedDeployError(ctx, app, errorMsg)
-		return
+		return handleUnifiedDeployError(ctx, app, errorMsg)
 	}
 
 	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("Contenedor containerd iniciado exitosamente con IP: %s", containerIP))
 
 	// Verificar que el contenedor esté realmente listo antes de ejecutar comandos
 	sendHybridLogMessage(ctx, app.ID, "info", "Verificando que el contenedor esté listo para comandos...")
-	readyCheck, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"echo", "container-ready"})
+	readyCheck, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"echo", "container-ready"})
 	if err != nil {
 		logrus.Errorf("Error verificando que el contenedor esté listo: %v", err)
-		handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error verificando que el contenedor esté listo: %v", err))
-		return
+		return handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error verificando que el contenedor esté listo: %v", err))
 	}
 	if readyCheck.ExitCode != 0 {
 		logrus.Errorf("Contenedor no está listo para comandos: %s", readyCheck.Error)
-		handleUnifiedDeployError(ctx, app, fmt.Sprintf("Contenedor no está listo para comandos: %s", readyCheck.Error))
-		return
+		return handleUnifiedDeployError(ctx, app, fmt.Sprintf("Contenedor no está listo para comandos: %s", readyCheck.Error))
 	}
 	sendHybridLogMessage(ctx, app.ID, "info", "Contenedor listo para comandos")
 
 	// Instalar git si es necesario (para imágenes Alpine)
+	recordDeploymentStep(app.ID, "dependencies")
 	sendHybridLogMessage(ctx, app.ID, "info", "Verificando dependencias...")
-	gitCheck, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"which", "git"})
+	gitCheck, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"which", "git"})
 	if err != nil {
 		logrus.Errorf("Error verificando git: %v", err)
-		handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error verificando git: %v", err))
-		return
+		return handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error verificando git: %v", err))
 	}
 
 	if gitCheck.ExitCode != 0 {
@@ -631,7 +922,7 @@ func deployWithContainerd(ctx *HybridContext, app *database.App, runtime runtime
 
 		// Verificar si es Alpine Linux
 		sendHybridLogMessage(ctx, app.ID, "info", "Detectando gestor de paquetes...")
-		alpineCheck, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"which", "apk"})
+		alpineCheck, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"which", "apk"})
 		if err != nil {
 			logrus.Errorf("Error verificando apk: %v", err)
 		} else {
@@ -640,20 +931,18 @@ func deployWithContainerd(ctx *HybridContext, app *database.App, runtime runtime
 
 		if err == nil && alpineCheck.ExitCode == 0 {
 			packageManager = "apk"
-			installResult, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"apk", "add", "--no-cache", "git"})
+			installResult, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"apk", "add", "--no-cache", "git"})
 			if err != nil {
 				logrus.Errorf("Error instalando git con apk: %v", err)
-				handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error instalando git con apk: %v", err))
-				return
+				return handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error instalando git con apk: %v", err))
 			}
 			if installResult.ExitCode != 0 {
 				logrus.Errorf("Error instalando git con apk: %s", installResult.Error)
-				handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error instalando git con apk: %s", installResult.Error))
-				return
+				return handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error instalando git con apk: %s", installResult.Error))
 			}
 		} else {
 			// Intentar con apt (Ubuntu/Debian)
-			aptCheck, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"which", "apt"})
+			aptCheck, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"which", "apt"})
 			if err != nil {
 				logrus.Errorf("Error verificando apt: %v", err)
 			} else {
@@ -663,7 +952,7 @@ func deployWithContainerd(ctx *HybridContext, app *database.App, runtime runtime
 			if err == nil && aptCheck.ExitCode == 0 {
 				packageManager = "apt"
 				// Actualizar repositorios primero
-				updateResult, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"apt-get", "update"})
+				updateResult, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"apt-get", "update"})
 				if err != nil {
 					logrus.Errorf("Error actualizando repositorios: %v", err)
 				}
@@ -671,20 +960,18 @@ func deployWithContainerd(ctx *HybridContext, app *database.App, runtime runtime
 					logrus.Warnf("Error actualizando repositorios: %s", updateResult.Error)
 				}
 
-				installResult, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"apt-get", "install", "-y", "git"})
+				installResult, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"apt-get", "install", "-y", "git"})
 				if err != nil {
 					logrus.Errorf("Error instalando git con apt: %v", err)
-					handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error instalando git con apt: %v", err))
-					return
+					return handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error instalando git con apt: %v", err))
 				}
 				if installResult.ExitCode != 0 {
 					logrus.Errorf("Error instalando git con apt: %s", installResult.Error)
-					handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error instalando git con apt: %s", installResult.Error))
-					return
+					return handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error instalando git con apt: %s", installResult.Error))
 				}
 			} else {
 				// Intentar con yum (RHEL/CentOS)
-				yumCheck, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"which", "yum"})
+				yumCheck, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"which", "yum"})
 				if err != nil {
 					logrus.Errorf("Error verificando yum: %v", err)
 				} else {
@@ -693,23 +980,20 @@ func deployWithContainerd(ctx *HybridContext, app *database.App, runtime runtime
 
 				if err == nil && yumCheck.ExitCode == 0 {
 					packageManager = "yum"
-					installResult, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"yum", "install", "-y", "git"})
+					installResult, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"yum", "install", "-y", "git"})
 					if err != nil {
 						logrus.Errorf("Error instalando git con yum: %v", err)
-						handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error instalando git con yum: %v", err))
-						return
+						return handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error instalando git con yum: %v", err))
 					}
 					if installResult.ExitCode != 0 {
 						logrus.Errorf("Error instalando git con yum: %s", installResult.Error)
-						handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error instalando git con yum: %s", installResult.Error))
-						return
+						return handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error instalando git con yum: %s", installResult.Error))
 					}
 				} else {
 					// No se pudo detectar el gestor de paquetes
 					errorMsg := "No se pudo detectar el gestor de paquetes (apk/apt/yum) para instalar git"
 					logrus.Error(errorMsg)
-					handleUnifiedDeployError(ctx, app, errorMsg)
-					return
+					return handleUnifiedDeployError(ctx, app, errorMsg)
 				}
 			}
 		}
@@ -720,44 +1004,33 @@ func deployWithContainerd(ctx *HybridContext, app *database.App, runtime runtime
 	}
 
 	// Clonar repositorio PRIMERO
+	recordDeploymentStep(app.ID, "clone")
 	sendHybridLogMessage(ctx, app.ID, "info", "Clonando repositorio...")
 
-	var cloneCmd []string
-	if gitHubToken != "" {
-		// Usar token para repositorios privados
-		repoURLWithToken := strings.Replace(app.RepoUrl, "https://github.com/", fmt.Sprintf("https://%s@github.com/", gitHubToken), 1)
-		cloneCmd = []string{"git", "clone", repoURLWithToken, "/app/src"}
+	if opts.GitHubToken != "" {
 		sendHybridLogMessage(ctx, app.ID, "info", "Clonando repositorio privado con token de GitHub")
-	} else {
-		// Clonación normal para repositorios públicos
-		cloneCmd = []string{"git", "clone", app.RepoUrl, "/app/src"}
-		sendHybridLogMessage(ctx, app.ID, "info", "Clonando repositorio público")
 	}
-
-	cloneResult, err := runtime.ExecuteCommand(context.Background(), container.ID, cloneCmd)
+	// El mismo commit que se revisó al elegir el runtime
+	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("Usando el commit %s", source.commit))
+	commit, err := cloneContainerdSource(jobCtx, runtime, container.ID, repoURLWithToken(app.RepoUrl, opts.GitHubToken), source.commit)
 	if err != nil {
 		logrus.Errorf("Error clonando repositorio: %v", err)
-		handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error clonando repositorio: %v", err))
-		return
-	}
-	if cloneResult.ExitCode != 0 {
-		logrus.Errorf("Error clonando repositorio: %s", cloneResult.Error)
-		handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error clonando repositorio: %s", cloneResult.Error))
-		return
+		return handleUnifiedDeployError(ctx, app, err.Error())
 	}
+	recordDeploymentCommit(app.ID, commit)
 
 	// Compilación Go con debug
+	recordDeploymentStep(app.ID, "build")
 	sendHybridLogMessage(ctx, app.ID, "info", "Compilando aplicación Go...")
 	// Verificar que Go esté instalado
-	goVersionResult, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"go", "version"})
+	goVersionResult, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"go", "version"})
 	if err != nil {
 		logrus.Errorf("Error verificando versión de Go: %v", err)
-		handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error verificando versión de Go: %v", err))
-		return
+		return handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error verificando versión de Go: %v", err))
 	}
 	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("Go instalado: %s", goVersionResult.Output))
 	// Verificar el contenido del directorio
-	lsResult, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"ls", "-la", "/app/src"})
+	lsResult, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"ls", "-la", "/app/src"})
 	if err != nil {
 		logrus.Errorf("Error listando directorio: %v", err)
 	} else {
@@ -766,10 +1039,10 @@ func deployWithContainerd(ctx *HybridContext, app *database.App, runtime runtime
 
 	// Verificar go.mod y mostrar información para debug
 	sendHybridLogMessage(ctx, app.ID, "info", "Verificando go.mod...")
-	_, err = runtime.ExecuteCommand(context.Background(), container.ID, []string{"ls", "-la", "/app/src/go.mod"})
+	_, err = runtime.ExecuteCommand(jobCtx, container.ID, []string{"ls", "-la", "/app/src/go.mod"})
 	if err == nil {
 		// go.mod existe, mostrar su contenido para debug
-		goModContent, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"cat", "/app/src/go.mod"})
+		goModContent, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"cat", "/app/src/go.mod"})
 		if err == nil {
 			sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("Contenido de go.mod: %s", goModContent.Output))
 		}
@@ -778,32 +1051,29 @@ func deployWithContainerd(ctx *HybridContext, app *database.App, runtime runtime
 	}
 
 	// Intentar compilar con más información de debug
-	buildResult, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"sh", "-c", "cd /app/src && go mod tidy && go build -v -o /app/app ."})
+	buildResult, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"sh", "-c", source.buildCommand})
 	if err != nil {
 		logrus.Errorf("Error compilando aplicación: %v", err)
-		handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error compilando aplicación: %v", err))
-		return
+		return handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error compilando aplicación: %v", err))
 	}
 	if buildResult.ExitCode != 0 {
 		logrus.Errorf("Error compilando aplicación: %s", buildResult.Error)
 		logrus.Errorf("Output de compilación: %s", buildResult.Output)
-		handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error compilando aplicación: %s\nOutput: %s", buildResult.Error, buildResult.Output))
-		return
+		return handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error compilando aplicación: %s\nOutput: %s", buildResult.Error, buildResult.Output))
 	}
 
 	sendHybridLogMessage(ctx, app.ID, "info", "Ejecutando aplicación...")
 	// Ejecutar la app en background con el puerto correcto
-	execCmd := fmt.Sprintf("cd /app && PORT=%d nohup ./app > /app/app.log 2>&1 &", app.Port)
-	execResult, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"sh", "-c", execCmd})
+	recordDeploymentStep(app.ID, "run")
+	execCmd := containerdStartCommand(app.Port)
+	execResult, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"sh", "-c", execCmd})
 	if err != nil {
 		logrus.Errorf("Error ejecutando aplicación: %v", err)
-		handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error ejecutando aplicación: %v", err))
-		return
+		return handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error ejecutando aplicación: %v", err))
 	}
 	if execResult.ExitCode != 0 {
 		logrus.Errorf("Error ejecutando aplicación: %s", execResult.Error)
-		handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error ejecutando aplicación: %s", execResult.Error))
-		return
+		return handleUnifiedDeployError(ctx, app, fmt.Sprintf("Error ejecutando aplicación: %s", execResult.Error))
 	}
 
 	sendHybridLogMessage(ctx, app.ID, "success", "Aplicación Go compilada y ejecutada exitosamente")
@@ -828,6 +1098,12 @@ func deployWithContainerd(ctx *HybridContext, app *database.App, runtime runtime
 		logrus.Errorf("Error actualizando aplicación: %v", err)
 	}
 
+	// Guardar imagen y configuración para poder recrear el contenedor en la recuperación
+	if err := saveContainerdSpec(ctx.queries, app, containerReq, source, opts.GitHubToken); err != nil {
+		logrus.Errorf("Error guardando configuración containerd de la app %s: %v", app.ID, err)
+	}
+	refreshRoutes(ctx.Context)
+
 	// Mensajes informativos finales detallados
 	sendHybridLogMessage(ctx, app.ID, "success", "🎉 ¡Deployment completado exitosamente!")
 	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("📋 Información del contenedor:"))
@@ -836,10 +1112,11 @@ func deployWithContainerd(ctx *HybridContext, app *database.App, runtime runtime
 	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("   • IP: %s", containerIP))
 	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("   • Puerto: %d", app.Port))
 	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("   • Lenguaje: %s", language))
-	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("   • Estado: %s", app.Status))
+	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("   • Estado: %s", app.Status.String))
 
 	sendHybridLogMessage(ctx, app.ID, "success", fmt.Sprintf("🌐 Aplicación disponible en: http://%s:%d", containerIP, app.Port))
 	sendHybridLogMessage(ctx, app.ID, "success", fmt.Sprintf("🔗 URL local: http://localhost:%d", app.Port))
+	sendHybridLogMessage(ctx, app.ID, "success", fmt.Sprintf("🔀 URL del proxy: %s", appURL(ctx.Context, app)))
 
 	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("📊 Recursos asignados:"))
 	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("   • Memoria: 512MB"))
@@ -848,14 +1125,30 @@ func deployWithContainerd(ctx *HybridContext, app *database.App, runtime runtime
 
 	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("📝 Logs disponibles en: /app/app.log dentro del contenedor"))
 	sendHybridLogMessage(ctx, app.ID, "success", fmt.Sprintf("✅ Contenedor %s listo y funcionando", container.ID))
+	return nil
 }
 
-// unifiedRedeployApp ejecuta el redeploy usando el runtime factory
-func unifiedRedeployApp(ctx *HybridContext, app *database.App, factory runtimePkg.RuntimeFactory, gitHubToken string) {
+// unifiedRedeployApp ejecuta el redeploy usando el runtime factory y lo
+// registra en el historial con el origen trigger
+func unifiedRedeployApp(jobCtx context.Context, ctx *HybridContext, app *database.App, factory runtimePkg.RuntimeFactory, opts deployOptions) (err error) {
 	logrus.Infof("Iniciando redeploy unificado de: %s (%s)", app.Name, app.ID)
 
-	// Obtener runtime preferido para el redeploy
-	preferredRuntime := factory.GetPreferredRuntime()
+	beginDeployment(ctx.queries, app.ID, opts.Trigger, string(factory.GetPreferredRuntime()), opts.CommitSHA)
+	defer func() {
+		// Si la nueva versión no pasó el health check se vuelve a la última sana
+		if finishDeployment(app.ID, err) == "health_check" {
+			autoRollback(jobCtx, ctx.Context, app)
+		}
+	}()
+
+	// Obtener runtime para el redeploy: el preferido o Docker si la app lo necesita
+	preferredRuntime, source, err := deployRuntime(jobCtx, ctx, app, opts, factory)
+	if err != nil {
+		logrus.Errorf("Error eligiendo runtime para %s: %v", app.ID, err)
+		return handleUnifiedRedeployError(ctx, app, err.Error())
+	}
+	recordDeploymentRuntime(app.ID, string(preferredRuntime))
+
 	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("Iniciando redeploy con runtime %s", preferredRuntime))
 
 	// Actualizar estado a redeploying
@@ -870,6 +1163,7 @@ func unifiedRedeployApp(ctx *HybridContext, app *database.App, factory runtimePk
 		Status:      app.Status,
 		ErrorMsg:    app.ErrorMsg,
 		ContainerID: app.ContainerID,
+		ImageID:     app.ImageID,
 		UpdatedAt:   sql.NullTime{Time: time.Now(), Valid: true},
 	}); err != nil {
 		logrus.Errorf("Error actualizando estado de redeploy: %v", err)
@@ -900,12 +1194,10 @@ func unifiedRedeployApp(ctx *HybridContext, app *database.App, factory runtimePk
 				logrus.Infof("Cambiando a runtime Docker para redeploy")
 				sendHybridLogMessage(ctx, app.ID, "info", "Cambiando a runtime Docker")
 			} else {
-				handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error creando runtime %s y no hay fallback disponible: %v", preferredRuntime, err))
-				return
+				return handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error creando runtime %s y no hay fallback disponible: %v", preferredRuntime, err))
 			}
 		} else {
-			handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error creando runtime %s: %v", preferredRuntime, err))
-			return
+			return handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error creando runtime %s: %v", preferredRuntime, err))
 		}
 	}
 
@@ -926,26 +1218,26 @@ func unifiedRedeployApp(ctx *HybridContext, app *database.App, factory runtimePk
 	case runtimePkg.RuntimeTypeDocker:
 		// Usar el sistema Docker existente
 		regularCtx := ctx.Context
-		redeployExistingApp(regularCtx, app)
+		return redeployExistingApp(jobCtx, regularCtx, app, opts)
 	case runtimePkg.RuntimeTypeContainerd:
 		if runtime != nil {
-			redeployWithContainerd(ctx, app, runtime, gitHubToken)
+			return redeployWithContainerd(jobCtx, ctx, app, runtime, source, opts)
 		} else {
 			// Fallback a Docker si no se pudo crear el runtime containerd
 			logrus.Warnf("No se pudo crear runtime containerd para redeploy, usando Docker como fallback")
 			regularCtx := ctx.Context
-			redeployExistingApp(regularCtx, app)
+			return redeployExistingApp(jobCtx, regularCtx, app, opts)
 		}
 	default:
 		// Fallback a Docker
 		logrus.Warnf("Runtime %s no implementado para redeploy, usando Docker como fallback", preferredRuntime)
 		regularCtx := ctx.Context
-		redeployExistingApp(regularCtx, app)
+		return redeployExistingApp(jobCtx, regularCtx, app, opts)
 	}
 }
 
 // redeployWithContainerd ejecuta el redeploy usando containerd
-func redeployWithContainerd(ctx *HybridContext, app *database.App, runtime runtimePkg.ContainerRuntime, gitHubToken string) {
+func redeployWithContainerd(jobCtx context.Context, ctx *HybridContext, app *database.App, runtime runtimePkg.ContainerRuntime, source *containerdSource, opts deployOptions) error {
 	sendHybridLogMessage(ctx, app.ID, "info", "🔄 Iniciando redeploy con containerd...")
 	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("📦 Aplicación: %s (%s)", app.Name, app.ID))
 	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("🔗 Repositorio: %s", app.RepoUrl))
@@ -955,9 +1247,9 @@ func redeployWithContainerd(ctx *HybridContext, app *database.App, runtime runti
 		logrus.Warnf("Runtime containerd es nil, usando Docker como fallback")
 		sendHybridLogMessage(ctx, app.ID, "warning", "Runtime containerd no disponible, usando Docker como fallback")
 		regularCtx := ctx.Context
-		redeployExistingApp(regularCtx, app)
-		return
+		return redeployExistingApp(jobCtx, regularCtx, app, opts)
 	}
+	recordDeploymentRuntime(app.ID, string(runtimePkg.RuntimeTypeContainerd))
 
 	// Cargar variables de entorno existentes de la base de datos
 	existingEnvVars, err := ctx.queries.GetAppEnvVars(context.Background(), app.ID)
@@ -987,86 +1279,42 @@ func redeployWithContainerd(ctx *HybridContext, app *database.App, runtime runti
 		})
 	}
 
-	// Detectar lenguaje
-	sendHybridLogMessage(ctx, app.ID, "info", "Detectando lenguaje...")
-	language, err := detectLanguage(app.RepoUrl, gitHubToken)
-	if err != nil {
-		logrus.Errorf("Error detectando lenguaje en redeploy: %v", err)
-		handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error detectando lenguaje: %v", err))
-		return
-	}
+	recordDeploymentEnv(app.ID, envVars)
+
+	// El lenguaje ya se detectó al elegir el runtime
+	language, runConfig := source.language, source.runConfig
 	app.Language = sql.NullString{String: language, Valid: true}
-	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("Lenguaje detectado: %s", language))
 
-	// Parar contenedor anterior si existe
+	// La versión anterior sigue atendiendo tráfico hasta que la nueva esté sana
 	if app.ContainerID.String != "" {
-		sendHybridLogMessage(ctx, app.ID, "info", "Deteniendo contenedor anterior...")
-		if err := runtime.StopContainer(context.Background(), app.ContainerID.String); err != nil {
-			logrus.Warnf("Error deteniendo contenedor anterior %s: %v", app.ContainerID.String, err)
-			sendHybridLogMessage(ctx, app.ID, "warning", fmt.Sprintf("Error deteniendo contenedor anterior: %v", err))
-		} else {
-			sendHybridLogMessage(ctx, app.ID, "info", "Contenedor anterior detenido exitosamente")
-		}
-
-		// Eliminar contenedor anterior
-		if err := runtime.RemoveContainer(context.Background(), app.ContainerID.String); err != nil {
-			logrus.Warnf("Error eliminando contenedor anterior %s: %v", app.ContainerID.String, err)
-			sendHybridLogMessage(ctx, app.ID, "warning", fmt.Sprintf("Error eliminando contenedor anterior: %v", err))
-		} else {
-			sendHybridLogMessage(ctx, app.ID, "info", "Contenedor anterior eliminado exitosamente")
-		}
-
-		// Limpiar container ID
-		app.ContainerID = sql.NullString{String: "", Valid: true}
+		sendHybridLogMessage(ctx, app.ID, "info", "La versión actual seguirá activa hasta que la nueva esté lista")
 	}
 
 	// Limpieza adicional para containerd - eliminar snapshots y contenedores huérfanos
 	sendHybridLogMessage(ctx, app.ID, "info", "Limpiando recursos containerd...")
-	cleanupContainerdResources(ctx, app.ID, runtime)
+	cleanupContainerdResources(ctx, app.ID, runtime, app.ContainerID.String)
+
+	// La nueva versión arranca en un puerto temporal junto a la anterior
+	candidatePort, err := findCandidatePort(app)
+	if err != nil {
+		return handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error asignando puerto temporal: %v", err))
+	}
+	candidate := *app
+	candidate.Port = candidatePort
 
 	// Determinar imagen base según el lenguaje
 	baseImage := getContainerdBaseImage(language)
 	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("Usando imagen base: %s", baseImage))
-
-	// Preparar variables de entorno con el puerto correcto
-	envVarsMap := convertEnvVarsToMap(envVars)
-
-	// Agregar variables de entorno del sistema
-	envVarsMap["PORT"] = fmt.Sprintf("%d", app.Port)
-	envVarsMap["DIPLO_APP_ID"] = app.ID
-	envVarsMap["DIPLO_APP_NAME"] = app.Name
-	envVarsMap["DIPLO_APP_PORT"] = fmt.Sprintf("%d", app.Port)
+	recordDeploymentImage(app.ID, baseImage)
 
 	// Generar nombre único para el contenedor para evitar conflictos
 	containerName := fmt.Sprintf("%s_%d", app.ID, time.Now().Unix())
 
 	// Crear request para el nuevo contenedor
-	containerReq := &runtimePkg.CreateContainerRequest{
-		Name:        containerName, // Usar nombre único para evitar conflictos
-		Image:       baseImage,
-		Command:     []string{"sleep", "infinity"}, // Comando temporal para mantener contenedor vivo
-		WorkingDir:  "/app",
-		Environment: envVarsMap,
-		Ports: []runtimePkg.PortMapping{
-			{
-				HostPort:      int(app.Port),
-				ContainerPort: int(app.Port), // Usar el mismo puerto que el host
-				Protocol:      "tcp",
-			},
-		},
-		NetworkMode: "host", // Usar host networking para containerd
-		Labels: map[string]string{
-			"app.id":   app.ID,
-			"app.name": app.Name,
-			"runtime":  "containerd",
-		},
-		Resources: &runtimePkg.ResourceConfig{
-			Memory:    512 * 1024 * 1024, // 512MB
-			CPUShares: 512,
-		},
-	}
+	containerReq := newContainerdRequest(&candidate, containerName, baseImage, envVars)
 
 	// Crear nuevo contenedor con reintentos
+	recordDeploymentStep(app.ID, "create_container")
 	sendHybridLogMessage(ctx, app.ID, "info", "Creando nuevo contenedor containerd...")
 	var container *runtimePkg.Container
 	createMaxRetries := 3
@@ -1085,22 +1333,35 @@ func redeployWithContainerd(ctx *HybridContext, app *database.App, runtime runti
 			time.Sleep(createRetryDelay)
 
 			// Limpiar recursos nuevamente antes del reintento
-			cleanupContainerdResources(ctx, app.ID, runtime)
+			cleanupContainerdResources(ctx, app.ID, runtime, app.ContainerID.String)
 		}
 	}
 
 	if err != nil {
 		logrus.Errorf("Error creando contenedor containerd después de %d intentos: %v", createMaxRetries, err)
-		handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error creando contenedor containerd después de %d intentos: %v", createMaxRetries, err))
-		return
+		return handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error creando contenedor containerd después de %d intentos: %v", createMaxRetries, err))
 	}
 
+	// Si el redeploy falla antes de cambiar el tráfico se elimina la versión nueva
+	switched := false
+	defer func() {
+		if switched {
+			return
+		}
+		if err := runtime.StopContainer(context.Background(), container.ID); err != nil {
+			logrus.Debugf("Error deteniendo contenedor fallido %s: %v", container.ID, err)
+		}
+		if err := runtime.RemoveContainer(context.Background(), container.ID); err != nil {
+			logrus.Warnf("Error eliminando contenedor fallido %s: %v", container.ID, err)
+		}
+	}()
+
 	// Iniciar nuevo contenedor
+	recordDeploymentStep(app.ID, "start_container")
 	sendHybridLogMessage(ctx, app.ID, "info", "Iniciando nuevo contenedor containerd...")
-	if err := runtime.StartContainer(context.Background(), container.ID); err != nil {
+	if err := runtime.StartContainer(jobCtx, container.ID); err != nil {
 		logrus.Errorf("Error iniciando contenedor containerd: %v", err)
-		handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error iniciando contenedor containerd: %v", err))
-		return
+		return handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error iniciando contenedor containerd: %v", err))
 	}
 
 	// Esperar a que el contenedor esté corriendo
@@ -1156,34 +1417,31 @@ func redeployWithContainerd(ctx *HybridContext, app *database.App, runtime runti
 		}
 		errorMsg := fmt.Sprintf("No se pudo obtener IP del contenedor containerd después de múltiples intentos. Logs:\n%s", logOutput)
 		logrus.Error(errorMsg)
-		handleUnifiedRedeployError(ctx, app, errorMsg)
-		return
+		return handleUnifiedRedeployError(ctx, app, errorMsg)
 	}
 
 	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("Contenedor containerd iniciado exitosamente con IP: %s", containerIP))
 
 	// Verificar que el contenedor esté realmente listo antes de ejecutar comandos
 	sendHybridLogMessage(ctx, app.ID, "info", "Verificando que el contenedor esté listo para comandos...")
-	readyCheck, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"echo", "container-ready"})
+	readyCheck, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"echo", "container-ready"})
 	if err != nil {
 		logrus.Errorf("Error verificando que el contenedor esté listo: %v", err)
-		handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error verificando que el contenedor esté listo: %v", err))
-		return
+		return handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error verificando que el contenedor esté listo: %v", err))
 	}
 	if readyCheck.ExitCode != 0 {
 		logrus.Errorf("Contenedor no está listo para comandos: %s", readyCheck.Error)
-		handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Contenedor no está listo para comandos: %s", readyCheck.Error))
-		return
+		return handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Contenedor no está listo para comandos: %s", readyCheck.Error))
 	}
 	sendHybridLogMessage(ctx, app.ID, "info", "Contenedor listo para comandos")
 
 	// Instalar git si es necesario (para imágenes Alpine)
+	recordDeploymentStep(app.ID, "dependencies")
 	sendHybridLogMessage(ctx, app.ID, "info", "Verificando dependencias...")
-	gitCheck, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"which", "git"})
+	gitCheck, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"which", "git"})
 	if err != nil {
 		logrus.Errorf("Error verificando git: %v", err)
-		handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error verificando git: %v", err))
-		return
+		return handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error verificando git: %v", err))
 	}
 
 	if gitCheck.ExitCode != 0 {
@@ -1194,7 +1452,7 @@ func redeployWithContainerd(ctx *HybridContext, app *database.App, runtime runti
 
 		// Verificar si es Alpine Linux
 		sendHybridLogMessage(ctx, app.ID, "info", "Detectando gestor de paquetes...")
-		alpineCheck, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"which", "apk"})
+		alpineCheck, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"which", "apk"})
 		if err != nil {
 			logrus.Errorf("Error verificando apk: %v", err)
 		} else {
@@ -1203,20 +1461,18 @@ func redeployWithContainerd(ctx *HybridContext, app *database.App, runtime runti
 
 		if err == nil && alpineCheck.ExitCode == 0 {
 			packageManager = "apk"
-			installResult, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"apk", "add", "--no-cache", "git"})
+			installResult, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"apk", "add", "--no-cache", "git"})
 			if err != nil {
 				logrus.Errorf("Error instalando git con apk: %v", err)
-				handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error instalando git con apk: %v", err))
-				return
+				return handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error instalando git con apk: %v", err))
 			}
 			if installResult.ExitCode != 0 {
 				logrus.Errorf("Error instalando git con apk: %s", installResult.Error)
-				handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error instalando git con apk: %s", installResult.Error))
-				return
+				return handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error instalando git con apk: %s", installResult.Error))
 			}
 		} else {
 			// Intentar con apt (Ubuntu/Debian)
-			aptCheck, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"which", "apt"})
+			aptCheck, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"which", "apt"})
 			if err != nil {
 				logrus.Errorf("Error verificando apt: %v", err)
 			} else {
@@ -1226,7 +1482,7 @@ func redeployWithContainerd(ctx *HybridContext, app *database.App, runtime runti
 			if err == nil && aptCheck.ExitCode == 0 {
 				packageManager = "apt"
 				// Actualizar repositorios primero
-				updateResult, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"apt-get", "update"})
+				updateResult, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"apt-get", "update"})
 				if err != nil {
 					logrus.Errorf("Error actualizando repositorios: %v", err)
 				}
@@ -1234,20 +1490,18 @@ func redeployWithContainerd(ctx *HybridContext, app *database.App, runtime runti
 					logrus.Warnf("Error actualizando repositorios: %s", updateResult.Error)
 				}
 
-				installResult, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"apt-get", "install", "-y", "git"})
+				installResult, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"apt-get", "install", "-y", "git"})
 				if err != nil {
 					logrus.Errorf("Error instalando git con apt: %v", err)
-					handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error instalando git con apt: %v", err))
-					return
+					return handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error instalando git con apt: %v", err))
 				}
 				if installResult.ExitCode != 0 {
 					logrus.Errorf("Error instalando git con apt: %s", installResult.Error)
-					handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error instalando git con apt: %s", installResult.Error))
-					return
+					return handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error instalando git con apt: %s", installResult.Error))
 				}
 			} else {
 				// Intentar con yum (RHEL/CentOS)
-				yumCheck, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"which", "yum"})
+				yumCheck, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"which", "yum"})
 				if err != nil {
 					logrus.Errorf("Error verificando yum: %v", err)
 				} else {
@@ -1256,23 +1510,20 @@ func redeployWithContainerd(ctx *HybridContext, app *database.App, runtime runti
 
 				if err == nil && yumCheck.ExitCode == 0 {
 					packageManager = "yum"
-					installResult, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"yum", "install", "-y", "git"})
+					installResult, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"yum", "install", "-y", "git"})
 					if err != nil {
 						logrus.Errorf("Error instalando git con yum: %v", err)
-						handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error instalando git con yum: %v", err))
-						return
+						return handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error instalando git con yum: %v", err))
 					}
 					if installResult.ExitCode != 0 {
 						logrus.Errorf("Error instalando git con yum: %s", installResult.Error)
-						handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error instalando git con yum: %s", installResult.Error))
-						return
+						return handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error instalando git con yum: %s", installResult.Error))
 					}
 				} else {
 					// No se pudo detectar el gestor de paquetes
 					errorMsg := "No se pudo detectar el gestor de paquetes (apk/apt/yum) para instalar git"
 					logrus.Error(errorMsg)
-					handleUnifiedRedeployError(ctx, app, errorMsg)
-					return
+					return handleUnifiedRedeployError(ctx, app, errorMsg)
 				}
 			}
 		}
@@ -1283,44 +1534,33 @@ func redeployWithContainerd(ctx *HybridContext, app *database.App, runtime runti
 	}
 
 	// Clonar repositorio PRIMERO
+	recordDeploymentStep(app.ID, "clone")
 	sendHybridLogMessage(ctx, app.ID, "info", "Clonando repositorio...")
 
-	var cloneCmd []string
-	if gitHubToken != "" {
-		// Usar token para repositorios privados
-		repoURLWithToken := strings.Replace(app.RepoUrl, "https://github.com/", fmt.Sprintf("https://%s@github.com/", gitHubToken), 1)
-		cloneCmd = []string{"git", "clone", repoURLWithToken, "/app/src"}
+	if opts.GitHubToken != "" {
 		sendHybridLogMessage(ctx, app.ID, "info", "Clonando repositorio privado con token de GitHub")
-	} else {
-		// Clonación normal para repositorios públicos
-		cloneCmd = []string{"git", "clone", app.RepoUrl, "/app/src"}
-		sendHybridLogMessage(ctx, app.ID, "info", "Clonando repositorio público")
 	}
-
-	cloneResult, err := runtime.ExecuteCommand(context.Background(), container.ID, cloneCmd)
+	// El mismo commit que se revisó al elegir el runtime
+	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("Usando el commit %s", source.commit))
+	commit, err := cloneContainerdSource(jobCtx, runtime, container.ID, repoURLWithToken(app.RepoUrl, opts.GitHubToken), source.commit)
 	if err != nil {
 		logrus.Errorf("Error clonando repositorio: %v", err)
-		handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error clonando repositorio: %v", err))
-		return
-	}
-	if cloneResult.ExitCode != 0 {
-		logrus.Errorf("Error clonando repositorio: %s", cloneResult.Error)
-		handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error clonando repositorio: %s", cloneResult.Error))
-		return
+		return handleUnifiedRedeployError(ctx, app, err.Error())
 	}
+	recordDeploymentCommit(app.ID, commit)
 
 	// Compilación Go con debug
+	recordDeploymentStep(app.ID, "build")
 	sendHybridLogMessage(ctx, app.ID, "info", "Compilando aplicación Go...")
 	// Verificar que Go esté instalado
-	goVersionResult, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"go", "version"})
+	goVersionResult, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"go", "version"})
 	if err != nil {
 		logrus.Errorf("Error verificando versión de Go: %v", err)
-		handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error verificando versión de Go: %v", err))
-		return
+		return handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error verificando versión de Go: %v", err))
 	}
 	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("Go instalado: %s", goVersionResult.Output))
 	// Verificar el contenido del directorio
-	lsResult, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"ls", "-la", "/app/src"})
+	lsResult, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"ls", "-la", "/app/src"})
 	if err != nil {
 		logrus.Errorf("Error listando directorio: %v", err)
 	} else {
@@ -1329,10 +1569,10 @@ func redeployWithContainerd(ctx *HybridContext, app *database.App, runtime runti
 
 	// Verificar go.mod y mostrar información para debug
 	sendHybridLogMessage(ctx, app.ID, "info", "Verificando go.mod...")
-	_, err = runtime.ExecuteCommand(context.Background(), container.ID, []string{"ls", "-la", "/app/src/go.mod"})
+	_, err = runtime.ExecuteCommand(jobCtx, container.ID, []string{"ls", "-la", "/app/src/go.mod"})
 	if err == nil {
 		// go.mod existe, mostrar su contenido para debug
-		goModContent, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"cat", "/app/src/go.mod"})
+		goModContent, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"cat", "/app/src/go.mod"})
 		if err == nil {
 			sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("Contenido de go.mod: %s", goModContent.Output))
 		}
@@ -1341,37 +1581,46 @@ func redeployWithContainerd(ctx *HybridContext, app *database.App, runtime runti
 	}
 
 	// Intentar compilar con más información de debug
-	buildResult, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"sh", "-c", "cd /app/src && go mod tidy && go build -v -o /app/app ."})
+	buildResult, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"sh", "-c", source.buildCommand})
 	if err != nil {
 		logrus.Errorf("Error compilando aplicación: %v", err)
-		handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error compilando aplicación: %v", err))
-		return
+		return handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error compilando aplicación: %v", err))
 	}
 	if buildResult.ExitCode != 0 {
 		logrus.Errorf("Error compilando aplicación: %s", buildResult.Error)
 		logrus.Errorf("Output de compilación: %s", buildResult.Output)
-		handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error compilando aplicación: %s\nOutput: %s", buildResult.Error, buildResult.Output))
-		return
+		return handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error compilando aplicación: %s\nOutput: %s", buildResult.Error, buildResult.Output))
 	}
 
 	sendHybridLogMessage(ctx, app.ID, "info", "Ejecutando aplicación...")
-	// Ejecutar la app en background con el puerto correcto
-	execCmd := fmt.Sprintf("cd /app && PORT=%d nohup ./app > /app/app.log 2>&1 &", app.Port)
-	execResult, err := runtime.ExecuteCommand(context.Background(), container.ID, []string{"sh", "-c", execCmd})
+	// Ejecutar la app en background en el puerto temporal
+	recordDeploymentStep(app.ID, "run")
+	execCmd := containerdStartCommand(candidate.Port)
+	execResult, err := runtime.ExecuteCommand(jobCtx, container.ID, []string{"sh", "-c", execCmd})
 	if err != nil {
 		logrus.Errorf("Error ejecutando aplicación: %v", err)
-		handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error ejecutando aplicación: %v", err))
-		return
+		return handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error ejecutando aplicación: %v", err))
 	}
 	if execResult.ExitCode != 0 {
 		logrus.Errorf("Error ejecutando aplicación: %s", execResult.Error)
-		handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error ejecutando aplicación: %s", execResult.Error))
-		return
+		return handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Error ejecutando aplicación: %s", execResult.Error))
 	}
 
 	sendHybridLogMessage(ctx, app.ID, "success", "Aplicación Go compilada y ejecutada exitosamente")
 
+	// Esperar a que la nueva versión responda antes de cambiar el tráfico
+	recordDeploymentStep(app.ID, "health_check")
+	healthPath, healthTimeout := runConfigHealthCheck(app, runConfig)
+	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("Esperando a que la nueva versión responda %s...", describeHealthCheck(healthPath)))
+	if err := waitForHTTPHealthy(jobCtx, candidate.Port, healthPath, healthTimeout); err != nil {
+		logrus.Errorf("Nueva versión de %s no saludable: %v", app.ID, err)
+		return handleUnifiedRedeployError(ctx, app, fmt.Sprintf("Health check fallido: %v", err))
+	}
+	sendHybridLogMessage(ctx, app.ID, "success", "Nueva versión saludable, cambiando tráfico")
+	recordDeploymentStep(app.ID, "switch_traffic")
+
 	// Actualizar aplicación con información del nuevo contenedor
+	oldContainerID, oldPort := app.ContainerID.String, activePort(app)
 	app.Status = database.StatusRunning
 	app.ContainerID = sql.NullString{String: container.ID, Valid: true}
 	app.UpdatedAt = sql.NullTime{Time: time.Now(), Valid: true}
@@ -1386,11 +1635,27 @@ func redeployWithContainerd(ctx *HybridContext, app *database.App, runtime runti
 		Status:      app.Status,
 		ErrorMsg:    app.ErrorMsg,
 		ContainerID: app.ContainerID,
+		ImageID:     app.ImageID,
 		UpdatedAt:   app.UpdatedAt,
 	}); err != nil {
 		logrus.Errorf("Error actualizando aplicación después del redeploy: %v", err)
 	}
 
+	if err := promoteUpstream(ctx.queries, app, candidate.Port); err != nil {
+		logrus.Errorf("Error guardando el puerto de la nueva versión de %s: %v", app.ID, err)
+	}
+
+	// Guardar imagen y configuración para poder recrear el contenedor en la recuperación
+	if err := saveContainerdSpec(ctx.queries, app, containerReq, source, opts.GitHubToken); err != nil {
+		logrus.Errorf("Error guardando configuración containerd de la app %s: %v", app.ID, err)
+	}
+	refreshRoutes(ctx.Context)
+	switched = true
+
+	// Drenar y eliminar la versión anterior; el runtime se cierra al terminar la
+	// función, así que se usa un cliente propio
+	go drainContainer(ctx.Context, app.ID, oldContainerID, oldPort, removeContainerdContainer)
+
 	// Mensajes informativos finales detallados
 	sendHybridLogMessage(ctx, app.ID, "success", "🎉 ¡Redeploy completado exitosamente!")
 	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("📋 Información del contenedor:"))
@@ -1399,10 +1664,11 @@ func redeployWithContainerd(ctx *HybridContext, app *database.App, runtime runti
 	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("   • IP: %s", containerIP))
 	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("   • Puerto: %d", app.Port))
 	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("   • Lenguaje: %s", language))
-	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("   • Estado: %s", app.Status))
+	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("   • Estado: %s", app.Status.String))
 
 	sendHybridLogMessage(ctx, app.ID, "success", fmt.Sprintf("🌐 Aplicación disponible en: http://%s:%d", containerIP, app.Port))
 	sendHybridLogMessage(ctx, app.ID, "success", fmt.Sprintf("🔗 URL local: http://localhost:%d", app.Port))
+	sendHybridLogMessage(ctx, app.ID, "success", fmt.Sprintf("🔀 URL del proxy: %s", appURL(ctx.Context, app)))
 
 	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("📊 Recursos asignados:"))
 	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("   • Memoria: 512MB"))
@@ -1411,6 +1677,7 @@ func redeployWithContainerd(ctx *HybridContext, app *database.App, runtime runti
 
 	sendHybridLogMessage(ctx, app.ID, "info", fmt.Sprintf("📝 Logs disponibles en: /app/app.log dentro del contenedor"))
 	sendHybridLogMessage(ctx, app.ID, "success", fmt.Sprintf("✅ Contenedor %s listo y funcionando", container.ID))
+	return nil
 }
 
 func convertEnvVarsToMap(envVars []models.EnvVar) map[string]string {
@@ -1423,7 +1690,7 @@ func convertEnvVarsToMap(envVars []models.EnvVar) map[string]string {
 
 // Error handling functions
 
-func handleUnifiedDeployError(ctx *HybridContext, app *database.App, errorMsg string) {
+func handleUnifiedDeployError(ctx *HybridContext, app *database.App, errorMsg string) error {
 	app.Status = database.StatusError
 	app.ErrorMsg = sql.NullString{String: errorMsg, Valid: true}
 	if err := ctx.queries.UpdateApp(context.Background(), database.UpdateAppParams{
@@ -1440,10 +1707,11 @@ func handleUnifiedDeployError(ctx *HybridContext, app *database.App, errorMsg st
 		logrus.Errorf("Error actualizando aplicación con error de deployment: %v", err)
 	}
 	sendHybridLogMessage(ctx, app.ID, "error", errorMsg)
+	return errors.New(errorMsg)
 }
 
-func handleUnifiedRedeployError(ctx *HybridContext, app *database.App, errorMsg string) {
-	app.Status = database.StatusError
+func handleUnifiedRedeployError(ctx *HybridContext, app *database.App, errorMsg string) error {
+	errorMsg = redeployFailureStatus(app, errorMsg)
 	app.ErrorMsg = sql.NullString{String: errorMsg, Valid: true}
 	if err := ctx.queries.UpdateApp(context.Background(), database.UpdateAppParams{
 		ID:          app.ID,
@@ -1454,15 +1722,19 @@ func handleUnifiedRedeployError(ctx *HybridContext, app *database.App, errorMsg
 		Status:      app.Status,
 		ErrorMsg:    app.ErrorMsg,
 		ContainerID: app.ContainerID,
+		ImageID:     app.ImageID,
 		UpdatedAt:   sql.NullTime{Time: time.Now(), Valid: true},
 	}); err != nil {
 		logrus.Errorf("Error actualizando aplicación con error de redeploy: %v", err)
 	}
 	sendHybridLogMessage(ctx, app.ID, "error", errorMsg)
+	return errors.New(errorMsg)
 }
 
 // sendHybridLogMessage envía un mensaje de log desde el contexto híbrido
 func sendHybridLogMessage(ctx *HybridContext, appID, logType, message string) {
+	recordDeploymentLog(appID, logType, message)
+
 	// Usar el contexto regular para enviar logs
 	regularCtx := ctx.Context
 	regularCtx.logMu.RLock()
@@ -1488,6 +1760,11 @@ func sendUnifiedRuntimeEvent(ctx *HybridContext, appID string, event runtimePkg.
 	}
 
 	sendHybridLogMessage(ctx, appID, eventType, event.Message)
+
+	// La salida de los comandos (git clone, build, ...) solo va al build_log
+	if output, ok := event.Metadata["output"].(string); ok && strings.TrimSpace(output) != "" {
+		recordDeploymentLog(appID, "info", strings.TrimSpace(output))
+	}
 }
 
 // getContainerdBaseImage retorna la imagen base para containerd según el lenguaje
@@ -1506,8 +1783,9 @@ func getContainerdBaseImage(language string) string {
 	}
 }
 
-// cleanupContainerdResources limpia recursos containerd para evitar conflictos
-func cleanupContainerdResources(ctx *HybridContext, appID string, runtime runtimePkg.ContainerRuntime) {
+// cleanupContainerdResources limpia recursos containerd para evitar conflictos;
+// keepID es el contenedor que sigue sirviendo la app y no se toca
+func cleanupContainerdResources(ctx *HybridContext, appID string, runtime runtimePkg.ContainerRuntime, keepID string) {
 	// Verificar que el runtime esté disponible antes de intentar limpiar
 	if runtime == nil {
 		logrus.Warnf("Runtime es nil, saltando limpieza de recursos")
@@ -1524,6 +1802,10 @@ func cleanupContainerdResources(ctx *HybridContext, appID string, runtime runtim
 
 	cleanupErrors := 0
 	for _, containerName := range containerNames {
+		if containerName == keepID || fmt.Sprintf("diplo-%s", containerName) == keepID {
+			continue
+		}
+
 		// Intentar detener el contenedor si está corriendo
 		if err := runtime.StopContainer(context.Background(), containerName); err != nil {
 			logrus.Debugf("No se pudo detener contenedor %s (puede no existir): %v", containerName, err)
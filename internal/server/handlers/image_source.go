@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/distribution/reference"
+	"github.com/rodrwan/diplo/internal/database"
+	"github.com/rodrwan/diplo/internal/docker"
+	"github.com/rodrwan/diplo/internal/models"
+)
+
+// isImageApp indica si la app se despliega desde una imagen ya construida en vez de un repo
+func isImageApp(app *database.App) bool {
+	return app.Image.String != ""
+}
+
+// parseImageRef valida una referencia de imagen y la normaliza: sin tag ni
+// digest se usa latest y las imágenes de Docker Hub quedan en su forma corta
+// ("nginx:latest", "ghcr.io/org/tool:1.2")
+func parseImageRef(image string) (reference.Named, error) {
+	named, err := reference.ParseNormalizedNamed(strings.TrimSpace(image))
+	if err != nil {
+		return nil, fmt.Errorf("image inválida: %q: %v", image, err)
+	}
+	return reference.TagNameOnly(named), nil
+}
+
+// repoOnlyField devuelve el primer campo del request que solo aplica a apps
+// que se construyen desde un repo, o "" si no hay ninguno
+func repoOnlyField(req models.DeployRequest) string {
+	switch {
+	case req.Ref != "":
+		return "ref"
+	case req.Path != "":
+		return "path"
+	case req.DockerfilePath != "":
+		return "dockerfile_path"
+	case req.StartCommand != "":
+		return "start_command"
+	case req.Language != "":
+		return "language"
+	case req.GoTarget != nil || req.GoTags != nil || req.GoLDFlags != nil:
+		return "go_target, go_tags y go_ldflags"
+	case req.GitHubToken != "":
+		return "github_token"
+	case req.ForceRebuild:
+		return "force_rebuild"
+	}
+	return ""
+}
+
+// imageUpdate arma los parámetros para guardar la imagen de la app y las
+// credenciales de su registry; las credenciales omitidas se mantienen y
+// registry_password se guarda cifrado
+func imageUpdate(req models.DeployRequest, image reference.Named, app database.App) (database.UpdateAppImageParams, error) {
+	params := database.UpdateAppImageParams{
+		Image:            sql.NullString{String: reference.FamiliarString(image), Valid: true},
+		RegistryUsername: app.RegistryUsername,
+		RegistryPassword: app.RegistryPassword,
+		UpdatedAt:        sql.NullTime{Time: time.Now(), Valid: true},
+		ID:               app.ID,
+	}
+	if req.RegistryUsername != nil {
+		params.RegistryUsername = sql.NullString{String: *req.RegistryUsername, Valid: *req.RegistryUsername != ""}
+	}
+	if req.RegistryPassword != nil {
+		encrypted, err := encryptValue(*req.RegistryPassword)
+		if err != nil {
+			return params, fmt.Errorf("error cifrando registry_password: %w", err)
+		}
+		params.RegistryPassword = sql.NullString{String: encrypted, Valid: encrypted != ""}
+	}
+	return params, nil
+}
+
+// findImageApp busca la app del mismo repositorio de imagen sin importar el
+// tag, así desplegar una versión nueva redespliega la app. Devuelve
+// sql.ErrNoRows si no hay.
+func findImageApp(ctx context.Context, queries database.Querier, named reference.Named) (database.App, error) {
+	apps, err := queries.ListImageApps(ctx)
+	if err != nil {
+		return database.App{}, err
+	}
+	for _, app := range apps {
+		existing, err := reference.ParseNormalizedNamed(app.Image.String)
+		if err == nil && existing.Name() == named.Name() {
+			return app, nil
+		}
+	}
+	return database.App{}, sql.ErrNoRows
+}
+
+// registryAuth devuelve las credenciales del registry de la imagen de la app
+func registryAuth(app *database.App) (docker.RegistryAuth, error) {
+	named, err := reference.ParseNormalizedNamed(app.Image.String)
+	if err != nil {
+		return docker.RegistryAuth{}, fmt.Errorf("image inválida: %q: %v", app.Image.String, err)
+	}
+	auth := docker.RegistryAuth{
+		ServerAddress: reference.Domain(named),
+		Username:      app.RegistryUsername.String,
+	}
+	if app.RegistryPassword.String != "" {
+		if auth.Password, err = decryptValue(app.RegistryPassword.String); err != nil {
+			return docker.RegistryAuth{}, fmt.Errorf("error descifrando registry_password: %w", err)
+		}
+	}
+	return auth, nil
+}
+
+// pullAppImage descarga la imagen de una app de imagen y le pone el tag de la
+// app, así los rollbacks y la limpieza de imágenes antiguas la tratan como a
+// las construidas. Devuelve el tag y el ID de la imagen.
+func pullAppImage(jobCtx context.Context, ctx *Context, app *database.App) (imageTag, imageID string, err error) {
+	recordDeploymentStep(app.ID, "pull")
+	sendLogMessage(ctx, app.ID, "info", fmt.Sprintf("Descargando imagen %s...", app.Image.String))
+
+	auth, err := registryAuth(app)
+	if err != nil {
+		return "", "", err
+	}
+	imageID, err = ctx.docker.PullImage(jobCtx, app.Image.String, auth)
+	if err != nil {
+		return "", "", err
+	}
+
+	recordDeploymentStep(app.ID, "image_tag")
+	imageTag = docker.ImageTagForImageID(app.ID, imageID)
+	if err := ctx.docker.TagImage(jobCtx, imageID, imageTag); err != nil {
+		return "", "", err
+	}
+	recordDeploymentImage(app.ID, imageTag)
+	sendLogMessage(ctx, app.ID, "success", fmt.Sprintf("Imagen descargada: %s (tag %s)", app.Image.String, imageTag))
+	return imageTag, imageID, nil
+}
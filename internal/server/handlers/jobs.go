@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rodrwan/diplo/internal/database"
+	"github.com/rodrwan/diplo/internal/dto"
+	"github.com/rodrwan/diplo/internal/jobs"
+	runtimePkg "github.com/rodrwan/diplo/internal/runtime"
+	"github.com/sirupsen/logrus"
+)
+
+// Tipos de trabajo de la cola de deploys
+const (
+	JobKindDeploy   = "deploy"
+	JobKindRedeploy = "redeploy"
+	JobKindRollback = "rollback"
+)
+
+// Valores de on_conflict en POST /api/deploy
+const (
+	DeployConflictQueue   = "queue"
+	DeployConflictReplace = "replace"
+)
+
+const (
+	defaultJobsLimit = 50
+	maxJobsLimit     = 200
+)
+
+// deployJobPayload son los parámetros de un trabajo; se guarda como JSON con
+// el token de GitHub cifrado
+type deployJobPayload struct {
+	deployOptions
+	DeploymentID int64 `json:"deployment_id,omitempty"`
+}
+
+// enqueueDeployJob encola un trabajo de deploy, redeploy o rollback para la app
+func enqueueDeployJob(ctx context.Context, c *Context, appID, kind string, payload deployJobPayload) (database.Job, error) {
+	if payload.GitHubToken != "" {
+		encrypted, err := encryptValue(payload.GitHubToken)
+		if err != nil {
+			return database.Job{}, fmt.Errorf("error cifrando github_token del trabajo: %v", err)
+		}
+		payload.GitHubToken = encrypted
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return database.Job{}, err
+	}
+	return c.jobs.Enqueue(ctx, appID, kind, string(data))
+}
+
+// decodeJobPayload lee los parámetros de un trabajo y descifra su token de GitHub
+func decodeJobPayload(raw string) (deployJobPayload, error) {
+	var payload deployJobPayload
+	if err := json.Unmarshal([]byte(raw), &payload); err != nil {
+		return payload, fmt.Errorf("parámetros del trabajo inválidos: %v", err)
+	}
+	if payload.GitHubToken != "" {
+		token, err := decryptValue(payload.GitHubToken)
+		if err != nil {
+			return payload, fmt.Errorf("error descifrando github_token del trabajo: %v", err)
+		}
+		payload.GitHubToken = token
+	}
+	return payload, nil
+}
+
+// enqueueAppDeployJob encola un trabajo respetando on_conflict: con "replace"
+// cancela antes los trabajos pendientes de la app. Devuelve el trabajo y cuántos
+// trabajos quedan por delante o se reemplazaron.
+func enqueueAppDeployJob(ctx context.Context, c *Context, appID, kind, onConflict string, payload deployJobPayload) (database.Job, int64, error) {
+	var ahead int64
+	if onConflict == DeployConflictReplace {
+		replaced, err := c.jobs.CancelApp(ctx, appID)
+		if err != nil {
+			return database.Job{}, 0, fmt.Errorf("error cancelando deploys en curso: %v", err)
+		}
+		if replaced > 0 {
+			logrus.Infof("%d trabajos de la app %s reemplazados por un deploy nuevo", replaced, appID)
+		}
+		ahead = int64(replaced)
+	} else {
+		pending, err := c.queries.CountPendingAppJobs(ctx, appID)
+		if err != nil {
+			return database.Job{}, 0, err
+		}
+		ahead = pending
+	}
+
+	job, err := enqueueDeployJob(ctx, c, appID, kind, payload)
+	return job, ahead, err
+}
+
+// DeployJobHandler ejecuta los trabajos de la cola; el contexto del trabajo
+// llega hasta git, el build y el runtime para poder cancelarlos
+func DeployJobHandler(ctx *HybridContext) jobs.Handler {
+	return func(jobCtx context.Context, job database.Job) error {
+		var payload deployJobPayload
+		if job.Payload.Valid {
+			decoded, err := decodeJobPayload(job.Payload.String)
+			if err != nil {
+				return err
+			}
+			payload = decoded
+		}
+
+		app, err := ctx.queries.GetApp(jobCtx, job.AppID)
+		if err != nil {
+			return fmt.Errorf("error obteniendo aplicación %s: %v", job.AppID, err)
+		}
+
+		if job.Kind == JobKindRollback {
+			target, err := ctx.queries.GetDeployment(jobCtx, payload.DeploymentID)
+			if err != nil {
+				return fmt.Errorf("error obteniendo deployment %d: %v", payload.DeploymentID, err)
+			}
+			return rollbackApp(jobCtx, ctx.Context, &app, target, payload.Trigger)
+		}
+
+		factory, ok := ctx.runtimeFactory.(runtimePkg.RuntimeFactory)
+		if !ok {
+			return errors.New("runtime factory no es del tipo correcto")
+		}
+
+		switch job.Kind {
+		case JobKindDeploy:
+			return unifiedDeployApp(jobCtx, ctx, &app, factory, payload.deployOptions)
+		case JobKindRedeploy:
+			return unifiedRedeployApp(jobCtx, ctx, &app, factory, payload.deployOptions)
+		default:
+			return fmt.Errorf("tipo de trabajo desconocido: %s", job.Kind)
+		}
+	}
+}
+
+// ResetStuckApps saca del estado deploying/redeploying a las apps sin trabajos
+// pendientes: su deploy se perdió con el proceso anterior
+func ResetStuckApps(ctx context.Context, queries database.Querier) {
+	apps, err := queries.GetAllApps(ctx)
+	if err != nil {
+		logrus.Warnf("Error revisando apps con deploys interrumpidos: %v", err)
+		return
+	}
+
+	for _, app := range apps {
+		if app.Status.String != database.StatusDeploying.String && app.Status.String != database.StatusRedeploying.String {
+			continue
+		}
+		pending, err := queries.CountPendingAppJobs(ctx, app.ID)
+		if err != nil || pending > 0 {
+			continue
+		}
+
+		errorMsg := redeployFailureStatus(&app, "Deployment interrumpido por reinicio del servidor")
+		app.ErrorMsg = sql.NullString{String: errorMsg, Valid: true}
+		if err := queries.UpdateApp(ctx, database.UpdateAppParams{
+			ID:          app.ID,
+			Name:        app.Name,
+			RepoUrl:     app.RepoUrl,
+			Language:    app.Language,
+			Port:        app.Port,
+			Status:      app.Status,
+			ErrorMsg:    app.ErrorMsg,
+			ContainerID: app.ContainerID,
+			ImageID:     app.ImageID,
+			UpdatedAt:   sql.NullTime{Time: time.Now(), Valid: true},
+		}); err != nil {
+			logrus.Warnf("Error restaurando estado de la app %s: %v", app.ID, err)
+			continue
+		}
+		logrus.Infof("App %s quedó en %s tras un deploy interrumpido", app.ID, app.Status.String)
+	}
+}
+
+// ListJobsHandler lista los trabajos más recientes de la cola
+func ListJobsHandler(ctx *Context, w http.ResponseWriter, r *http.Request) (Response, error) {
+	limit := int64(defaultJobsLimit)
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed <= 0 {
+			return Response{Code: http.StatusBadRequest, Message: "limit inválido"}, nil
+		}
+		limit = min(parsed, maxJobsLimit)
+	}
+
+	items, err := ctx.queries.ListJobs(r.Context(), limit)
+	if err != nil {
+		return Response{Code: http.StatusInternalServerError, Message: "Error obteniendo trabajos"}, err
+	}
+
+	result := make([]dto.Job, 0, len(items))
+	for _, job := range items {
+		result = append(result, jobDTO(job))
+	}
+	return Response{Code: http.StatusOK, Data: result}, nil
+}
+
+// GetJobHandler devuelve el estado de un trabajo
+func GetJobHandler(ctx *Context, w http.ResponseWriter, r *http.Request) (Response, error) {
+	job, err := ctx.queries.GetJob(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Response{Code: http.StatusNotFound, Message: "Trabajo no encontrado"}, nil
+		}
+		return Response{Code: http.StatusInternalServerError, Message: "Error obteniendo trabajo"}, err
+	}
+
+	return Response{Code: http.StatusOK, Data: jobDTO(job)}, nil
+}
+
+// CancelJobHandler cancela un trabajo en cola o en ejecución
+func CancelJobHandler(ctx *Context, w http.ResponseWriter, r *http.Request) (Response, error) {
+	job, err := ctx.jobs.Cancel(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Response{Code: http.StatusNotFound, Message: "Trabajo no encontrado"}, nil
+		}
+		if errors.Is(err, jobs.ErrFinished) {
+			return Response{Code: http.StatusConflict, Message: fmt.Sprintf("El trabajo ya finalizó con estado %s", job.Status)}, nil
+		}
+		return Response{Code: http.StatusInternalServerError, Message: "Error cancelando trabajo"}, err
+	}
+
+	return Response{Code: http.StatusOK, Data: jobDTO(job), Message: "Cancelación solicitada"}, nil
+}
+
+func jobDTO(job database.Job) dto.Job {
+	item := dto.Job{
+		ID:        job.ID,
+		AppID:     job.AppID,
+		Kind:      job.Kind,
+		Status:    job.Status,
+		Error:     job.ErrorMsg.String,
+		Attempts:  job.Attempts,
+		CreatedAt: job.CreatedAt.Format(time.RFC3339),
+	}
+	if job.StartedAt.Valid {
+		item.StartedAt = job.StartedAt.Time.Format(time.RFC3339)
+	}
+	if job.FinishedAt.Valid {
+		item.FinishedAt = job.FinishedAt.Time.Format(time.RFC3339)
+	}
+	return item
+}
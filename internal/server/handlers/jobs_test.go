@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rodrwan/diplo/internal/database"
+	"github.com/rodrwan/diplo/internal/jobs"
+)
+
+// newTestContext crea un Context con una base de datos SQLite temporal y la
+// app indicada
+func newTestContext(t *testing.T, app database.CreateAppParams) *Context {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "diplo.db")+"?mode=rwc")
+	if err != nil {
+		t.Fatalf("abriendo base de datos: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	queries := database.New(db)
+	if err := queries.CreateTables(context.Background()); err != nil {
+		t.Fatalf("CreateTables: %v", err)
+	}
+	if err := queries.CreateApp(context.Background(), app); err != nil {
+		t.Fatalf("CreateApp: %v", err)
+	}
+
+	ctx := NewContext(nil, queries, map[string]chan string{})
+	ctx.SetJobQueue(jobs.New(queries, 1))
+	return ctx
+}
+
+func TestEnqueueDeployJobWithoutEncryptionKey(t *testing.T) {
+	t.Setenv("DIPLO_ENCRYPTION_KEY", "")
+
+	ctx := newTestContext(t, database.CreateAppParams{ID: "app1", Name: "app1", RepoUrl: "https://github.com/org/app1", Port: 3000})
+
+	job, err := enqueueDeployJob(context.Background(), ctx, "app1", JobKindRedeploy, deployJobPayload{
+		deployOptions: deployOptions{Trigger: DeploymentTriggerAPI, CommitSHA: "abc123"},
+	})
+	if err != nil {
+		t.Fatalf("enqueueDeployJob: %v", err)
+	}
+
+	stored, err := ctx.queries.GetJob(context.Background(), job.ID)
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	payload, err := decodeJobPayload(stored.Payload.String)
+	if err != nil {
+		t.Fatalf("decodeJobPayload: %v", err)
+	}
+	if payload.Trigger != DeploymentTriggerAPI || payload.CommitSHA != "abc123" {
+		t.Errorf("payload = %+v, se esperaba trigger %q y commit abc123", payload, DeploymentTriggerAPI)
+	}
+}
+
+func TestEnqueueDeployJobEncryptsGitHubToken(t *testing.T) {
+	t.Setenv("DIPLO_ENCRYPTION_KEY", "0123456789abcdef0123456789abcdef")
+
+	ctx := newTestContext(t, database.CreateAppParams{ID: "app1", Name: "app1", RepoUrl: "https://github.com/org/app1", Port: 3000})
+
+	job, err := enqueueDeployJob(context.Background(), ctx, "app1", JobKindDeploy, deployJobPayload{
+		deployOptions: deployOptions{GitHubToken: "ghp_secreto", Trigger: DeploymentTriggerAPI},
+	})
+	if err != nil {
+		t.Fatalf("enqueueDeployJob: %v", err)
+	}
+
+	stored, err := ctx.queries.GetJob(context.Background(), job.ID)
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	payload, err := decodeJobPayload(stored.Payload.String)
+	if err != nil {
+		t.Fatalf("decodeJobPayload: %v", err)
+	}
+	if payload.GitHubToken != "ghp_secreto" {
+		t.Errorf("GitHubToken = %q, se esperaba el token original", payload.GitHubToken)
+	}
+	if strings.Contains(stored.Payload.String, "ghp_secreto") {
+		t.Error("el payload guardado contiene el token en claro")
+	}
+}
+
+func TestDecodeJobPayloadReportsInvalidJSON(t *testing.T) {
+	t.Setenv("DIPLO_ENCRYPTION_KEY", "0123456789abcdef0123456789abcdef")
+
+	_, err := decodeJobPayload(`{"trigger": "api"`)
+	if err == nil || !strings.Contains(err.Error(), "parámetros del trabajo inválidos") {
+		t.Errorf("decodeJobPayload err = %v, se esperaba un error de JSON inválido", err)
+	}
+}
@@ -0,0 +1,310 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rodrwan/diplo/internal/database"
+	"github.com/rodrwan/diplo/internal/docker"
+	"github.com/rodrwan/diplo/internal/dto"
+	"github.com/rodrwan/diplo/internal/models"
+	runtimePkg "github.com/rodrwan/diplo/internal/runtime"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// releaseProcess es el proceso de Heroku que corre una vez por deploy; no se soporta
+	releaseProcess = "release"
+	// defaultProcessScale son las instancias de un proceso sin escala configurada
+	defaultProcessScale = 1
+	// maxProcessScale acota las instancias de un tipo de proceso
+	maxProcessScale = 10
+)
+
+// processLocks serializa la sincronización de procesos de cada app: deploys,
+// cambios de escala y el reconciliador pueden coincidir
+var processLocks sync.Map
+
+// syncProcesses deja corriendo, de cada proceso no web del Procfile de la imagen
+// actual de la app, tantas instancias como su escala. Elimina los contenedores
+// de otra imagen, de tipos que ya no existen o que sobran, y crea los que faltan.
+// El proceso web es el contenedor de la app y no se toca aquí.
+func syncProcesses(ctx context.Context, d *docker.Client, queries database.Querier, app *database.App, envVars []models.EnvVar) error {
+	lock, _ := processLocks.LoadOrStore(app.ID, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	desired := make(map[string]int)
+	if app.ImageID.String != "" {
+		runConfig, err := d.ImageRunConfig(ctx, app.ImageID.String)
+		if err != nil {
+			return err
+		}
+		scales, err := appProcessScales(ctx, queries, app.ID)
+		if err != nil {
+			return err
+		}
+		for process := range runConfig.Processes {
+			if process == docker.WebProcess {
+				continue
+			}
+			desired[process] = defaultProcessScale
+			if scale, ok := scales[process]; ok {
+				desired[process] = int(scale)
+			}
+		}
+	}
+
+	containers, err := d.ListProcessContainers(ctx, app.ID)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	running := make(map[string]bool)
+	for _, c := range containers {
+		key := fmt.Sprintf("%s.%d", c.Process, c.Index)
+		// Docker reinicia los contenedores que terminan (restart policy always)
+		alive := c.State == "running" || c.State == "restarting"
+		keep := c.Index < desired[c.Process] && sameImage(c.ImageID, app.ImageID.String) && alive && !running[key]
+		if keep {
+			running[key] = true
+			continue
+		}
+		if err := d.RemoveContainer(c.ID); err != nil {
+			errs = append(errs, fmt.Errorf("error eliminando %s: %w", key, err))
+		}
+	}
+
+	for _, process := range slices.Sorted(maps.Keys(desired)) {
+		for index := 0; index < desired[process]; index++ {
+			key := fmt.Sprintf("%s.%d", process, index)
+			if running[key] {
+				continue
+			}
+			if _, err := d.RunProcess(ctx, app, app.ImageID.String, envVars, process, index); err != nil {
+				errs = append(errs, fmt.Errorf("error iniciando %s: %w", key, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// syncDeployProcesses sincroniza los procesos tras cambiar la versión activa e
+// informa el resultado en el log del deploy. Un proceso que no arranca no
+// revierte el deploy: el reconciliador lo reintenta.
+func syncDeployProcesses(jobCtx context.Context, ctx *Context, app *database.App, envVars []models.EnvVar) {
+	if err := syncProcesses(jobCtx, ctx.docker, ctx.queries, app, envVars); err != nil {
+		logrus.Warnf("Error sincronizando procesos de %s: %v", app.ID, err)
+		sendLogMessage(ctx, app.ID, "warning", fmt.Sprintf("Error iniciando procesos: %v", err))
+		return
+	}
+
+	containers, err := ctx.docker.ListProcessContainers(jobCtx, app.ID)
+	if err != nil || len(containers) == 0 {
+		return
+	}
+	counts := make(map[string]int)
+	for _, c := range containers {
+		counts[c.Process]++
+	}
+	summary := make([]string, 0, len(counts))
+	for _, process := range slices.Sorted(maps.Keys(counts)) {
+		summary = append(summary, fmt.Sprintf("%s x%d", process, counts[process]))
+	}
+	sendLogMessage(ctx, app.ID, "success", fmt.Sprintf("Procesos en ejecución: %s", strings.Join(summary, ", ")))
+}
+
+// removeProcessContainers elimina todos los contenedores de procesos no web de la app
+func removeProcessContainers(ctx context.Context, d *docker.Client, appID string) error {
+	containers, err := d.ListProcessContainers(ctx, appID)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, c := range containers {
+		if err := d.RemoveContainer(c.ID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// appProcessScales devuelve la escala configurada de cada tipo de proceso
+func appProcessScales(ctx context.Context, queries database.Querier, appID string) (map[string]int64, error) {
+	rows, err := queries.ListAppProcesses(ctx, appID)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo escala de procesos: %w", err)
+	}
+	scales := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		scales[row.Type] = row.Scale
+	}
+	return scales, nil
+}
+
+// sameImage compara IDs de imagen con o sin el prefijo "sha256:"
+func sameImage(a, b string) bool {
+	return a != "" && strings.TrimPrefix(a, "sha256:") == strings.TrimPrefix(b, "sha256:")
+}
+
+// webScaleUnsupportedMessage explica por qué el proceso web no se escala: el proxy
+// y el blue/green trabajan con un solo contenedor web por app
+const webScaleUnsupportedMessage = "Escalar el proceso web no está soportado: el proxy envía el tráfico a un solo contenedor por app, así que web siempre tiene una instancia. Solo se escalan los demás procesos del Procfile"
+
+// ScaleProcessRequest es el cuerpo de PUT /api/v1/apps/{id}/processes/{type}
+type ScaleProcessRequest struct {
+	Scale *int64 `json:"scale"`
+}
+
+// GetProcessesHandler devuelve el estado de cada proceso de la app
+func GetProcessesHandler(ctx *Context, w http.ResponseWriter, r *http.Request) (Response, error) {
+	app, err := ctx.queries.GetApp(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		return Response{Code: http.StatusNotFound, Message: "Aplicación no encontrada"}, nil
+	}
+
+	processes, err := appProcesses(r.Context(), ctx, &app)
+	if err != nil {
+		return Response{Code: http.StatusInternalServerError, Message: "Error obteniendo procesos"}, err
+	}
+	return Response{Code: http.StatusOK, Data: processes}, nil
+}
+
+// ScaleProcessHandler cambia las instancias de un proceso no web y aplica el cambio
+func ScaleProcessHandler(ctx *Context, w http.ResponseWriter, r *http.Request) (Response, error) {
+	vars := mux.Vars(r)
+	app, err := ctx.queries.GetApp(r.Context(), vars["id"])
+	if err != nil {
+		return Response{Code: http.StatusNotFound, Message: "Aplicación no encontrada"}, nil
+	}
+	process := vars["type"]
+
+	var req ScaleProcessRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Scale == nil {
+		return Response{Code: http.StatusBadRequest, Message: "Se requiere scale"}, nil
+	}
+	scale := *req.Scale
+
+	if process == docker.WebProcess {
+		if scale != 1 {
+			return Response{Code: http.StatusBadRequest, Message: webScaleUnsupportedMessage}, nil
+		}
+		return Response{Code: http.StatusOK, Message: "Sin cambios"}, nil
+	}
+	if scale < 0 || scale > maxProcessScale {
+		return Response{Code: http.StatusBadRequest, Message: fmt.Sprintf("scale debe estar entre 0 y %d", maxProcessScale)}, nil
+	}
+	if app.ImageID.String == "" || inferRuntimeFromContainerID(app.ContainerID.String) != runtimePkg.RuntimeTypeDocker {
+		return Response{Code: http.StatusConflict, Message: "Los procesos solo se ejecutan en apps desplegadas con Docker"}, nil
+	}
+
+	runConfig, err := ctx.docker.ImageRunConfig(r.Context(), app.ImageID.String)
+	if err != nil {
+		return Response{Code: http.StatusInternalServerError, Message: "Error leyendo procesos de la imagen"}, err
+	}
+	if runConfig.Processes[process] == "" {
+		return Response{Code: http.StatusNotFound, Message: fmt.Sprintf("El Procfile de la versión actual no declara el proceso %s", process)}, nil
+	}
+
+	if err := ctx.queries.UpsertAppProcessScale(r.Context(), database.UpsertAppProcessScaleParams{
+		AppID:     app.ID,
+		Type:      process,
+		Scale:     scale,
+		UpdatedAt: time.Now(),
+	}); err != nil {
+		return Response{Code: http.StatusInternalServerError, Message: "Error guardando escala"}, err
+	}
+
+	if err := syncProcesses(r.Context(), ctx.docker, ctx.queries, &app, appEnvVars(ctx.queries, app.ID)); err != nil {
+		return Response{Code: http.StatusInternalServerError, Message: fmt.Sprintf("Escala guardada, pero hubo errores aplicándola: %v", err)}, err
+	}
+
+	logrus.Infof("Proceso %s de la app %s escalado a %d", process, app.ID, scale)
+	processes, err := appProcesses(r.Context(), ctx, &app)
+	if err != nil {
+		return Response{Code: http.StatusInternalServerError, Message: "Error obteniendo procesos"}, err
+	}
+	return Response{Code: http.StatusOK, Data: processes, Message: fmt.Sprintf("Proceso %s escalado a %d", process, scale)}, nil
+}
+
+// appProcesses arma el estado de los procesos: el web (el contenedor de la app)
+// y los del Procfile de la imagen actual con sus instancias
+func appProcesses(ctx context.Context, handlerCtx *Context, app *database.App) ([]dto.Process, error) {
+	web := dto.Process{Type: docker.WebProcess, Scale: 1, Instances: []dto.ProcessInstance{}}
+	if app.ContainerID.String != "" {
+		instance := dto.ProcessInstance{ContainerID: app.ContainerID.String, State: app.Status.String}
+		if inferRuntimeFromContainerID(app.ContainerID.String) == runtimePkg.RuntimeTypeDocker {
+			if state, err := handlerCtx.docker.GetContainerStatus(app.ContainerID.String); err == nil {
+				instance.State = state
+			} else {
+				instance.State = "missing"
+			}
+		}
+		web.Instances = append(web.Instances, instance)
+		if instance.State == "running" {
+			web.Running = 1
+		}
+	}
+	processes := []dto.Process{web}
+
+	if app.ImageID.String == "" || inferRuntimeFromContainerID(app.ContainerID.String) != runtimePkg.RuntimeTypeDocker {
+		return processes, nil
+	}
+	runConfig, err := handlerCtx.docker.ImageRunConfig(ctx, app.ImageID.String)
+	if err != nil {
+		return nil, err
+	}
+	processes[0].Command = runConfig.Processes[docker.WebProcess]
+
+	scales, err := appProcessScales(ctx, handlerCtx.queries, app.ID)
+	if err != nil {
+		return nil, err
+	}
+	containers, err := handlerCtx.docker.ListProcessContainers(ctx, app.ID)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(containers, func(a, b docker.ProcessContainer) int { return a.Index - b.Index })
+
+	for _, process := range slices.Sorted(maps.Keys(runConfig.Processes)) {
+		if process == docker.WebProcess {
+			continue
+		}
+		item := dto.Process{
+			Type:      process,
+			Command:   runConfig.Processes[process],
+			Scale:     defaultProcessScale,
+			Instances: []dto.ProcessInstance{},
+			Scalable:  true,
+		}
+		if scale, ok := scales[process]; ok {
+			item.Scale = scale
+		}
+		for _, c := range containers {
+			if c.Process != process {
+				continue
+			}
+			item.Instances = append(item.Instances, dto.ProcessInstance{
+				Index:       c.Index,
+				ContainerID: c.ID,
+				State:       c.State,
+				Status:      c.Status,
+			})
+			if c.State == "running" {
+				item.Running++
+			}
+		}
+		processes = append(processes, item)
+	}
+	return processes, nil
+}
@@ -0,0 +1,688 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rodrwan/diplo/internal/database"
+	"github.com/rodrwan/diplo/internal/docker"
+	"github.com/rodrwan/diplo/internal/dto"
+	"github.com/rodrwan/diplo/internal/models"
+	"github.com/rodrwan/diplo/internal/proxy"
+	runtimePkg "github.com/rodrwan/diplo/internal/runtime"
+	"github.com/sirupsen/logrus"
+)
+
+// Acciones registradas en la auditoría del reconciliador
+const (
+	ReconcileActionRecreate     = "recreate_container"
+	ReconcileActionRestart      = "restart_container"
+	ReconcileActionRemoveOrphan = "remove_orphan"
+	ReconcileActionFixID        = "fix_container_id"
+	ReconcileActionMarkError    = "mark_error"
+	ReconcileActionSyncProcess  = "sync_processes"
+)
+
+const (
+	reconcileResultSuccess = "success"
+	reconcileResultError   = "error"
+
+	// orphanGracePeriod evita eliminar contenedores recién creados cuyo deploy
+	// todavía no ha guardado el container_id en la BD
+	orphanGracePeriod = 2 * time.Minute
+)
+
+// ReconcileMetrics acumula estadísticas de todas las pasadas del reconciliador
+type ReconcileMetrics struct {
+	Passes         int64     `json:"passes"`
+	LastRunAt      time.Time `json:"last_run_at"`
+	LastDurationMs int64     `json:"last_duration_ms"`
+	LastError      string    `json:"last_error,omitempty"`
+	Recreated      int64     `json:"recreated"`
+	Restarted      int64     `json:"restarted"`
+	OrphansRemoved int64     `json:"orphans_removed"`
+	IDsFixed       int64     `json:"ids_fixed"`
+	MarkedError    int64     `json:"marked_error"`
+	Failures       int64     `json:"failures"`
+}
+
+// ReconcileReport resume el resultado de una pasada de reconciliación
+type ReconcileReport struct {
+	StartedAt      time.Time                `json:"started_at"`
+	DurationMs     int64                    `json:"duration_ms"`
+	Runtimes       []runtimePkg.RuntimeType `json:"runtimes"`
+	TotalApps      int                      `json:"total_apps"`
+	Containers     int                      `json:"containers"`
+	Healthy        int                      `json:"healthy"`
+	Skipped        int                      `json:"skipped"`
+	Recreated      int                      `json:"recreated"`
+	Restarted      int                      `json:"restarted"`
+	OrphansRemoved int                      `json:"orphans_removed"`
+	IDsFixed       int                      `json:"ids_fixed"`
+	MarkedError    int                      `json:"marked_error"`
+	Errors         int                      `json:"errors"`
+}
+
+// observedContainer es un contenedor gestionado por Diplo tal como lo reporta el runtime
+type observedContainer struct {
+	ID    string
+	AppID string
+	// Process es el tipo de proceso del Procfile; los contenedores web son los de la app
+	Process   string
+	Running   bool
+	Runtime   runtimePkg.RuntimeType
+	CreatedAt time.Time
+}
+
+// Reconciler compara periódicamente el estado deseado de cada app (BD) con el
+// estado real del runtime y corrige las diferencias
+type Reconciler struct {
+	docker         *docker.Client
+	queries        database.Querier
+	runtimeFactory runtimePkg.RuntimeFactory
+	interval       time.Duration
+	// proxy se actualiza al final de cada pasada (puertos o apps pueden cambiar)
+	proxy *proxy.Proxy
+
+	// runMu serializa las pasadas (loop periódico y ejecuciones manuales)
+	runMu sync.Mutex
+
+	mu      sync.RWMutex
+	metrics ReconcileMetrics
+}
+
+// NewReconciler crea un reconciliador; un intervalo <= 0 deshabilita el loop periódico
+func NewReconciler(docker *docker.Client, queries database.Querier, runtimeFactory runtimePkg.RuntimeFactory, interval time.Duration) *Reconciler {
+	return &Reconciler{
+		docker:         docker,
+		queries:        queries,
+		runtimeFactory: runtimeFactory,
+		interval:       interval,
+	}
+}
+
+// SetProxy asocia el proxy inverso cuyas rutas se refrescan tras cada pasada
+func (r *Reconciler) SetProxy(p *proxy.Proxy) {
+	r.proxy = p
+}
+
+// Start ejecuta el loop de reconciliación hasta que el contexto se cancele
+func (r *Reconciler) Start(ctx context.Context) {
+	if r.interval <= 0 {
+		logrus.Info("Reconciliación periódica deshabilitada")
+		return
+	}
+
+	logrus.Infof("🔁 Reconciliación periódica cada %s", r.interval)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("Deteniendo loop de reconciliación")
+			return
+		case <-ticker.C:
+			if _, err := r.RunOnce(ctx); err != nil {
+				logrus.Errorf("Error en pasada de reconciliación: %v", err)
+			}
+		}
+	}
+}
+
+// Metrics devuelve una copia de las métricas acumuladas
+func (r *Reconciler) Metrics() ReconcileMetrics {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.metrics
+}
+
+// RunOnce ejecuta una pasada completa de reconciliación
+func (r *Reconciler) RunOnce(ctx context.Context) (*ReconcileReport, error) {
+	r.runMu.Lock()
+	defer r.runMu.Unlock()
+
+	report := &ReconcileReport{StartedAt: time.Now()}
+	err := r.reconcile(ctx, report)
+	report.DurationMs = time.Since(report.StartedAt).Milliseconds()
+
+	r.mu.Lock()
+	r.metrics.Passes++
+	r.metrics.LastRunAt = report.StartedAt
+	r.metrics.LastDurationMs = report.DurationMs
+	r.metrics.Recreated += int64(report.Recreated)
+	r.metrics.Restarted += int64(report.Restarted)
+	r.metrics.OrphansRemoved += int64(report.OrphansRemoved)
+	r.metrics.IDsFixed += int64(report.IDsFixed)
+	r.metrics.MarkedError += int64(report.MarkedError)
+	r.metrics.Failures += int64(report.Errors)
+	r.metrics.LastError = ""
+	if err != nil {
+		r.metrics.LastError = err.Error()
+	}
+	r.mu.Unlock()
+
+	if r.proxy != nil {
+		if refreshErr := r.proxy.Refresh(ctx); refreshErr != nil {
+			logrus.Errorf("Error actualizando rutas del proxy: %v", refreshErr)
+		}
+	}
+
+	if err != nil {
+		return report, err
+	}
+
+	if report.Recreated+report.Restarted+report.OrphansRemoved+report.IDsFixed+report.MarkedError+report.Errors > 0 {
+		logrus.Infof("🎯 Reconciliación: %d sanas, %d recreadas, %d reiniciadas, %d huérfanos eliminados, %d IDs corregidos, %d marcadas con error, %d errores",
+			report.Healthy, report.Recreated, report.Restarted, report.OrphansRemoved, report.IDsFixed, report.MarkedError, report.Errors)
+	} else {
+		logrus.Debugf("Reconciliación sin cambios: %d apps sanas", report.Healthy)
+	}
+
+	return report, nil
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, report *ReconcileReport) error {
+	apps, err := r.queries.GetAllApps(ctx)
+	if err != nil {
+		return fmt.Errorf("error obteniendo aplicaciones: %v", err)
+	}
+	report.TotalApps = len(apps)
+
+	// Observar el estado real de cada runtime disponible. Un runtime que no se
+	// pudo observar no se reconcilia: no podemos distinguir "perdido" de "no visible"
+	observed := make(map[runtimePkg.RuntimeType]bool)
+	var containers []observedContainer
+
+	dockerContainers, err := r.observeDocker()
+	if err != nil {
+		logrus.Warnf("No se pudo observar Docker durante la reconciliación: %v", err)
+	} else {
+		observed[runtimePkg.RuntimeTypeDocker] = true
+		containers = append(containers, dockerContainers...)
+	}
+
+	if r.isRuntimeAvailable(runtimePkg.RuntimeTypeContainerd) {
+		containerdContainers, err := r.observeContainerd(ctx, apps)
+		if err != nil {
+			logrus.Warnf("No se pudo observar containerd durante la reconciliación: %v", err)
+		} else {
+			observed[runtimePkg.RuntimeTypeContainerd] = true
+			containers = append(containers, containerdContainers...)
+		}
+	}
+
+	if len(observed) == 0 {
+		return fmt.Errorf("ningún runtime disponible para reconciliar")
+	}
+	for runtimeType := range observed {
+		report.Runtimes = append(report.Runtimes, runtimeType)
+	}
+	report.Containers = len(containers)
+
+	byApp := make(map[string][]observedContainer)
+	for _, c := range containers {
+		byApp[c.AppID] = append(byApp[c.AppID], c)
+	}
+
+	// Contenedores ya eliminados durante esta pasada
+	removed := make(map[string]bool)
+
+	appsByID := make(map[string]*database.App, len(apps))
+	for i := range apps {
+		appsByID[apps[i].ID] = &apps[i]
+	}
+
+	// Apps con jobs en cola o en ejecución: el worker es dueño de sus contenedores
+	busy := make(map[string]bool)
+	for i := range apps {
+		pending, err := r.queries.CountPendingAppJobs(ctx, apps[i].ID)
+		if err != nil {
+			logrus.Warnf("No se pudieron contar los jobs pendientes de la app %s: %v", apps[i].ID, err)
+			busy[apps[i].ID] = true
+			continue
+		}
+		busy[apps[i].ID] = pending > 0
+	}
+
+	// 1. Apps con estado deseado "running"
+	for i := range apps {
+		app := &apps[i]
+		if app.Status.String != database.StatusRunning.String || busy[app.ID] {
+			report.Skipped++
+			continue
+		}
+
+		runtimeType := r.appRuntime(app)
+		if !observed[runtimeType] {
+			logrus.Errorf("Runtime %s de la app %s no disponible, no se puede reconciliar", runtimeType, app.ID)
+			report.Errors++
+			continue
+		}
+
+		r.reconcileApp(ctx, app, runtimeType, byApp[app.ID], removed, report)
+		if runtimeType == runtimePkg.RuntimeTypeDocker && app.Status.String == database.StatusRunning.String {
+			r.reconcileProcesses(ctx, app, report)
+		}
+	}
+
+	// 2. Contenedores gestionados sin app o que ya no son el contenedor actual de su app
+	for _, c := range containers {
+		if removed[c.ID] {
+			continue
+		}
+		app, exists := appsByID[c.AppID]
+		// Los procesos no web de una app existente los gestiona reconcileProcesses
+		if exists && c.Process != docker.WebProcess {
+			continue
+		}
+		if exists {
+			// Durante un deploy pueden coexistir contenedores viejos y nuevos
+			if app.Status.String == database.StatusDeploying.String || app.Status.String == database.StatusRedeploying.String {
+				continue
+			}
+			if busy[app.ID] || app.ContainerID.String == c.ID {
+				continue
+			}
+		}
+		// Sin fecha de creación no podemos respetar el período de gracia
+		if c.CreatedAt.IsZero() || time.Since(c.CreatedAt) < orphanGracePeriod {
+			continue
+		}
+		// Versiones reemplazadas por un redeploy blue/green que aún drenan conexiones
+		if isDraining(c.ID) {
+			continue
+		}
+
+		detail := "contenedor sin aplicación en la BD"
+		if exists {
+			detail = fmt.Sprintf("contenedor obsoleto, la app usa %s", app.ContainerID.String)
+		}
+		logrus.Warnf("🧹 Eliminando contenedor huérfano %s (%s)", c.ID, detail)
+
+		if err := r.removeContainer(ctx, c); err != nil {
+			report.Errors++
+			r.audit(ctx, c.AppID, c.ID, c.Runtime, ReconcileActionRemoveOrphan, err, detail)
+			continue
+		}
+		report.OrphansRemoved++
+		r.audit(ctx, c.AppID, c.ID, c.Runtime, ReconcileActionRemoveOrphan, nil, detail)
+	}
+
+	return nil
+}
+
+// reconcileApp lleva una app con estado deseado "running" a su estado deseado
+func (r *Reconciler) reconcileApp(ctx context.Context, app *database.App, runtimeType runtimePkg.RuntimeType, containers []observedContainer, removed map[string]bool, report *ReconcileReport) {
+	var current, running *observedContainer
+	for i := range containers {
+		c := &containers[i]
+		if c.Runtime != runtimeType || c.Process != docker.WebProcess {
+			continue
+		}
+		if c.ID == app.ContainerID.String {
+			current = c
+		}
+		if c.Running && (running == nil || c.CreatedAt.After(running.CreatedAt)) {
+			running = c
+		}
+	}
+
+	// Contenedor registrado y ejecutándose: nada que hacer
+	if current != nil && current.Running {
+		report.Healthy++
+		return
+	}
+
+	// El contenedor registrado no existe pero hay otro de la app ejecutándose: ID obsoleto
+	if current == nil && running != nil {
+		detail := fmt.Sprintf("container_id %q reemplazado por %q", app.ContainerID.String, running.ID)
+		logrus.Infof("🔧 App %s: %s", app.ID, detail)
+
+		err := r.updateAppContainer(ctx, app, running.ID, database.StatusRunning, "")
+		r.audit(ctx, app.ID, running.ID, runtimeType, ReconcileActionFixID, err, detail)
+		if err != nil {
+			report.Errors++
+			return
+		}
+		report.IDsFixed++
+		return
+	}
+
+	// Un contenedor containerd detenido (ej. tras reiniciar el host) conserva la
+	// app compilada: basta con iniciar la task y relanzar la app
+	if current != nil && runtimeType == runtimePkg.RuntimeTypeContainerd {
+		err := r.restartContainerd(ctx, app, current.ID)
+		r.audit(ctx, app.ID, current.ID, runtimeType, ReconcileActionRestart, err, "")
+		if err == nil {
+			if err := r.updateAppContainer(ctx, app, current.ID, database.StatusRunning, ""); err != nil {
+				logrus.Errorf("Error actualizando app %s después de reiniciar contenedor: %v", app.ID, err)
+			}
+			report.Restarted++
+			return
+		}
+		logrus.Warnf("No se pudo reiniciar el contenedor %s, se recreará: %v", current.ID, err)
+	}
+
+	// El contenedor existe pero está detenido: eliminarlo antes de recrearlo
+	if current != nil {
+		if err := r.removeContainer(ctx, *current); err != nil {
+			logrus.Warnf("Error eliminando contenedor detenido %s: %v", current.ID, err)
+		} else {
+			removed[current.ID] = true
+		}
+	}
+
+	logrus.Warnf("⚠️  Contenedor de la app %s no está ejecutándose, recreando...", app.ID)
+	containerID, err := r.recreateContainer(ctx, app, runtimeType)
+	if err != nil {
+		detail := fmt.Sprintf("Error recreando contenedor: %v", err)
+		r.audit(ctx, app.ID, app.ContainerID.String, runtimeType, ReconcileActionRecreate, err, "")
+		report.Errors++
+
+		markErr := r.updateAppContainer(ctx, app, app.ContainerID.String, database.StatusError, detail)
+		r.audit(ctx, app.ID, app.ContainerID.String, runtimeType, ReconcileActionMarkError, markErr, detail)
+		if markErr == nil {
+			report.MarkedError++
+		}
+		return
+	}
+
+	r.audit(ctx, app.ID, containerID, runtimeType, ReconcileActionRecreate, nil, fmt.Sprintf("reemplaza a %q", app.ContainerID.String))
+	report.Recreated++
+}
+
+// reconcileProcesses lleva los procesos no web del Procfile a su escala
+func (r *Reconciler) reconcileProcesses(ctx context.Context, app *database.App, report *ReconcileReport) {
+	envVars, err := r.loadEnvVars(ctx, app.ID)
+	if err == nil {
+		err = syncProcesses(ctx, r.docker, r.queries, app, envVars)
+	}
+	if err != nil {
+		report.Errors++
+		r.audit(ctx, app.ID, "", runtimePkg.RuntimeTypeDocker, ReconcileActionSyncProcess, err, "")
+	}
+}
+
+// recreateContainer crea un nuevo contenedor para la app usando su imagen almacenada
+func (r *Reconciler) recreateContainer(ctx context.Context, app *database.App, runtimeType runtimePkg.RuntimeType) (string, error) {
+	envVars, err := r.loadEnvVars(ctx, app.ID)
+	if err != nil {
+		return "", err
+	}
+
+	// El contenedor se recrea en el puerto de la versión activa, al que apunta
+	// el proxy. Nunca se cambia de runtime: si el runtime de la app falla, la
+	// recreación falla.
+	active := withActivePort(app)
+	var containerID string
+	switch runtimeType {
+	case runtimePkg.RuntimeTypeDocker:
+		imageID := app.ImageID.String
+		if imageID == "" {
+			return "", fmt.Errorf("no hay image_id disponible para recrear contenedor")
+		}
+		containerID, err = r.docker.RunContainer(ctx, active, imageID, envVars)
+	case runtimePkg.RuntimeTypeContainerd:
+		runtime, runtimeErr := r.runtimeFactory.CreateRuntime(runtimePkg.RuntimeTypeContainerd)
+		if runtimeErr != nil {
+			return "", fmt.Errorf("containerd no disponible para recrear contenedor: %v", runtimeErr)
+		}
+		defer runtime.Close()
+		containerID, err = recreateContainerdApp(ctx, runtime, active, envVars)
+	default:
+		return "", fmt.Errorf("runtime no soportado para recrear contenedor: %s", runtimeType)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error ejecutando contenedor: %v", err)
+	}
+
+	if err := r.updateAppContainer(ctx, app, containerID, database.StatusRunning, ""); err != nil {
+		return containerID, fmt.Errorf("error actualizando app: %v", err)
+	}
+
+	logrus.Infof("✅ Contenedor recreado exitosamente para app %s: %s", app.ID, containerID)
+	return containerID, nil
+}
+
+// restartContainerd reinicia un contenedor containerd existente y relanza la app
+func (r *Reconciler) restartContainerd(ctx context.Context, app *database.App, containerID string) error {
+	runtime, err := r.runtimeFactory.CreateRuntime(runtimePkg.RuntimeTypeContainerd)
+	if err != nil {
+		return fmt.Errorf("containerd no disponible: %v", err)
+	}
+	defer runtime.Close()
+
+	return restartContainerdApp(ctx, runtime, withActivePort(app), containerID)
+}
+
+// loadEnvVars obtiene las variables de entorno de la app descifrando los secretos
+func (r *Reconciler) loadEnvVars(ctx context.Context, appID string) ([]models.EnvVar, error) {
+	envVars, err := r.queries.GetAppEnvVars(ctx, appID)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo variables de entorno: %v", err)
+	}
+
+	envVarsList := make([]models.EnvVar, 0, len(envVars))
+	for _, env := range envVars {
+		value := env.Value
+
+		// Descifrar valores secretos si es necesario
+		if env.IsSecret.Bool {
+			decryptedValue, err := decryptValue(env.Value)
+			if err != nil {
+				logrus.Errorf("Error descifrando valor secreto %s: %v", env.Key, err)
+				continue
+			}
+			value = decryptedValue
+		}
+
+		envVarsList = append(envVarsList, models.EnvVar{
+			Name:  env.Key,
+			Value: value,
+		})
+	}
+
+	return envVarsList, nil
+}
+
+// updateAppContainer guarda container_id, estado y error preservando el resto de la app
+func (r *Reconciler) updateAppContainer(ctx context.Context, app *database.App, containerID string, status sql.NullString, errorMsg string) error {
+	app.ContainerID = sql.NullString{String: containerID, Valid: containerID != ""}
+	app.Status = status
+	app.ErrorMsg = sql.NullString{String: errorMsg, Valid: errorMsg != ""}
+	app.UpdatedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	return r.queries.UpdateApp(ctx, database.UpdateAppParams{
+		ID:          app.ID,
+		Name:        app.Name,
+		RepoUrl:     app.RepoUrl,
+		Language:    app.Language,
+		Port:        app.Port,
+		ContainerID: app.ContainerID,
+		ImageID:     app.ImageID,
+		Status:      app.Status,
+		ErrorMsg:    app.ErrorMsg,
+		UpdatedAt:   app.UpdatedAt,
+	})
+}
+
+// audit registra una acción del reconciliador en la BD
+func (r *Reconciler) audit(ctx context.Context, appID, containerID string, runtimeType runtimePkg.RuntimeType, action string, actionErr error, detail string) {
+	result := reconcileResultSuccess
+	if actionErr != nil {
+		result = reconcileResultError
+		if detail != "" {
+			detail = fmt.Sprintf("%s: %v", detail, actionErr)
+		} else {
+			detail = actionErr.Error()
+		}
+		logrus.Errorf("❌ Reconciliación %s falló para app %s: %v", action, appID, actionErr)
+	}
+
+	if err := r.queries.CreateReconcileAction(ctx, database.CreateReconcileActionParams{
+		AppID:       sql.NullString{String: appID, Valid: appID != ""},
+		ContainerID: sql.NullString{String: containerID, Valid: containerID != ""},
+		Runtime:     string(runtimeType),
+		Action:      action,
+		Result:      result,
+		Detail:      sql.NullString{String: detail, Valid: detail != ""},
+	}); err != nil {
+		logrus.Errorf("Error registrando auditoría de reconciliación: %v", err)
+	}
+}
+
+// appRuntime determina en qué runtime vive (o debe vivir) la app
+func (r *Reconciler) appRuntime(app *database.App) runtimePkg.RuntimeType {
+	if app.ContainerID.String != "" {
+		return inferRuntimeFromContainerID(app.ContainerID.String)
+	}
+	if r.runtimeFactory != nil {
+		return r.runtimeFactory.GetPreferredRuntime()
+	}
+	return runtimePkg.RuntimeTypeDocker
+}
+
+func (r *Reconciler) isRuntimeAvailable(runtimeType runtimePkg.RuntimeType) bool {
+	if r.runtimeFactory == nil {
+		return false
+	}
+	for _, available := range r.runtimeFactory.GetAvailableRuntimes() {
+		if available == runtimeType {
+			return true
+		}
+	}
+	return false
+}
+
+// observeDocker lista los contenedores Docker etiquetados con diplo.managed=true
+func (r *Reconciler) observeDocker() ([]observedContainer, error) {
+	dockerContainers, err := r.docker.ListManagedContainers()
+	if err != nil {
+		return nil, err
+	}
+
+	containers := make([]observedContainer, 0, len(dockerContainers))
+	for _, c := range dockerContainers {
+		containers = append(containers, observedContainer{
+			ID:        c.ID,
+			AppID:     c.Labels["diplo.app.id"],
+			Process:   docker.ProcessOf(c.Labels),
+			Running:   c.State == "running",
+			Runtime:   runtimePkg.RuntimeTypeDocker,
+			CreatedAt: time.Unix(c.Created, 0),
+		})
+	}
+
+	return containers, nil
+}
+
+// observeContainerd lista los contenedores "diplo-*" del namespace de Diplo
+func (r *Reconciler) observeContainerd(ctx context.Context, apps []database.App) ([]observedContainer, error) {
+	client, err := runtimePkg.NewContainerdClient("", "")
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	list, err := client.ListContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var containers []observedContainer
+	for _, c := range list {
+		if !strings.HasPrefix(c.ID, "diplo-") || seen[c.ID] {
+			continue
+		}
+		seen[c.ID] = true
+
+		containers = append(containers, observedContainer{
+			ID:        c.ID,
+			AppID:     appIDFromContainerdName(c.ID, apps),
+			Process:   docker.WebProcess,
+			Running:   c.Status == runtimePkg.ContainerStatusRunning,
+			Runtime:   runtimePkg.RuntimeTypeContainerd,
+			CreatedAt: c.CreatedAt,
+		})
+	}
+
+	return containers, nil
+}
+
+// appIDFromContainerdName resuelve la app de un contenedor "diplo-<appID>[_<timestamp>]"
+func appIDFromContainerdName(containerID string, apps []database.App) string {
+	name := strings.TrimPrefix(containerID, "diplo-")
+	for _, app := range apps {
+		if name == app.ID || strings.HasPrefix(name, app.ID+"_") {
+			return app.ID
+		}
+	}
+	return ""
+}
+
+func (r *Reconciler) removeContainer(ctx context.Context, c observedContainer) error {
+	switch c.Runtime {
+	case runtimePkg.RuntimeTypeDocker:
+		return r.docker.RemoveContainer(c.ID)
+	case runtimePkg.RuntimeTypeContainerd:
+		return removeContainerdContainer(c.ID)
+	default:
+		return fmt.Errorf("runtime no soportado: %s", c.Runtime)
+	}
+}
+
+// ReconcileStatusHandler devuelve las métricas del reconciliador y las últimas acciones auditadas
+func ReconcileStatusHandler(ctx *Context, w http.ResponseWriter, r *http.Request) (Response, error) {
+	if ctx.reconciler == nil {
+		return Response{Code: http.StatusServiceUnavailable, Message: "Reconciliador no configurado"}, nil
+	}
+
+	limit := int64(50)
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed <= 0 {
+			return Response{Code: http.StatusBadRequest, Message: "Parámetro limit inválido"}, nil
+		}
+		limit = parsed
+	}
+
+	actions, err := ctx.queries.ListReconcileActions(r.Context(), limit)
+	if err != nil {
+		logrus.Errorf("Error obteniendo acciones de reconciliación: %v", err)
+		return Response{Code: http.StatusInternalServerError, Message: "Error obteniendo acciones de reconciliación"}, err
+	}
+
+	actionsDTO := make([]*dto.ReconcileAction, 0, len(actions))
+	for _, action := range actions {
+		createdAt := ""
+		if action.CreatedAt.Valid {
+			createdAt = action.CreatedAt.Time.Format(time.RFC3339)
+		}
+		actionsDTO = append(actionsDTO, &dto.ReconcileAction{
+			ID:          action.ID,
+			AppID:       action.AppID.String,
+			ContainerID: action.ContainerID.String,
+			Runtime:     action.Runtime,
+			Action:      action.Action,
+			Result:      action.Result,
+			Detail:      action.Detail.String,
+			CreatedAt:   createdAt,
+		})
+	}
+
+	response := map[string]interface{}{
+		"interval": ctx.reconciler.interval.String(),
+		"metrics":  ctx.reconciler.Metrics(),
+		"actions":  actionsDTO,
+	}
+
+	return Response{Code: http.StatusOK, Data: response}, nil
+}
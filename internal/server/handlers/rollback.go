@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rodrwan/diplo/internal/database"
+	"github.com/rodrwan/diplo/internal/models"
+	runtimePkg "github.com/rodrwan/diplo/internal/runtime"
+	"github.com/sirupsen/logrus"
+)
+
+// rollbackHistoryLimit es cuántos deployments se revisan al buscar el destino de un rollback
+const rollbackHistoryLimit = 50
+
+// RollbackRequest elige el deployment al que volver; sin deployment_id se usa
+// la versión exitosa anterior a la actual
+type RollbackRequest struct {
+	DeploymentID int64 `json:"deployment_id,omitempty"`
+}
+
+// canRollbackTo indica si un deployment dejó una imagen que se puede volver a
+// ejecutar sin rebuild. Containerd compila dentro del contenedor, así que
+// solo los deployments Docker son reutilizables.
+func canRollbackTo(deployment database.Deployment) bool {
+	return deployment.Status == database.DeploymentSucceeded &&
+		deployment.Runtime == string(runtimePkg.RuntimeTypeDocker) &&
+		deployment.ImageTag.String != ""
+}
+
+// findRollbackTarget devuelve el último deployment exitoso reutilizable; con
+// skipCurrent ignora la imagen que está corriendo
+func findRollbackTarget(ctx context.Context, queries database.Querier, appID string, skipCurrent bool) (database.Deployment, error) {
+	deployments, err := queries.ListAppDeployments(ctx, database.ListAppDeploymentsParams{
+		AppID: appID,
+		Limit: rollbackHistoryLimit,
+	})
+	if err != nil {
+		return database.Deployment{}, err
+	}
+
+	current := ""
+	for _, deployment := range deployments {
+		if deployment.Status != database.DeploymentSucceeded {
+			continue
+		}
+		if skipCurrent && current == "" {
+			current = deployment.ImageTag.String
+			continue
+		}
+		if canRollbackTo(deployment) && deployment.ImageTag.String != current {
+			return deployment, nil
+		}
+	}
+
+	return database.Deployment{}, sql.ErrNoRows
+}
+
+// RollbackAppHandler vuelve a ejecutar la imagen de un deployment anterior con
+// sus variables de entorno, sin reconstruir
+func RollbackAppHandler(ctx *Context, w http.ResponseWriter, r *http.Request) (Response, error) {
+	appID := mux.Vars(r)["id"]
+
+	var req RollbackRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return Response{Code: http.StatusBadRequest, Message: "JSON inválido"}, nil
+		}
+	}
+
+	app, err := ctx.queries.GetApp(r.Context(), appID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Response{Code: http.StatusNotFound, Message: "Aplicación no encontrada"}, nil
+		}
+		return Response{Code: http.StatusInternalServerError, Message: "Error obteniendo aplicación"}, err
+	}
+	if app.Status == database.StatusDeploying || app.Status == database.StatusRedeploying {
+		return Response{Code: http.StatusConflict, Message: "La aplicación tiene un deployment en curso"}, nil
+	}
+
+	var target database.Deployment
+	if req.DeploymentID != 0 {
+		target, err = ctx.queries.GetDeployment(r.Context(), req.DeploymentID)
+		if err != nil || target.AppID != appID {
+			return Response{Code: http.StatusNotFound, Message: "Deployment no encontrado"}, nil
+		}
+	} else {
+		target, err = findRollbackTarget(r.Context(), ctx.queries, appID, true)
+		if err == sql.ErrNoRows {
+			return Response{Code: http.StatusConflict, Message: "No hay una versión anterior a la que volver"}, nil
+		}
+		if err != nil {
+			return Response{Code: http.StatusInternalServerError, Message: "Error obteniendo deployments"}, err
+		}
+	}
+
+	if !canRollbackTo(target) {
+		return Response{Code: http.StatusConflict, Message: "Solo se puede volver a deployments Docker exitosos"}, nil
+	}
+	if _, err := ctx.docker.GetImageID(target.ImageTag.String); err != nil {
+		return Response{Code: http.StatusConflict, Message: fmt.Sprintf("La imagen %s ya no está disponible", target.ImageTag.String)}, nil
+	}
+
+	job, err := enqueueDeployJob(r.Context(), ctx, app.ID, JobKindRollback, deployJobPayload{
+		deployOptions: deployOptions{Trigger: DeploymentTriggerRollback},
+		DeploymentID:  target.ID,
+	})
+	if err != nil {
+		return Response{Code: http.StatusInternalServerError, Message: "Error encolando rollback"}, err
+	}
+
+	response := map[string]interface{}{
+		"job_id":        job.ID,
+		"app_id":        app.ID,
+		"deployment_id": target.ID,
+		"image_tag":     target.ImageTag.String,
+		"commit_sha":    target.CommitSha.String,
+		"message":       "Rollback iniciado",
+	}
+	return Response{Code: http.StatusAccepted, Data: response}, nil
+}
+
+// rollbackApp ejecuta la imagen de target como un redeploy blue/green y lo
+// registra como un deployment nuevo
+func rollbackApp(jobCtx context.Context, ctx *Context, app *database.App, target database.Deployment, trigger string) (err error) {
+	logrus.Infof("Iniciando rollback de %s al deployment %d (%s)", app.ID, target.ID, target.ImageTag.String)
+
+	beginDeployment(ctx.queries, app.ID, trigger, string(runtimePkg.RuntimeTypeDocker), target.CommitSha.String)
+	defer func() { finishDeployment(app.ID, err) }()
+
+	imageTag := target.ImageTag.String
+	recordDeploymentImage(app.ID, imageTag)
+	sendLogMessage(ctx, app.ID, "info", fmt.Sprintf("Iniciando rollback al deployment #%d (%s)", target.ID, imageTag))
+
+	app.Status = database.StatusRedeploying
+	app.ErrorMsg = sql.NullString{String: "", Valid: true}
+	if err := ctx.queries.UpdateApp(context.Background(), database.UpdateAppParams{
+		ID:          app.ID,
+		Name:        app.Name,
+		RepoUrl:     app.RepoUrl,
+		Language:    app.Language,
+		Port:        app.Port,
+		Status:      app.Status,
+		ErrorMsg:    app.ErrorMsg,
+		ContainerID: app.ContainerID,
+		ImageID:     app.ImageID,
+		UpdatedAt:   app.UpdatedAt,
+	}); err != nil {
+		logrus.Errorf("Error actualizando estado de rollback: %v", err)
+	}
+
+	envVars, err := deploymentEnvVars(target)
+	if err != nil {
+		logrus.Warnf("Rollback de %s sin snapshot de entorno: %v", app.ID, err)
+		sendLogMessage(ctx, app.ID, "warning", "El deployment no guardó sus variables de entorno, se usan las actuales")
+		envVars = appEnvVars(ctx.queries, app.ID)
+	}
+	recordDeploymentEnv(app.ID, envVars)
+
+	imageID, err := ctx.docker.GetImageID(imageTag)
+	if err != nil {
+		return handleRedeployError(ctx, app, fmt.Sprintf("La imagen %s ya no está disponible: %v", imageTag, err))
+	}
+
+	recordDeploymentStep(app.ID, "run")
+	candidatePort, err := findCandidatePort(app)
+	if err != nil {
+		return handleRedeployError(ctx, app, fmt.Sprintf("Error asignando puerto temporal: %v", err))
+	}
+	candidate := *app
+	candidate.Port = candidatePort
+
+	sendLogMessage(ctx, app.ID, "info", fmt.Sprintf("Ejecutando versión anterior en puerto temporal %d", candidatePort))
+	containerID, err := ctx.docker.RunContainer(jobCtx, &candidate, imageTag, envVars)
+	if err != nil {
+		return handleRedeployError(ctx, app, fmt.Sprintf("Error ejecutando contenedor: %v", err))
+	}
+
+	recordDeploymentStep(app.ID, "health_check")
+	healthPath, healthTimeout := imageHealthCheck(jobCtx, ctx, app, imageTag)
+	sendLogMessage(ctx, app.ID, "info", fmt.Sprintf("Esperando a que la versión anterior responda %s...", describeHealthCheck(healthPath)))
+	if healthErr := waitForHTTPHealthy(jobCtx, candidatePort, healthPath, healthTimeout); healthErr != nil {
+		if stopErr := ctx.docker.StopContainer(containerID); stopErr != nil {
+			logrus.Warnf("Error eliminando contenedor fallido %s: %v", containerID, stopErr)
+		}
+		return handleRedeployError(ctx, app, fmt.Sprintf("Health check del rollback fallido: %v", healthErr))
+	}
+
+	recordDeploymentStep(app.ID, "switch_traffic")
+	oldContainerID, oldPort := app.ContainerID.String, activePort(app)
+	app.Status = database.StatusRunning
+	app.ContainerID = sql.NullString{String: containerID, Valid: true}
+	app.ImageID = sql.NullString{String: imageID, Valid: true}
+	app.UpdatedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	app.ErrorMsg = sql.NullString{String: "", Valid: true}
+
+	if err := ctx.queries.UpdateApp(context.Background(), database.UpdateAppParams{
+		ID:          app.ID,
+		Name:        app.Name,
+		RepoUrl:     app.RepoUrl,
+		Language:    app.Language,
+		Port:        app.Port,
+		Status:      app.Status,
+		ErrorMsg:    app.ErrorMsg,
+		ContainerID: app.ContainerID,
+		ImageID:     app.ImageID,
+		UpdatedAt:   app.UpdatedAt,
+	}); err != nil {
+		logrus.Errorf("Error actualizando aplicación después del rollback: %v", err)
+	}
+	if err := promoteUpstream(ctx.queries, app, candidatePort); err != nil {
+		logrus.Errorf("Error guardando el puerto de la versión restaurada de %s: %v", app.ID, err)
+	}
+	refreshRoutes(ctx)
+	syncDeployProcesses(jobCtx, ctx, app, envVars)
+
+	go drainContainer(ctx, app.ID, oldContainerID, oldPort, ctx.docker.StopContainer)
+
+	logrus.Infof("Rollback completado: %s ejecuta %s en puerto %d", app.ID, imageTag, app.Port)
+	sendLogMessage(ctx, app.ID, "success", fmt.Sprintf("Rollback completado: %s en puerto %d", imageTag, app.Port))
+	return nil
+}
+
+// autoRollback vuelve a la última versión sana cuando un redeploy Docker falla
+// el health check y la versión anterior ya no está corriendo
+func autoRollback(jobCtx context.Context, ctx *Context, app *database.App) {
+	deployments, err := ctx.queries.ListAppDeployments(context.Background(), database.ListAppDeploymentsParams{
+		AppID: app.ID,
+		Limit: 1,
+	})
+	if err != nil || len(deployments) == 0 || deployments[0].Runtime != string(runtimePkg.RuntimeTypeDocker) {
+		return
+	}
+
+	if app.ContainerID.String != "" {
+		if status, err := ctx.docker.GetContainerStatus(app.ContainerID.String); err == nil && status == "running" {
+			sendLogMessage(ctx, app.ID, "info", "La versión anterior sigue activa, no se requiere rollback")
+			return
+		}
+	}
+
+	// La última versión sana es la que estaba activa antes del redeploy fallido
+	target, err := findRollbackTarget(context.Background(), ctx.queries, app.ID, false)
+	if err != nil {
+		logrus.Warnf("Sin versión anterior para el rollback automático de %s: %v", app.ID, err)
+		return
+	}
+	if _, err := ctx.docker.GetImageID(target.ImageTag.String); err != nil {
+		logrus.Warnf("Imagen de rollback %s no disponible: %v", target.ImageTag.String, err)
+		return
+	}
+
+	sendLogMessage(ctx, app.ID, "warning", fmt.Sprintf("Rollback automático al deployment #%d", target.ID))
+	rollbackApp(jobCtx, ctx, app, target, DeploymentTriggerAutoRollback)
+}
+
+// appEnvVars carga las variables de entorno actuales de la app, descifrando los secretos
+func appEnvVars(queries database.Querier, appID string) []models.EnvVar {
+	existingEnvVars, err := queries.GetAppEnvVars(context.Background(), appID)
+	if err != nil {
+		logrus.Warnf("Error cargando variables de entorno de %s: %v", appID, err)
+	}
+
+	envVars := make([]models.EnvVar, 0, len(existingEnvVars))
+	for _, env := range existingEnvVars {
+		value := env.Value
+		if env.IsSecret.Bool {
+			decryptedValue, err := decryptValue(env.Value)
+			if err != nil {
+				logrus.Errorf("Error descifrando valor secreto para contenedor %s: %v", env.Key, err)
+				continue
+			}
+			value = decryptedValue
+		}
+		envVars = append(envVars, models.EnvVar{Name: env.Key, Value: value})
+	}
+	return envVars
+}
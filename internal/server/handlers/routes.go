@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"net/http"
+)
+
+// ListRoutesHandler devuelve las rutas publicadas por el proxy inverso
+func ListRoutesHandler(ctx *Context, w http.ResponseWriter, r *http.Request) (Response, error) {
+	if ctx.proxy == nil {
+		return Response{Code: http.StatusServiceUnavailable, Message: "Proxy no configurado"}, nil
+	}
+
+	response := map[string]interface{}{
+		"base_domain": ctx.proxy.BaseDomain(),
+		"routes":      ctx.proxy.Routes(),
+	}
+
+	return Response{Code: http.StatusOK, Data: response}, nil
+}
@@ -0,0 +1,638 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rodrwan/diplo/internal/database"
+	"github.com/rodrwan/diplo/internal/docker"
+	"github.com/rodrwan/diplo/internal/gitserver"
+	"github.com/rodrwan/diplo/internal/manifest"
+	"github.com/rodrwan/diplo/internal/models"
+	runtimePkg "github.com/rodrwan/diplo/internal/runtime"
+	"github.com/sirupsen/logrus"
+)
+
+// deployOptions son los parámetros de un deploy que no se guardan en la app
+type deployOptions struct {
+	GitHubToken string `json:"github_token,omitempty"`
+	Trigger     string `json:"trigger"`
+	// CommitSHA fija el commit a desplegar; vacío despliega el último commit
+	CommitSHA string `json:"commit_sha,omitempty"`
+	// ForceRebuild construye la imagen sin caché aunque exista una del mismo commit
+	ForceRebuild bool `json:"force_rebuild,omitempty"`
+}
+
+// buildSource es el código de un deploy: un commit resuelto en el mirror del
+// repo (o en el repo alojado), extraído para detectar el lenguaje y empaquetado
+// como contexto de build
+type buildSource struct {
+	commit  string
+	dir     string
+	archive []byte
+	// manifest es el diplo.yaml del commit; nil si no tiene
+	manifest *manifest.Manifest
+	// processes son los procesos del Procfile del commit; nil si no tiene
+	processes map[string]string
+	// toolchain es la versión de la imagen base elegida para la plantilla
+	toolchain runtimePkg.ToolchainVersion
+	// appStart es el comando de inicio configurado en la app; vacío si no tiene
+	appStart string
+	// appLanguage es el lenguaje fijado en la app; vacío si se detecta
+	appLanguage string
+	// appGoBuild indica si la app configura el paquete o los flags de Go
+	appGoBuild bool
+	// start es el comando de inicio elegido para la plantilla
+	start runtimePkg.StartCommand
+	// goTarget es el paquete main elegido para la plantilla de Go
+	goTarget runtimePkg.GoTarget
+}
+
+// defaultDockerfilePath es el Dockerfile del repo que se usa si la app no configura otro
+const defaultDockerfilePath = "Dockerfile"
+
+// errUnknownLanguage es el error de un repo sin Dockerfile cuyo lenguaje no se reconoce
+var errUnknownLanguage = errors.New("no se reconoce el lenguaje del repositorio: agrega un Dockerfile, define language en diplo.yaml o envía language en el deploy")
+
+// autoLanguage quita el lenguaje fijado en la app
+const autoLanguage = "auto"
+
+// autoStartCommand quita el comando de inicio configurado en la app
+const autoStartCommand = "auto"
+
+// dockerOnlyReason explica qué configuración de la app solo aplica el build
+// con Docker: apps de imagen, repos alojados y apps con un Dockerfile o un
+// comando de inicio configurados. Vacío si containerd puede construirla:
+// containerd clona el repo dentro del contenedor en el commit a desplegar y
+// compila el paquete main de Go del path de la app, sin usar Dockerfiles.
+func dockerOnlyReason(ctx *Context, app *database.App) string {
+	switch {
+	case isImageApp(app):
+		return "La app despliega una imagen ya construida"
+	case ctx.gitRepos.Hosts(app.RepoUrl):
+		return "El repositorio está alojado en Diplo (git push)"
+	case app.DockerfilePath.String != "":
+		return fmt.Sprintf("La app usa el Dockerfile %s (dockerfile_path)", app.DockerfilePath.String)
+	case app.StartCommand.String != "":
+		return "La app tiene start_command"
+	}
+	return ""
+}
+
+// dockerOnlyField devuelve el campo del deploy que solo aplica el build con
+// Docker; vacío si no envía ninguno. req.Language ya viene normalizado.
+func dockerOnlyField(req models.DeployRequest) string {
+	switch {
+	case req.Image != "":
+		// containerd no descarga imágenes: solo compila Go dentro del contenedor
+		return "image"
+	case req.DockerfilePath != "" && req.DockerfilePath != defaultDockerfilePath:
+		return "dockerfile_path"
+	case req.StartCommand != "" && req.StartCommand != autoStartCommand:
+		return "start_command"
+	case req.Language != "" && req.Language != autoLanguage && req.Language != "go":
+		return fmt.Sprintf("language %s", req.Language)
+	}
+	return ""
+}
+
+// errAmbiguousApp indica que un deploy sin ref coincide con varias apps del mismo repo y path
+var errAmbiguousApp = errors.New("hay varias apps para este repositorio")
+
+// findAppForDeploy busca la app que identifican repo, path y ref; "HEAD" es la
+// app sin ref fijado. Sin ref se usa la única app del repo y path o, si hay
+// varias, la que sigue la rama por defecto. Devuelve sql.ErrNoRows si no hay.
+func findAppForDeploy(ctx context.Context, queries database.Querier, repoURL, path, ref string) (database.App, error) {
+	apps, err := queries.ListAppsByRepoPath(ctx, database.ListAppsByRepoPathParams{RepoUrl: repoURL, Path: path})
+	if err != nil {
+		return database.App{}, err
+	}
+	if ref != "" {
+		if ref == "HEAD" {
+			ref = ""
+		}
+		for _, app := range apps {
+			if app.Ref.String == ref {
+				return app, nil
+			}
+		}
+		return database.App{}, sql.ErrNoRows
+	}
+
+	switch len(apps) {
+	case 0:
+		return database.App{}, sql.ErrNoRows
+	case 1:
+		return apps[0], nil
+	}
+	refs := make([]string, 0, len(apps))
+	for _, app := range apps {
+		if app.Ref.String == "" {
+			return app, nil
+		}
+		refs = append(refs, app.Ref.String)
+	}
+	return database.App{}, fmt.Errorf("%w (refs: %s): indica ref para elegir una", errAmbiguousApp, strings.Join(refs, ", "))
+}
+
+// goBuildRequest es la configuración de go build pedida en un deploy; nil
+// mantiene el valor de la app
+type goBuildRequest struct {
+	target, tags, ldflags *string
+}
+
+// parseGoBuild valida y normaliza go_target, go_tags y go_ldflags del request
+func parseGoBuild(req models.DeployRequest) (goBuildRequest, error) {
+	build := goBuildRequest{tags: req.GoTags, ldflags: req.GoLDFlags}
+	if req.GoTarget != nil {
+		target, err := runtimePkg.NormalizeGoTarget(*req.GoTarget)
+		if err != nil {
+			return build, err
+		}
+		build.target = &target
+	}
+	if build.tags != nil {
+		tags := strings.TrimSpace(*build.tags)
+		if err := runtimePkg.ValidateGoBuildTags(tags); err != nil {
+			return build, err
+		}
+		build.tags = &tags
+	}
+	if build.ldflags != nil {
+		ldflags := strings.TrimSpace(*build.ldflags)
+		if strings.ContainsAny(ldflags, "\n\r") {
+			return build, errors.New("go_ldflags debe ser una sola línea")
+		}
+		build.ldflags = &ldflags
+	}
+	return build, nil
+}
+
+// set indica si el request cambia algún campo
+func (b goBuildRequest) set() bool {
+	return b.target != nil || b.tags != nil || b.ldflags != nil
+}
+
+// apply combina el request con la configuración guardada en app
+func (b goBuildRequest) apply(app database.App) database.UpdateAppGoBuildParams {
+	params := database.UpdateAppGoBuildParams{
+		GoTarget:  app.GoTarget,
+		GoTags:    app.GoTags,
+		GoLdflags: app.GoLdflags,
+		UpdatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		ID:        app.ID,
+	}
+	for _, field := range []struct {
+		value  *string
+		column *sql.NullString
+	}{{b.target, &params.GoTarget}, {b.tags, &params.GoTags}, {b.ldflags, &params.GoLdflags}} {
+		if field.value != nil {
+			*field.column = sql.NullString{String: *field.value, Valid: *field.value != ""}
+		}
+	}
+	return params
+}
+
+// normalizeAppPath valida el subdirectorio de una app y lo deja sin "./" ni
+// barras sobrantes; "." y "/" son la raíz del repo ("")
+func normalizeAppPath(path string) (string, error) {
+	path = strings.Trim(strings.TrimSpace(path), "/")
+	if path == "" {
+		return "", nil
+	}
+	clean := filepath.ToSlash(filepath.Clean(path))
+	if clean == "." {
+		return "", nil
+	}
+	if clean == ".." || strings.HasPrefix(clean, "../") || strings.Contains(clean, ":") {
+		return "", fmt.Errorf("path inválido: %q (debe ser un subdirectorio del repositorio)", path)
+	}
+	return clean, nil
+}
+
+// validateDockerfilePath comprueba que path sea una ruta relativa dentro del repo
+func validateDockerfilePath(path string) error {
+	clean := filepath.ToSlash(filepath.Clean(path))
+	if path == "" || filepath.IsAbs(path) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("dockerfile_path inválido: %q (debe ser una ruta relativa dentro del repositorio)", path)
+	}
+	return nil
+}
+
+// validateRef comprueba que ref sea una rama, un tag o un commit del repo.
+// Los commits no se pueden consultar sin clonar: basta con que parezcan un SHA
+// y, si no existen, el deploy falla al resolverlos.
+func validateRef(ctx context.Context, repoURL, ref, gitHubToken string) error {
+	if ref == "" || strings.HasPrefix(ref, "-") || strings.Contains(ref, "..") || strings.ContainsAny(ref, " ~^:?*[\\") {
+		return fmt.Errorf("ref inválido: %q", ref)
+	}
+	_, err := gitserver.LsRemote(ctx, repoURLWithToken(repoURL, gitHubToken), ref)
+	if err != nil && isCommitSHA(ref) {
+		return nil
+	}
+	return err
+}
+
+// isCommitSHA indica si ref parece un SHA de commit, completo o abreviado
+func isCommitSHA(ref string) bool {
+	if len(ref) < 7 || len(ref) > 40 {
+		return false
+	}
+	for _, c := range ref {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// repoURLWithToken añade el token de GitHub a la URL para clonar repos privados
+func repoURLWithToken(repoURL, gitHubToken string) string {
+	if gitHubToken == "" {
+		return repoURL
+	}
+	return strings.Replace(repoURL, "https://github.com/", fmt.Sprintf("https://%s@github.com/", gitHubToken), 1)
+}
+
+// prepareBuildSource resuelve el commit pedido, el ref de la app o HEAD y lo
+// extrae en un directorio temporal que cleanup elimina; en apps con path solo
+// se extrae ese subdirectorio. Los repos remotos se sirven desde su mirror,
+// actualizado con fetch en cada deploy.
+func prepareBuildSource(jobCtx context.Context, ctx *Context, app *database.App, opts deployOptions) (*buildSource, error) {
+	repoDir, cleanupRepo, err := fetchRepo(jobCtx, ctx, app, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanupRepo()
+
+	rev := opts.CommitSHA
+	if rev == "" {
+		rev = app.Ref.String
+	}
+	if rev == "" {
+		rev = "HEAD"
+	}
+	commit, err := gitserver.ResolveCommit(jobCtx, repoDir, rev)
+	if err != nil {
+		return nil, err
+	}
+	recordDeploymentCommit(app.ID, commit)
+	archive, err := gitserver.Archive(jobCtx, repoDir, commit, app.Path.String)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "diplo-source-*")
+	if err != nil {
+		return nil, fmt.Errorf("error creando directorio temporal: %w", err)
+	}
+	if err := gitserver.Extract(archive, dir); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	source := &buildSource{commit: commit, dir: dir, archive: archive, appStart: app.StartCommand.String, appLanguage: app.LanguageOverride.String,
+		appGoBuild: app.GoTarget.String != "" || app.GoTags.String != "" || app.GoLdflags.String != ""}
+	if source.manifest, err = loadManifest(dir); err != nil {
+		reportManifestError(ctx, app.ID, err)
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	if source.manifest != nil {
+		sendLogMessage(ctx, app.ID, "info", fmt.Sprintf("Usando la configuración de %s", manifest.FileName))
+	}
+	if source.processes, err = loadProcfile(dir); err != nil {
+		reportManifestError(ctx, app.ID, err)
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	if source.processes != nil {
+		sendLogMessage(ctx, app.ID, "info", fmt.Sprintf("Procesos del %s: %s", manifest.ProcfileName, strings.Join(slices.Sorted(maps.Keys(source.processes)), ", ")))
+		if _, ok := source.processes[docker.WebProcess]; ok && source.manifest != nil && source.manifest.Start != "" {
+			sendLogMessage(ctx, app.ID, "warning", fmt.Sprintf("El start de %s reemplaza al proceso web del %s", manifest.FileName, manifest.ProcfileName))
+		}
+	}
+	return source, nil
+}
+
+// fetchRepo devuelve el repositorio bare de la app: el alojado, su mirror
+// actualizado o, sin directorio de mirrors, un clon temporal que cleanup elimina
+func fetchRepo(jobCtx context.Context, ctx *Context, app *database.App, opts deployOptions) (repoDir string, cleanup func(), err error) {
+	switch {
+	case ctx.gitRepos.Hosts(app.RepoUrl):
+		// Los repos alojados ya están en disco
+		return app.RepoUrl, func() {}, nil
+	case ctx.gitMirrors != nil:
+		repoDir, err = ctx.gitMirrors.Sync(jobCtx, app.RepoUrl, repoURLWithToken(app.RepoUrl, opts.GitHubToken))
+		return repoDir, func() {}, err
+	}
+
+	// Sin directorio de mirrors el repo se clona aparte en cada deploy
+	cloneDir, err := os.MkdirTemp("", "diplo-clone-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("error creando directorio temporal: %w", err)
+	}
+	if err := gitserver.CloneBare(jobCtx, repoURLWithToken(app.RepoUrl, opts.GitHubToken), cloneDir); err != nil {
+		os.RemoveAll(cloneDir)
+		return "", nil, err
+	}
+	return cloneDir, func() { os.RemoveAll(cloneDir) }, nil
+}
+
+// containerdSource es el commit que compila containerd, ya revisado en el
+// host: el contenedor clona ese mismo commit
+type containerdSource struct {
+	commit    string
+	language  string
+	runConfig docker.RunConfig
+	// buildCommand compila el paquete main elegido dentro del path de la app
+	buildCommand string
+}
+
+// prepareContainerdSource prepara una sola vez el commit a desplegar con
+// containerd. reason explica por qué containerd no puede construirlo: tiene un
+// Dockerfile, diplo.yaml o Procfile, que containerd no aplica, o su lenguaje
+// no es Go, el único que containerd compila. Esos deploys se construyen con
+// Docker aunque la app no tenga nada configurado.
+func prepareContainerdSource(jobCtx context.Context, ctx *Context, app *database.App, opts deployOptions) (prepared *containerdSource, reason string, err error) {
+	source, err := prepareBuildSource(jobCtx, ctx, app, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	defer source.cleanup()
+
+	if _, err := os.Lstat(filepath.Join(source.dir, defaultDockerfilePath)); err == nil {
+		return nil, fmt.Sprintf("El repositorio tiene %s", defaultDockerfilePath), nil
+	} else if !os.IsNotExist(err) {
+		return nil, "", fmt.Errorf("error leyendo %s: %w", defaultDockerfilePath, err)
+	}
+	if source.manifest != nil {
+		return nil, fmt.Sprintf("El repositorio tiene %s", manifest.FileName), nil
+	}
+	if source.processes != nil {
+		return nil, fmt.Sprintf("El repositorio tiene %s", manifest.ProcfileName), nil
+	}
+
+	detection := source.detectLanguage()
+	reportLanguage(ctx, app.ID, detection)
+	if detection.Language == "" {
+		return nil, "", errUnknownLanguage
+	}
+	if detection.Language != "go" {
+		return nil, fmt.Sprintf("El lenguaje detectado es %s y containerd solo compila Go", detection.Language), nil
+	}
+
+	// La imagen base de containerd es fija: la versión que pide el proyecto no se aplica
+	baseImage := getContainerdBaseImage(detection.Language)
+	if version := source.toolchainVersion(detection.Language); version.Version != "" && !strings.HasSuffix(baseImage, ":"+version.Version) {
+		sendLogMessage(ctx, app.ID, "warning", fmt.Sprintf("containerd usa %s: se ignora la versión %s de %s", baseImage, version.Version, version.Source))
+	}
+	target, err := runtimePkg.SelectGoTarget(source.dir, app.GoTarget.String)
+	if err != nil {
+		return nil, "", err
+	}
+	sendLogMessage(ctx, app.ID, "info", fmt.Sprintf("Paquete de Go: %s (%s)", target.Package, target.Reason))
+	return &containerdSource{
+		commit:       source.commit,
+		language:     detection.Language,
+		runConfig:    source.runConfig(),
+		buildCommand: containerdBuildCommand(app.Path.String, target.Package, app.GoTags.String, app.GoLdflags.String),
+	}, "", nil
+}
+
+// loadProcfile lee el Procfile del código extraído en dir. El proceso release
+// de Heroku se ejecuta una vez por deploy y Diplo no lo soporta: se omite.
+func loadProcfile(dir string) (map[string]string, error) {
+	processes, err := manifest.LoadProcfile(dir)
+	if err != nil || processes == nil {
+		return nil, err
+	}
+	if _, ok := processes[releaseProcess]; ok {
+		logrus.Warnf("Se omite el proceso %s del %s: Diplo no ejecuta tareas de release", releaseProcess, manifest.ProcfileName)
+		delete(processes, releaseProcess)
+	}
+	if len(processes) == 0 {
+		return nil, nil
+	}
+	return processes, nil
+}
+
+// loadManifest lee y valida el diplo.yaml del código extraído en dir
+func loadManifest(dir string) (*manifest.Manifest, error) {
+	m, err := manifest.Load(dir)
+	if err != nil || m == nil {
+		return nil, err
+	}
+	if m.Language != "" && !runtimePkg.NewDockerTemplateManager().HasTemplate(m.Language) {
+		return nil, &manifest.ValidationError{File: manifest.FileName, Problems: []string{fmt.Sprintf("language: lenguaje no soportado %q", m.Language)}}
+	}
+	return m, nil
+}
+
+// reportManifestError envía al log del deploy cada problema de diplo.yaml o del Procfile
+func reportManifestError(ctx *Context, appID string, err error) {
+	var invalid *manifest.ValidationError
+	if !errors.As(err, &invalid) {
+		return
+	}
+	sendLogMessage(ctx, appID, "error", fmt.Sprintf("%s tiene %d errores:", invalid.File, len(invalid.Problems)))
+	for _, problem := range invalid.Problems {
+		sendLogMessage(ctx, appID, "error", fmt.Sprintf("  %s: %s", invalid.File, problem))
+	}
+}
+
+// cleanup elimina el directorio temporal del código
+func (s *buildSource) cleanup() {
+	os.RemoveAll(s.dir)
+}
+
+// detectLanguage elige el lenguaje del código a construir: el fijado en la
+// app, el de diplo.yaml o el detectado a partir de los archivos del commit
+func (s *buildSource) detectLanguage() runtimePkg.LanguageDetection {
+	switch {
+	case s.appLanguage != "":
+		return runtimePkg.LanguageDetection{Language: runtimePkg.NormalizeLanguage(s.appLanguage), Source: runtimePkg.LanguageSourceApp, Reason: "fijado en la app", Confidence: 1}
+	case s.manifest != nil && s.manifest.Language != "":
+		return runtimePkg.LanguageDetection{Language: runtimePkg.NormalizeLanguage(s.manifest.Language), Source: runtimePkg.LanguageSourceManifest, Reason: "language de " + manifest.FileName, Confidence: 1}
+	}
+	detection := runtimePkg.DetectLanguage(s.dir)
+	if detection.Language == "" {
+		logrus.Warnf("No se pudo detectar el lenguaje del commit %s: %s", s.commit, detection.Reason)
+	}
+	return detection
+}
+
+// imageTag genera el tag de la imagen a partir del commit construido
+func (s *buildSource) imageTag(appID string) string {
+	return docker.ImageTagForCommit(appID, s.commit)
+}
+
+// dockerfile devuelve el Dockerfile a construir y su origen: el del repo
+// (app.DockerfilePath o "Dockerfile" en la raíz) o, si el repo no trae uno, la
+// plantilla del lenguaje. Un Dockerfile configurado que no existe es un error.
+func (s *buildSource) dockerfile(app *database.App, language string) (content, origin string, err error) {
+	path := defaultDockerfilePath
+	if app.DockerfilePath.String != "" {
+		path = app.DockerfilePath.String
+	}
+	if err := validateDockerfilePath(path); err != nil {
+		return "", "", err
+	}
+
+	// Los enlaces simbólicos podrían apuntar fuera del código extraído
+	file := filepath.Join(s.dir, filepath.FromSlash(path))
+	info, err := os.Lstat(file)
+	switch {
+	case err == nil && !info.Mode().IsRegular():
+		return "", "", fmt.Errorf("%s no es un archivo regular", path)
+	case err == nil:
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", "", fmt.Errorf("error leyendo %s: %w", path, err)
+		}
+		return string(data), "repo:" + path, nil
+	case !os.IsNotExist(err):
+		return "", "", fmt.Errorf("error leyendo %s: %w", path, err)
+	case app.DockerfilePath.String != "":
+		return "", "", fmt.Errorf("el repositorio no tiene %s en el commit %s", path, s.commit)
+	}
+
+	if language == "" {
+		return "", "", errUnknownLanguage
+	}
+	s.toolchain = s.toolchainVersion(language)
+	s.start = s.startCommand(language)
+	if language == "go" {
+		if s.goTarget, err = runtimePkg.SelectGoTarget(s.dir, app.GoTarget.String); err != nil {
+			return "", "", err
+		}
+	}
+	content, err = generateDockerfile(language, s.templateOptions(app))
+	if err != nil {
+		return "", "", err
+	}
+	return content, "template:" + language, nil
+}
+
+// toolchainVersion elige la versión de la imagen base: la de diplo.yaml, la que
+// declara el proyecto o, sin ninguna, la de la plantilla (Version vacía)
+func (s *buildSource) toolchainVersion(language string) runtimePkg.ToolchainVersion {
+	if s.manifest != nil && s.manifest.Version != "" {
+		return runtimePkg.ToolchainVersion{Version: s.manifest.Version, Source: manifest.FileName}
+	}
+	return runtimePkg.DetectToolchainVersion(s.dir, language)
+}
+
+// configuredStart devuelve el comando de inicio configurado: el de la app o el
+// de diplo.yaml, con su origen
+func (s *buildSource) configuredStart() (command, origin string) {
+	switch {
+	case s.appStart != "":
+		return s.appStart, "start_command de la app"
+	case s.manifest != nil && s.manifest.Start != "":
+		return s.manifest.Start, manifest.FileName
+	}
+	return "", ""
+}
+
+// startCommand elige el comando de inicio de la plantilla: el configurado o el
+// que se deduce del framework; Command vacío mantiene el de la plantilla
+func (s *buildSource) startCommand(language string) runtimePkg.StartCommand {
+	if command, origin := s.configuredStart(); command != "" {
+		return runtimePkg.StartCommand{Command: command, Reason: origin}
+	}
+	if _, ok := s.processes[docker.WebProcess]; ok {
+		// El proceso web del Procfile reemplaza al CMD de la imagen
+		return runtimePkg.StartCommand{}
+	}
+	return runtimePkg.DetectStartCommand(s.dir, language)
+}
+
+// templateOptions aplica a la plantilla la versión, el comando de inicio y el
+// paquete de Go elegidos, los flags de Go de la app y el build y el puerto de
+// diplo.yaml
+func (s *buildSource) templateOptions(app *database.App) runtimePkg.TemplateOptions {
+	opts := runtimePkg.TemplateOptions{
+		Port:         int(app.Port),
+		Version:      s.toolchain.Version,
+		StartCommand: s.start.Command,
+		BuildCommand: s.start.Build,
+		GoPackage:    s.goTarget.Package,
+		GoTags:       app.GoTags.String,
+		GoLDFlags:    app.GoLdflags.String,
+	}
+	if m := s.manifest; m != nil {
+		if m.Build != "" {
+			opts.BuildCommand = m.Build
+		}
+		if m.Port > 0 {
+			opts.Port = m.Port
+		}
+	}
+	return opts
+}
+
+// ignoredManifestFields devuelve los campos de diplo.yaml que solo aplican a
+// las plantillas y que un Dockerfile propio ignora
+func (s *buildSource) ignoredManifestFields() []string {
+	var fields []string
+	if m := s.manifest; m != nil {
+		for _, field := range []struct{ name, value string }{{"version", m.Version}, {"build", m.Build}, {"start", m.Start}} {
+			if field.value != "" {
+				fields = append(fields, field.name)
+			}
+		}
+	}
+	return fields
+}
+
+// runConfig traduce la configuración de ejecución de diplo.yaml y los procesos
+// del Procfile; se guarda en la imagen para que rollbacks y recuperaciones la
+// respeten. El start de la app o de diplo.yaml tiene prioridad sobre el proceso web.
+func (s *buildSource) runConfig() docker.RunConfig {
+	var config docker.RunConfig
+	if len(s.processes) > 0 {
+		config.Processes = maps.Clone(s.processes)
+		if command, _ := s.configuredStart(); command != "" {
+			delete(config.Processes, docker.WebProcess)
+		}
+	}
+	m := s.manifest
+	if m == nil {
+		return config
+	}
+
+	config.Port = m.Port
+	if m.HealthCheck != nil {
+		config.HealthPath = m.HealthCheck.Path
+		config.HealthTimeout, _ = m.HealthCheckTimeout()
+	}
+	config.MemoryBytes = m.MemoryBytes()
+	config.NanoCPUs = int64(m.Resources.CPUs * 1e9)
+	for _, volume := range m.Volumes {
+		config.Volumes = append(config.Volumes, docker.Volume{Name: volume.Name, Path: volume.Path})
+	}
+	config.Env = m.Env
+	return config
+}
+
+// buildImage construye la imagen del código o reutiliza la construida antes con
+// el mismo commit y Dockerfile; reused indica si se evitó el build. force
+// construye siempre y sin la caché de capas.
+func (s *buildSource) buildImage(jobCtx context.Context, ctx *Context, imageTag, dockerfile string, force bool) (imageID string, reused bool, err error) {
+	if !force {
+		if imageID, ok := ctx.docker.FindBuiltImage(jobCtx, imageTag, dockerfile, s.archive); ok {
+			return imageID, true, nil
+		}
+	}
+	imageID, err = ctx.docker.BuildImage(jobCtx, imageTag, dockerfile, s.archive, force, s.runConfig())
+	return imageID, false, err
+}
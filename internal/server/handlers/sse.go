@@ -8,6 +8,7 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/rodrwan/diplo/internal/docker"
 	runtimePkg "github.com/rodrwan/diplo/internal/runtime"
 	"github.com/sirupsen/logrus"
 )
@@ -42,9 +43,28 @@ func LogsSSEHandler(ctx *Context, w http.ResponseWriter, r *http.Request) (Respo
 	fmt.Fprintf(w, "data: %s\n\n", `{"type": "connected", "message": "Conexión SSE establecida"}`)
 	w.(http.Flusher).Flush()
 
-	// Escuchar logs del contenedor si está ejecutándose
-	if app.ContainerID.String != "" {
-		go streamContainerLogs(ctx, app.ContainerID.String, logChan)
+	// Escuchar logs del contenedor si está ejecutándose. ?process=<tipo> muestra
+	// los de un proceso del Procfile, cada línea con su instancia
+	switch process := r.URL.Query().Get("process"); process {
+	case "", docker.WebProcess:
+		if app.ContainerID.String != "" {
+			go streamContainerLogs(ctx, app.ContainerID.String, "", logChan)
+		}
+	default:
+		containers, err := ctx.docker.ListProcessContainers(r.Context(), app.ID)
+		if err != nil {
+			logChan <- createLogMessage("error", fmt.Sprintf("Error obteniendo contenedores del proceso %s: %v", process, err))
+		}
+		found := false
+		for _, c := range containers {
+			if c.Process == process {
+				found = true
+				go streamContainerLogs(ctx, c.ID, fmt.Sprintf("%s.%d | ", c.Process, c.Index), logChan)
+			}
+		}
+		if err == nil && !found {
+			logChan <- createLogMessage("error", fmt.Sprintf("El proceso %s no tiene contenedores", process))
+		}
 	}
 
 	// Escuchar canal de logs
@@ -63,8 +83,9 @@ func LogsSSEHandler(ctx *Context, w http.ResponseWriter, r *http.Request) (Respo
 	}
 }
 
-// streamContainerLogs obtiene logs del contenedor en tiempo real
-func streamContainerLogs(ctx *Context, containerID string, logChan chan<- string) {
+// streamContainerLogs obtiene logs del contenedor en tiempo real; prefix se
+// antepone a cada línea
+func streamContainerLogs(ctx *Context, containerID, prefix string, logChan chan<- string) {
 	// Determinar el runtime basándose en el container ID
 	runtimeType := inferRuntimeFromContainerID(containerID)
 
@@ -117,7 +138,7 @@ func streamContainerLogs(ctx *Context, containerID string, logChan chan<- string
 		// Limpiar la línea de caracteres de control
 		cleanLine := sanitizeString(line)
 		if cleanLine != "" {
-			logMsg := createLogMessage("log", cleanLine)
+			logMsg := createLogMessage("log", prefix+cleanLine)
 			logChan <- logMsg
 		}
 	}
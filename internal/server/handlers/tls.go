@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+)
+
+// ListCertificatesHandler devuelve el estado de TLS y los certificados emitidos por la CA local
+func ListCertificatesHandler(ctx *Context, w http.ResponseWriter, r *http.Request) (Response, error) {
+	if ctx.certs == nil {
+		return Response{Code: http.StatusOK, Data: map[string]interface{}{"enabled": false}}, nil
+	}
+
+	response := map[string]interface{}{
+		"enabled":        true,
+		"acme_directory": ctx.certs.ACMEDirectoryURL(),
+		"root_url":       "/api/v1/tls/root.pem",
+		"certificates":   ctx.certs.Certificates(),
+	}
+
+	return Response{Code: http.StatusOK, Data: response}, nil
+}
+
+// RootCertificateHandler descarga el certificado raíz de la CA local en PEM
+// para instalarlo como CA de confianza en los clientes (maneja su propia respuesta)
+func RootCertificateHandler(ctx *Context, w http.ResponseWriter, r *http.Request) error {
+	if ctx.certs == nil {
+		http.Error(w, "TLS no habilitado", http.StatusNotFound)
+		return nil
+	}
+
+	w.Header().Set("Content-Type", "application/x-pem-file")
+	w.Header().Set("Content-Disposition", `attachment; filename="diplo-root-ca.pem"`)
+	_, err := w.Write(ctx.certs.RootCertificatePEM())
+	return err
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rodrwan/diplo/internal/database"
+	"github.com/rodrwan/diplo/internal/dto"
+	"github.com/sirupsen/logrus"
+)
+
+// apiTokenPrefix identifica los tokens de Diplo en configuraciones y logs
+const apiTokenPrefix = "dpl_"
+
+// adminTokenEnv es la variable con el secreto de arranque que permite crear el
+// primer token de API, cuando todavía no hay ninguno con el que autenticarse
+const adminTokenEnv = "DIPLO_ADMIN_TOKEN"
+
+// errInvalidToken indica credenciales ausentes o que no corresponden a ningún token
+var errInvalidToken = errors.New("token inválido")
+
+// unauthorizedTokens es la respuesta de los endpoints de tokens sin credenciales válidas
+var unauthorizedTokens = Response{Code: http.StatusUnauthorized, Message: "Se requiere un token de API o " + adminTokenEnv + " como Bearer"}
+
+// CreateTokenRequest es el cuerpo de POST /api/v1/tokens
+type CreateTokenRequest struct {
+	Name string `json:"name"`
+}
+
+// CreateTokenHandler crea un token de API; el valor solo se devuelve en esta respuesta
+func CreateTokenHandler(ctx *Context, w http.ResponseWriter, r *http.Request) (Response, error) {
+	if err := authorizeTokenAdmin(ctx, r); err != nil {
+		if err == errInvalidToken {
+			return unauthorizedTokens, nil
+		}
+		return Response{Code: http.StatusInternalServerError, Message: "Error validando token"}, err
+	}
+	var req CreateTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return Response{Code: http.StatusBadRequest, Message: "JSON inválido"}, nil
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return Response{Code: http.StatusBadRequest, Message: "name es requerido"}, nil
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return Response{Code: http.StatusInternalServerError, Message: "Error generando token"}, err
+	}
+	token := apiTokenPrefix + hex.EncodeToString(secret)
+
+	createdAt := time.Now()
+	id, err := ctx.queries.CreateApiToken(r.Context(), database.CreateApiTokenParams{
+		Name:      req.Name,
+		TokenHash: hashToken(token),
+		CreatedAt: createdAt,
+	})
+	if err != nil {
+		return Response{Code: http.StatusInternalServerError, Message: "Error guardando token"}, err
+	}
+
+	logrus.Infof("Token de API %d (%s) creado", id, req.Name)
+	return Response{
+		Code: http.StatusCreated,
+		Data: dto.APIToken{
+			ID:        id,
+			Name:      req.Name,
+			Token:     token,
+			CreatedAt: createdAt.Format(time.RFC3339),
+		},
+		Message: "Guarda el token: no se volverá a mostrar",
+	}, nil
+}
+
+// ListTokensHandler lista los tokens de API sin su valor
+func ListTokensHandler(ctx *Context, w http.ResponseWriter, r *http.Request) (Response, error) {
+	if err := authorizeTokenAdmin(ctx, r); err != nil {
+		if err == errInvalidToken {
+			return unauthorizedTokens, nil
+		}
+		return Response{Code: http.StatusInternalServerError, Message: "Error validando token"}, err
+	}
+	tokens, err := ctx.queries.ListApiTokens(r.Context())
+	if err != nil {
+		return Response{Code: http.StatusInternalServerError, Message: "Error obteniendo tokens"}, err
+	}
+
+	result := make([]dto.APIToken, 0, len(tokens))
+	for _, token := range tokens {
+		item := dto.APIToken{
+			ID:        token.ID,
+			Name:      token.Name,
+			CreatedAt: token.CreatedAt.Format(time.RFC3339),
+		}
+		if token.LastUsedAt.Valid {
+			item.LastUsedAt = token.LastUsedAt.Time.Format(time.RFC3339)
+		}
+		result = append(result, item)
+	}
+	return Response{Code: http.StatusOK, Data: result}, nil
+}
+
+// DeleteTokenHandler revoca un token de API
+func DeleteTokenHandler(ctx *Context, w http.ResponseWriter, r *http.Request) (Response, error) {
+	if err := authorizeTokenAdmin(ctx, r); err != nil {
+		if err == errInvalidToken {
+			return unauthorizedTokens, nil
+		}
+		return Response{Code: http.StatusInternalServerError, Message: "Error validando token"}, err
+	}
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		return Response{Code: http.StatusBadRequest, Message: "ID de token inválido"}, nil
+	}
+
+	deleted, err := ctx.queries.DeleteApiToken(r.Context(), id)
+	if err != nil {
+		return Response{Code: http.StatusInternalServerError, Message: "Error eliminando token"}, err
+	}
+	if deleted == 0 {
+		return Response{Code: http.StatusNotFound, Message: "Token no encontrado"}, nil
+	}
+
+	logrus.Infof("Token de API %d revocado", id)
+	return Response{Code: http.StatusOK, Message: "Token revocado"}, nil
+}
+
+// authenticateToken valida el token de la petición, enviado como Bearer o
+// como contraseña de Basic auth (así lo envía git), y registra su uso
+func authenticateToken(ctx *Context, r *http.Request) (database.ApiToken, error) {
+	var token string
+	if username, password, ok := r.BasicAuth(); ok {
+		token = password
+		if token == "" {
+			token = username
+		}
+	} else if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		token = strings.TrimSpace(bearer)
+	}
+	if !strings.HasPrefix(token, apiTokenPrefix) {
+		return database.ApiToken{}, errInvalidToken
+	}
+
+	apiToken, err := ctx.queries.GetApiTokenByHash(r.Context(), hashToken(token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return database.ApiToken{}, errInvalidToken
+		}
+		return database.ApiToken{}, err
+	}
+
+	if err := ctx.queries.TouchApiToken(r.Context(), database.TouchApiTokenParams{
+		LastUsedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		ID:         apiToken.ID,
+	}); err != nil {
+		logrus.Warnf("Error registrando uso del token %d: %v", apiToken.ID, err)
+	}
+	return apiToken, nil
+}
+
+// authorizeTokenAdmin permite gestionar tokens con el secreto de arranque
+// (DIPLO_ADMIN_TOKEN) o con un token de API existente
+func authorizeTokenAdmin(ctx *Context, r *http.Request) error {
+	bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	admin := os.Getenv(adminTokenEnv)
+	if ok && admin != "" && subtle.ConstantTimeCompare([]byte(strings.TrimSpace(bearer)), []byte(admin)) == 1 {
+		return nil
+	}
+	_, err := authenticateToken(ctx, r)
+	return err
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rodrwan/diplo/internal/database"
+	"github.com/rodrwan/diplo/internal/dto"
+	"github.com/rodrwan/diplo/internal/webhooks"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultWebhookBranch es la rama que se despliega si no se configura otra
+	defaultWebhookBranch = "main"
+	// maxWebhookBodyBytes es el tamaño máximo de una entrega (el de GitHub)
+	maxWebhookBodyBytes = 25 << 20
+	// webhookDeliveryRetention es cuánto se recuerdan las entregas para descartar reintentos
+	webhookDeliveryRetention = 7 * 24 * time.Hour
+)
+
+// ConfigureWebhookRequest es el cuerpo de POST /api/v1/apps/{id}/webhook
+type ConfigureWebhookRequest struct {
+	Branch string `json:"branch"`
+}
+
+// ConfigureWebhookHandler activa el webhook de la app o rota su secreto; el
+// secreto solo se devuelve en esta respuesta
+func ConfigureWebhookHandler(ctx *Context, w http.ResponseWriter, r *http.Request) (Response, error) {
+	appID := mux.Vars(r)["id"]
+	app, err := ctx.queries.GetApp(r.Context(), appID)
+	if err != nil {
+		return Response{Code: http.StatusNotFound, Message: "Aplicación no encontrada"}, nil
+	}
+	if isImageApp(&app) {
+		return Response{Code: http.StatusBadRequest, Message: "Las apps desplegadas desde una imagen no tienen repositorio: redespliega con la nueva imagen"}, nil
+	}
+
+	var req ConfigureWebhookRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return Response{Code: http.StatusBadRequest, Message: "JSON inválido"}, nil
+		}
+	}
+	req.Branch = strings.TrimPrefix(strings.TrimSpace(req.Branch), "refs/heads/")
+	if isCommitSHA(app.Ref.String) {
+		return Response{Code: http.StatusBadRequest, Message: fmt.Sprintf("La app está fijada al commit %s: los webhooks no aplican", app.Ref.String)}, nil
+	}
+	if req.Branch, err = pinnedBranch(&app, req.Branch); err != nil {
+		return Response{Code: http.StatusBadRequest, Message: err.Error()}, nil
+	}
+	if req.Branch == "" {
+		req.Branch = defaultWebhookBranch
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return Response{Code: http.StatusInternalServerError, Message: "Error generando secreto"}, err
+	}
+	secret := hex.EncodeToString(raw)
+	encrypted, err := encryptValue(secret)
+	if err != nil {
+		return Response{Code: http.StatusInternalServerError, Message: "Error cifrando secreto"}, err
+	}
+
+	webhook := database.AppWebhook{
+		AppID:     appID,
+		Secret:    encrypted,
+		Branch:    req.Branch,
+		CreatedAt: time.Now(),
+	}
+	if err := ctx.queries.UpsertAppWebhook(r.Context(), database.UpsertAppWebhookParams{
+		AppID:     webhook.AppID,
+		Secret:    webhook.Secret,
+		Branch:    webhook.Branch,
+		CreatedAt: webhook.CreatedAt,
+	}); err != nil {
+		return Response{Code: http.StatusInternalServerError, Message: "Error guardando webhook"}, err
+	}
+
+	logrus.Infof("Webhook de la app %s configurado para la rama %s", appID, webhook.Branch)
+	result := webhookDTO(r, webhook)
+	result.Secret = secret
+	return Response{Code: http.StatusOK, Data: result, Message: "Guarda el secreto: no se volverá a mostrar"}, nil
+}
+
+// GetWebhookHandler devuelve la configuración del webhook de la app sin el secreto
+func GetWebhookHandler(ctx *Context, w http.ResponseWriter, r *http.Request) (Response, error) {
+	webhook, err := ctx.queries.GetAppWebhook(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Response{Code: http.StatusNotFound, Message: "La aplicación no tiene webhook"}, nil
+		}
+		return Response{Code: http.StatusInternalServerError, Message: "Error obteniendo webhook"}, err
+	}
+
+	return Response{Code: http.StatusOK, Data: webhookDTO(r, webhook)}, nil
+}
+
+// DeleteWebhookHandler desactiva el webhook de la app
+func DeleteWebhookHandler(ctx *Context, w http.ResponseWriter, r *http.Request) (Response, error) {
+	deleted, err := ctx.queries.DeleteAppWebhook(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		return Response{Code: http.StatusInternalServerError, Message: "Error eliminando webhook"}, err
+	}
+	if deleted == 0 {
+		return Response{Code: http.StatusNotFound, Message: "La aplicación no tiene webhook"}, nil
+	}
+
+	return Response{Code: http.StatusOK, Message: "Webhook eliminado"}, nil
+}
+
+// WebhookHandler recibe los push de GitHub, GitLab o Gitea en
+// /api/v1/hooks/{provider}/{app} y encola el redeploy del commit enviado
+func WebhookHandler(ctx *Context, w http.ResponseWriter, r *http.Request) (Response, error) {
+	vars := mux.Vars(r)
+	provider, appID := vars["provider"], vars["app"]
+	if !webhooks.ValidProvider(provider) {
+		return Response{Code: http.StatusNotFound, Message: "Proveedor de webhook desconocido"}, nil
+	}
+
+	webhook, err := ctx.queries.GetAppWebhook(r.Context(), appID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Response{Code: http.StatusNotFound, Message: "La aplicación no tiene webhook"}, nil
+		}
+		return Response{Code: http.StatusInternalServerError, Message: "Error obteniendo webhook"}, err
+	}
+	app, err := ctx.queries.GetApp(r.Context(), appID)
+	if err != nil {
+		return Response{Code: http.StatusInternalServerError, Message: "Error obteniendo aplicación"}, err
+	}
+	secret, err := decryptValue(webhook.Secret)
+	if err != nil {
+		return Response{Code: http.StatusInternalServerError, Message: "Error descifrando secreto del webhook"}, err
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodyBytes))
+	if err != nil {
+		return Response{Code: http.StatusRequestEntityTooLarge, Message: "Entrega demasiado grande"}, nil
+	}
+
+	event, err := webhooks.Parse(provider, r.Header, body, secret)
+	if err != nil {
+		if errors.Is(err, webhooks.ErrInvalidSignature) {
+			logrus.Warnf("Webhook %s de la app %s con firma inválida", provider, appID)
+			return Response{Code: http.StatusUnauthorized, Message: "Firma inválida"}, nil
+		}
+		return Response{Code: http.StatusBadRequest, Message: err.Error()}, nil
+	}
+	if !event.IsPush() {
+		return Response{Code: http.StatusOK, Message: fmt.Sprintf("Evento %q ignorado", event.Type)}, nil
+	}
+
+	branch, isBranch := event.Branch()
+	if !isBranch || branch != webhook.Branch {
+		return Response{Code: http.StatusOK, Message: fmt.Sprintf("Push a %s ignorado: solo se despliega la rama %s", event.Ref, webhook.Branch)}, nil
+	}
+	// Webhooks configurados antes de validar la rama contra el ref de la app
+	if _, err := pinnedBranch(&app, branch); err != nil {
+		return Response{Code: http.StatusOK, Message: fmt.Sprintf("Push a %s ignorado: %v", event.Ref, err)}, nil
+	}
+	if event.Deleted() {
+		return Response{Code: http.StatusOK, Message: fmt.Sprintf("Rama %s eliminada, nada que desplegar", branch)}, nil
+	}
+
+	// Los proveedores reintentan las entregas que no confirmaron a tiempo
+	now := time.Now()
+	recorded, err := ctx.queries.RecordWebhookDelivery(r.Context(), database.RecordWebhookDeliveryParams{
+		Provider:   provider,
+		DeliveryID: event.DeliveryID,
+		AppID:      appID,
+		ReceivedAt: now,
+	})
+	if err != nil {
+		return Response{Code: http.StatusInternalServerError, Message: "Error registrando entrega"}, err
+	}
+	if recorded == 0 {
+		return Response{Code: http.StatusOK, Message: "Entrega duplicada ignorada"}, nil
+	}
+	if err := ctx.queries.PruneWebhookDeliveries(r.Context(), now.Add(-webhookDeliveryRetention)); err != nil {
+		logrus.Warnf("Error limpiando entregas de webhooks antiguas: %v", err)
+	}
+
+	job, ahead, err := enqueueAppDeployJob(r.Context(), ctx, appID, JobKindRedeploy, DeployConflictQueue, deployJobPayload{
+		deployOptions: deployOptions{Trigger: DeploymentTriggerWebhook, CommitSHA: event.Commit},
+	})
+	if err != nil {
+		// Sin el registro, el reintento del proveedor vuelve a intentar encolar
+		if err := ctx.queries.DeleteWebhookDelivery(context.Background(), database.DeleteWebhookDeliveryParams{
+			Provider:   provider,
+			DeliveryID: event.DeliveryID,
+		}); err != nil {
+			logrus.Errorf("Error liberando entrega %s del webhook %s: %v", event.DeliveryID, provider, err)
+		}
+		return Response{Code: http.StatusInternalServerError, Message: "Error encolando redeploy"}, err
+	}
+
+	logrus.Infof("Webhook %s encoló el redeploy de %s en %s (trabajo %s)", provider, event.Commit, appID, job.ID)
+	return Response{
+		Code: http.StatusAccepted,
+		Data: map[string]interface{}{
+			"job_id":        job.ID,
+			"commit":        event.Commit,
+			"branch":        branch,
+			"queued_behind": ahead,
+		},
+		Message: "Redeploy encolado",
+	}, nil
+}
+
+// pinnedBranch valida la rama que siguen los webhooks o el polling de la app.
+// Una app con ref fijado solo puede seguir esa rama: un push a otra la sacaría
+// del ref. Sin branch devuelve el ref de la app, o "" si no tiene.
+func pinnedBranch(app *database.App, branch string) (string, error) {
+	ref := app.Ref.String
+	if ref == "" || branch == ref {
+		return branch, nil
+	}
+	if branch == "" {
+		return ref, nil
+	}
+	return "", fmt.Errorf("la app está fijada a %s: solo puede seguir esa rama", ref)
+}
+
+func webhookDTO(r *http.Request, webhook database.AppWebhook) dto.Webhook {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	urls := make(map[string]string)
+	for _, provider := range []string{webhooks.ProviderGitHub, webhooks.ProviderGitLab, webhooks.ProviderGitea} {
+		urls[provider] = fmt.Sprintf("%s://%s/api/v1/hooks/%s/%s", scheme, r.Host, provider, webhook.AppID)
+	}
+
+	return dto.Webhook{
+		AppID:     webhook.AppID,
+		Branch:    webhook.Branch,
+		URLs:      urls,
+		CreatedAt: webhook.CreatedAt.Format(time.RFC3339),
+	}
+}
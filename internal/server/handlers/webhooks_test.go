@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/rodrwan/diplo/internal/database"
+)
+
+func TestPinnedBranch(t *testing.T) {
+	tests := []struct {
+		name    string
+		ref     string
+		branch  string
+		want    string
+		wantErr bool
+	}{
+		{"sin ref usa la rama pedida", "", "develop", "develop", false},
+		{"sin ref ni rama", "", "", "", false},
+		{"con ref y sin rama usa el ref", "staging", "", "staging", false},
+		{"con ref y la misma rama", "staging", "staging", "staging", false},
+		{"con ref y otra rama", "v1.2.0", "main", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &database.App{Ref: sql.NullString{String: tt.ref, Valid: tt.ref != ""}}
+			got, err := pinnedBranch(app, tt.branch)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("pinnedBranch = %q, se esperaba %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -9,21 +9,24 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/rodrwan/diplo/internal/certs"
 	"github.com/rodrwan/diplo/internal/database"
 	"github.com/rodrwan/diplo/internal/docker"
-	"github.com/rodrwan/diplo/internal/models"
+	"github.com/rodrwan/diplo/internal/gitserver"
+	"github.com/rodrwan/diplo/internal/jobs"
+	"github.com/rodrwan/diplo/internal/proxy"
 	"github.com/rodrwan/diplo/internal/runtime"
 	"github.com/rodrwan/diplo/internal/server/handlers"
 	"github.com/rodrwan/diplo/internal/templates"
 	"github.com/sirupsen/logrus"
 
 	_ "github.com/mattn/go-sqlite3"
-	runtimePkg "github.com/rodrwan/diplo/internal/runtime"
 )
 
 type Server struct {
@@ -36,6 +39,162 @@ type Server struct {
 	queries        database.Querier
 	// Para SSE - canales de logs por app
 	logChannels map[string]chan string
+	// Reconciliación continua estado deseado (BD) vs. estado real (runtime)
+	reconciler *handlers.Reconciler
+	// stopBackground detiene el reconciliador, la cola de deploys, el polling git y la rotación de certificados
+	stopBackground context.CancelFunc
+	// Proxy inverso por hostname/prefijo; proxyServer solo existe con DIPLO_PROXY_ADDR
+	proxy       *proxy.Proxy
+	proxyServer *http.Server
+	// HTTPS con CA local o ACME; solo existen con DIPLO_TLS_ADDR
+	certs     *certs.Manager
+	tlsServer *http.Server
+	// Cola persistente de deploys, redeploys y rollbacks
+	jobs *jobs.Queue
+	// Repositorios alojados que reciben git push
+	gitRepos *gitserver.Repos
+	// Mirrors de los repos remotos que se despliegan
+	gitMirrors *gitserver.Mirrors
+	// Polling de ramas remotas para apps sin webhooks
+	gitPoller *handlers.GitPoller
+}
+
+// defaultReconcileInterval es el intervalo del loop de reconciliación si no se
+// configura DIPLO_RECONCILE_INTERVAL
+const defaultReconcileInterval = 30 * time.Second
+
+// reconcileIntervalFromEnv lee DIPLO_RECONCILE_INTERVAL (ej. "1m"); "0" deshabilita el loop
+func reconcileIntervalFromEnv() time.Duration {
+	raw := os.Getenv("DIPLO_RECONCILE_INTERVAL")
+	if raw == "" {
+		return defaultReconcileInterval
+	}
+
+	interval, err := time.ParseDuration(raw)
+	if err != nil {
+		logrus.Warnf("DIPLO_RECONCILE_INTERVAL inválido (%q), usando %s", raw, defaultReconcileInterval)
+		return defaultReconcileInterval
+	}
+
+	return interval
+}
+
+// defaultDrainTimeout es cuánto espera la versión anterior de un redeploy a
+// que terminen sus conexiones si no se configura DIPLO_DRAIN_TIMEOUT
+const defaultDrainTimeout = 30 * time.Second
+
+// drainTimeoutFromEnv lee DIPLO_DRAIN_TIMEOUT (ej. "2m"); "0" elimina la
+// versión anterior sin esperar
+func drainTimeoutFromEnv() time.Duration {
+	raw := os.Getenv("DIPLO_DRAIN_TIMEOUT")
+	if raw == "" {
+		return defaultDrainTimeout
+	}
+
+	timeout, err := time.ParseDuration(raw)
+	if err != nil || timeout < 0 {
+		logrus.Warnf("DIPLO_DRAIN_TIMEOUT inválido (%q), usando %s", raw, defaultDrainTimeout)
+		return defaultDrainTimeout
+	}
+
+	return timeout
+}
+
+// defaultDeployWorkers es cuántos trabajos de deploy corren en paralelo si no
+// se configura DIPLO_DEPLOY_WORKERS
+const defaultDeployWorkers = 2
+
+// deployWorkersFromEnv lee DIPLO_DEPLOY_WORKERS (número de workers de la cola)
+func deployWorkersFromEnv() int {
+	raw := os.Getenv("DIPLO_DEPLOY_WORKERS")
+	if raw == "" {
+		return defaultDeployWorkers
+	}
+
+	workers, err := strconv.Atoi(raw)
+	if err != nil || workers < 1 {
+		logrus.Warnf("DIPLO_DEPLOY_WORKERS inválido (%q), usando %d", raw, defaultDeployWorkers)
+		return defaultDeployWorkers
+	}
+
+	return workers
+}
+
+// defaultGitDir es el directorio de los repositorios alojados si no se
+// configura DIPLO_GIT_DIR
+const defaultGitDir = "repos"
+
+// defaultGitCacheDir es el directorio de los mirrors de repos remotos si no se
+// configura DIPLO_GIT_CACHE_DIR
+const defaultGitCacheDir = "git-cache"
+
+// proxyConfigFromEnv construye la configuración del proxy desde DIPLO_BASE_DOMAIN
+// y DIPLO_PROXY_ADDR; sin listener dedicado las URLs usan el puerto del servidor
+func proxyConfigFromEnv(serverPort int) proxy.Config {
+	config := proxy.Config{
+		BaseDomain: os.Getenv("DIPLO_BASE_DOMAIN"),
+		PublicPort: serverPort,
+	}
+
+	if addr := os.Getenv("DIPLO_PROXY_ADDR"); addr != "" {
+		if port, err := portFromAddr(addr); err == nil {
+			config.PublicPort = port
+		} else {
+			logrus.Warnf("DIPLO_PROXY_ADDR inválido (%q): %v", addr, err)
+		}
+	}
+
+	// Con HTTPS habilitado las URLs públicas apuntan al listener TLS
+	if addr := os.Getenv("DIPLO_TLS_ADDR"); addr != "" {
+		if port, err := portFromAddr(addr); err == nil {
+			config.TLS = true
+			config.PublicPort = port
+		} else {
+			logrus.Warnf("DIPLO_TLS_ADDR inválido (%q): %v", addr, err)
+		}
+	}
+
+	return config
+}
+
+func portFromAddr(addr string) (int, error) {
+	_, rawPort, err := net.SplitHostPort(addr)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(rawPort)
+}
+
+// certsConfigFromEnv construye la configuración TLS: DIPLO_TLS_DIR, DIPLO_TLS_HOSTS
+// (nombres extra del API/UI) y DIPLO_ACME_DIRECTORY/EMAIL/CA_BUNDLE para ACME
+func certsConfigFromEnv(host, baseDomain string) certs.Config {
+	names := []string{"localhost", "127.0.0.1", "::1", baseDomain}
+	if host != "" && host != "0.0.0.0" {
+		names = append(names, host)
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		names = append(names, hostname, hostname+".local")
+	}
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && !ipNet.IP.IsLinkLocalUnicast() {
+				names = append(names, ipNet.IP.String())
+			}
+		}
+	}
+	for _, extra := range strings.Split(os.Getenv("DIPLO_TLS_HOSTS"), ",") {
+		if extra = strings.TrimSpace(extra); extra != "" {
+			names = append(names, extra)
+		}
+	}
+
+	return certs.Config{
+		Dir:              os.Getenv("DIPLO_TLS_DIR"),
+		ServerNames:      names,
+		ACMEDirectoryURL: os.Getenv("DIPLO_ACME_DIRECTORY"),
+		ACMEEmail:        os.Getenv("DIPLO_ACME_EMAIL"),
+		ACMECABundle:     os.Getenv("DIPLO_ACME_CA_BUNDLE"),
+	}
 }
 
 // ensureDatabaseWritable verifica y corrige permisos de la base de datos
@@ -71,7 +230,6 @@ func NewServer(host string, port int) *Server {
 		router: router,
 		server: &http.Server{
 			Addr:         fmt.Sprintf("%s:%d", host, port),
-			Handler:      router,
 			ReadTimeout:  30 * time.Second,
 			WriteTimeout: 30 * time.Second,
 			IdleTimeout:  120 * time.Second,
@@ -108,6 +266,13 @@ func NewServer(host string, port int) *Server {
 	if err := queries.CreateTables(context.Background()); err != nil {
 		logrus.Fatalf("error creando tablas: %v", err)
 	}
+	// Los deployments que seguían en curso se interrumpieron con el proceso anterior
+	if err := queries.FailRunningDeployments(context.Background(), database.FailRunningDeploymentsParams{
+		ErrorMsg:   sql.NullString{String: "Deployment interrumpido por reinicio del servidor", Valid: true},
+		FinishedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	}); err != nil {
+		logrus.Warnf("Error cerrando deployments interrumpidos: %v", err)
+	}
 	srv.db = db
 	srv.queries = queries
 
@@ -127,8 +292,82 @@ func NewServer(host string, port int) *Server {
 		logrus.Debugf("Evento Docker global: %s - %s", event.Type, event.Message)
 	})
 
-	// Recuperar contenedores existentes al iniciar el servidor
-	if err := srv.recoverContainers(); err != nil {
+	// Proxy inverso: las peticiones a <app>.<dominio> o /apps/<app>/ van al
+	// contenedor de la app y el resto al router de Diplo
+	srv.proxy = proxy.New(srv.queries, proxyConfigFromEnv(port))
+	srv.server.Handler = srv.proxy.Handler(router)
+	if addr := os.Getenv("DIPLO_PROXY_ADDR"); addr != "" {
+		srv.proxyServer = &http.Server{
+			Addr:              addr,
+			Handler:           srv.proxy.Handler(http.NotFoundHandler()),
+			ReadHeaderTimeout: 30 * time.Second,
+			IdleTimeout:       120 * time.Second,
+		}
+	}
+
+	// HTTPS para el API/UI y las apps, con certificados por hostname
+	if addr := os.Getenv("DIPLO_TLS_ADDR"); addr != "" {
+		certsConfig := certsConfigFromEnv(host, srv.proxy.BaseDomain())
+		certsConfig.HostPolicy = srv.proxy.HasHost
+		certManager, err := certs.New(certsConfig)
+		if err != nil {
+			logrus.Fatalf("Error inicializando certificados TLS: %v", err)
+		}
+		srv.certs = certManager
+		srv.tlsServer = &http.Server{
+			Addr:         addr,
+			Handler:      srv.proxy.Handler(router),
+			TLSConfig:    certManager.TLSConfig(),
+			ReadTimeout:  30 * time.Second,
+			WriteTimeout: 30 * time.Second,
+			IdleTimeout:  120 * time.Second,
+		}
+
+		// Los desafíos ACME http-01 llegan por HTTP
+		srv.server.Handler = certManager.HTTPHandler(srv.server.Handler)
+		if srv.proxyServer != nil {
+			srv.proxyServer.Handler = certManager.HTTPHandler(srv.proxyServer.Handler)
+		}
+	}
+
+	// Sin clave de cifrado no se pueden guardar ni leer secretos: variables
+	// secretas, tokens, secretos de webhooks y contraseñas de registries
+	if err := handlers.CheckEncryptionKey(); err != nil {
+		logrus.Warnf("⚠️  %v. Los deploys que usen secretos fallarán", err)
+	}
+
+	// Los trabajos que corrían cuando el proceso anterior se detuvo vuelven a la
+	// cola; las apps que quedaron deployando sin trabajo pendiente se restauran
+	srv.jobs = jobs.New(srv.queries, deployWorkersFromEnv())
+	if err := srv.jobs.Recover(context.Background()); err != nil {
+		logrus.Errorf("Error recuperando trabajos interrumpidos: %v", err)
+	}
+	handlers.ResetStuckApps(context.Background(), srv.queries)
+
+	// Repositorios bare que reciben git push en /git/<app>.git
+	gitDir := os.Getenv("DIPLO_GIT_DIR")
+	if gitDir == "" {
+		gitDir = defaultGitDir
+	}
+	if srv.gitRepos, err = gitserver.NewRepos(gitDir); err != nil {
+		logrus.Errorf("Error inicializando repositorios git, push deshabilitado: %v", err)
+	}
+
+	// Mirrors de los repos remotos: cada deploy hace fetch en vez de clonar
+	gitCacheDir := os.Getenv("DIPLO_GIT_CACHE_DIR")
+	if gitCacheDir == "" {
+		gitCacheDir = defaultGitCacheDir
+	}
+	if srv.gitMirrors, err = gitserver.NewMirrors(gitCacheDir); err != nil {
+		logrus.Errorf("Error inicializando caché de repositorios git, se clonará en cada deploy: %v", err)
+	}
+
+	// Recuperar contenedores existentes al iniciar el servidor con una primera
+	// pasada de reconciliación; el loop periódico arranca en Start
+	srv.reconciler = handlers.NewReconciler(srv.docker, srv.queries, srv.runtimeFactory, reconcileIntervalFromEnv())
+	srv.reconciler.SetProxy(srv.proxy)
+	logrus.Info("🔍 Iniciando recuperación de contenedores...")
+	if _, err := srv.reconciler.RunOnce(context.Background()); err != nil {
 		logrus.Errorf("Error recuperando contenedores: %v", err)
 	}
 
@@ -159,6 +398,12 @@ func (s *Server) setupRoutes() {
 
 	// Contexto híbrido para deployment inteligente
 	hybridCtx := handlers.NewHybridContext(s.docker, s.queries, s.logChannels, s.runtimeFactory)
+	hybridCtx.SetProxy(s.proxy)
+	hybridCtx.SetJobQueue(s.jobs)
+	hybridCtx.SetGitRepos(s.gitRepos)
+	hybridCtx.SetGitMirrors(s.gitMirrors)
+	hybridCtx.SetDrainTimeout(drainTimeoutFromEnv())
+	s.jobs.SetHandler(handlers.DeployJobHandler(hybridCtx))
 
 	// Endpoints principales con sistema híbrido
 	api.HandleFunc("/status", hybridCtx.ServeHTTP(handlers.UnifiedStatusHandler)).Methods("GET")
@@ -166,12 +411,44 @@ func (s *Server) setupRoutes() {
 
 	// Contexto tradicional para gestión de apps y env vars
 	ctx := handlers.NewContext(s.docker, s.queries, s.logChannels)
+	ctx.SetReconciler(s.reconciler)
+	ctx.SetProxy(s.proxy)
+	ctx.SetCertManager(s.certs)
+	ctx.SetJobQueue(s.jobs)
+	ctx.SetGitRepos(s.gitRepos)
+	ctx.SetGitMirrors(s.gitMirrors)
+	ctx.SetDrainTimeout(drainTimeoutFromEnv())
+	s.gitPoller = handlers.NewGitPoller(ctx)
 
 	// Endpoints de gestión de aplicaciones
 	api.HandleFunc("/apps", ctx.ServeHTTP(handlers.ListAppsHandler)).Methods("GET")
 	api.HandleFunc("/apps/{id}", ctx.ServeHTTP(handlers.GetAppHandler)).Methods("GET")
 	api.HandleFunc("/apps/{id}", ctx.ServeHTTP(handlers.DeleteAppHandler)).Methods("DELETE")
 	api.HandleFunc("/apps/{id}/health", ctx.ServeHTTP(handlers.HealthCheckHandler)).Methods("GET")
+	api.HandleFunc("/apps/{id}/deployments", ctx.ServeHTTP(handlers.ListAppDeploymentsHandler)).Methods("GET")
+	api.HandleFunc("/apps/{id}/rollback", ctx.ServeHTTP(handlers.RollbackAppHandler)).Methods("POST")
+	api.HandleFunc("/deployments/{id}", ctx.ServeHTTP(handlers.GetDeploymentHandler)).Methods("GET")
+	// Cola de trabajos de deploy
+	api.HandleFunc("/jobs", ctx.ServeHTTP(handlers.ListJobsHandler)).Methods("GET")
+	api.HandleFunc("/jobs/{id}", ctx.ServeHTTP(handlers.GetJobHandler)).Methods("GET")
+	api.HandleFunc("/jobs/{id}", ctx.ServeHTTP(handlers.CancelJobHandler)).Methods("DELETE")
+	// Webhooks de push: configuración por app y entregas de GitHub, GitLab y Gitea
+	api.HandleFunc("/apps/{id}/webhook", ctx.ServeHTTP(handlers.GetWebhookHandler)).Methods("GET")
+	api.HandleFunc("/apps/{id}/webhook", ctx.ServeHTTP(handlers.ConfigureWebhookHandler)).Methods("POST")
+	api.HandleFunc("/apps/{id}/webhook", ctx.ServeHTTP(handlers.DeleteWebhookHandler)).Methods("DELETE")
+	api.HandleFunc("/hooks/{provider}/{app}", ctx.ServeHTTP(handlers.WebhookHandler)).Methods("POST")
+	// Polling de la rama remota (auto-deploy sin webhooks)
+	api.HandleFunc("/apps/{id}/polling", ctx.ServeHTTP(handlers.GetGitPollingHandler)).Methods("GET")
+	api.HandleFunc("/apps/{id}/polling", ctx.ServeHTTP(handlers.ConfigureGitPollingHandler)).Methods("PUT")
+	api.HandleFunc("/apps/{id}/polling", ctx.ServeHTTP(handlers.DeleteGitPollingHandler)).Methods("DELETE")
+
+	// Procesos del Procfile: estado y escala por tipo
+	api.HandleFunc("/apps/{id}/processes", ctx.ServeHTTP(handlers.GetProcessesHandler)).Methods("GET")
+	api.HandleFunc("/apps/{id}/processes/{type}", ctx.ServeHTTP(handlers.ScaleProcessHandler)).Methods("PUT")
+	// Tokens de API (autentican git push)
+	api.HandleFunc("/tokens", ctx.ServeHTTP(handlers.ListTokensHandler)).Methods("GET")
+	api.HandleFunc("/tokens", ctx.ServeHTTP(handlers.CreateTokenHandler)).Methods("POST")
+	api.HandleFunc("/tokens/{id}", ctx.ServeHTTP(handlers.DeleteTokenHandler)).Methods("DELETE")
 	// Environment variables endpoints
 	api.HandleFunc("/apps/{id}/env", ctx.ServeHTTP(handlers.ListAppEnvVarsHandler)).Methods("GET")
 	api.HandleFunc("/apps/{id}/env", ctx.ServeHTTP(handlers.CreateAppEnvVarHandler)).Methods("POST")
@@ -183,6 +460,16 @@ func (s *Server) setupRoutes() {
 	api.HandleFunc("/maintenance/cleanup-orphaned-containers", ctx.ServeHTTP(handlers.CleanupOrphanedContainersHandler)).Methods("POST")
 	api.HandleFunc("/maintenance/aggressive-cleanup", ctx.ServeHTTP(handlers.AggressiveCleanupContainersHandler)).Methods("POST")
 	api.HandleFunc("/maintenance/recover-containers", ctx.ServeHTTP(handlers.RecoverContainersHandler)).Methods("POST")
+	api.HandleFunc("/maintenance/reconcile", ctx.ServeHTTP(handlers.ReconcileStatusHandler)).Methods("GET")
+	// Rutas publicadas por el proxy inverso
+	api.HandleFunc("/routes", ctx.ServeHTTP(handlers.ListRoutesHandler)).Methods("GET")
+	// TLS: certificados emitidos y certificado raíz de la CA local
+	api.HandleFunc("/tls/certificates", ctx.ServeHTTP(handlers.ListCertificatesHandler)).Methods("GET")
+	api.HandleFunc("/tls/root.pem", func(w http.ResponseWriter, r *http.Request) {
+		if err := handlers.RootCertificateHandler(ctx, w, r); err != nil {
+			logrus.Errorf("Error enviando certificado raíz: %v", err)
+		}
+	}).Methods("GET")
 	// SSE endpoint para logs en tiempo real (maneja su propia respuesta)
 	api.HandleFunc("/apps/{id}/logs", func(w http.ResponseWriter, r *http.Request) {
 		// El handler SSE maneja su propia respuesta, no usar el wrapper JSON
@@ -192,6 +479,18 @@ func (s *Server) setupRoutes() {
 		}
 	}).Methods("GET")
 
+	// git push a los repositorios alojados (smart HTTP, maneja su propia respuesta)
+	s.router.HandleFunc("/git/{app}.git/info/refs", func(w http.ResponseWriter, r *http.Request) {
+		if err := handlers.GitInfoRefsHandler(hybridCtx, w, r); err != nil {
+			logrus.Errorf("Error anunciando referencias git: %v", err)
+		}
+	}).Methods("GET")
+	s.router.HandleFunc("/git/{app}.git/git-receive-pack", func(w http.ResponseWriter, r *http.Request) {
+		if err := handlers.GitReceivePackHandler(hybridCtx, w, r); err != nil {
+			logrus.Errorf("Error procesando git push: %v", err)
+		}
+	}).Methods("POST")
+
 	// Endpoints específicos de runtime (opcional - para debugging)
 	lxcAPI := s.router.PathPrefix("/api/lxc").Subrouter()
 	lxcAPI.HandleFunc("/status", hybridCtx.ServeHTTP(handlers.HybridLXCStatusHandler)).Methods("GET")
@@ -255,284 +554,60 @@ func (s *Server) Start() error {
 
 	log.Printf("Servidor escuchando en http://%s\n", s.server.Addr)
 
-	return s.server.Serve(listener)
-}
-
-// recoverContainers recupera contenedores que estaban ejecutándose antes del reinicio del servidor
-func (s *Server) recoverContainers() error {
-	logrus.Info("🔍 Iniciando recuperación de contenedores...")
-
-	// Obtener todas las aplicaciones de la base de datos
-	apps, err := s.queries.GetAllApps(context.Background())
-	if err != nil {
-		return fmt.Errorf("error obteniendo aplicaciones de BD: %v", err)
-	}
-
-	if len(apps) == 0 {
-		logrus.Info("✅ No hay aplicaciones para recuperar")
-		return nil
-	}
+	backgroundCtx, cancel := context.WithCancel(context.Background())
+	s.stopBackground = cancel
+	go s.reconciler.Start(backgroundCtx)
+	go s.jobs.Start(backgroundCtx)
+	go s.gitPoller.Start(backgroundCtx)
 
-	logrus.Infof("📋 Encontradas %d aplicaciones en BD", len(apps))
-
-	// Obtener runtime preferido
-	preferredRuntime := s.runtimeFactory.GetPreferredRuntime()
-	logrus.Infof("🎯 Usando runtime preferido: %s", preferredRuntime)
-
-	// Crear runtime para recuperación
-	runtime, err := s.runtimeFactory.CreateRuntime(preferredRuntime)
-	if err != nil {
-		logrus.Errorf("Error creando runtime %s: %v", preferredRuntime, err)
-		// Intentar fallback a Docker
-		logrus.Warnf("Intentando fallback a Docker...")
-		runtime, err = s.runtimeFactory.CreateRuntime(runtimePkg.RuntimeTypeDocker)
+	if s.proxyServer != nil {
+		proxyListener, err := net.Listen("tcp4", s.proxyServer.Addr)
 		if err != nil {
-			return fmt.Errorf("error creando runtime de fallback: %v", err)
+			return fmt.Errorf("error iniciando proxy en %s: %v", s.proxyServer.Addr, err)
 		}
-		preferredRuntime = runtimePkg.RuntimeTypeDocker
-	}
-	defer runtime.Close()
-
-	// Obtener contenedores ejecutándose según el runtime
-	var runningContainers []*runtimePkg.Container
-	var runningContainerMap map[string]bool
-
-	switch preferredRuntime {
-	case runtimePkg.RuntimeTypeDocker:
-		// Usar Docker client
-		dockerContainers, err := s.docker.GetRunningContainers()
-		if err != nil {
-			return fmt.Errorf("error obteniendo contenedores Docker: %v", err)
-		}
-		logrus.Infof("🐳 Encontrados %d contenedores Docker ejecutándose", len(dockerContainers))
-
-		// Convertir a formato Container
-		runningContainers = make([]*runtimePkg.Container, 0, len(dockerContainers))
-		runningContainerMap = make(map[string]bool)
-		for _, container := range dockerContainers {
-			containerName := container.ID
-			if len(container.Names) > 0 {
-				containerName = container.Names[0]
+		log.Printf("Proxy de aplicaciones escuchando en http://%s\n", s.proxyServer.Addr)
+		go func() {
+			if err := s.proxyServer.Serve(proxyListener); err != nil && err != http.ErrServerClosed {
+				logrus.Errorf("Error en el proxy de aplicaciones: %v", err)
 			}
-			runningContainers = append(runningContainers, &runtimePkg.Container{
-				ID:        container.ID,
-				Name:      containerName,
-				Image:     container.Image,
-				Status:    runtimePkg.ContainerStatusRunning,
-				Runtime:   runtimePkg.RuntimeTypeDocker,
-				CreatedAt: time.Unix(container.Created, 0),
-			})
-			runningContainerMap[container.ID] = true
-		}
-
-	case runtimePkg.RuntimeTypeContainerd:
-		// Usar containerd client
-		containerdContainers, err := runtime.GetRunningContainers()
-		if err != nil {
-			return fmt.Errorf("error obteniendo contenedores containerd: %v", err)
-		}
-		logrus.Infof("🔧 Encontrados %d contenedores containerd ejecutándose", len(containerdContainers))
-
-		runningContainers = containerdContainers
-		runningContainerMap = make(map[string]bool)
-		for _, container := range containerdContainers {
-			runningContainerMap[container.ID] = true
-		}
-
-	default:
-		return fmt.Errorf("runtime no soportado: %s", preferredRuntime)
+		}()
 	}
 
-	// Procesar cada aplicación
-	recoveredCount := 0
-	errorCount := 0
-
-	for _, app := range apps {
-		// Solo procesar aplicaciones que estaban en estado "running"
-		if app.Status.String != "running" {
-			logrus.Debugf("⏭️  Saltando app %s (estado: %s)", app.ID, app.Status.String)
-			continue
-		}
-
-		// Verificar si el contenedor está realmente ejecutándose
-		containerID := app.ContainerID.String
-		if containerID == "" {
-			logrus.Warnf("⚠️  App %s marcada como running pero sin container_id", app.ID)
-			// Marcar como error
-			if err := s.updateAppStatus(app.ID, "error", "Contenedor perdido durante reinicio"); err != nil {
-				logrus.Errorf("Error actualizando estado de app %s: %v", app.ID, err)
-				errorCount++
-			}
-			continue
+	if s.tlsServer != nil {
+		tlsListener, err := net.Listen("tcp4", s.tlsServer.Addr)
+		if err != nil {
+			return fmt.Errorf("error iniciando HTTPS en %s: %v", s.tlsServer.Addr, err)
 		}
-
-		if runningContainerMap[containerID] {
-			// Contenedor está ejecutándose - verificar que esté healthy
-			logrus.Infof("✅ Contenedor %s para app %s está ejecutándose", containerID, app.ID)
-
-			// Verificar health del contenedor según el runtime
-			var status string
-			var statusErr error
-
-			switch preferredRuntime {
-			case runtimePkg.RuntimeTypeDocker:
-				status, statusErr = s.docker.GetContainerStatus(containerID)
-			case runtimePkg.RuntimeTypeContainerd:
-				status, statusErr = runtime.GetContainerStatus(containerID)
-			default:
-				statusErr = fmt.Errorf("runtime no soportado para health check")
+		log.Printf("Servidor HTTPS escuchando en https://%s\n", s.tlsServer.Addr)
+		go s.certs.Start(backgroundCtx)
+		go func() {
+			if err := s.tlsServer.ServeTLS(tlsListener, "", ""); err != nil && err != http.ErrServerClosed {
+				logrus.Errorf("Error en el servidor HTTPS: %v", err)
 			}
-
-			if statusErr != nil {
-				logrus.Warnf("⚠️  Error verificando estado del contenedor %s: %v", containerID, statusErr)
-				// Marcar como error
-				if err := s.updateAppStatus(app.ID, "error", fmt.Sprintf("Error verificando contenedor: %v", statusErr)); err != nil {
-					logrus.Errorf("Error actualizando estado de app %s: %v", app.ID, err)
-					errorCount++
-				}
-				continue
-			}
-
-			if strings.Contains(strings.ToUpper(status), "RUNNING") {
-				logrus.Infof("✅ App %s recuperada exitosamente (contenedor: %s)", app.ID, containerID)
-				recoveredCount++
-			} else {
-				logrus.Warnf("⚠️  Contenedor %s no está running (estado: %s)", containerID, status)
-				// Marcar como error
-				if err := s.updateAppStatus(app.ID, "error", fmt.Sprintf("Contenedor no está running: %s", status)); err != nil {
-					logrus.Errorf("Error actualizando estado de app %s: %v", app.ID, err)
-					errorCount++
-				}
-			}
-		} else {
-			// Contenedor no está ejecutándose - intentar recrearlo
-			logrus.Warnf("⚠️  Contenedor %s para app %s no está ejecutándose, intentando recrear...", containerID, app.ID)
-
-			if err := s.recreateContainer(&app, preferredRuntime); err != nil {
-				logrus.Errorf("❌ Error recreando contenedor para app %s: %v", app.ID, err)
-				// Marcar como error
-				if err := s.updateAppStatus(app.ID, "error", fmt.Sprintf("Error recreando contenedor: %v", err)); err != nil {
-					logrus.Errorf("Error actualizando estado de app %s: %v", app.ID, err)
-					errorCount++
-				}
-			} else {
-				logrus.Infof("✅ Contenedor recreado exitosamente para app %s", app.ID)
-				recoveredCount++
-			}
-		}
+		}()
 	}
 
-	logrus.Infof("🎯 Recuperación completada: %d recuperadas, %d errores", recoveredCount, errorCount)
-	return nil
-}
-
-// updateAppStatus actualiza el estado de una aplicación en la base de datos
-func (s *Server) updateAppStatus(appID, status, errorMsg string) error {
-	ctx := context.Background()
-
-	// Obtener la app actual
-	app, err := s.queries.GetApp(ctx, appID)
-	if err != nil {
-		return fmt.Errorf("error obteniendo app %s: %v", appID, err)
-	}
-
-	// Actualizar estado
-	app.Status = sql.NullString{String: status, Valid: true}
-	if errorMsg != "" {
-		app.ErrorMsg = sql.NullString{String: errorMsg, Valid: true}
-	}
-	app.UpdatedAt = sql.NullTime{Time: time.Now(), Valid: true}
-
-	// Guardar en BD
-	return s.queries.UpdateApp(ctx, database.UpdateAppParams{
-		ID:        app.ID,
-		Name:      app.Name,
-		RepoUrl:   app.RepoUrl,
-		Language:  app.Language,
-		Port:      app.Port,
-		Status:    app.Status,
-		ErrorMsg:  app.ErrorMsg,
-		UpdatedAt: app.UpdatedAt,
-	})
+	return s.server.Serve(listener)
 }
 
-// recreateContainer intenta recrear un contenedor para una aplicación
-func (s *Server) recreateContainer(app *database.App, runtimeType runtimePkg.RuntimeType) error {
-	logrus.Infof("🔄 Recreando contenedor para app %s", app.ID)
-
-	// Obtener variables de entorno
-	envVars, err := s.queries.GetAppEnvVars(context.Background(), app.ID)
-	if err != nil {
-		return fmt.Errorf("error obteniendo variables de entorno: %v", err)
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s.stopBackground != nil {
+		s.stopBackground()
 	}
 
-	// Convertir a formato models.EnvVar
-	envVarsList := make([]models.EnvVar, 0, len(envVars))
-	for _, env := range envVars {
-		value := env.Value
-
-		// Descifrar valores secretos si es necesario
-		if env.IsSecret.Bool {
-			if decryptedValue, err := handlers.DecryptValue(env.Value); err != nil {
-				logrus.Errorf("Error descifrando valor secreto %s: %v", env.Key, err)
-				continue
-			} else {
-				value = decryptedValue
-			}
+	if s.tlsServer != nil {
+		if err := s.tlsServer.Shutdown(ctx); err != nil {
+			logrus.Errorf("Error deteniendo el servidor HTTPS: %v", err)
 		}
+	}
 
-		envVarsList = append(envVarsList, models.EnvVar{
-			Name:  env.Key,
-			Value: value,
-		})
-	}
-
-	// Intentar recrear el contenedor usando la imagen existente
-	imageID := app.ImageID.String
-	if imageID == "" {
-		return fmt.Errorf("no hay image_id disponible para recrear contenedor")
-	}
-
-	// Ejecutar nuevo contenedor
-	var containerID string
-	var containerErr error
-
-	switch runtimeType {
-	case runtimePkg.RuntimeTypeDocker:
-		containerID, containerErr = s.docker.RunContainer(app, imageID, envVarsList)
-	case runtimePkg.RuntimeTypeContainerd:
-		// Para containerd, necesitamos crear el contenedor usando la interfaz
-		// Por ahora, usar Docker como fallback
-		logrus.Warnf("Recreación de contenedor containerd no implementada, usando Docker como fallback")
-		containerID, containerErr = s.docker.RunContainer(app, imageID, envVarsList)
-	default:
-		return fmt.Errorf("runtime no soportado para recrear contenedor: %s", runtimeType)
-	}
-
-	if containerErr != nil {
-		return fmt.Errorf("error ejecutando contenedor: %v", containerErr)
-	}
-
-	// Actualizar aplicación con nuevo container_id
-	app.ContainerID = sql.NullString{String: containerID, Valid: true}
-	app.Status = database.StatusRunning
-	app.UpdatedAt = sql.NullTime{Time: time.Now(), Valid: true}
-	app.ErrorMsg = sql.NullString{String: "", Valid: true}
-
-	return s.queries.UpdateApp(context.Background(), database.UpdateAppParams{
-		ID:          app.ID,
-		Name:        app.Name,
-		RepoUrl:     app.RepoUrl,
-		Language:    app.Language,
-		Port:        app.Port,
-		Status:      app.Status,
-		ErrorMsg:    app.ErrorMsg,
-		ContainerID: app.ContainerID,
-		UpdatedAt:   app.UpdatedAt,
-	})
-}
+	if s.proxyServer != nil {
+		if err := s.proxyServer.Shutdown(ctx); err != nil {
+			logrus.Errorf("Error deteniendo el proxy de aplicaciones: %v", err)
+		}
+	}
+	s.proxy.Close()
 
-func (s *Server) Shutdown(ctx context.Context) error {
 	if err := s.docker.Close(); err != nil {
 		logrus.Errorf("Error cerrando conexión a Docker: %v", err)
 	}
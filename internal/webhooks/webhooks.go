@@ -0,0 +1,147 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Proveedores de webhooks soportados
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+	ProviderGitea  = "gitea"
+)
+
+// EventPush es el tipo de los eventos de push a una rama o tag
+const EventPush = "push"
+
+// zeroCommit es el "after" de un push que elimina la referencia
+const zeroCommit = "0000000000000000000000000000000000000000"
+
+var (
+	// ErrUnknownProvider indica un proveedor no soportado
+	ErrUnknownProvider = errors.New("proveedor de webhook desconocido")
+	// ErrInvalidSignature indica que la entrega no está firmada con el secreto de la app
+	ErrInvalidSignature = errors.New("firma de webhook inválida")
+)
+
+// Event es una entrega de webhook con la firma ya verificada
+type Event struct {
+	Provider string
+	// DeliveryID identifica la entrega; los reintentos del proveedor repiten el mismo ID
+	DeliveryID string
+	// Type es EventPush para los push; el resto de eventos (ping, issues...) se ignoran
+	Type   string
+	Ref    string
+	Commit string
+}
+
+// IsPush indica si el evento es un push
+func (e Event) IsPush() bool {
+	return e.Type == EventPush
+}
+
+// Branch devuelve el nombre de la rama si el push es a refs/heads/*
+func (e Event) Branch() (string, bool) {
+	return strings.CutPrefix(e.Ref, "refs/heads/")
+}
+
+// Deleted indica si el push elimina la referencia
+func (e Event) Deleted() bool {
+	return e.Commit == "" || e.Commit == zeroCommit
+}
+
+// ValidProvider indica si provider es un proveedor soportado
+func ValidProvider(provider string) bool {
+	switch provider {
+	case ProviderGitHub, ProviderGitLab, ProviderGitea:
+		return true
+	}
+	return false
+}
+
+// Parse verifica la entrega con el secreto de la app y extrae el evento.
+// GitHub y Gitea firman el cuerpo con HMAC-SHA256; GitLab envía el secreto
+// tal cual en X-Gitlab-Token.
+func Parse(provider string, header http.Header, body []byte, secret string) (Event, error) {
+	if secret == "" {
+		return Event{}, ErrInvalidSignature
+	}
+
+	event := Event{Provider: provider}
+	switch provider {
+	case ProviderGitHub:
+		if !validHMAC(body, secret, strings.TrimPrefix(header.Get("X-Hub-Signature-256"), "sha256=")) {
+			return Event{}, ErrInvalidSignature
+		}
+		event.DeliveryID = header.Get("X-GitHub-Delivery")
+		event.Type = header.Get("X-GitHub-Event")
+	case ProviderGitea:
+		if !validHMAC(body, secret, header.Get("X-Gitea-Signature")) {
+			return Event{}, ErrInvalidSignature
+		}
+		event.DeliveryID = header.Get("X-Gitea-Delivery")
+		event.Type = header.Get("X-Gitea-Event")
+	case ProviderGitLab:
+		if subtle.ConstantTimeCompare([]byte(header.Get("X-Gitlab-Token")), []byte(secret)) != 1 {
+			return Event{}, ErrInvalidSignature
+		}
+		event.DeliveryID = header.Get("X-Gitlab-Event-UUID")
+		if header.Get("X-Gitlab-Event") == "Push Hook" {
+			event.Type = EventPush
+		} else {
+			event.Type = header.Get("X-Gitlab-Event")
+		}
+	default:
+		return Event{}, ErrUnknownProvider
+	}
+
+	// Sin ID de entrega, el cuerpo identifica los reintentos
+	if event.DeliveryID == "" {
+		sum := sha256.Sum256(body)
+		event.DeliveryID = hex.EncodeToString(sum[:])
+	}
+
+	if !event.IsPush() {
+		return event, nil
+	}
+
+	// Los tres proveedores envían ref y after con el mismo formato
+	var payload struct {
+		Ref   string `json:"ref"`
+		After string `json:"after"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return Event{}, fmt.Errorf("payload de push inválido: %w", err)
+	}
+	event.Ref = payload.Ref
+	event.Commit = payload.After
+	return event, nil
+}
+
+// Sign calcula la firma HMAC-SHA256 en hexadecimal que GitHub y Gitea envían
+// para body; sirve para reproducir entregas localmente
+func Sign(body []byte, secret string) string {
+	return hex.EncodeToString(signature(body, secret))
+}
+
+func signature(body []byte, secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+func validHMAC(body []byte, secret, received string) bool {
+	decoded, err := hex.DecodeString(received)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(signature(body, secret), decoded)
+}
@@ -0,0 +1,137 @@
+package webhooks
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testSecret = "s3cr3t"
+
+// signedHeader arma los headers de una entrega de provider firmada con secret
+func signedHeader(provider string, body []byte, secret, deliveryID string) http.Header {
+	header := http.Header{}
+	switch provider {
+	case ProviderGitHub:
+		header.Set("X-GitHub-Event", "push")
+		header.Set("X-GitHub-Delivery", deliveryID)
+		header.Set("X-Hub-Signature-256", "sha256="+Sign(body, secret))
+	case ProviderGitea:
+		header.Set("X-Gitea-Event", "push")
+		header.Set("X-Gitea-Delivery", deliveryID)
+		header.Set("X-Gitea-Signature", Sign(body, secret))
+	case ProviderGitLab:
+		header.Set("X-Gitlab-Event", "Push Hook")
+		header.Set("X-Gitlab-Event-UUID", deliveryID)
+		header.Set("X-Gitlab-Token", secret)
+	}
+	return header
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("leyendo %s: %v", name, err)
+	}
+	return body
+}
+
+func TestParsePush(t *testing.T) {
+	tests := []struct {
+		provider   string
+		fixture    string
+		deliveryID string
+		commit     string
+	}{
+		{ProviderGitHub, "github_push.json", "72d3162e-cc78-11e3-81ab-4c9367dc0958", "59b20b8d5c6ff8d09518454d4dd8b7b30f095ab5"},
+		{ProviderGitLab, "gitlab_push.json", "13792a34-cac6-4bda-95a8-c58a8e8ac95f", "da1560886d4f094c3e6c9ef40349f7d38b5d27d7"},
+		{ProviderGitea, "gitea_push.json", "f6266f16-1bf3-46a5-9ea4-602e06ead473", "bffeb74224043ba2feb48d137756c8a9331c449a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			body := readFixture(t, tt.fixture)
+
+			event, err := Parse(tt.provider, signedHeader(tt.provider, body, testSecret, tt.deliveryID), body, testSecret)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if !event.IsPush() {
+				t.Fatalf("Type = %q, se esperaba un push", event.Type)
+			}
+			if branch, ok := event.Branch(); !ok || branch != "main" {
+				t.Errorf("Branch() = %q, %v; se esperaba main", branch, ok)
+			}
+			if event.Commit != tt.commit {
+				t.Errorf("Commit = %q, se esperaba %q", event.Commit, tt.commit)
+			}
+			if event.DeliveryID != tt.deliveryID {
+				t.Errorf("DeliveryID = %q, se esperaba %q", event.DeliveryID, tt.deliveryID)
+			}
+			if event.Deleted() {
+				t.Error("Deleted() = true para un push con commit")
+			}
+		})
+	}
+}
+
+func TestParseInvalidSignature(t *testing.T) {
+	fixtures := map[string]string{
+		ProviderGitHub: "github_push.json",
+		ProviderGitLab: "gitlab_push.json",
+		ProviderGitea:  "gitea_push.json",
+	}
+
+	for provider, fixture := range fixtures {
+		t.Run(provider, func(t *testing.T) {
+			body := readFixture(t, fixture)
+
+			// Firmado con otro secreto
+			header := signedHeader(provider, body, "otro-secreto", "delivery")
+			if _, err := Parse(provider, header, body, testSecret); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("Parse con otro secreto: err = %v, se esperaba ErrInvalidSignature", err)
+			}
+
+			// Cuerpo modificado después de firmar; GitLab no firma el cuerpo
+			header = signedHeader(provider, body, testSecret, "delivery")
+			tampered := append([]byte(nil), body...)
+			tampered[len(tampered)-2] = ' '
+			if provider != ProviderGitLab {
+				if _, err := Parse(provider, header, tampered, testSecret); !errors.Is(err, ErrInvalidSignature) {
+					t.Errorf("Parse con cuerpo modificado: err = %v, se esperaba ErrInvalidSignature", err)
+				}
+			}
+
+			// App sin secreto configurado
+			if _, err := Parse(provider, header, body, ""); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("Parse sin secreto: err = %v, se esperaba ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func TestParseDeliveryIDFallback(t *testing.T) {
+	body := readFixture(t, "github_push.json")
+	header := signedHeader(ProviderGitHub, body, testSecret, "")
+
+	first, err := Parse(ProviderGitHub, header, body, testSecret)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	second, err := Parse(ProviderGitHub, header, body, testSecret)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if first.DeliveryID == "" || first.DeliveryID != second.DeliveryID {
+		t.Errorf("DeliveryID sin header = %q y %q, se esperaba el mismo ID no vacío", first.DeliveryID, second.DeliveryID)
+	}
+}
+
+func TestParseUnknownProvider(t *testing.T) {
+	if _, err := Parse("bitbucket", http.Header{}, []byte("{}"), testSecret); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("err = %v, se esperaba ErrUnknownProvider", err)
+	}
+}